@@ -2,11 +2,14 @@ package common
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"strings"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
 	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const WorkloadAnnotation = "keptn.sh/workload"
@@ -21,12 +24,44 @@ const PreDeploymentEvaluationAnnotation = "keptn.sh/pre-deployment-evaluations"
 const PostDeploymentEvaluationAnnotation = "keptn.sh/post-deployment-evaluations"
 const TaskNameAnnotation = "keptn.sh/task-name"
 const NamespaceEnabledAnnotation = "keptn.sh/lifecycle-toolkit"
+const ScaleUpGuardAnnotation = "keptn.sh/scale-up-guard"
+const FailedVersionAnnotation = "keptn.sh/failed-version"
+const ReleaseAuditAnnotation = "keptn.sh/release-audit"
+const ReleasedVersionAnnotation = "keptn.sh/released-version"
+const ReleasedAtAnnotation = "keptn.sh/released-at"
+const ReleasedAfterChecksAnnotation = "keptn.sh/released-after-checks"
+const PreviousScaleUpPolicyAnnotation = "keptn.sh/previous-scale-up-select-policy"
+const LifecycleDeadlineAnnotation = "keptn.sh/lifecycle-deadline"
+const PreDeploymentChecksAnnotation = "keptn.sh/pre-deployment-checks"
+const TrafficSwitchServiceAnnotation = "keptn.sh/traffic-switch-service"
+const TrafficSwitchSelectorAnnotation = "keptn.sh/traffic-switch-selector"
+
+// PreDeploymentSchedulingGate is the scheduling gate that holds pods back until the pre-deployment checks of their
+// workload instance have succeeded
+const PreDeploymentSchedulingGate = "keptn.sh/pre-deployment"
+
+// SchedulingGatedLabel marks the pods that carry PreDeploymentSchedulingGate, and is removed together with the gate
+const SchedulingGatedLabel = "keptn.sh/scheduling-gated"
+
+// WorkloadInstanceLabel is set on gated pods to the name of their workload instance, see
+// GetWorkloadInstanceLabelValue, and is removed together with the gate
+const WorkloadInstanceLabel = "keptn.sh/workload-instance"
+
+// ManagedByLabel marks the Jobs of KeptnTasks and their pods, which are never handled by the webhook
+const ManagedByLabel = "keptn.sh/managed-by"
+const ManagedByLifecycleToolkit = "lifecycle-toolkit"
+
+// CheckTypeLabel is set on KeptnTasks, their Jobs and pods, and on KeptnEvaluations to the type of check they run
+const CheckTypeLabel = "keptn.sh/check-type"
 
 const MaxAppNameLength = 25
 const MaxWorkloadNameLength = 25
 const MaxTaskNameLength = 25
 const MaxVersionLength = 12
 
+// MaxFailureMessageLength is the length failure messages are truncated to before they are stored in a status
+const MaxFailureMessageLength = 1024
+
 type KeptnState string
 
 const (
@@ -55,6 +90,15 @@ func (k KeptnState) IsPending() bool {
 	return k == StatePending
 }
 
+// SetPhaseState sets the state of a phase and returns the resulting state. A phase that has already reached a
+// terminal state keeps it, so that recreated or re-applied objects can never make a phase regress.
+func SetPhaseState(phaseState *KeptnState, state KeptnState) KeptnState {
+	if !phaseState.IsCompleted() {
+		*phaseState = state
+	}
+	return *phaseState
+}
+
 type StatusSummary struct {
 	Total       int
 	progressing int
@@ -107,6 +151,18 @@ func TruncateString(s string, max int) string {
 	return s
 }
 
+// GetWorkloadInstanceLabelValue returns the value of WorkloadInstanceLabel for a workload instance. Names that are
+// longer than the maximum length of label values are truncated and suffixed with a hash of the full name, so that
+// they stay unique.
+func GetWorkloadInstanceLabelValue(workloadInstanceName string) string {
+	if len(workloadInstanceName) <= validation.LabelValueMaxLength {
+		return workloadInstanceName
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(workloadInstanceName))
+	return fmt.Sprintf("%s-%08x", workloadInstanceName[:validation.LabelValueMaxLength-9], hash.Sum32())
+}
+
 type CheckType string
 
 const PreDeploymentCheckType CheckType = "pre"
@@ -114,15 +170,32 @@ const PostDeploymentCheckType CheckType = "post"
 const PreDeploymentEvaluationCheckType CheckType = "pre-eval"
 const PostDeploymentEvaluationCheckType CheckType = "post-eval"
 
+// GateWaitReasonChecks is the reason reported for pods that have been waiting for the pre-deployment checks of their workload
+const GateWaitReasonChecks = "checks"
+
+// GateWaitReasonLifecycleDeadline is the reason reported for pods that have been released since the lifecycle deadline of their workload has been exceeded
+const GateWaitReasonLifecycleDeadline = "lifecycle-deadline"
+
 type KeptnMeters struct {
-	TaskCount          syncint64.Counter
-	TaskDuration       syncfloat64.Histogram
-	DeploymentCount    syncint64.Counter
-	DeploymentDuration syncfloat64.Histogram
-	AppCount           syncint64.Counter
-	AppDuration        syncfloat64.Histogram
-	EvaluationCount    syncint64.Counter
-	EvaluationDuration syncfloat64.Histogram
+	TaskCount                  syncint64.Counter
+	TaskDuration               syncfloat64.Histogram
+	TaskSchedulingDuration     syncfloat64.Histogram
+	TaskExecutionDuration      syncfloat64.Histogram
+	TaskRestarts               syncint64.Counter
+	TaskInterruptions          syncint64.Counter
+	DeploymentCount            syncint64.Counter
+	DeploymentDuration         syncfloat64.Histogram
+	AppCount                   syncint64.Counter
+	AppDuration                syncfloat64.Histogram
+	EvaluationCount            syncint64.Counter
+	EvaluationDuration         syncfloat64.Histogram
+	GateWaitDuration           syncfloat64.Histogram
+	GateBypasses               syncint64.Counter
+	PreDeploymentDuration      syncfloat64.Histogram
+	DeferredStarts             syncint64.Counter
+	ParkedVersions             syncint64.Counter
+	PreDeploymentChecks        syncint64.Counter
+	PreDeploymentCheckDuration syncfloat64.Histogram
 }
 
 const (
@@ -142,6 +215,18 @@ const (
 	EvaluationStatus        attribute.Key = attribute.Key("keptn.deployment.evaluation.status")
 	EvaluationName          attribute.Key = attribute.Key("keptn.deployment.evaluation.name")
 	EvaluationType          attribute.Key = attribute.Key("keptn.deployment.evaluation.type")
+	GateWaitReason          attribute.Key = attribute.Key("keptn.deployment.gate.reason")
+	ThrottleReason          attribute.Key = attribute.Key("keptn.throttle.reason")
+	CapabilityName          attribute.Key = attribute.Key("keptn.capability.name")
+	BreakerTarget           attribute.Key = attribute.Key("keptn.breaker.target")
+	ObjectKind              attribute.Key = attribute.Key("keptn.object.kind")
+	CreationResult          attribute.Key = attribute.Key("keptn.object.creation.result")
+	InterruptionReason      attribute.Key = attribute.Key("keptn.deployment.task.interruption")
+	CheckResult             attribute.Key = attribute.Key("keptn.deployment.check.result")
+	CloudEventDropReason    attribute.Key = attribute.Key("keptn.cloudevent.drop.reason")
+	FeatureGateName         attribute.Key = attribute.Key("keptn.featuregate.name")
+	FeatureGateStage        attribute.Key = attribute.Key("keptn.featuregate.stage")
+	Enforced                attribute.Key = attribute.Key("keptn.deployment.enforced")
 )
 
 func GenerateTaskName(checkType CheckType, taskName string) string {
@@ -149,6 +234,16 @@ func GenerateTaskName(checkType CheckType, taskName string) string {
 	return fmt.Sprintf("%s-%s-%d", checkType, TruncateString(taskName, 32), randomId)
 }
 
+// ParseTaskDefinitionReference splits a reference to a KeptnTaskDefinition of the form [namespace/]name, as it is
+// listed in the annotations of pods and the specs of KeptnApps and KeptnWorkloads. The namespace is empty if the
+// reference does not name one.
+func ParseTaskDefinitionReference(reference string) (namespace string, name string) {
+	if namespace, name, found := strings.Cut(reference, "/"); found {
+		return namespace, name
+	}
+	return "", reference
+}
+
 func GenerateEvaluationName(checkType CheckType, evalName string) string {
 	randomId := rand.Intn(99_999-10_000) + 10000
 	return fmt.Sprintf("%s-%s-%d", checkType, TruncateString(evalName, 27), randomId)
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestSetPhaseState(t *testing.T) {
+	states := []KeptnState{StatePending, StateProgressing, StateUnknown, StateSucceeded, StateFailed, ""}
+	for _, from := range states {
+		for _, to := range states {
+			phaseState := from
+			got := SetPhaseState(&phaseState, to)
+			if from.IsCompleted() {
+				require.Equal(t, from, got, "%s -> %s", from, to)
+			} else {
+				require.Equal(t, to, got, "%s -> %s", from, to)
+			}
+			require.Equal(t, got, phaseState)
+		}
+	}
+}
+
+func TestGetWorkloadInstanceLabelValue(t *testing.T) {
+	require.Equal(t, "my-app-my-workload-1.0.0", GetWorkloadInstanceLabelValue("my-app-my-workload-1.0.0"))
+
+	// names longer than a label value are shortened, but still tell different versions apart
+	prefix := strings.Repeat("a", 25) + "-" + strings.Repeat("w", 25) + "-"
+	first := GetWorkloadInstanceLabelValue(prefix + "1.0.0-rc.1-build.20230101")
+	second := GetWorkloadInstanceLabelValue(prefix + "1.0.0-rc.1-build.20230102")
+	require.NotEqual(t, first, second)
+	for _, value := range []string{first, second} {
+		require.Len(t, value, validation.LabelValueMaxLength)
+		require.Empty(t, validation.IsValidLabelValue(value))
+	}
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Duration returns the time between two timestamps of a status, or 0 if one of them has not been set.
+// metav1.Time is stored with a precision of seconds, so both timestamps are truncated to seconds in UTC first,
+// which gives the same duration whether a timestamp has just been set or has been read back from the API server.
+// The timestamps may have been recorded by controllers on nodes with skewed clocks, so a negative duration is
+// clamped to zero.
+func Duration(start metav1.Time, end metav1.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return nonNegative(end.Rfc3339Copy().UTC().Sub(start.Rfc3339Copy().UTC()))
+}
+
+// MicroDuration returns the time between two timestamps with a precision of microseconds, or 0 if one of them has
+// not been set. New timestamps that durations are computed from are stored as metav1.MicroTime.
+func MicroDuration(start metav1.MicroTime, end metav1.MicroTime) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return nonNegative(end.UTC().Round(0).Truncate(time.Microsecond).Sub(start.UTC().Round(0).Truncate(time.Microsecond)))
+}
+
+// Since returns the time that has passed since the given timestamp
+func Since(t metav1.Time) time.Duration {
+	return Duration(t, metav1.NewTime(time.Now().UTC()))
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
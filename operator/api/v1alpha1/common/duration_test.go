@@ -0,0 +1,93 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDuration(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  time.Duration
+	}{
+		{
+			name:  "end after start",
+			start: start,
+			end:   start.Add(90 * time.Second),
+			want:  90 * time.Second,
+		},
+		{
+			name:  "equal timestamps",
+			start: start,
+			end:   start,
+			want:  0,
+		},
+		{
+			name:  "end before start due to clock skew",
+			start: start,
+			end:   start.Add(-2 * time.Second),
+			want:  0,
+		},
+		{
+			name:  "end has just been set and start has been read back in seconds",
+			start: start,
+			end:   start.Add(1500 * time.Millisecond),
+			want:  time.Second,
+		},
+		{
+			name:  "end within the same second as start",
+			start: start.Add(900 * time.Millisecond),
+			end:   start.Add(950 * time.Millisecond),
+			want:  0,
+		},
+		{
+			name:  "timestamps in different time zones",
+			start: start,
+			end:   start.Add(time.Minute).In(time.FixedZone("CET", 3600)),
+			want:  time.Minute,
+		},
+		{
+			name: "start has not been set",
+			end:  start,
+			want: 0,
+		},
+		{
+			name:  "end has not been set",
+			start: start,
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Duration(metav1.NewTime(tt.start), metav1.NewTime(tt.end)))
+		})
+	}
+}
+
+func TestDuration_MonotonicClock(t *testing.T) {
+	// a timestamp that has just been taken carries a monotonic clock reading, one that has been read back does not
+	now := time.Now()
+	stored := metav1.NewTime(now).Rfc3339Copy()
+	require.Equal(t, Duration(stored, metav1.NewTime(now.Add(time.Minute))), Duration(stored, metav1.NewTime(now.Add(time.Minute).Round(0))))
+}
+
+func TestMicroDuration(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 1500*time.Millisecond, MicroDuration(metav1.NewMicroTime(start), metav1.NewMicroTime(start.Add(1500*time.Millisecond))))
+	require.Equal(t, 2*time.Microsecond, MicroDuration(metav1.NewMicroTime(start.Add(999)), metav1.NewMicroTime(start.Add(2999))))
+	require.Zero(t, MicroDuration(metav1.NewMicroTime(start), metav1.NewMicroTime(start)))
+	require.Zero(t, MicroDuration(metav1.NewMicroTime(start), metav1.NewMicroTime(start.Add(-time.Millisecond))))
+	require.Zero(t, MicroDuration(metav1.MicroTime{}, metav1.NewMicroTime(start)))
+}
+
+func TestSince(t *testing.T) {
+	require.Zero(t, Since(metav1.NewTime(time.Now().Add(time.Hour))))
+	require.GreaterOrEqual(t, Since(metav1.NewTime(time.Now().Add(-time.Hour))), 59*time.Minute)
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MetadataAnnotationPrefix marks the annotations of a pod that are carried as metadata of its workload,
+// e.g. keptn.sh/metadata.ticket: ABC-123. The events of its workload instances carry the same annotations.
+const MetadataAnnotationPrefix = "keptn.sh/metadata."
+
+// MaxMetadataEntries and MaxMetadataValueLength limit the size of the metadata of a workload
+const MaxMetadataEntries = 20
+const MaxMetadataValueLength = 256
+
+// reservedMetadataPrefix is the prefix of the span attributes of the toolkit, which metadata must not override
+const reservedMetadataPrefix = "keptn."
+
+var metadataKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.-]{0,62}$`)
+
+// cloudEventContextAttributes are the attributes of a CloudEvent that metadata must not be mapped onto
+var cloudEventContextAttributes = map[string]bool{
+	"specversion":     true,
+	"id":              true,
+	"source":          true,
+	"type":            true,
+	"subject":         true,
+	"time":            true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"data":            true,
+}
+
+// ValidateMetadata checks the number of entries, the keys and the length of the values of the metadata of a workload.
+// Keys that would override a span attribute of the toolkit or an attribute of a CloudEvent are rejected.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataEntries {
+		return fmt.Errorf("too many metadata entries: %d, at most %d are allowed", len(metadata), MaxMetadataEntries)
+	}
+	extensions := map[string]string{}
+	for _, key := range sortedKeys(metadata) {
+		if !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata key %q: must start with a letter and consist of at most 63 letters, digits, '_', '-' or '.'", key)
+		}
+		if len(metadata[key]) > MaxMetadataValueLength {
+			return fmt.Errorf("value of metadata key %q is longer than %d characters", key, MaxMetadataValueLength)
+		}
+		if strings.HasPrefix(strings.ToLower(key), reservedMetadataPrefix) {
+			return fmt.Errorf("metadata key %q is reserved: keys must not start with %q", key, reservedMetadataPrefix)
+		}
+		extension := cloudEventExtensionName(key)
+		if cloudEventContextAttributes[extension] {
+			return fmt.Errorf("metadata key %q is reserved: it collides with the CloudEvent attribute %q", key, extension)
+		}
+		if other, ok := extensions[extension]; ok {
+			return fmt.Errorf("metadata keys %q and %q collide: both map to the CloudEvent extension %q", other, key, extension)
+		}
+		extensions[extension] = key
+	}
+	return nil
+}
+
+// GetMetadataAttributes maps the metadata to span attributes named by its keys
+func GetMetadataAttributes(metadata map[string]string) []attribute.KeyValue {
+	attributes := make([]attribute.KeyValue, 0, len(metadata))
+	for _, key := range sortedKeys(metadata) {
+		attributes = append(attributes, attribute.String(key, metadata[key]))
+	}
+	return attributes
+}
+
+// GetMetadataEventAnnotations maps the metadata to annotations of Kubernetes events, prefixed by keptn.sh/metadata.
+func GetMetadataEventAnnotations(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	annotations := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		annotations[MetadataAnnotationPrefix+key] = value
+	}
+	return annotations
+}
+
+// GetMetadataCloudEventExtensions maps the metadata to CloudEvent extension attributes. Extension names may only
+// consist of lower case letters and digits, so the keys are lower cased and all other characters are removed.
+func GetMetadataCloudEventExtensions(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	extensions := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		extensions[cloudEventExtensionName(key)] = value
+	}
+	return extensions
+}
+
+func cloudEventExtensionName(key string) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			name.WriteRune(r)
+		}
+	}
+	return name.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxMetadataEntries; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  string
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:     "valid metadata",
+			metadata: map[string]string{"ticket": "ABC-123", "commit-sha": "9f2c1e4", "team.name": "checkout"},
+		},
+		{
+			name:     "too many entries",
+			metadata: tooMany,
+			wantErr:  "too many metadata entries",
+		},
+		{
+			name:     "key starting with a digit",
+			metadata: map[string]string{"1ticket": "ABC-123"},
+			wantErr:  "invalid metadata key",
+		},
+		{
+			name:     "key with a slash",
+			metadata: map[string]string{"example.com/ticket": "ABC-123"},
+			wantErr:  "invalid metadata key",
+		},
+		{
+			name:     "value too long",
+			metadata: map[string]string{"ticket": strings.Repeat("a", MaxMetadataValueLength+1)},
+			wantErr:  "longer than",
+		},
+		{
+			name:     "key colliding with a span attribute",
+			metadata: map[string]string{"keptn.deployment.app.name": "other-app"},
+			wantErr:  "is reserved: keys must not start with",
+		},
+		{
+			name:     "key colliding with a CloudEvent attribute",
+			metadata: map[string]string{"Data": "payload"},
+			wantErr:  "collides with the CloudEvent attribute \"data\"",
+		},
+		{
+			name:     "keys colliding with each other",
+			metadata: map[string]string{"commit-sha": "9f2c1e4", "commitsha": "9f2c1e4"},
+			wantErr:  "both map to the CloudEvent extension \"commitsha\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetadata(tt.metadata)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestGetMetadataAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     []attribute.KeyValue
+	}{
+		{
+			name: "no metadata",
+			want: []attribute.KeyValue{},
+		},
+		{
+			name:     "sorted by key",
+			metadata: map[string]string{"ticket": "ABC-123", "commit-sha": "9f2c1e4"},
+			want: []attribute.KeyValue{
+				attribute.String("commit-sha", "9f2c1e4"),
+				attribute.String("ticket", "ABC-123"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetMetadataAttributes(tt.metadata))
+		})
+	}
+}
+
+func TestGetMetadataEventAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     map[string]string
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:     "prefixed keys",
+			metadata: map[string]string{"ticket": "ABC-123", "commit-sha": "9f2c1e4"},
+			want: map[string]string{
+				"keptn.sh/metadata.ticket":     "ABC-123",
+				"keptn.sh/metadata.commit-sha": "9f2c1e4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetMetadataEventAnnotations(tt.metadata))
+		})
+	}
+}
+
+func TestGetMetadataCloudEventExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     map[string]string
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:     "lower case letters and digits",
+			metadata: map[string]string{"ticket": "ABC-123", "Commit-SHA": "9f2c1e4", "team.name_2": "checkout"},
+			want: map[string]string{
+				"ticket":    "ABC-123",
+				"commitsha": "9f2c1e4",
+				"teamname2": "checkout",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetMetadataCloudEventExtensions(tt.metadata))
+		})
+	}
+}
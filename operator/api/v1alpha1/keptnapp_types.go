@@ -25,6 +25,28 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// ConcurrencyPolicy describes how overlapping KeptnAppVersions of the same KeptnApp are handled
+// +kubebuilder:validation:Enum=Wait;LatestWins
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyPolicyWait lets a KeptnAppVersion wait until all older versions of the app have completed
+	ConcurrencyPolicyWait ConcurrencyPolicy = "Wait"
+	// ConcurrencyPolicyLatestWins cancels all older versions of the app that have not completed yet
+	ConcurrencyPolicyLatestWins ConcurrencyPolicy = "LatestWins"
+)
+
+// PropagationPolicy describes what happens to the KeptnWorkloadInstances of a KeptnAppVersion when it is deleted
+// +kubebuilder:validation:Enum=Cascade;Orphan
+type PropagationPolicy string
+
+const (
+	// PropagationPolicyCascade deletes the KeptnWorkloadInstances owned by a KeptnAppVersion together with it
+	PropagationPolicyCascade PropagationPolicy = "Cascade"
+	// PropagationPolicyOrphan keeps the KeptnWorkloadInstances of a KeptnAppVersion when it is deleted
+	PropagationPolicyOrphan PropagationPolicy = "Orphan"
+)
+
 // KeptnAppSpec defines the desired state of KeptnApp
 type KeptnAppSpec struct {
 	Version                   string             `json:"version"`
@@ -33,6 +55,26 @@ type KeptnAppSpec struct {
 	PostDeploymentTasks       []string           `json:"postDeploymentTasks,omitempty"`
 	PreDeploymentEvaluations  []string           `json:"preDeploymentEvaluations,omitempty"`
 	PostDeploymentEvaluations []string           `json:"postDeploymentEvaluations,omitempty"`
+	// ConcurrencyPolicy defines whether a new version of the app waits for older versions to complete
+	// before running its checks (Wait) or cancels them (LatestWins)
+	// +kubebuilder:default:=Wait
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// ConcurrencyWaitTimeout is the time a new version of the app waits for older versions to complete with the Wait
+	// policy, measured from its creation. Afterwards, the older versions are cancelled as with LatestWins.
+	// It defaults to 1h.
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	ConcurrencyWaitTimeout *metav1.Duration `json:"concurrencyWaitTimeout,omitempty"`
+	// LifecycleDeadline is the default maximum time the lifecycle of a KeptnWorkloadInstance of the app may take
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	LifecycleDeadline *metav1.Duration `json:"lifecycleDeadline,omitempty"`
+	// PropagationPolicy defines whether the KeptnWorkloadInstances of a version of the app are deleted
+	// together with the KeptnAppVersion (Cascade) or kept (Orphan)
+	// +kubebuilder:default:=Orphan
+	PropagationPolicy PropagationPolicy `json:"propagationPolicy,omitempty"`
 }
 
 // KeptnAppStatus defines the observed state of KeptnApp
@@ -18,13 +18,21 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// WaitingForPreviousVersionConditionType is set to true while a KeptnAppVersion waits for older versions of the app to complete
+const WaitingForPreviousVersionConditionType = "WaitingForPreviousVersion"
+
+// MemberScaledToZeroConditionType is set to true while workloads of a KeptnAppVersion do not participate in its lifecycle since they are scaled to zero
+const MemberScaledToZeroConditionType = "MemberScaledToZero"
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -60,12 +68,17 @@ type KeptnAppVersionStatus struct {
 
 	StartTime metav1.Time `json:"startTime,omitempty"`
 	EndTime   metav1.Time `json:"endTime,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type WorkloadStatus struct {
 	Workload KeptnWorkloadRef `json:"workload,omitempty"`
 	// +kubebuilder:default:=Pending
 	Status common.KeptnState `json:"status,omitempty"`
+	// ScaledToZeroTime is the time the workload has first been observed to be scaled to zero without an instance
+	// of this version
+	ScaledToZeroTime metav1.Time `json:"scaledToZeroTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -227,6 +240,57 @@ func (v *KeptnAppVersion) Complete() {
 	v.SetEndTime()
 }
 
+// SetWaitingForPreviousVersion sets the WaitingForPreviousVersion condition, naming the version that is waited for
+func (v *KeptnAppVersion) SetWaitingForPreviousVersion(waiting bool, previousVersion string) {
+	condition := metav1.Condition{
+		Type:               WaitingForPreviousVersionConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoPreviousVersionRunning",
+		ObservedGeneration: v.Generation,
+	}
+	if waiting {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PreviousVersionRunning"
+		condition.Message = fmt.Sprintf("waiting for KeptnAppVersion %s to complete", previousVersion)
+	}
+	meta.SetStatusCondition(&v.Status.Conditions, condition)
+}
+
+// SetMemberScaledToZero sets the MemberScaledToZero condition, naming the workloads that are scaled to zero
+func (v *KeptnAppVersion) SetMemberScaledToZero(workloads []string) {
+	if len(workloads) == 0 && meta.FindStatusCondition(v.Status.Conditions, MemberScaledToZeroConditionType) == nil {
+		return
+	}
+	condition := metav1.Condition{
+		Type:               MemberScaledToZeroConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "AllMembersParticipating",
+		ObservedGeneration: v.Generation,
+	}
+	if len(workloads) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ScaledToZero"
+		condition.Message = fmt.Sprintf("workloads %s are scaled to zero and do not participate", strings.Join(workloads, ", "))
+	}
+	meta.SetStatusCondition(&v.Status.Conditions, condition)
+}
+
+// SetAllowedTaskFailures sets the TasksFailureAllowed condition if any pre- or post-deployment task has failed
+// without blocking the deployment
+func (v *KeptnAppVersion) SetAllowedTaskFailures() {
+	failed := getAllowedTaskFailures(v.Status.PreDeploymentTaskStatus, v.Status.PostDeploymentTaskStatus)
+	if len(failed) == 0 {
+		return
+	}
+	meta.SetStatusCondition(&v.Status.Conditions, metav1.Condition{
+		Type:               TasksFailureAllowedConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             TaskFailureAllowedReason,
+		Message:            getAllowedTaskFailuresMessage(failed),
+		ObservedGeneration: v.Generation,
+	})
+}
+
 func (v KeptnAppVersion) GetVersion() string {
 	return v.Spec.Version
 }
@@ -234,3 +298,12 @@ func (v KeptnAppVersion) GetVersion() string {
 func (v KeptnAppVersion) GetSpanName(phase string) string {
 	return fmt.Sprintf("%s.%s.%s.%s", v.Spec.TraceId, v.Spec.AppName, v.Spec.Version, phase)
 }
+
+// GetFailedChecks returns the names of the task and evaluation definitions whose checks have failed
+func (v KeptnAppVersion) GetFailedChecks() []string {
+	return getChecksInState(
+		[][]TaskStatus{v.Status.PreDeploymentTaskStatus, v.Status.PostDeploymentTaskStatus},
+		[][]EvaluationStatus{v.Status.PreDeploymentEvaluationTaskStatus, v.Status.PostDeploymentEvaluationTaskStatus},
+		common.StateFailed,
+	)
+}
@@ -17,16 +17,25 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// DefaultRetryInterval is used by KeptnEvaluations that do not specify a retry interval
+const DefaultRetryInterval = 5 * time.Second
+
+// ProviderUnavailableConditionType is true while the KeptnEvaluationProvider of the evaluation is not queried,
+// since its circuit breaker is open after consecutive failed queries
+const ProviderUnavailableConditionType = "ProviderUnavailable"
+
 // KeptnEvaluationSpec defines the desired state of KeptnEvaluation
 type KeptnEvaluationSpec struct {
 	Workload             string `json:"workload,omitempty"`
@@ -36,11 +45,12 @@ type KeptnEvaluationSpec struct {
 	EvaluationDefinition string `json:"evaluationDefinition"`
 	// +kubebuilder:default:=10
 	Retries int `json:"retries,omitempty"`
+	// RetryInterval is the time to wait between two evaluation attempts, given as a duration string such as "5s" or "1m30s".
+	// An empty or zero value falls back to the default retry interval.
 	// +optional
 	// +kubebuilder:default:="5s"
-	// +kubebuilder:validation:Pattern="^0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
 	// +kubebuilder:validation:Type:=string
-	// +optional
 	RetryInterval metav1.Duration  `json:"retryInterval,omitempty"`
 	FailAction    string           `json:"failAction,omitempty"`
 	Type          common.CheckType `json:"checkType,omitempty"`
@@ -55,6 +65,8 @@ type KeptnEvaluationStatus struct {
 	OverallStatus common.KeptnState `json:"overallStatus"`
 	StartTime     metav1.Time       `json:"startTime,omitempty"`
 	EndTime       metav1.Time       `json:"endTime,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type EvaluationStatusItem struct {
@@ -96,6 +108,39 @@ func init() {
 	SchemeBuilder.Register(&KeptnEvaluation{}, &KeptnEvaluationList{})
 }
 
+// GetRetryInterval returns the configured retry interval, or DefaultRetryInterval if none has been set
+func (i KeptnEvaluation) GetRetryInterval() time.Duration {
+	if i.Spec.RetryInterval.Duration <= 0 {
+		return DefaultRetryInterval
+	}
+	return i.Spec.RetryInterval.Duration
+}
+
+// SetProviderUnavailable updates the ProviderUnavailable condition and returns true if its status has changed
+func (i *KeptnEvaluation) SetProviderUnavailable(unavailable bool, provider string) bool {
+	condition := metav1.Condition{
+		Type:               ProviderUnavailableConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "ProviderAvailable",
+		Message:            fmt.Sprintf("provider %s is queried", provider),
+		ObservedGeneration: i.Generation,
+	}
+	if unavailable {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "CircuitBreakerOpen"
+		condition.Message = fmt.Sprintf("provider %s is not queried after consecutive failures", provider)
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, ProviderUnavailableConditionType)
+	if existing == nil && !unavailable {
+		return false
+	}
+	if existing != nil && existing.Status == condition.Status {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, condition)
+	return true
+}
+
 func (i *KeptnEvaluation) SetStartTime() {
 	if i.Status.StartTime.IsZero() {
 		i.Status.StartTime = metav1.NewTime(time.Now().UTC())
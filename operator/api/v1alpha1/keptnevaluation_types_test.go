@@ -0,0 +1,58 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeptnEvaluationSpec_RetryIntervalMarshaling(t *testing.T) {
+	spec := KeptnEvaluationSpec{}
+	err := json.Unmarshal([]byte(`{"retryInterval":"1m30s"}`), &spec)
+	require.Nil(t, err)
+	require.Equal(t, 90*time.Second, spec.RetryInterval.Duration)
+
+	out, err := json.Marshal(spec)
+	require.Nil(t, err)
+	require.Contains(t, string(out), `"retryInterval":"1m30s"`)
+
+	err = json.Unmarshal([]byte(`{"retryInterval":"five minutes"}`), &spec)
+	require.NotNil(t, err)
+}
+
+func TestKeptnEvaluation_GetRetryInterval(t *testing.T) {
+	evaluation := KeptnEvaluation{}
+	require.Equal(t, DefaultRetryInterval, evaluation.GetRetryInterval())
+
+	evaluation.Spec.RetryInterval = metav1.Duration{Duration: 0}
+	require.Equal(t, DefaultRetryInterval, evaluation.GetRetryInterval())
+
+	evaluation.Spec.RetryInterval = metav1.Duration{Duration: 5 * time.Minute}
+	require.Equal(t, 5*time.Minute, evaluation.GetRetryInterval())
+}
+
+func FuzzKeptnEvaluationSpec_RetryInterval(f *testing.F) {
+	f.Add("5s")
+	f.Add("1m30s")
+	f.Add("0")
+	f.Add("-5s")
+	f.Add("")
+	f.Add("1h2m3.5s")
+
+	f.Fuzz(func(t *testing.T, interval string) {
+		raw, err := json.Marshal(map[string]string{"retryInterval": interval})
+		if err != nil {
+			return
+		}
+		evaluation := KeptnEvaluation{}
+		if err := json.Unmarshal(raw, &evaluation.Spec); err != nil {
+			return
+		}
+		if evaluation.GetRetryInterval() <= 0 {
+			t.Errorf("non-positive retry interval derived from %q", interval)
+		}
+	})
+}
@@ -30,9 +30,17 @@ type KeptnEvaluationDefinitionSpec struct {
 }
 
 type Objective struct {
-	Name             string `json:"name"`
-	Query            string `json:"query"`
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	// EvaluationTarget compares the result of the query with a threshold, using one of the operators <, <=, > or >=,
+	// e.g. "<0.05".
 	EvaluationTarget string `json:"evaluationTarget"`
+	// Window is the time before the evaluation within which every value of the query must meet the target, given as a
+	// duration string such as "5m". If it is not set, only the current value of the query is evaluated.
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	Window metav1.Duration `json:"window,omitempty"`
 }
 
 // KeptnEvaluationDefinitionStatus defines the observed state of KeptnEvaluationDefinition
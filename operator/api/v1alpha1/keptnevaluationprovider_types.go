@@ -17,16 +17,36 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// DefaultQueryTimeout is used by KeptnEvaluationProviders that do not specify a query timeout
+const DefaultQueryTimeout = 5 * time.Second
+
+// PrometheusProviderType is the type of KeptnEvaluationProviders that run PromQL queries against a Prometheus server
+const PrometheusProviderType = "prometheus"
+
 // KeptnEvaluationProviderSpec defines the desired state of KeptnEvaluationProvider
 type KeptnEvaluationProviderSpec struct {
+	// Type is the kind of monitoring backend that the queries of the provider are run against
+	// +optional
+	// +kubebuilder:default:=prometheus
+	// +kubebuilder:validation:Enum=prometheus
+	Type         string `json:"type,omitempty"`
 	TargetServer string `json:"targetServer"`
 	SecretName   string `json:"secretName,omitempty"`
+	// QueryTimeout limits the duration of a single query against the provider, given as a duration string such as "5s".
+	// An empty or zero value falls back to the default query timeout.
+	// +optional
+	// +kubebuilder:default:="5s"
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	QueryTimeout metav1.Duration `json:"queryTimeout,omitempty"`
 }
 
 // KeptnEvaluationProviderStatus defines the observed state of KeptnEvaluationProvider
@@ -57,6 +77,22 @@ type KeptnEvaluationProviderList struct {
 	Items           []KeptnEvaluationProvider `json:"items"`
 }
 
+// GetQueryTimeout returns the configured query timeout, or DefaultQueryTimeout if none has been set
+func (p KeptnEvaluationProvider) GetQueryTimeout() time.Duration {
+	if p.Spec.QueryTimeout.Duration <= 0 {
+		return DefaultQueryTimeout
+	}
+	return p.Spec.QueryTimeout.Duration
+}
+
+// GetType returns the configured type, or PrometheusProviderType if none has been set
+func (p KeptnEvaluationProvider) GetType() string {
+	if p.Spec.Type == "" {
+		return PrometheusProviderType
+	}
+	return p.Spec.Type
+}
+
 func init() {
 	SchemeBuilder.Register(&KeptnEvaluationProvider{}, &KeptnEvaluationProviderList{})
 }
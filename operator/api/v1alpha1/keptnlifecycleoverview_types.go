@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeptnLifecycleOverviewName is the name of the KeptnLifecycleOverview maintained by the operator
+const KeptnLifecycleOverviewName = "keptn-lifecycle-overview"
+
+// KeptnLifecycleOverviewSpec defines the desired state of KeptnLifecycleOverview
+type KeptnLifecycleOverviewSpec struct {
+}
+
+// KeptnLifecycleOverviewStatus summarizes the KeptnWorkloadInstances of the cluster
+type KeptnLifecycleOverviewStatus struct {
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Stuck     int `json:"stuck"`
+	// Namespaces contains the summary of each namespace containing KeptnWorkloadInstances, ordered by name
+	Namespaces []NamespaceLifecycleSummary `json:"namespaces,omitempty"`
+	// LastChangeTime is the time the summary has changed the last time
+	LastChangeTime metav1.Time `json:"lastChangeTime,omitempty"`
+}
+
+// NamespaceLifecycleSummary summarizes the KeptnWorkloadInstances of a namespace
+type NamespaceLifecycleSummary struct {
+	Namespace string `json:"namespace"`
+	Running   int    `json:"running"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Stuck     int    `json:"stuck"`
+	// OldestStuckInstance is the stuck KeptnWorkloadInstance that has been in its current phase for the longest time
+	OldestStuckInstance *InstanceReference `json:"oldestStuckInstance,omitempty"`
+	// LastFailure is the KeptnWorkloadInstance that has failed most recently
+	LastFailure *InstanceReference `json:"lastFailure,omitempty"`
+}
+
+// InstanceReference refers to a KeptnWorkloadInstance of the same namespace
+type InstanceReference struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase,omitempty"`
+	// Since is the time the instance has entered its phase for stuck instances and the time it has failed otherwise
+	Since metav1.Time `json:"since,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=keptnlifecycleoverviews,shortName=klo,scope=Cluster
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.running`
+// +kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Stuck",type=integer,JSONPath=`.status.stuck`
+// +kubebuilder:printcolumn:name="LastChange",type=date,JSONPath=`.status.lastChangeTime`
+
+// KeptnLifecycleOverview is the Schema for the keptnlifecycleoverviews API.
+// It is maintained by the operator and gives an overview of the lifecycle of all workloads in the cluster.
+type KeptnLifecycleOverview struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeptnLifecycleOverviewSpec   `json:"spec,omitempty"`
+	Status KeptnLifecycleOverviewStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeptnLifecycleOverviewList contains a list of KeptnLifecycleOverview
+type KeptnLifecycleOverviewList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeptnLifecycleOverview `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeptnLifecycleOverview{}, &KeptnLifecycleOverviewList{})
+}
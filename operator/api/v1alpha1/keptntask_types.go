@@ -24,6 +24,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// DefaultTaskRetries is the number of retries of the Job of a KeptnTask that does not set them
+	DefaultTaskRetries int32 = 10
+	// DefaultTaskTimeoutSeconds is the time the Job of a KeptnTask that does not set a timeout may run
+	DefaultTaskTimeoutSeconds int64 = 300
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -38,6 +45,24 @@ type KeptnTaskSpec struct {
 	Parameters       TaskParameters   `json:"parameters,omitempty"`
 	SecureParameters SecureParameters `json:"secureParameters,omitempty"`
 	Type             common.CheckType `json:"checkType,omitempty"`
+	// TaskDefinitionNamespace is the namespace of the KeptnTaskDefinition. It defaults to the namespace of the task.
+	// Definitions of other namespaces can only be used if cross-namespace references are enabled for the namespace.
+	// +optional
+	TaskDefinitionNamespace string `json:"taskDefinitionNamespace,omitempty"`
+	// WaitForQuota lets the task wait until its Job fits into the ResourceQuotas of the namespace,
+	// instead of failing right away
+	// +optional
+	WaitForQuota bool `json:"waitForQuota,omitempty"`
+	// Retries is the number of times the pod of the Job is restarted before the task fails. It defaults to 10.
+	// +optional
+	Retries *int32 `json:"retries,omitempty"`
+	// TimeoutSeconds is the time the Job may run before the task fails. It defaults to 300 seconds.
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+	// PhaseSpanContext is the W3C trace context of the span of the phase that has created the task.
+	// The spans of the task link to it, since they may end up in a separate trace.
+	// +optional
+	PhaseSpanContext map[string]string `json:"phaseSpanContext,omitempty"`
 }
 
 type TaskContext struct {
@@ -60,10 +85,33 @@ type SecureParameters struct {
 // KeptnTaskStatus defines the observed state of KeptnTask
 type KeptnTaskStatus struct {
 	JobName string `json:"jobName,omitempty"`
+	// JobAttempt is increased whenever the Job of the task is lost and has to be created again
+	JobAttempt int `json:"jobAttempt,omitempty"`
 	// +kubebuilder:default:=Pending
 	Status    common.KeptnState `json:"status,omitempty"`
 	StartTime metav1.Time       `json:"startTime,omitempty"`
 	EndTime   metav1.Time       `json:"endTime,omitempty"`
+	// SchedulingDuration is the time the last attempt of the Job waited for its container to start,
+	// including pod scheduling and image pulls
+	SchedulingDuration metav1.Duration `json:"schedulingDuration,omitempty"`
+	// ExecutionDuration is the time the container of the last attempt of the Job has been running
+	ExecutionDuration metav1.Duration `json:"executionDuration,omitempty"`
+	// Restarts is the number of attempts of the Job that preceded the last one
+	Restarts int `json:"restarts,omitempty"`
+	// InfrastructureRetries is the number of Jobs of the task that have failed since their pod has been removed by
+	// the infrastructure, e.g. by the cluster autoscaler scaling down its node, and have been replaced by a new Job
+	InfrastructureRetries int `json:"infrastructureRetries,omitempty"`
+	// Reason is a brief CamelCase reason why the Job of a failed task has failed: BackoffLimitExceeded if it has used
+	// up its retries, DeadlineExceeded if it has timed out, or the reason its container has terminated with.
+	// While the Job of a pending task waits to be admitted by its queue, the reason is QueuedByScheduler.
+	Reason string `json:"reason,omitempty"`
+	// Message describes why the Job of a failed task has failed. It contains the exit code and termination message
+	// of its container, which ends with the last lines of its log.
+	Message string `json:"message,omitempty"`
+	// SpanContext is the W3C trace context of the first span of the task, which the span of the phase that has
+	// created the task links to
+	// +optional
+	SpanContext map[string]string `json:"spanContext,omitempty"`
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 }
@@ -111,6 +159,39 @@ func (i *KeptnTask) SetEndTime() {
 	}
 }
 
+// GetRetries returns the number of retries of the Job of the task
+func (i KeptnTask) GetRetries() int32 {
+	if i.Spec.Retries == nil {
+		return DefaultTaskRetries
+	}
+	return *i.Spec.Retries
+}
+
+// GetTimeoutSeconds returns the time the Job of the task may run
+func (i KeptnTask) GetTimeoutSeconds() int64 {
+	if i.Spec.TimeoutSeconds == nil {
+		return DefaultTaskTimeoutSeconds
+	}
+	return *i.Spec.TimeoutSeconds
+}
+
+// GetTaskDefinitionNamespace returns the namespace the KeptnTaskDefinition of the task is searched in
+func (i KeptnTask) GetTaskDefinitionNamespace() string {
+	if i.Spec.TaskDefinitionNamespace == "" {
+		return i.Namespace
+	}
+	return i.Spec.TaskDefinitionNamespace
+}
+
+// GetTaskDefinitionReference returns the reference to the KeptnTaskDefinition of the task as it is listed by its
+// owner, i.e. namespace/name if the task names the namespace of its definition and the name otherwise
+func (i KeptnTask) GetTaskDefinitionReference() string {
+	if i.Spec.TaskDefinitionNamespace == "" {
+		return i.Spec.TaskDefinition
+	}
+	return i.Spec.TaskDefinitionNamespace + "/" + i.Spec.TaskDefinition
+}
+
 func (i *KeptnTask) IsStartTimeSet() bool {
 	return !i.Status.StartTime.IsZero()
 }
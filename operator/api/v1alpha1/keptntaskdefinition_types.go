@@ -25,7 +25,39 @@ import (
 
 // KeptnTaskDefinitionSpec defines the desired state of KeptnTaskDefinition
 type KeptnTaskDefinitionSpec struct {
-	Function FunctionSpec `json:"function,omitempty"`
+	Function  FunctionSpec `json:"function,omitempty"`
+	ApiAccess ApiAccess    `json:"apiAccess,omitempty"`
+	// Cooldown delays a run of the task for a workload until the given duration has passed since the task
+	// has last been started for another version of the same workload
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+	// AllowFailure lets the deployment of a workload or an application proceed if the task fails. The failure is still
+	// reported in the status and events of the KeptnWorkloadInstance or KeptnAppVersion.
+	// +optional
+	AllowFailure bool `json:"allowFailure,omitempty"`
+	// Queue submits the Jobs of the task to a queueing system such as Kueue instead of running them right away
+	// +optional
+	Queue *TaskQueue `json:"queue,omitempty"`
+}
+
+// TaskQueue submits the Jobs of a task suspended and labeled with the name of a queue, so that a queueing system
+// such as Kueue admits them by resuming them
+type TaskQueue struct {
+	// Name of the queue, e.g. of a LocalQueue of Kueue
+	Name string `json:"name"`
+	// Timeout fails the task if its Job has not been admitted by the queue within the given duration
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ApiAccess requests access to the Kubernetes API for the Jobs executing the task
+type ApiAccess struct {
+	// ClusterRole is the name of a pre-approved ClusterRole that is bound to a ServiceAccount created for each task run
+	ClusterRole string `json:"clusterRole,omitempty"`
 }
 
 type FunctionSpec struct {
@@ -35,6 +67,30 @@ type FunctionSpec struct {
 	ConfigMapReference ConfigMapReference `json:"configMapRef,omitempty"`
 	Parameters         TaskParameters     `json:"parameters,omitempty"`
 	SecureParameters   SecureParameters   `json:"secureParameters,omitempty"`
+	// EnvFromMetadata exposes labels and annotations of the KeptnWorkloadInstance or KeptnAppVersion
+	// the task runs for as environment variables of the function
+	EnvFromMetadata []EnvFromMetadata `json:"envFromMetadata,omitempty"`
+}
+
+type MetadataSource string
+
+const (
+	MetadataSourceLabel      MetadataSource = "label"
+	MetadataSourceAnnotation MetadataSource = "annotation"
+)
+
+// EnvFromMetadata maps a label or annotation to an environment variable. The value is resolved when the Job is created.
+type EnvFromMetadata struct {
+	// Name of the environment variable
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z][-._a-zA-Z0-9]*$`
+	Name string `json:"name"`
+	// Source of the value, either label or annotation
+	// +kubebuilder:validation:Enum=label;annotation
+	Source MetadataSource `json:"source"`
+	// Key of the label or annotation
+	Key string `json:"key"`
+	// Required fails the task if the key is not present, otherwise the variable is set to an empty string
+	Required bool `json:"required,omitempty"`
 }
 
 type ConfigMapReference struct {
@@ -35,11 +35,70 @@ type KeptnWorkloadSpec struct {
 	PreDeploymentEvaluations  []string          `json:"preDeploymentEvaluations,omitempty"`
 	PostDeploymentEvaluations []string          `json:"postDeploymentEvaluations,omitempty"`
 	ResourceReference         ResourceReference `json:"resourceReference"`
+	// LifecycleDeadline is the maximum time the whole lifecycle of a KeptnWorkloadInstance may take,
+	// measured from its creation without the time the lifecycle has been paused. It defaults to the lifecycle
+	// deadline of the KeptnApp.
+	// +optional
+	// +kubebuilder:validation:Pattern="^(0|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$"
+	// +kubebuilder:validation:Type:=string
+	LifecycleDeadline *metav1.Duration `json:"lifecycleDeadline,omitempty"`
+	// VersionSource states whether the version has been given explicitly by an annotation or label of the pod,
+	// or has been derived from its containers
+	// +optional
+	VersionSource VersionSource `json:"versionSource,omitempty"`
+	// PreDeploymentChecks states whether the pre-deployment checks run if the pods of the version are already running
+	// when the KeptnWorkloadInstance is created. By default, they are skipped. With Always, they run, but do not hold
+	// back the pods of the workload.
+	// +optional
+	PreDeploymentChecks PreDeploymentChecksMode `json:"preDeploymentChecks,omitempty"`
+	// Metadata is context like ticket IDs, commit SHAs or build URLs that is attached to the spans, events and
+	// CloudEvents of the workload instances. It is taken from the keptn.sh/metadata.<key> annotations of the pods.
+	// +kubebuilder:validation:MaxProperties=20
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// TrafficSwitch names a Service whose selector is switched to the new version once the post-deployment tasks of a
+	// KeptnWorkloadInstance have succeeded. It is taken from the keptn.sh/traffic-switch-service and
+	// keptn.sh/traffic-switch-selector annotations of the pods.
+	// +optional
+	TrafficSwitch TrafficSwitch `json:"trafficSwitch,omitempty"`
 }
 
+// TrafficSwitch describes a Service whose selector is switched to the new version
+// once the post-deployment tasks of the KeptnWorkloadInstance have succeeded
+type TrafficSwitch struct {
+	// ServiceName is the name of the Service in the namespace of the KeptnWorkloadInstance
+	ServiceName string `json:"serviceName,omitempty"`
+	// Selector is merged into the selector of the Service
+	Selector map[string]string `json:"selector,omitempty"`
+}
+
+// VersionSource states where the version of a workload has been taken from
+// +kubebuilder:validation:Enum=annotation;image
+type VersionSource string
+
+const (
+	// VersionSourceAnnotation is used for versions given by the keptn.sh/version or app.kubernetes.io/version annotation or label
+	VersionSourceAnnotation VersionSource = "annotation"
+	// VersionSourceImage is used for versions derived from the image tag or the containers of the pod
+	VersionSourceImage VersionSource = "image"
+)
+
+// PreDeploymentChecksMode states whether the pre-deployment checks run for versions that are already deployed
+// +kubebuilder:validation:Enum=IfNotDeployed;Always
+type PreDeploymentChecksMode string
+
+const (
+	// PreDeploymentChecksIfNotDeployed skips the pre-deployment checks of versions that are already deployed
+	PreDeploymentChecksIfNotDeployed PreDeploymentChecksMode = "IfNotDeployed"
+	// PreDeploymentChecksAlways runs the pre-deployment checks of versions that are already deployed, without holding back their pods
+	PreDeploymentChecksAlways PreDeploymentChecksMode = "Always"
+)
+
 // KeptnWorkloadStatus defines the observed state of KeptnWorkload
 type KeptnWorkloadStatus struct {
 	CurrentVersion string `json:"currentVersion,omitempty"`
+	// CurrentInstance is the name of the KeptnWorkloadInstance of the current version
+	CurrentInstance string `json:"currentInstance,omitempty"`
 }
 
 //+kubebuilder:object:root=true
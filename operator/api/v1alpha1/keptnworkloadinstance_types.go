@@ -18,13 +18,49 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// CompletedConditionType is set to true as soon as a KeptnWorkloadInstance has reached a terminal state
+const CompletedConditionType = "Completed"
+
+// PreDeploymentChecksStartedConditionType is set to true as soon as the pre-deployment checks of a KeptnWorkloadInstance have started
+const PreDeploymentChecksStartedConditionType = "PreDeploymentChecksStarted"
+
+// PreDeploymentChecksSucceededConditionType is unknown while the pre-deployment checks of a KeptnWorkloadInstance are running,
+// and true or false once they have succeeded or failed
+const PreDeploymentChecksSucceededConditionType = "PreDeploymentChecksSucceeded"
+
+// StuckConditionType is set to true while a KeptnWorkloadInstance remains in its current phase for longer than the configured threshold
+const StuckConditionType = "Stuck"
+
+// ReleasePolicyConditionType reflects the last decision of the release policy on releasing the pods of a KeptnWorkloadInstance
+const ReleasePolicyConditionType = "ReleasePolicyAllowed"
+
+// CreationQuotaExceededConditionType is true while the KeptnTasks or KeptnEvaluations of a KeptnWorkloadInstance cannot be created since a ResourceQuota is exceeded
+const CreationQuotaExceededConditionType = "CreationQuotaExceeded"
+
+// TooManyActiveVersionsConditionType is true while a KeptnWorkloadInstance is held back since too many older versions
+// of its workload have not completed yet
+const TooManyActiveVersionsConditionType = "TooManyActiveVersions"
+
+// ScaleUpGuardUnavailableConditionType is true while the result of a KeptnWorkloadInstance is not propagated to the
+// scale-up guard of its Deployment, since an API the scale-up guard depends on is not served by the cluster
+const ScaleUpGuardUnavailableConditionType = "ScaleUpGuardUnavailable"
+
+// TasksFailureAllowedConditionType is set to true as soon as a task of a KeptnWorkloadInstance or KeptnAppVersion has
+// failed without blocking the deployment, since its KeptnTaskDefinition allows it to fail
+const TasksFailureAllowedConditionType = "TasksFailureAllowed"
+
+// TaskFailureAllowedReason is set on the status of a failed task whose KeptnTaskDefinition allows it to fail
+const TaskFailureAllowedReason = "FailureAllowed"
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -34,6 +70,53 @@ type KeptnWorkloadInstanceSpec struct {
 	WorkloadName      string            `json:"workloadName"`
 	PreviousVersion   string            `json:"previousVersion,omitempty"`
 	TraceId           map[string]string `json:"traceId,omitempty"`
+	// Readiness states when the deployment phase of the KeptnWorkloadInstance has succeeded
+	// +optional
+	Readiness Readiness `json:"readiness,omitempty"`
+	// TaskSettings overrides the retries and timeout of the Jobs of the pre- and post-deployment tasks
+	// +optional
+	// +listType=map
+	// +listMapKey=taskDefinition
+	TaskSettings []TaskSettings `json:"taskSettings,omitempty"`
+}
+
+// TaskSettings overrides the retries and timeout of the Job of a task
+type TaskSettings struct {
+	// TaskDefinition is the name of the KeptnTaskDefinition of the task
+	TaskDefinition string `json:"taskDefinition"`
+	// Retries is the number of times the pod of the Job is restarted before the task fails
+	// +optional
+	Retries *int32 `json:"retries,omitempty"`
+	// TimeoutSeconds is the time the Job may run before the task fails
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// ReadinessMode states what the deployment phase of a KeptnWorkloadInstance waits for
+// +kubebuilder:validation:Enum=Pods;Endpoints
+type ReadinessMode string
+
+const (
+	// ReadinessModePods waits until the pods of the version are ready
+	ReadinessModePods ReadinessMode = "Pods"
+	// ReadinessModeEndpoints waits until the pods of the version are ready and serving as endpoints of a Service
+	ReadinessModeEndpoints ReadinessMode = "Endpoints"
+)
+
+// Readiness states when the deployment phase of a KeptnWorkloadInstance has succeeded
+type Readiness struct {
+	// Mode is Pods by default. With Endpoints, the deployment phase also waits until the pods of the version are ready
+	// endpoints of the Service named by ServiceName, which also covers the propagation of the endpoints.
+	// Endpoints of pods of other versions selected by the same Service are not counted.
+	// +optional
+	Mode ReadinessMode `json:"mode,omitempty"`
+	// ServiceName is the name of the Service in the namespace of the KeptnWorkloadInstance whose endpoints are checked
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// MinReadyEndpoints is the number of ready endpoints of the version the deployment phase waits for.
+	// By default, it waits for all pods of the version.
+	// +optional
+	MinReadyEndpoints int32 `json:"minReadyEndpoints,omitempty"`
 }
 
 // KeptnWorkloadInstanceStatus defines the observed state of KeptnWorkloadInstance
@@ -55,8 +138,50 @@ type KeptnWorkloadInstanceStatus struct {
 	StartTime                          metav1.Time        `json:"startTime,omitempty"`
 	EndTime                            metav1.Time        `json:"endTime,omitempty"`
 	CurrentPhase                       string             `json:"currentPhase,omitempty"`
+	// PhaseStartTime is the time the KeptnWorkloadInstance entered its current phase
+	PhaseStartTime metav1.Time `json:"phaseStartTime,omitempty"`
 	// +kubebuilder:default:=Pending
 	Status common.KeptnState `json:"status,omitempty"`
+	// PreDeploymentStartTime is the time the pre-deployment checks of the KeptnWorkloadInstance have started
+	PreDeploymentStartTime metav1.Time `json:"preDeploymentStartTime,omitempty"`
+	// PreDeploymentEndTime is the time the pre-deployment checks of the KeptnWorkloadInstance have succeeded or failed
+	PreDeploymentEndTime metav1.Time `json:"preDeploymentEndTime,omitempty"`
+	// PreDeploymentReason is the reason why the first failed pre-deployment task has failed
+	PreDeploymentReason string `json:"preDeploymentReason,omitempty"`
+	// PreDeploymentMessage describes why the first failed pre-deployment task has failed
+	PreDeploymentMessage string `json:"preDeploymentMessage,omitempty"`
+	// GateReleaseTime is the time the pre-deployment checks of the KeptnWorkloadInstance have succeeded and its pods are released by the scheduler
+	GateReleaseTime metav1.Time `json:"gateReleaseTime,omitempty"`
+	// GateWaitDuration is the time between the creation of the KeptnWorkloadInstance and GateReleaseTime
+	GateWaitDuration metav1.Duration `json:"gateWaitDuration,omitempty"`
+	// TrafficSwitchTime is the time the selector of the Service referenced in spec.trafficSwitch has been patched
+	TrafficSwitchTime metav1.Time `json:"trafficSwitchTime,omitempty"`
+	// CompletedAt is set exactly once, when the KeptnWorkloadInstance reaches a terminal state
+	CompletedAt metav1.Time `json:"completedAt,omitempty"`
+	// ActiveDuration is the time the lifecycle has been active until it has last been paused. It is unset as long as
+	// the lifecycle has never been paused, so that it has been active since the creation of the KeptnWorkloadInstance.
+	// +optional
+	ActiveDuration *metav1.Duration `json:"activeDuration,omitempty"`
+	// ActiveSince is the time the lifecycle has been resumed after it has last been paused, and is unset while it is paused
+	ActiveSince metav1.Time `json:"activeSince,omitempty"`
+	// Enforcement is decided once, when the KeptnWorkloadInstance starts, and never changes afterwards
+	// +optional
+	Enforcement *EnforcementStatus `json:"enforcement,omitempty"`
+	// PhaseSpanContext is the W3C trace context of the span of the current phase, which the tasks created in the
+	// phase link to
+	// +optional
+	PhaseSpanContext map[string]string `json:"phaseSpanContext,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// EnforcementStatus tells whether the pods of a KeptnWorkloadInstance are held back until its pre-deployment checks
+// have succeeded
+type EnforcementStatus struct {
+	// Enforced is false if the pre-deployment checks run in audit mode, without holding back the pods
+	Enforced bool `json:"enforced"`
+	// Bucket is the bucket of the workload between 0 and 99, which is enforced if it is below the enforcement percentage
+	Bucket int `json:"bucket"`
 }
 
 type TaskStatus struct {
@@ -66,6 +191,15 @@ type TaskStatus struct {
 	TaskName  string            `json:"taskName,omitempty"`
 	StartTime metav1.Time       `json:"startTime,omitempty"`
 	EndTime   metav1.Time       `json:"endTime,omitempty"`
+	// Reason explains why a pending task has not been created yet, or why a failed task does not block the deployment
+	Reason string `json:"reason,omitempty"`
+	// EarliestStartTime is the time a task that is cooling down is created at the earliest
+	EarliestStartTime metav1.Time `json:"earliestStartTime,omitempty"`
+	// Recreations is the number of times the check has been created again after it has been deleted while it was running
+	Recreations int `json:"recreations,omitempty"`
+	// SpanContext is the W3C trace context of the span of the task, which the span of the phase links to
+	// +optional
+	SpanContext map[string]string `json:"spanContext,omitempty"`
 }
 
 type EvaluationStatus struct {
@@ -75,6 +209,8 @@ type EvaluationStatus struct {
 	EvaluationName string            `json:"evaluationName,omitempty"`
 	StartTime      metav1.Time       `json:"startTime,omitempty"`
 	EndTime        metav1.Time       `json:"endTime,omitempty"`
+	// Recreations is the number of times the check has been created again after it has been deleted while it was running
+	Recreations int `json:"recreations,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -184,6 +320,128 @@ func (i *KeptnWorkloadInstance) SetEndTime() {
 	}
 }
 
+// StartPreDeployment records the time the pre-deployment checks of the KeptnWorkloadInstance have started
+// and sets the PreDeploymentChecksStarted condition
+func (i *KeptnWorkloadInstance) StartPreDeployment() {
+	if !i.Status.PreDeploymentStartTime.IsZero() {
+		return
+	}
+	i.Status.PreDeploymentStartTime = metav1.NewTime(time.Now().UTC())
+	i.setCondition(PreDeploymentChecksStartedConditionType, metav1.ConditionTrue, "Started", "pre-deployment checks have started", i.Status.PreDeploymentStartTime)
+	i.setCondition(PreDeploymentChecksSucceededConditionType, metav1.ConditionUnknown, "InProgress", "pre-deployment checks are running", i.Status.PreDeploymentStartTime)
+}
+
+// EndPreDeployment records the time the pre-deployment checks of the KeptnWorkloadInstance have succeeded or failed.
+// It returns false if the end time has already been recorded before.
+func (i *KeptnWorkloadInstance) EndPreDeployment() bool {
+	if !i.Status.PreDeploymentEndTime.IsZero() {
+		return false
+	}
+	i.StartPreDeployment()
+	i.Status.PreDeploymentEndTime = metav1.NewTime(time.Now().UTC())
+	return true
+}
+
+// SetPreDeploymentResult sets the PreDeploymentChecksSucceeded condition according to the final state of the
+// pre-deployment checks
+func (i *KeptnWorkloadInstance) SetPreDeploymentResult(state common.KeptnState, message string) {
+	if state.IsSucceeded() {
+		i.setCondition(PreDeploymentChecksSucceededConditionType, metav1.ConditionTrue, "Succeeded", message, metav1.NewTime(time.Now().UTC()))
+		return
+	}
+	i.setCondition(PreDeploymentChecksSucceededConditionType, metav1.ConditionFalse, string(state), message, metav1.NewTime(time.Now().UTC()))
+}
+
+// SkipPreDeployment marks the pre-deployment tasks and evaluations of the KeptnWorkloadInstance as succeeded without
+// running them, and states the reason in the PreDeploymentChecksSucceeded condition
+func (i *KeptnWorkloadInstance) SkipPreDeployment(reason string, message string) {
+	i.Status.PreDeploymentStatus = common.StateSucceeded
+	i.Status.PreDeploymentEvaluationStatus = common.StateSucceeded
+	i.setCondition(PreDeploymentChecksSucceededConditionType, metav1.ConditionTrue, reason, message, metav1.NewTime(time.Now().UTC()))
+}
+
+// SetAllowedTaskFailures sets the TasksFailureAllowed condition if any pre- or post-deployment task has failed
+// without blocking the deployment
+func (i *KeptnWorkloadInstance) SetAllowedTaskFailures() {
+	failed := getAllowedTaskFailures(i.Status.PreDeploymentTaskStatus, i.Status.PostDeploymentTaskStatus)
+	if len(failed) == 0 {
+		return
+	}
+	i.setCondition(TasksFailureAllowedConditionType, metav1.ConditionTrue, TaskFailureAllowedReason, getAllowedTaskFailuresMessage(failed), metav1.NewTime(time.Now().UTC()))
+}
+
+// getAllowedTaskFailures returns the names of the task definitions whose tasks have failed without blocking the deployment
+func getAllowedTaskFailures(taskStatuses ...[]TaskStatus) []string {
+	var failed []string
+	for _, statuses := range taskStatuses {
+		for _, status := range statuses {
+			if status.IsFailureAllowed() {
+				failed = append(failed, status.TaskDefinitionName)
+			}
+		}
+	}
+	return failed
+}
+
+func getAllowedTaskFailuresMessage(failed []string) string {
+	return fmt.Sprintf("tasks have failed without blocking the deployment: %s", strings.Join(failed, ", "))
+}
+
+// GetCondition returns the condition of the given type, or nil if it has not been set
+func (i KeptnWorkloadInstance) GetCondition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(i.Status.Conditions, conditionType)
+}
+
+// setCondition sets the condition of the given type. The transition time is only changed if its status changes.
+func (i *KeptnWorkloadInstance) setCondition(conditionType string, status metav1.ConditionStatus, reason string, message string, transitionTime metav1.Time) {
+	meta.SetStatusCondition(&i.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: i.Generation,
+		LastTransitionTime: transitionTime,
+	})
+}
+
+// GetPreDeploymentDuration returns the time the pre-deployment checks have taken, or 0 if they have not finished yet
+func (i KeptnWorkloadInstance) GetPreDeploymentDuration() time.Duration {
+	return common.Duration(i.Status.PreDeploymentStartTime, i.Status.PreDeploymentEndTime)
+}
+
+// ReleaseGate records the time the pods of the KeptnWorkloadInstance are released and how long they have been waiting
+func (i *KeptnWorkloadInstance) ReleaseGate() {
+	if !i.Status.GateReleaseTime.IsZero() {
+		return
+	}
+	i.Status.GateReleaseTime = metav1.NewTime(time.Now().UTC())
+	i.Status.GateWaitDuration = metav1.Duration{Duration: common.Duration(i.CreationTimestamp, i.Status.GateReleaseTime)}
+}
+
+// GetUserMetadata returns the metadata the user has attached to the workload
+func (i KeptnWorkloadInstance) GetUserMetadata() map[string]string {
+	return i.Spec.Metadata
+}
+
+// GetTaskSettings returns the settings of the task of the given KeptnTaskDefinition
+func (i KeptnWorkloadInstance) GetTaskSettings(taskDefinition string) TaskSettings {
+	for _, settings := range i.Spec.TaskSettings {
+		if settings.TaskDefinition == taskDefinition {
+			return settings
+		}
+	}
+	return TaskSettings{TaskDefinition: taskDefinition}
+}
+
+// WaitsForEndpoints reports whether the deployment phase waits for the endpoints of a Service
+func (i KeptnWorkloadInstance) WaitsForEndpoints() bool {
+	return i.Spec.Readiness.Mode == ReadinessModeEndpoints && i.Spec.Readiness.ServiceName != ""
+}
+
+func (i KeptnWorkloadInstance) IsTrafficSwitchPending() bool {
+	return i.Spec.TrafficSwitch.ServiceName != "" && i.Status.TrafficSwitchTime.IsZero()
+}
+
 func (i *KeptnWorkloadInstance) IsStartTimeSet() bool {
 	return !i.Status.StartTime.IsZero()
 }
@@ -204,6 +462,11 @@ func (i *TaskStatus) SetEndTime() {
 	}
 }
 
+// IsFailureAllowed returns true if the task has failed without blocking the deployment
+func (i TaskStatus) IsFailureAllowed() bool {
+	return i.Status == common.StateFailed && i.Reason == TaskFailureAllowedReason
+}
+
 func (i *EvaluationStatus) SetStartTime() {
 	if i.StartTime.IsZero() {
 		i.StartTime = metav1.NewTime(time.Now().UTC())
@@ -216,6 +479,72 @@ func (i *EvaluationStatus) SetEndTime() {
 	}
 }
 
+// getChecksInState returns the definition names of the tasks and evaluations in the given state, in the order in which they are run
+func getChecksInState(taskStatuses [][]TaskStatus, evaluationStatuses [][]EvaluationStatus, state common.KeptnState) []string {
+	var checks []string
+	for _, statuses := range taskStatuses {
+		for _, status := range statuses {
+			if status.Status == state {
+				checks = append(checks, status.TaskDefinitionName)
+			}
+		}
+	}
+	for _, statuses := range evaluationStatuses {
+		for _, status := range statuses {
+			if status.Status == state {
+				checks = append(checks, status.EvaluationDefinitionName)
+			}
+		}
+	}
+	return checks
+}
+
+// GetFailedChecks returns the names of the task and evaluation definitions whose checks have failed
+func (i KeptnWorkloadInstance) GetFailedChecks() []string {
+	return getChecksInState(
+		[][]TaskStatus{i.Status.PreDeploymentTaskStatus, i.Status.PostDeploymentTaskStatus},
+		[][]EvaluationStatus{i.Status.PreDeploymentEvaluationTaskStatus, i.Status.PostDeploymentEvaluationTaskStatus},
+		common.StateFailed,
+	)
+}
+
+// GetTaskSpanContexts returns the trace contexts of the spans of the tasks of the phase
+func (i KeptnWorkloadInstance) GetTaskSpanContexts(phase string) []map[string]string {
+	var statuses []TaskStatus
+	switch phase {
+	case common.PhaseWorkloadPreDeployment.ShortName:
+		statuses = i.Status.PreDeploymentTaskStatus
+	case common.PhaseWorkloadPostDeployment.ShortName:
+		statuses = i.Status.PostDeploymentTaskStatus
+	}
+	var spanContexts []map[string]string
+	for _, status := range statuses {
+		if len(status.SpanContext) > 0 {
+			spanContexts = append(spanContexts, status.SpanContext)
+		}
+	}
+	return spanContexts
+}
+
+// SetPhaseSpanContext records the trace context of the span of the current phase
+func (i *KeptnWorkloadInstance) SetPhaseSpanContext(spanContext map[string]string) {
+	i.Status.PhaseSpanContext = spanContext
+}
+
+// GetFailureMessage describes why the first failed pre-deployment task has failed
+func (i KeptnWorkloadInstance) GetFailureMessage() string {
+	return i.Status.PreDeploymentMessage
+}
+
+// GetPassedPreDeploymentChecks returns the names of the task and evaluation definitions whose pre-deployment checks have succeeded
+func (i KeptnWorkloadInstance) GetPassedPreDeploymentChecks() []string {
+	return getChecksInState(
+		[][]TaskStatus{i.Status.PreDeploymentTaskStatus},
+		[][]EvaluationStatus{i.Status.PreDeploymentEvaluationTaskStatus},
+		common.StateSucceeded,
+	)
+}
+
 func (i KeptnWorkloadInstance) GetActiveMetricsAttributes() []attribute.KeyValue {
 	return []attribute.KeyValue{
 		common.AppName.String(i.Spec.AppName),
@@ -235,6 +564,21 @@ func (i KeptnWorkloadInstance) GetMetricsAttributes() []attribute.KeyValue {
 	}
 }
 
+func (i KeptnWorkloadInstance) GetGateWaitMetricsAttributes(reason string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		common.AppName.String(i.Spec.AppName),
+		common.WorkloadNamespace.String(i.Namespace),
+		common.GateWaitReason.String(reason),
+	}
+}
+
+func (i KeptnWorkloadInstance) GetPreDeploymentMetricsAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		common.AppName.String(i.Spec.AppName),
+		common.WorkloadName.String(i.Spec.WorkloadName),
+	}
+}
+
 func (i KeptnWorkloadInstance) GetIntervalMetricsAttributes() []attribute.KeyValue {
 	return []attribute.KeyValue{
 		common.AppName.String(i.Spec.AppName),
@@ -257,11 +601,206 @@ func (i KeptnWorkloadInstance) GetCurrentPhase() string {
 }
 
 func (i *KeptnWorkloadInstance) SetCurrentPhase(phase string) {
+	if i.Status.CurrentPhase != phase || i.Status.PhaseStartTime.IsZero() {
+		i.Status.PhaseStartTime = metav1.NewTime(time.Now().UTC())
+	}
 	i.Status.CurrentPhase = phase
 }
 
+// IsStuck returns true if the KeptnWorkloadInstance has not completed and remained in its current phase for longer than the given threshold
+func (i KeptnWorkloadInstance) IsStuck(threshold time.Duration, now time.Time) bool {
+	if i.IsCompleted() || i.Status.PhaseStartTime.IsZero() {
+		return false
+	}
+	return common.Duration(i.Status.PhaseStartTime, metav1.NewTime(now)) > threshold
+}
+
+// SetStuck updates the Stuck condition and returns true if its status has changed
+func (i *KeptnWorkloadInstance) SetStuck(stuck bool, threshold time.Duration) bool {
+	condition := metav1.Condition{
+		Type:               StuckConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "InProgress",
+		Message:            fmt.Sprintf("phase %s is progressing", i.Status.CurrentPhase),
+		ObservedGeneration: i.Generation,
+	}
+	if stuck {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PhaseTimeExceeded"
+		condition.Message = fmt.Sprintf("phase %s has not finished within %s", i.Status.CurrentPhase, threshold)
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, StuckConditionType)
+	if existing == nil && !stuck {
+		return false
+	}
+	if existing != nil && existing.Status == condition.Status && (!stuck || existing.Message == condition.Message) {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, condition)
+	return true
+}
+
+// SetReleasePolicyDecision records the decision of the release policy in the ReleasePolicyAllowed condition and
+// returns true if its status or message has changed
+func (i *KeptnWorkloadInstance) SetReleasePolicyDecision(allowed bool, reason string, message string) bool {
+	status := metav1.ConditionFalse
+	if allowed {
+		status = metav1.ConditionTrue
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, ReleasePolicyConditionType)
+	if existing != nil && existing.Status == status && existing.Message == message {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, metav1.Condition{
+		Type:               ReleasePolicyConditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: i.Generation,
+	})
+	return true
+}
+
+// SetCreationQuotaExceeded updates the CreationQuotaExceeded condition and returns true if its status has changed
+func (i *KeptnWorkloadInstance) SetCreationQuotaExceeded(exceeded bool, message string) bool {
+	condition := metav1.Condition{
+		Type:               CreationQuotaExceededConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Created",
+		Message:            "checks have been created",
+		ObservedGeneration: i.Generation,
+	}
+	if exceeded {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "QuotaExceeded"
+		condition.Message = message
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, CreationQuotaExceededConditionType)
+	if existing == nil && !exceeded {
+		return false
+	}
+	if existing != nil && existing.Status == condition.Status {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, condition)
+	return true
+}
+
+// SetTooManyActiveVersions updates the TooManyActiveVersions condition and returns true if its status has changed
+func (i *KeptnWorkloadInstance) SetTooManyActiveVersions(parked bool, message string) bool {
+	condition := metav1.Condition{
+		Type:               TooManyActiveVersionsConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Started",
+		Message:            "the number of active versions of the workload allows starting",
+		ObservedGeneration: i.Generation,
+	}
+	if parked {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = TooManyActiveVersionsConditionType
+		condition.Message = message
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, TooManyActiveVersionsConditionType)
+	if existing == nil && !parked {
+		return false
+	}
+	if existing != nil && existing.Status == condition.Status {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, condition)
+	return true
+}
+
+// SetScaleUpGuardUnavailable updates the ScaleUpGuardUnavailable condition and returns true if its status has changed
+func (i *KeptnWorkloadInstance) SetScaleUpGuardUnavailable(unavailable bool, reason string, message string) bool {
+	condition := metav1.Condition{
+		Type:               ScaleUpGuardUnavailableConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Available",
+		Message:            "the APIs of the scale-up guard are served by the cluster",
+		ObservedGeneration: i.Generation,
+	}
+	if unavailable {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reason
+		condition.Message = message
+	}
+	existing := meta.FindStatusCondition(i.Status.Conditions, ScaleUpGuardUnavailableConditionType)
+	if existing == nil && !unavailable {
+		return false
+	}
+	if existing != nil && existing.Status == condition.Status {
+		return false
+	}
+	meta.SetStatusCondition(&i.Status.Conditions, condition)
+	return true
+}
+
+// GetActiveDuration returns the time the lifecycle has been active, which does not count the time it has been paused
+func (i KeptnWorkloadInstance) GetActiveDuration() time.Duration {
+	if i.Status.ActiveDuration == nil {
+		return common.Since(i.CreationTimestamp)
+	}
+	active := i.Status.ActiveDuration.Duration
+	if !i.Status.ActiveSince.IsZero() {
+		active += common.Since(i.Status.ActiveSince)
+	}
+	return active
+}
+
+// IsLifecyclePaused returns true if the lifecycle is paused, so that the time does not count as active
+func (i KeptnWorkloadInstance) IsLifecyclePaused() bool {
+	return i.Status.ActiveDuration != nil && i.Status.ActiveSince.IsZero()
+}
+
+// PauseLifecycle stops accumulating the active time of the lifecycle and returns true if it has been active
+func (i *KeptnWorkloadInstance) PauseLifecycle() bool {
+	if i.IsLifecyclePaused() {
+		return false
+	}
+	i.Status.ActiveDuration = &metav1.Duration{Duration: i.GetActiveDuration()}
+	i.Status.ActiveSince = metav1.Time{}
+	return true
+}
+
+// ResumeLifecycle accumulates the active time of a paused lifecycle again and returns true if it has been paused
+func (i *KeptnWorkloadInstance) ResumeLifecycle() bool {
+	if !i.IsLifecyclePaused() {
+		return false
+	}
+	i.Status.ActiveSince = metav1.NewTime(time.Now().UTC())
+	return true
+}
+
+// IsReleasePolicyAllowed returns true if the release policy has already allowed releasing the pods
+func (i KeptnWorkloadInstance) IsReleasePolicyAllowed() bool {
+	return meta.IsStatusConditionTrue(i.Status.Conditions, ReleasePolicyConditionType)
+}
+
 func (i *KeptnWorkloadInstance) Complete() {
+	i.CompleteWithReason("Completed", "workload instance has reached a terminal state")
+}
+
+// CompleteWithReason completes the KeptnWorkloadInstance and states the reason in its Completed condition
+func (i *KeptnWorkloadInstance) CompleteWithReason(reason string, message string) {
 	i.SetEndTime()
+	if i.Status.CompletedAt.IsZero() {
+		i.Status.CompletedAt = i.Status.EndTime
+	}
+	i.setCondition(CompletedConditionType, metav1.ConditionTrue, reason, message, i.Status.CompletedAt)
+	// pre-deployment checks that are still running when the instance is cancelled will never succeed
+	if condition := i.GetCondition(PreDeploymentChecksSucceededConditionType); condition != nil && condition.Status == metav1.ConditionUnknown {
+		i.setCondition(PreDeploymentChecksSucceededConditionType, metav1.ConditionFalse, reason, message, i.Status.CompletedAt)
+	}
+}
+
+// IsCompleted returns true if the Completed condition is set.
+// Instances that completed before the condition has been introduced are recognized by their end time.
+func (i KeptnWorkloadInstance) IsCompleted() bool {
+	if meta.IsStatusConditionTrue(i.Status.Conditions, CompletedConditionType) {
+		return true
+	}
+	return i.IsEndTimeSet()
 }
 
 func (i KeptnWorkloadInstance) GetVersion() string {
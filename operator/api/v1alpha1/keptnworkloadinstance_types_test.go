@@ -0,0 +1,145 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeptnWorkloadInstance_Complete(t *testing.T) {
+	instance := KeptnWorkloadInstance{}
+	require.False(t, instance.IsCompleted())
+
+	instance.Complete()
+	require.True(t, instance.IsCompleted())
+	require.False(t, instance.Status.CompletedAt.IsZero())
+	require.Equal(t, instance.Status.EndTime, instance.Status.CompletedAt)
+
+	completedAt := instance.Status.CompletedAt
+	instance.Complete()
+	require.Equal(t, completedAt, instance.Status.CompletedAt)
+	require.Len(t, instance.Status.Conditions, 1)
+}
+
+func TestKeptnWorkloadInstance_IsCompletedWithoutCondition(t *testing.T) {
+	instance := KeptnWorkloadInstance{
+		Status: KeptnWorkloadInstanceStatus{
+			EndTime: metav1.Now(),
+		},
+	}
+	require.True(t, instance.IsCompleted())
+	require.Nil(t, meta.FindStatusCondition(instance.Status.Conditions, CompletedConditionType))
+
+	instance.Complete()
+	require.True(t, meta.IsStatusConditionTrue(instance.Status.Conditions, CompletedConditionType))
+	require.Equal(t, instance.Status.EndTime, instance.Status.CompletedAt)
+}
+
+func TestKeptnWorkloadInstance_ReleaseGate(t *testing.T) {
+	instance := KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+	}
+	instance.ReleaseGate()
+	require.False(t, instance.Status.GateReleaseTime.IsZero())
+	require.GreaterOrEqual(t, instance.Status.GateWaitDuration.Duration, time.Minute)
+
+	releaseTime := instance.Status.GateReleaseTime
+	instance.ReleaseGate()
+	require.Equal(t, releaseTime, instance.Status.GateReleaseTime)
+}
+
+func TestKeptnWorkloadInstance_ActiveDuration(t *testing.T) {
+	instance := KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+	}
+	// a lifecycle that has never been paused has been active since the creation of the instance
+	require.False(t, instance.IsLifecyclePaused())
+	require.GreaterOrEqual(t, instance.GetActiveDuration(), time.Hour)
+	require.False(t, instance.ResumeLifecycle())
+
+	require.True(t, instance.PauseLifecycle())
+	require.False(t, instance.PauseLifecycle())
+	require.True(t, instance.IsLifecyclePaused())
+	require.GreaterOrEqual(t, instance.GetActiveDuration(), time.Hour)
+
+	// the time the lifecycle has been paused does not count
+	instance.Status.ActiveDuration.Duration = 10 * time.Minute
+	require.Equal(t, 10*time.Minute, instance.GetActiveDuration())
+	require.True(t, instance.ResumeLifecycle())
+	require.False(t, instance.IsLifecyclePaused())
+	instance.Status.ActiveSince = metav1.NewTime(instance.Status.ActiveSince.Add(-5 * time.Minute))
+	require.GreaterOrEqual(t, instance.GetActiveDuration(), 15*time.Minute)
+	require.Less(t, instance.GetActiveDuration(), 20*time.Minute)
+}
+
+func TestKeptnWorkloadInstance_PreDeploymentTimes(t *testing.T) {
+	instance := KeptnWorkloadInstance{}
+	require.Zero(t, instance.GetPreDeploymentDuration())
+
+	instance.StartPreDeployment()
+	startTime := instance.Status.PreDeploymentStartTime
+	require.False(t, startTime.IsZero())
+	require.Zero(t, instance.GetPreDeploymentDuration())
+
+	// the times survive requeues
+	instance.Status.PreDeploymentStartTime = metav1.NewTime(startTime.Add(-time.Minute))
+	instance.StartPreDeployment()
+	require.True(t, instance.EndPreDeployment())
+	require.False(t, instance.EndPreDeployment())
+	require.GreaterOrEqual(t, instance.GetPreDeploymentDuration(), time.Minute)
+}
+
+func TestKeptnWorkloadInstance_PreDeploymentConditions(t *testing.T) {
+	instance := KeptnWorkloadInstance{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	require.Nil(t, instance.GetCondition(PreDeploymentChecksStartedConditionType))
+	require.Nil(t, instance.GetCondition(PreDeploymentChecksSucceededConditionType))
+
+	instance.StartPreDeployment()
+	started := instance.GetCondition(PreDeploymentChecksStartedConditionType)
+	require.Equal(t, metav1.ConditionTrue, started.Status)
+	require.Equal(t, "Started", started.Reason)
+	require.Equal(t, int64(2), started.ObservedGeneration)
+	require.Equal(t, instance.Status.PreDeploymentStartTime, started.LastTransitionTime)
+	running := instance.GetCondition(PreDeploymentChecksSucceededConditionType)
+	require.Equal(t, metav1.ConditionUnknown, running.Status)
+	require.Equal(t, "InProgress", running.Reason)
+
+	// starting again does not touch the conditions
+	instance.Status.Conditions[0].Message = "unchanged"
+	instance.StartPreDeployment()
+	require.Equal(t, "unchanged", instance.Status.Conditions[0].Message)
+
+	succeeded := instance.DeepCopy()
+	succeeded.SetPreDeploymentResult(common.StateSucceeded, "pre-deployment checks have succeeded")
+	condition := succeeded.GetCondition(PreDeploymentChecksSucceededConditionType)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "Succeeded", condition.Reason)
+	require.Equal(t, "pre-deployment checks have succeeded", condition.Message)
+	// completing keeps the result
+	succeeded.Complete()
+	require.Equal(t, metav1.ConditionTrue, succeeded.GetCondition(PreDeploymentChecksSucceededConditionType).Status)
+	require.Equal(t, metav1.ConditionTrue, succeeded.GetCondition(CompletedConditionType).Status)
+
+	failed := instance.DeepCopy()
+	failed.SetPreDeploymentResult(common.StateFailed, "pre-deployment evaluations have failed")
+	condition = failed.GetCondition(PreDeploymentChecksSucceededConditionType)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "Failed", condition.Reason)
+	require.False(t, condition.LastTransitionTime.Before(&running.LastTransitionTime))
+
+	// checks that are still running when the instance is cancelled have failed
+	cancelled := instance.DeepCopy()
+	cancelled.CompleteWithReason("WorkloadDeleted", "workload has been deleted")
+	condition = cancelled.GetCondition(PreDeploymentChecksSucceededConditionType)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "WorkloadDeleted", condition.Reason)
+	require.True(t, cancelled.IsCompleted())
+}
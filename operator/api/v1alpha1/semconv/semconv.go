@@ -10,12 +10,14 @@ func AddAttributeFromWorkload(s trace.Span, w v1alpha1.KeptnWorkload) {
 	s.SetAttributes(common.AppName.String(w.Spec.AppName))
 	s.SetAttributes(common.WorkloadName.String(w.Name))
 	s.SetAttributes(common.WorkloadVersion.String(w.Spec.Version))
+	s.SetAttributes(common.GetMetadataAttributes(w.Spec.Metadata)...)
 }
 
 func AddAttributeFromWorkloadInstance(s trace.Span, w v1alpha1.KeptnWorkloadInstance) {
 	s.SetAttributes(common.AppName.String(w.Spec.AppName))
 	s.SetAttributes(common.WorkloadName.String(w.Spec.WorkloadName))
 	s.SetAttributes(common.WorkloadVersion.String(w.Spec.Version))
+	s.SetAttributes(common.GetMetadataAttributes(w.Spec.Metadata)...)
 }
 
 func AddAttributeFromApp(s trace.Span, a v1alpha1.KeptnApp) {
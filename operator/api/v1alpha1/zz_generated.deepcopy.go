@@ -22,9 +22,25 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApiAccess) DeepCopyInto(out *ApiAccess) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApiAccess.
+func (in *ApiAccess) DeepCopy() *ApiAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(ApiAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
 	*out = *in
@@ -55,6 +71,36 @@ func (in *ContainerSpec) DeepCopy() *ContainerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnforcementStatus) DeepCopyInto(out *EnforcementStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnforcementStatus.
+func (in *EnforcementStatus) DeepCopy() *EnforcementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnforcementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvFromMetadata) DeepCopyInto(out *EnvFromMetadata) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvFromMetadata.
+func (in *EnvFromMetadata) DeepCopy() *EnvFromMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvFromMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EvaluationStatus) DeepCopyInto(out *EvaluationStatus) {
 	*out = *in
@@ -111,6 +157,11 @@ func (in *FunctionSpec) DeepCopyInto(out *FunctionSpec) {
 	out.ConfigMapReference = in.ConfigMapReference
 	in.Parameters.DeepCopyInto(&out.Parameters)
 	out.SecureParameters = in.SecureParameters
+	if in.EnvFromMetadata != nil {
+		in, out := &in.EnvFromMetadata, &out.EnvFromMetadata
+		*out = make([]EnvFromMetadata, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionSpec.
@@ -168,6 +219,22 @@ func (in *Inline) DeepCopy() *Inline {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceReference) DeepCopyInto(out *InstanceReference) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceReference.
+func (in *InstanceReference) DeepCopy() *InstanceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeptnApp) DeepCopyInto(out *KeptnApp) {
 	*out = *in
@@ -255,6 +322,16 @@ func (in *KeptnAppSpec) DeepCopyInto(out *KeptnAppSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConcurrencyWaitTimeout != nil {
+		in, out := &in.ConcurrencyWaitTimeout, &out.ConcurrencyWaitTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.LifecycleDeadline != nil {
+		in, out := &in.LifecycleDeadline, &out.LifecycleDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnAppSpec.
@@ -370,7 +447,9 @@ func (in *KeptnAppVersionStatus) DeepCopyInto(out *KeptnAppVersionStatus) {
 	if in.WorkloadStatus != nil {
 		in, out := &in.WorkloadStatus, &out.WorkloadStatus
 		*out = make([]WorkloadStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.PreDeploymentTaskStatus != nil {
 		in, out := &in.PreDeploymentTaskStatus, &out.PreDeploymentTaskStatus
@@ -402,6 +481,13 @@ func (in *KeptnAppVersionStatus) DeepCopyInto(out *KeptnAppVersionStatus) {
 	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnAppVersionStatus.
@@ -684,6 +770,13 @@ func (in *KeptnEvaluationStatus) DeepCopyInto(out *KeptnEvaluationStatus) {
 	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnEvaluationStatus.
@@ -696,6 +789,103 @@ func (in *KeptnEvaluationStatus) DeepCopy() *KeptnEvaluationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeptnLifecycleOverview) DeepCopyInto(out *KeptnLifecycleOverview) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnLifecycleOverview.
+func (in *KeptnLifecycleOverview) DeepCopy() *KeptnLifecycleOverview {
+	if in == nil {
+		return nil
+	}
+	out := new(KeptnLifecycleOverview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeptnLifecycleOverview) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeptnLifecycleOverviewList) DeepCopyInto(out *KeptnLifecycleOverviewList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeptnLifecycleOverview, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnLifecycleOverviewList.
+func (in *KeptnLifecycleOverviewList) DeepCopy() *KeptnLifecycleOverviewList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeptnLifecycleOverviewList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeptnLifecycleOverviewList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeptnLifecycleOverviewSpec) DeepCopyInto(out *KeptnLifecycleOverviewSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnLifecycleOverviewSpec.
+func (in *KeptnLifecycleOverviewSpec) DeepCopy() *KeptnLifecycleOverviewSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeptnLifecycleOverviewSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeptnLifecycleOverviewStatus) DeepCopyInto(out *KeptnLifecycleOverviewStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceLifecycleSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastChangeTime.DeepCopyInto(&out.LastChangeTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnLifecycleOverviewStatus.
+func (in *KeptnLifecycleOverviewStatus) DeepCopy() *KeptnLifecycleOverviewStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeptnLifecycleOverviewStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeptnTask) DeepCopyInto(out *KeptnTask) {
 	*out = *in
@@ -786,6 +976,17 @@ func (in *KeptnTaskDefinitionList) DeepCopyObject() runtime.Object {
 func (in *KeptnTaskDefinitionSpec) DeepCopyInto(out *KeptnTaskDefinitionSpec) {
 	*out = *in
 	in.Function.DeepCopyInto(&out.Function)
+	out.ApiAccess = in.ApiAccess
+	if in.Cooldown != nil {
+		in, out := &in.Cooldown, &out.Cooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Queue != nil {
+		in, out := &in.Queue, &out.Queue
+		*out = new(TaskQueue)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnTaskDefinitionSpec.
@@ -852,6 +1053,23 @@ func (in *KeptnTaskSpec) DeepCopyInto(out *KeptnTaskSpec) {
 	out.Context = in.Context
 	in.Parameters.DeepCopyInto(&out.Parameters)
 	out.SecureParameters = in.SecureParameters
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.PhaseSpanContext != nil {
+		in, out := &in.PhaseSpanContext, &out.PhaseSpanContext
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnTaskSpec.
@@ -869,6 +1087,15 @@ func (in *KeptnTaskStatus) DeepCopyInto(out *KeptnTaskStatus) {
 	*out = *in
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	out.SchedulingDuration = in.SchedulingDuration
+	out.ExecutionDuration = in.ExecutionDuration
+	if in.SpanContext != nil {
+		in, out := &in.SpanContext, &out.SpanContext
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnTaskStatus.
@@ -978,6 +1205,14 @@ func (in *KeptnWorkloadInstanceSpec) DeepCopyInto(out *KeptnWorkloadInstanceSpec
 			(*out)[key] = val
 		}
 	}
+	out.Readiness = in.Readiness
+	if in.TaskSettings != nil {
+		in, out := &in.TaskSettings, &out.TaskSettings
+		*out = make([]TaskSettings, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnWorkloadInstanceSpec.
@@ -1023,6 +1258,38 @@ func (in *KeptnWorkloadInstanceStatus) DeepCopyInto(out *KeptnWorkloadInstanceSt
 	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	in.PhaseStartTime.DeepCopyInto(&out.PhaseStartTime)
+	in.PreDeploymentStartTime.DeepCopyInto(&out.PreDeploymentStartTime)
+	in.PreDeploymentEndTime.DeepCopyInto(&out.PreDeploymentEndTime)
+	in.GateReleaseTime.DeepCopyInto(&out.GateReleaseTime)
+	out.GateWaitDuration = in.GateWaitDuration
+	in.TrafficSwitchTime.DeepCopyInto(&out.TrafficSwitchTime)
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+	if in.ActiveDuration != nil {
+		in, out := &in.ActiveDuration, &out.ActiveDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.ActiveSince.DeepCopyInto(&out.ActiveSince)
+	if in.Enforcement != nil {
+		in, out := &in.Enforcement, &out.Enforcement
+		*out = new(EnforcementStatus)
+		**out = **in
+	}
+	if in.PhaseSpanContext != nil {
+		in, out := &in.PhaseSpanContext, &out.PhaseSpanContext
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnWorkloadInstanceStatus.
@@ -1106,6 +1373,19 @@ func (in *KeptnWorkloadSpec) DeepCopyInto(out *KeptnWorkloadSpec) {
 		copy(*out, *in)
 	}
 	out.ResourceReference = in.ResourceReference
+	if in.LifecycleDeadline != nil {
+		in, out := &in.LifecycleDeadline, &out.LifecycleDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.TrafficSwitch.DeepCopyInto(&out.TrafficSwitch)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeptnWorkloadSpec.
@@ -1133,9 +1413,35 @@ func (in *KeptnWorkloadStatus) DeepCopy() *KeptnWorkloadStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceLifecycleSummary) DeepCopyInto(out *NamespaceLifecycleSummary) {
+	*out = *in
+	if in.OldestStuckInstance != nil {
+		in, out := &in.OldestStuckInstance, &out.OldestStuckInstance
+		*out = new(InstanceReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastFailure != nil {
+		in, out := &in.LastFailure, &out.LastFailure
+		*out = new(InstanceReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceLifecycleSummary.
+func (in *NamespaceLifecycleSummary) DeepCopy() *NamespaceLifecycleSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceLifecycleSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Objective) DeepCopyInto(out *Objective) {
 	*out = *in
+	out.Window = in.Window
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Objective.
@@ -1148,6 +1454,21 @@ func (in *Objective) DeepCopy() *Objective {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Readiness) DeepCopyInto(out *Readiness) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Readiness.
+func (in *Readiness) DeepCopy() *Readiness {
+	if in == nil {
+		return nil
+	}
+	out := new(Readiness)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceReference) DeepCopyInto(out *ResourceReference) {
 	*out = *in
@@ -1215,11 +1536,64 @@ func (in *TaskParameters) DeepCopy() *TaskParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskQueue) DeepCopyInto(out *TaskQueue) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskQueue.
+func (in *TaskQueue) DeepCopy() *TaskQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskSettings) DeepCopyInto(out *TaskSettings) {
+	*out = *in
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSettings.
+func (in *TaskSettings) DeepCopy() *TaskSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
 	*out = *in
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	in.EarliestStartTime.DeepCopyInto(&out.EarliestStartTime)
+	if in.SpanContext != nil {
+		in, out := &in.SpanContext, &out.SpanContext
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
@@ -1232,10 +1606,33 @@ func (in *TaskStatus) DeepCopy() *TaskStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSwitch) DeepCopyInto(out *TrafficSwitch) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSwitch.
+func (in *TrafficSwitch) DeepCopy() *TrafficSwitch {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSwitch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadStatus) DeepCopyInto(out *WorkloadStatus) {
 	*out = *in
 	out.Workload = in.Workload
+	in.ScaledToZeroTime.DeepCopyInto(&out.ScaledToZeroTime)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadStatus.
@@ -0,0 +1,64 @@
+// convert migrates the shipyard of a Keptn v1 project, and optionally the config of its job executor service, to
+// the KeptnTaskDefinitions, KeptnEvaluationDefinitions and KeptnApp of the lifecycle toolkit.
+//
+//	convert --shipyard shipyard.yaml [--job-config job/config.yaml] [--stage dev] > checks.yaml
+//
+// The constructs that cannot be converted faithfully are reported on stderr.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/keptn/lifecycle-toolkit/operator/internal/shipyard"
+)
+
+func main() {
+	var shipyardPath string
+	var jobConfigPath string
+	var options shipyard.Options
+	flag.StringVar(&shipyardPath, "shipyard", "shipyard.yaml", "The shipyard.yaml of the Keptn v1 project.")
+	flag.StringVar(&jobConfigPath, "job-config", "", "The job/config.yaml of the job executor service. If empty, placeholder task definitions are emitted.")
+	flag.StringVar(&options.Stage, "stage", "", "The stage whose delivery sequence is converted. If empty, the first stage is converted.")
+	flag.StringVar(&options.AppName, "app", "", "The name of the KeptnApp. If empty, the name of the shipyard is used.")
+	flag.StringVar(&options.AppVersion, "app-version", shipyard.DefaultAppVersion, "The version of the KeptnApp.")
+	flag.StringVar(&options.Namespace, "namespace", "", "The namespace of the converted objects. If empty, no namespace is set.")
+	flag.Parse()
+
+	if err := run(shipyardPath, jobConfigPath, options); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(shipyardPath string, jobConfigPath string, options shipyard.Options) error {
+	data, err := os.ReadFile(shipyardPath)
+	if err != nil {
+		return err
+	}
+	parsedShipyard, err := shipyard.ParseShipyard(data)
+	if err != nil {
+		return err
+	}
+
+	var jobConfig *shipyard.JobConfig
+	if jobConfigPath != "" {
+		data, err := os.ReadFile(jobConfigPath)
+		if err != nil {
+			return err
+		}
+		if jobConfig, err = shipyard.ParseJobConfig(data); err != nil {
+			return err
+		}
+	}
+
+	result, err := shipyard.Convert(parsedShipyard, jobConfig, options)
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	return shipyard.WriteYAML(os.Stdout, result.Objects)
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AllowedClusterRolesEnv holds a comma separated list of ClusterRoles that KeptnTaskDefinitions may request via
+// apiAccess. The operator is allowed to bind any ClusterRole, so this list is the only guard against task definitions
+// granting themselves arbitrary permissions.
+const AllowedClusterRolesEnv = "TASK_API_ACCESS_CLUSTER_ROLES"
+
+// IsClusterRoleAllowed returns whether the ClusterRole is listed in AllowedClusterRolesEnv
+func IsClusterRoleAllowed(clusterRole string) bool {
+	if clusterRole == "" {
+		return false
+	}
+	for _, allowed := range strings.Split(os.Getenv(AllowedClusterRolesEnv), ",") {
+		if strings.TrimSpace(allowed) == clusterRole {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateApiAccess returns an error if a KeptnTaskDefinition requests a ClusterRole that is not allowed
+func ValidateApiAccess(clusterRole string) error {
+	if clusterRole == "" || IsClusterRoleAllowed(clusterRole) {
+		return nil
+	}
+	return fmt.Errorf("ClusterRole %s is not allowed for apiAccess, allowed ClusterRoles are listed in %s", clusterRole, AllowedClusterRolesEnv)
+}
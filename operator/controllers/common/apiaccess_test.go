@@ -0,0 +1,28 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsClusterRoleAllowed(t *testing.T) {
+	t.Setenv(AllowedClusterRolesEnv, "view, keptn-deployer")
+
+	require.True(t, IsClusterRoleAllowed("view"))
+	require.True(t, IsClusterRoleAllowed("keptn-deployer"))
+	require.False(t, IsClusterRoleAllowed("cluster-admin"))
+	require.False(t, IsClusterRoleAllowed("vie"))
+	require.False(t, IsClusterRoleAllowed(""))
+
+	require.Nil(t, ValidateApiAccess(""))
+	require.Nil(t, ValidateApiAccess("view"))
+	require.EqualError(t, ValidateApiAccess("cluster-admin"), "ClusterRole cluster-admin is not allowed for apiAccess, allowed ClusterRoles are listed in TASK_API_ACCESS_CLUSTER_ROLES")
+}
+
+func TestIsClusterRoleAllowed_EmptyList(t *testing.T) {
+	t.Setenv(AllowedClusterRolesEnv, "")
+
+	require.False(t, IsClusterRoleAllowed("view"))
+	require.False(t, IsClusterRoleAllowed(""))
+}
@@ -0,0 +1,143 @@
+package common
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+)
+
+const DefaultCapabilityRefreshInterval = 5 * time.Minute
+
+// Capability is an optional API an integration of the operator depends on
+type Capability struct {
+	GroupVersion string
+	Resource     string
+}
+
+func (c Capability) String() string {
+	return c.Resource + "." + c.GroupVersion
+}
+
+// CapabilityHorizontalPodAutoscalerV2 is needed by the scale-up guard, it is served by Kubernetes 1.23 and later
+var CapabilityHorizontalPodAutoscalerV2 = Capability{GroupVersion: "autoscaling/v2", Resource: "horizontalpodautoscalers"}
+
+var knownCapabilities = []Capability{
+	CapabilityHorizontalPodAutoscalerV2,
+}
+
+// Capabilities keeps track of the optional APIs served by the cluster. They are discovered by Refresh, which is
+// called periodically by Start, so that APIs that are installed or removed later on are picked up.
+// A nil *Capabilities reports every capability as available.
+type Capabilities struct {
+	Discovery discovery.DiscoveryInterface
+	Log       logr.Logger
+	Interval  time.Duration
+
+	mu        sync.RWMutex
+	available map[Capability]bool
+}
+
+func NewCapabilities(discovery discovery.DiscoveryInterface, log logr.Logger) *Capabilities {
+	return &Capabilities{
+		Discovery: discovery,
+		Log:       log,
+		Interval:  DefaultCapabilityRefreshInterval,
+		available: map[Capability]bool{},
+	}
+}
+
+// Has returns true if the API of the capability is served by the cluster
+func (c *Capabilities) Has(capability Capability) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.available[capability]
+}
+
+// Refresh discovers the known capabilities. If the discovery of a capability fails for any other reason than
+// the API not being served, its previous state is kept and the error is returned.
+func (c *Capabilities) Refresh() error {
+	var firstErr error
+	for _, capability := range knownCapabilities {
+		available, err := c.discover(capability)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		c.mu.Lock()
+		previous, known := c.available[capability]
+		c.available[capability] = available
+		c.mu.Unlock()
+		if !known || previous != available {
+			c.Log.Info("discovered capability", "capability", capability.String(), "available", available)
+		}
+	}
+	return firstErr
+}
+
+func (c *Capabilities) discover(capability Capability) (bool, error) {
+	resources, err := c.Discovery.ServerResourcesForGroupVersion(capability.GroupVersion)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == capability.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Start refreshes the capabilities until the given context is cancelled. It implements manager.Runnable.
+func (c *Capabilities) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.Refresh(); err != nil {
+			c.Log.Error(err, "could not discover capabilities")
+		}
+	}, c.Interval)
+	return nil
+}
+
+// NeedLeaderElection returns false, since every replica needs to know the capabilities of the cluster
+func (c *Capabilities) NeedLeaderElection() bool {
+	return false
+}
+
+// GetCapabilities returns 1 for every available and 0 for every missing capability
+func (c *Capabilities) GetCapabilities() []common.GaugeValue {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	res := make([]common.GaugeValue, 0, len(c.available))
+	for capability, available := range c.available {
+		value := int64(0)
+		if available {
+			value = 1
+		}
+		res = append(res, common.GaugeValue{
+			Value:      value,
+			Attributes: []attribute.KeyValue{common.CapabilityName.String(capability.String())},
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Attributes[0].Value.AsString() < res[j].Attributes[0].Value.AsString()
+	})
+	return res
+}
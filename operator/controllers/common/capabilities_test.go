@@ -0,0 +1,42 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCapabilities_Refresh(t *testing.T) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	capabilities := NewCapabilities(discovery, logr.Discard())
+
+	// autoscaling/v2 is not served
+	require.Nil(t, capabilities.Refresh())
+	require.False(t, capabilities.Has(CapabilityHorizontalPodAutoscalerV2))
+	gauges := capabilities.GetCapabilities()
+	require.Len(t, gauges, 1)
+	require.Equal(t, int64(0), gauges[0].Value)
+	require.Equal(t, "horizontalpodautoscalers.autoscaling/v2", gauges[0].Attributes[0].Value.AsString())
+
+	// autoscaling/v2 has been installed later on
+	discovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "autoscaling/v2",
+			APIResources: []metav1.APIResource{{Name: "horizontalpodautoscalers"}},
+		},
+	}
+	require.Nil(t, capabilities.Refresh())
+	require.True(t, capabilities.Has(CapabilityHorizontalPodAutoscalerV2))
+	gauges = capabilities.GetCapabilities()
+	require.Len(t, gauges, 1)
+	require.Equal(t, int64(1), gauges[0].Value)
+}
+
+func TestCapabilities_Nil(t *testing.T) {
+	var capabilities *Capabilities
+	require.True(t, capabilities.Has(CapabilityHorizontalPodAutoscalerV2))
+}
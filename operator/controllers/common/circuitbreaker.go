@@ -0,0 +1,163 @@
+package common
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenDuration     = 30 * time.Second
+)
+
+// BreakerState is the state of the circuit breaker of a single target
+type BreakerState int64
+
+const (
+	// BreakerClosed lets all requests pass
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe pass after the breaker has been open for the open duration
+	BreakerHalfOpen
+	// BreakerOpen rejects all requests
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakers holds a circuit breaker for every target, e.g. a KeptnEvaluationProvider, that is shared by all
+// requests against that target. A breaker opens after FailureThreshold consecutive failures and rejects requests
+// for OpenDuration, after which a single probe is let through. A successful probe closes the breaker, a failed one
+// opens it again.
+// A nil *CircuitBreakers lets all requests pass.
+type CircuitBreakers struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+type breaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probedAt time.Time
+}
+
+// NewCircuitBreakers returns circuit breakers that open after failureThreshold consecutive failures.
+// If failureThreshold is not positive, nil is returned and requests are never rejected.
+func NewCircuitBreakers(failureThreshold int, openDuration time.Duration) *CircuitBreakers {
+	if failureThreshold <= 0 {
+		return nil
+	}
+	return &CircuitBreakers{
+		FailureThreshold: failureThreshold,
+		OpenDuration:     openDuration,
+		breakers:         map[string]*breaker{},
+		now:              time.Now,
+	}
+}
+
+// Allow returns true if a request against the target may be sent.
+// If the breaker of the target is open for longer than the open duration, the caller becomes the half-open probe
+// and must report its outcome with Record.
+func (c *CircuitBreakers) Allow(target string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[target]
+	if !ok {
+		return true
+	}
+	switch b.state {
+	case BreakerOpen:
+		if c.now().Sub(b.openedAt) < c.OpenDuration {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probedAt = c.now()
+		return true
+	case BreakerHalfOpen:
+		// a probe is already in flight, unless its outcome has not been reported within the open duration
+		if c.now().Sub(b.probedAt) < c.OpenDuration {
+			return false
+		}
+		b.probedAt = c.now()
+		return true
+	default:
+		return true
+	}
+}
+
+// Record reports the outcome of a request against the target
+func (c *CircuitBreakers) Record(target string, success bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[target]
+	if !ok {
+		b = &breaker{}
+		c.breakers[target] = b
+	}
+	if success {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= c.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = c.now()
+	}
+}
+
+// State returns the state of the breaker of the target
+func (c *CircuitBreakers) State(target string) BreakerState {
+	if c == nil {
+		return BreakerClosed
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.breakers[target]; ok {
+		return b.state
+	}
+	return BreakerClosed
+}
+
+// GetStates returns the state of every known breaker, to be observed by a gauge
+func (c *CircuitBreakers) GetStates() []common.GaugeValue {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]common.GaugeValue, 0, len(c.breakers))
+	for target, b := range c.breakers {
+		res = append(res, common.GaugeValue{
+			Value:      int64(b.state),
+			Attributes: []attribute.KeyValue{common.BreakerTarget.String(target)},
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Attributes[0].Value.AsString() < res[j].Attributes[0].Value.AsString()
+	})
+	return res
+}
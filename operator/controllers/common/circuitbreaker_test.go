@@ -0,0 +1,59 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	now := time.Now()
+	breakers := NewCircuitBreakers(2, time.Minute)
+	breakers.now = func() time.Time { return now }
+
+	// a single failure does not open the breaker
+	require.True(t, breakers.Allow("default/prometheus"))
+	breakers.Record("default/prometheus", false)
+	require.Equal(t, BreakerClosed, breakers.State("default/prometheus"))
+	require.True(t, breakers.Allow("default/prometheus"))
+
+	// consecutive failures open it
+	breakers.Record("default/prometheus", false)
+	require.Equal(t, BreakerOpen, breakers.State("default/prometheus"))
+	require.False(t, breakers.Allow("default/prometheus"))
+	require.True(t, breakers.Allow("default/other"))
+
+	// a single probe is let through after the open duration
+	now = now.Add(time.Minute)
+	require.True(t, breakers.Allow("default/prometheus"))
+	require.Equal(t, BreakerHalfOpen, breakers.State("default/prometheus"))
+	require.False(t, breakers.Allow("default/prometheus"))
+
+	// a failed probe opens the breaker again
+	breakers.Record("default/prometheus", false)
+	require.Equal(t, BreakerOpen, breakers.State("default/prometheus"))
+	require.False(t, breakers.Allow("default/prometheus"))
+
+	// a successful probe closes it
+	now = now.Add(time.Minute)
+	require.True(t, breakers.Allow("default/prometheus"))
+	breakers.Record("default/prometheus", true)
+	require.Equal(t, BreakerClosed, breakers.State("default/prometheus"))
+	require.True(t, breakers.Allow("default/prometheus"))
+
+	states := breakers.GetStates()
+	require.Len(t, states, 1)
+	require.Equal(t, int64(BreakerClosed), states[0].Value)
+	require.Equal(t, "default/prometheus", states[0].Attributes[0].Value.AsString())
+}
+
+func TestCircuitBreakers_Nil(t *testing.T) {
+	breakers := NewCircuitBreakers(0, time.Minute)
+	require.Nil(t, breakers)
+
+	breakers.Record("default/prometheus", false)
+	require.True(t, breakers.Allow("default/prometheus"))
+	require.Equal(t, BreakerClosed, breakers.State("default/prometheus"))
+	require.Empty(t, breakers.GetStates())
+}
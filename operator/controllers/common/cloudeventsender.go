@@ -0,0 +1,309 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	apicommon "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	DefaultCloudEventQueueSize       = 1000
+	DefaultCloudEventMaxAttempts     = 5
+	DefaultCloudEventShutdownTimeout = 10 * time.Second
+	cloudEventRetryInterval          = 500 * time.Millisecond
+	cloudEventMaxRetryInterval       = 30 * time.Second
+	cloudEventSource                 = "keptn-lifecycle-operator"
+	cloudEventSpecVersion            = "1.0"
+)
+
+const (
+	// CloudEventTypeWorkloadInstanceFinished is the type of the CloudEvent sent when a KeptnWorkloadInstance has completed
+	CloudEventTypeWorkloadInstanceFinished = "sh.keptn.lifecycle.workloadinstance.finished"
+	// CloudEventTypeAppVersionFinished is the type of the CloudEvent sent when a KeptnAppVersion has completed
+	CloudEventTypeAppVersionFinished = "sh.keptn.lifecycle.appversion.finished"
+)
+
+const (
+	// CloudEventDropReasonOverflow is reported for the oldest queued event that has been dropped to make room for a new one
+	CloudEventDropReasonOverflow = "overflow"
+	// CloudEventDropReasonRetries is reported for an event that could not be delivered within the maximum number of attempts
+	CloudEventDropReasonRetries = "retries"
+	// CloudEventDropReasonShutdown is reported for an event that could not be delivered before the shutdown deadline
+	CloudEventDropReasonShutdown = "shutdown"
+)
+
+// CloudEvent is a CloudEvent in the structured JSON format
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+	// Extensions are extension attributes, which are sent next to the context attributes of the event
+	Extensions map[string]string `json:"-"`
+}
+
+// MarshalJSON adds the extension attributes to the structured JSON format. Extensions never override context attributes.
+func (e CloudEvent) MarshalJSON() ([]byte, error) {
+	type cloudEvent CloudEvent
+	raw, err := json.Marshal(cloudEvent(e))
+	if err != nil || len(e.Extensions) == 0 {
+		return raw, err
+	}
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return nil, err
+	}
+	for name, value := range e.Extensions {
+		if _, ok := attributes[name]; ok {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		attributes[name] = encoded
+	}
+	return json.Marshal(attributes)
+}
+
+// NewCloudEvent returns a CloudEvent of the given type carrying the given data as JSON
+func NewCloudEvent(eventType string, subject string, data interface{}) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     cloudEventSpecVersion,
+		ID:              string(uuid.NewUUID()),
+		Source:          cloudEventSource,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// CloudEventSender sends CloudEvents to an HTTP sink without blocking the reconciliations that produce them.
+// Events are kept in a bounded in-memory queue. If the sink cannot keep up and the queue is full, the oldest queued
+// event is dropped to make room for the new one, so that the most recent state is delivered first once the sink
+// recovers. Each event is retried with an exponentially increasing interval up to MaxAttempts times.
+// On shutdown, the queued events are sent until ShutdownTimeout has passed. Every dropped event is counted by reason.
+// A nil *CloudEventSender is valid and does not send anything.
+type CloudEventSender struct {
+	URL              string
+	Client           *http.Client
+	Log              logr.Logger
+	Dropped          syncint64.Counter
+	MaxAttempts      int
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	ShutdownTimeout  time.Duration
+
+	mu      sync.Mutex
+	queue   []CloudEvent
+	head    int
+	length  int
+	notify  chan struct{}
+	dropped int64
+}
+
+func NewCloudEventSender(url string, queueSize int, maxAttempts int, dropped syncint64.Counter, log logr.Logger) *CloudEventSender {
+	return &CloudEventSender{
+		URL:              url,
+		Client:           NewHTTPClient(10 * time.Second),
+		Log:              log,
+		Dropped:          dropped,
+		MaxAttempts:      maxAttempts,
+		RetryInterval:    cloudEventRetryInterval,
+		MaxRetryInterval: cloudEventMaxRetryInterval,
+		ShutdownTimeout:  DefaultCloudEventShutdownTimeout,
+		queue:            make([]CloudEvent, queueSize),
+		notify:           make(chan struct{}, 1),
+	}
+}
+
+// Send queues the event without blocking. It returns false if the oldest queued event has been dropped to make room.
+func (s *CloudEventSender) Send(event CloudEvent) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	overflow := s.length == len(s.queue)
+	if overflow {
+		s.head = (s.head + 1) % len(s.queue)
+		s.length--
+	}
+	s.queue[(s.head+s.length)%len(s.queue)] = event
+	s.length++
+	s.mu.Unlock()
+
+	if overflow {
+		s.drop(1, CloudEventDropReasonOverflow)
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return !overflow
+}
+
+// QueueLength returns the number of events waiting to be sent
+func (s *CloudEventSender) QueueLength() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.length
+}
+
+// DroppedCount returns the number of events that have been dropped so far
+func (s *CloudEventSender) DroppedCount() int64 {
+	if s == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Start sends the queued events until the given context is cancelled and then flushes the queue until the
+// shutdown deadline. It implements manager.Runnable.
+func (s *CloudEventSender) Start(ctx context.Context) error {
+	for {
+		if ctx.Err() != nil {
+			s.flush(nil)
+			return nil
+		}
+		event, ok := s.pop()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				s.flush(nil)
+				return nil
+			case <-s.notify:
+			}
+			continue
+		}
+		// a request in flight is completed on shutdown, so that its event is not delivered twice
+		if s.send(ctx, context.Background(), event) == sendInterrupted {
+			// the event is sent with the rest of the queue
+			s.flush(&event)
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection returns true, since only the leader reconciles and produces events
+func (s *CloudEventSender) NeedLeaderElection() bool {
+	return true
+}
+
+// flush sends the pending event and the queued events until the shutdown deadline and drops the rest
+func (s *CloudEventSender) flush(pending *CloudEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	for {
+		var event CloudEvent
+		if pending != nil {
+			event = *pending
+			pending = nil
+		} else if next, ok := s.pop(); ok {
+			event = next
+		} else {
+			return
+		}
+		if ctx.Err() != nil || s.send(ctx, ctx, event) == sendInterrupted {
+			s.drop(1, CloudEventDropReasonShutdown)
+		}
+	}
+}
+
+func (s *CloudEventSender) pop() (CloudEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.length == 0 {
+		return CloudEvent{}, false
+	}
+	event := s.queue[s.head]
+	s.queue[s.head] = CloudEvent{}
+	s.head = (s.head + 1) % len(s.queue)
+	s.length--
+	return event, true
+}
+
+type sendResult int
+
+const (
+	sendDelivered sendResult = iota
+	sendDropped
+	sendInterrupted
+)
+
+// send sends the event, retrying with an increasing interval. An event that has used up its attempts is dropped.
+// If the context is done before, the event is left to the caller. The requests are bound to requestCtx.
+func (s *CloudEventSender) send(ctx context.Context, requestCtx context.Context, event CloudEvent) sendResult {
+	body, err := json.Marshal(event)
+	if err != nil {
+		s.Log.Error(err, "could not encode CloudEvent", "type", event.Type)
+		s.drop(1, CloudEventDropReasonRetries)
+		return sendDropped
+	}
+
+	interval := s.RetryInterval
+	for attempt := 1; ; attempt++ {
+		if err = s.post(requestCtx, body); err == nil {
+			return sendDelivered
+		}
+		if ctx.Err() != nil {
+			return sendInterrupted
+		}
+		if attempt >= s.MaxAttempts {
+			s.Log.Error(err, "could not send CloudEvent", "type", event.Type, "attempts", attempt)
+			s.drop(1, CloudEventDropReasonRetries)
+			return sendDropped
+		}
+		select {
+		case <-ctx.Done():
+			return sendInterrupted
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > s.MaxRetryInterval {
+			interval = s.MaxRetryInterval
+		}
+	}
+}
+
+func (s *CloudEventSender) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *CloudEventSender) drop(count int64, reason string) {
+	atomic.AddInt64(&s.dropped, count)
+	if s.Dropped != nil {
+		s.Dropped.Add(context.Background(), count, apicommon.CloudEventDropReason.String(reason))
+	}
+}
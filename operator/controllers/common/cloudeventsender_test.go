@@ -0,0 +1,178 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+)
+
+func startCloudEventSender(t *testing.T, sender *CloudEventSender) (context.CancelFunc, chan struct{}) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		require.Nil(t, sender.Start(ctx))
+		close(done)
+	}()
+	return cancel, done
+}
+
+func TestCloudEventSender_Send(t *testing.T) {
+	received := make(chan CloudEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+		event := CloudEvent{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	sender := NewCloudEventSender(server.URL, 10, DefaultCloudEventMaxAttempts, nil, logr.Discard())
+	cancel, done := startCloudEventSender(t, sender)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.True(t, sender.Send(NewCloudEvent("sh.keptn.lifecycle.workloadinstance.finished", "default/my-app-my-workload-1.0.0", LifecycleRecord{Version: "1.0.0"})))
+	event := <-received
+	require.Equal(t, "1.0", event.SpecVersion)
+	require.Equal(t, "sh.keptn.lifecycle.workloadinstance.finished", event.Type)
+	require.Equal(t, "default/my-app-my-workload-1.0.0", event.Subject)
+	require.NotEmpty(t, event.ID)
+	require.Equal(t, "1.0.0", event.Data.(map[string]interface{})["version"])
+
+	var disabled *CloudEventSender
+	require.False(t, disabled.Send(CloudEvent{}))
+	require.Zero(t, disabled.QueueLength())
+}
+
+func TestCloudEvent_MarshalJSONExtensions(t *testing.T) {
+	event := NewCloudEvent("sh.keptn.lifecycle.workloadinstance.finished", "default/my-app-my-workload-1.0.0", LifecycleRecord{Version: "1.0.0"})
+	event.Extensions = map[string]string{"ticket": "ABC-123", "type": "overridden"}
+
+	raw, err := json.Marshal(event)
+	require.Nil(t, err)
+	attributes := map[string]interface{}{}
+	require.Nil(t, json.Unmarshal(raw, &attributes))
+	require.Equal(t, "ABC-123", attributes["ticket"])
+	// extensions never override context attributes
+	require.Equal(t, "sh.keptn.lifecycle.workloadinstance.finished", attributes["type"])
+	require.Equal(t, "1.0.0", attributes["data"].(map[string]interface{})["version"])
+}
+
+func TestCloudEventSender_DropsOldestEvent(t *testing.T) {
+	sender := NewCloudEventSender("http://localhost", 2, DefaultCloudEventMaxAttempts, nil, logr.Discard())
+	require.True(t, sender.Send(CloudEvent{ID: "1"}))
+	require.True(t, sender.Send(CloudEvent{ID: "2"}))
+	require.False(t, sender.Send(CloudEvent{ID: "3"}))
+	require.Equal(t, 2, sender.QueueLength())
+	require.Equal(t, int64(1), sender.DroppedCount())
+
+	event, _ := sender.pop()
+	require.Equal(t, "2", event.ID)
+	event, _ = sender.pop()
+	require.Equal(t, "3", event.ID)
+}
+
+func TestCloudEventSender_RetriesUntilMaxAttempts(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewCloudEventSender(server.URL, 10, 3, nil, logr.Discard())
+	sender.RetryInterval = time.Millisecond
+
+	// the event is delivered with the last attempt
+	require.Equal(t, sendDelivered, sender.send(context.TODO(), context.TODO(), CloudEvent{}))
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	// the next one fails on all attempts and is dropped
+	atomic.StoreInt32(&attempts, -10)
+	require.Equal(t, sendDropped, sender.send(context.TODO(), context.TODO(), CloudEvent{}))
+	require.Equal(t, int32(-7), atomic.LoadInt32(&attempts))
+	require.Equal(t, int64(1), sender.DroppedCount())
+}
+
+func TestCloudEventSender_ShutdownFlushesWithDeadline(t *testing.T) {
+	var delivered int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer server.Close()
+
+	sender := NewCloudEventSender(server.URL, 100, DefaultCloudEventMaxAttempts, nil, logr.Discard())
+	sender.ShutdownTimeout = 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		sender.Send(CloudEvent{})
+	}
+
+	// the sender is stopped before it has started, so all events are sent by the flush
+	cancel, done := startCloudEventSender(t, sender)
+	cancel()
+	start := time.Now()
+	<-done
+
+	require.Less(t, time.Since(start), time.Second)
+	require.Zero(t, sender.QueueLength())
+	require.Greater(t, atomic.LoadInt32(&delivered), int32(0))
+	require.Equal(t, int64(100), int64(atomic.LoadInt32(&delivered))+sender.DroppedCount())
+}
+
+// TestCloudEventSender_Soak pushes events faster than a slow sink can take them and verifies that the queue stays
+// bounded and every event is either delivered or counted as dropped
+func TestCloudEventSender_Soak(t *testing.T) {
+	const (
+		queueSize = 50
+		events    = 5000
+	)
+	var delivered int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer server.Close()
+
+	sender := NewCloudEventSender(server.URL, queueSize, DefaultCloudEventMaxAttempts, nil, logr.Discard())
+	cancel, done := startCloudEventSender(t, sender)
+
+	maxLength := 0
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for producer := 0; producer < 5; producer++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < events/5; i++ {
+				sender.Send(NewCloudEvent("sh.keptn.test", "", LifecycleRecord{}))
+				mu.Lock()
+				if length := sender.QueueLength(); length > maxLength {
+					maxLength = length
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	cancel()
+	<-done
+
+	require.LessOrEqual(t, maxLength, queueSize)
+	require.Len(t, sender.queue, queueSize)
+	require.Zero(t, sender.QueueLength())
+	require.Greater(t, sender.DroppedCount(), int64(0))
+	require.Equal(t, int64(events), int64(atomic.LoadInt32(&delivered))+sender.DroppedCount())
+}
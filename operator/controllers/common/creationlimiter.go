@@ -0,0 +1,125 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultCreationQPS   = 20
+	DefaultCreationBurst = 50
+
+	// DefaultThrottleRequeueDelay is used if the API server did not send a Retry-After header with a 429 response
+	DefaultThrottleRequeueDelay = 5 * time.Second
+
+	ThrottleReasonLimiter = "limiter"
+	ThrottleReasonServer  = "apiserver"
+)
+
+// CreationLimiter is a token bucket shared by all reconcilers that create KeptnTasks, KeptnEvaluations and Jobs, and
+// by the Kubernetes Events they record. It smooths the burst of creations after an operator restart, when all
+// unfinished instances are reconciled at once.
+// A nil *CreationLimiter does not limit anything.
+type CreationLimiter struct {
+	Throttled syncint64.Counter
+
+	limiter *rate.Limiter
+}
+
+// NewCreationLimiter returns a limiter allowing qps creations per second with the given burst.
+// If qps is not positive, nil is returned and creations are not limited.
+func NewCreationLimiter(qps float64, burst int, throttled syncint64.Counter) *CreationLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &CreationLimiter{
+		Throttled: throttled,
+		limiter:   rate.NewLimiter(rate.Limit(qps), burst),
+	}
+}
+
+// Create waits for a token and creates the given object
+func (l *CreationLimiter) Create(ctx context.Context, c client.Client, obj client.Object, opts ...client.CreateOption) error {
+	if err := l.wait(ctx); err != nil {
+		return err
+	}
+	err := c.Create(ctx, obj, opts...)
+	if apierrors.IsTooManyRequests(err) {
+		l.count(ThrottleReasonServer)
+	}
+	return err
+}
+
+// EventRecorder returns a recorder that waits for a token before recording an Event with the given recorder.
+// The recorder is returned as it is by a nil *CreationLimiter.
+func (l *CreationLimiter) EventRecorder(recorder record.EventRecorder) record.EventRecorder {
+	if l == nil {
+		return recorder
+	}
+	return &limitedEventRecorder{recorder: recorder, limiter: l}
+}
+
+type limitedEventRecorder struct {
+	recorder record.EventRecorder
+	limiter  *CreationLimiter
+}
+
+func (r *limitedEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	_ = r.limiter.wait(context.Background())
+	r.recorder.Event(object, eventtype, reason, message)
+}
+
+func (r *limitedEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	_ = r.limiter.wait(context.Background())
+	r.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+}
+
+func (r *limitedEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	_ = r.limiter.wait(context.Background())
+	r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+}
+
+func (l *CreationLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if l.limiter.Allow() {
+		return nil
+	}
+	l.count(ThrottleReasonLimiter)
+	return l.limiter.Wait(ctx)
+}
+
+func (l *CreationLimiter) count(reason string) {
+	if l != nil && l.Throttled != nil {
+		l.Throttled.Add(context.Background(), 1, common.ThrottleReason.String(reason))
+	}
+}
+
+// ThrottledRequeue converts a 429 response of the API server into a delayed requeue, honoring the Retry-After
+// header if present. A random jitter of up to half the delay is added, so that throttled reconciliations do not
+// hit the API server again at the same time. The second return value is false for any other error.
+func ThrottledRequeue(err error) (ctrl.Result, bool) {
+	if !apierrors.IsTooManyRequests(err) {
+		return ctrl.Result{}, false
+	}
+	delay := DefaultThrottleRequeueDelay
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return ctrl.Result{Requeue: true, RequeueAfter: delay}, true
+}
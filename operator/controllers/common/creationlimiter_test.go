@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestThrottledRequeue(t *testing.T) {
+	result, throttled := ThrottledRequeue(apierrors.NewTooManyRequests("slow down", 10))
+	require.True(t, throttled)
+	require.GreaterOrEqual(t, result.RequeueAfter, 10*time.Second)
+	require.LessOrEqual(t, result.RequeueAfter, 15*time.Second)
+
+	result, throttled = ThrottledRequeue(apierrors.NewTooManyRequests("slow down", 0))
+	require.True(t, throttled)
+	require.GreaterOrEqual(t, result.RequeueAfter, DefaultThrottleRequeueDelay)
+
+	_, throttled = ThrottledRequeue(errors.New("some error"))
+	require.False(t, throttled)
+
+	_, throttled = ThrottledRequeue(nil)
+	require.False(t, throttled)
+}
+
+func TestCreationLimiter_Create(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+
+	var unlimited *CreationLimiter
+	require.Nil(t, unlimited.Create(context.TODO(), c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unlimited"}}))
+	require.Nil(t, NewCreationLimiter(0, 10, nil))
+
+	limiter := NewCreationLimiter(1000, 1, nil)
+	require.Nil(t, limiter.Create(context.TODO(), c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "first"}}))
+	require.Nil(t, limiter.Create(context.TODO(), c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "second"}}))
+
+	// a cancelled context aborts waiting for a token
+	limiter = NewCreationLimiter(0.001, 1, nil)
+	require.Nil(t, limiter.Create(context.TODO(), c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "third"}}))
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	require.NotNil(t, limiter.Create(ctx, c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fourth"}}))
+}
+
+func TestCreationLimiter_EventRecorder(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	var unlimited *CreationLimiter
+	require.Equal(t, record.EventRecorder(recorder), unlimited.EventRecorder(recorder))
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-pod"}}
+	limiter := NewCreationLimiter(1000, 1, nil)
+	limited := limiter.EventRecorder(recorder)
+	limited.Event(pod, "Normal", "First", "first event")
+	limited.Eventf(pod, "Normal", "Second", "%s event", "second")
+	limited.AnnotatedEventf(pod, map[string]string{"foo": "bar"}, "Normal", "Third", "%s event", "third")
+	require.Len(t, recorder.Events, 3)
+
+	// every Event uses up a token of the limiter
+	limiter = NewCreationLimiter(0.001, 1, nil)
+	limiter.EventRecorder(recorder).Event(pod, "Normal", "Fourth", "fourth event")
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	require.NotNil(t, limiter.wait(ctx))
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apicommon "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ErrCrossNamespaceReferencesDisabled is returned for references to KeptnTaskDefinitions of other namespaces while the
+// CrossNamespaceReferences feature gate is disabled
+var ErrCrossNamespaceReferencesDisabled = errors.New("cross-namespace task references are disabled")
+
+// CrossNamespaceReferences decides whether KeptnTasks may run KeptnTaskDefinitions of other namespaces. This is denied
+// unless the CrossNamespaceReferences feature gate is enabled and the namespace of the definition is listed as a
+// provider of KeptnTaskDefinitions, so that teams cannot run the definitions of any other team.
+// A nil *CrossNamespaceReferences denies all references to other namespaces.
+type CrossNamespaceReferences struct {
+	FeatureGates       *featuregate.Gates
+	ProviderNamespaces []string
+}
+
+// NewCrossNamespaceReferences parses the comma separated list of namespaces that provide KeptnTaskDefinitions to
+// other namespaces
+func NewCrossNamespaceReferences(featureGates *featuregate.Gates, providerNamespaces string) *CrossNamespaceReferences {
+	c := &CrossNamespaceReferences{FeatureGates: featureGates}
+	for _, namespace := range strings.Split(providerNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			c.ProviderNamespaces = append(c.ProviderNamespaces, namespace)
+		}
+	}
+	return c
+}
+
+// Check returns an error if a KeptnTask of the namespace must not run the KeptnTaskDefinitions of definitionNamespace.
+// References within the same namespace are always allowed.
+func (c *CrossNamespaceReferences) Check(namespace string, definitionNamespace string) error {
+	if definitionNamespace == "" || definitionNamespace == namespace {
+		return nil
+	}
+	if c == nil || !c.FeatureGates.Enabled(featuregate.CrossNamespaceReferences) {
+		return ErrCrossNamespaceReferencesDisabled
+	}
+	for _, provider := range c.ProviderNamespaces {
+		if provider == definitionNamespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %s does not provide KeptnTaskDefinitions to other namespaces", definitionNamespace)
+}
+
+// GetTaskDefinitionKey resolves a reference of the form [namespace/]name to a KeptnTaskDefinition, listed by an object
+// of the given namespace
+func GetTaskDefinitionKey(namespace string, reference string) types.NamespacedName {
+	definitionNamespace, name := apicommon.ParseTaskDefinitionReference(reference)
+	if definitionNamespace == "" {
+		definitionNamespace = namespace
+	}
+	return types.NamespacedName{Namespace: definitionNamespace, Name: name}
+}
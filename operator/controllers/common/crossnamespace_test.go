@@ -0,0 +1,43 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNewCrossNamespaceReferences(t *testing.T) {
+	references := NewCrossNamespaceReferences(nil, " platform, ,shared-checks")
+	require.Equal(t, []string{"platform", "shared-checks"}, references.ProviderNamespaces)
+
+	require.Empty(t, NewCrossNamespaceReferences(nil, "").ProviderNamespaces)
+}
+
+func TestCrossNamespaceReferences_Check(t *testing.T) {
+	enabled := featuregate.New()
+	require.Nil(t, enabled.SetEnabled(featuregate.CrossNamespaceReferences, true))
+
+	var none *CrossNamespaceReferences
+	disabled := NewCrossNamespaceReferences(featuregate.New(), "platform")
+	allowed := NewCrossNamespaceReferences(enabled, "platform")
+
+	for _, references := range []*CrossNamespaceReferences{none, disabled, allowed} {
+		// references within the namespace are always allowed
+		require.Nil(t, references.Check("default", ""))
+		require.Nil(t, references.Check("default", "default"))
+	}
+
+	require.ErrorIs(t, none.Check("default", "platform"), ErrCrossNamespaceReferencesDisabled)
+	require.ErrorIs(t, disabled.Check("default", "platform"), ErrCrossNamespaceReferencesDisabled)
+	require.EqualError(t, disabled.Check("default", "platform"), "cross-namespace task references are disabled")
+
+	require.Nil(t, allowed.Check("default", "platform"))
+	require.EqualError(t, allowed.Check("default", "team-b"), "namespace team-b does not provide KeptnTaskDefinitions to other namespaces")
+}
+
+func TestGetTaskDefinitionKey(t *testing.T) {
+	require.Equal(t, types.NamespacedName{Namespace: "default", Name: "migrate"}, GetTaskDefinitionKey("default", "migrate"))
+	require.Equal(t, types.NamespacedName{Namespace: "platform", Name: "migrate"}, GetTaskDefinitionKey("default", "platform/migrate"))
+}
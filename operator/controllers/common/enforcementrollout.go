@@ -0,0 +1,45 @@
+package common
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// EnforcementBuckets is the number of buckets the workloads are spread across by EnforcementRollout
+const EnforcementBuckets = 100
+
+// EnforcementRollout decides which workloads have their pods held back until their pre-deployment checks have
+// succeeded, so that enforcement can be ramped up gradually. Every workload is assigned to one of 100 buckets by a hash
+// of its namespace and name, and workloads whose bucket is below the percentage are enforced. The checks of all other
+// workloads run in audit mode, without holding back their pods.
+// A nil *EnforcementRollout enforces every workload.
+type EnforcementRollout struct {
+	Percentage int
+}
+
+// NewEnforcementRollout returns nil if all workloads are enforced
+func NewEnforcementRollout(percentage int) (*EnforcementRollout, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, fmt.Errorf("invalid enforcement percentage %d, must be between 0 and 100", percentage)
+	}
+	if percentage == 100 {
+		return nil, nil
+	}
+	return &EnforcementRollout{Percentage: percentage}, nil
+}
+
+// Decide returns whether the workload is enforced and the bucket it has been assigned to
+func (e *EnforcementRollout) Decide(namespace string, workload string) (bool, int) {
+	bucket := GetEnforcementBucket(namespace, workload)
+	if e == nil {
+		return true, bucket
+	}
+	return bucket < e.Percentage, bucket
+}
+
+// GetEnforcementBucket returns the bucket of the workload, which is the same for all of its versions
+func GetEnforcementBucket(namespace string, workload string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace + "/" + workload))
+	return int(h.Sum32() % EnforcementBuckets)
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnforcementRollout(t *testing.T) {
+	rollout, err := NewEnforcementRollout(100)
+	require.Nil(t, err)
+	require.Nil(t, rollout)
+
+	rollout, err = NewEnforcementRollout(10)
+	require.Nil(t, err)
+	require.Equal(t, 10, rollout.Percentage)
+
+	_, err = NewEnforcementRollout(101)
+	require.ErrorContains(t, err, "invalid enforcement percentage 101")
+	_, err = NewEnforcementRollout(-1)
+	require.ErrorContains(t, err, "invalid enforcement percentage -1")
+}
+
+func TestEnforcementRollout_Decide(t *testing.T) {
+	var all *EnforcementRollout
+	none := &EnforcementRollout{Percentage: 0}
+	some := &EnforcementRollout{Percentage: 30}
+
+	enforcedWorkloads := 0
+	for i := 0; i < 1000; i++ {
+		workload := fmt.Sprintf("my-app-workload-%d", i)
+		bucket := GetEnforcementBucket("default", workload)
+		require.GreaterOrEqual(t, bucket, 0)
+		require.Less(t, bucket, EnforcementBuckets)
+
+		enforced, gotBucket := all.Decide("default", workload)
+		require.True(t, enforced)
+		require.Equal(t, bucket, gotBucket)
+
+		enforced, _ = none.Decide("default", workload)
+		require.False(t, enforced)
+
+		enforced, _ = some.Decide("default", workload)
+		require.Equal(t, bucket < 30, enforced)
+		if enforced {
+			enforcedWorkloads++
+		}
+	}
+	// the buckets are spread evenly enough to ramp up enforcement gradually
+	require.InDelta(t, 300, enforcedWorkloads, 60)
+
+	// the decision is deterministic
+	first, firstBucket := some.Decide("default", "my-app-my-workload")
+	second, secondBucket := some.Decide("default", "my-app-my-workload")
+	require.Equal(t, first, second)
+	require.Equal(t, firstBucket, secondBucket)
+	require.Equal(t, GetEnforcementBucket("default", "my-app-my-workload"), firstBucket)
+}
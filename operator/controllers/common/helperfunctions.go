@@ -1,9 +1,16 @@
 package common
 
 import (
+	"context"
+	"fmt"
+
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	apicommon "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func GetTaskStatus(taskName string, instanceStatus []klcv1alpha1.TaskStatus) klcv1alpha1.TaskStatus {
@@ -35,3 +42,100 @@ func GetEvaluationStatus(evaluationName string, instanceStatus []klcv1alpha1.Eva
 func GetAppVersionName(namespace string, appName string, version string) types.NamespacedName {
 	return types.NamespacedName{Namespace: namespace, Name: appName + "-" + version}
 }
+
+// MaxCheckRecreations is the number of times a KeptnTask or KeptnEvaluation that has been deleted while it was
+// running is created again, before its check fails
+const MaxCheckRecreations = 3
+
+// RecreateDeletedCheck counts another recreation of a check whose KeptnTask or KeptnEvaluation has been deleted while
+// it was running. It returns false if the check has already been created again MaxCheckRecreations times, e.g. since
+// a cleanup job keeps deleting it, in which case the check has to fail instead of being created again.
+func RecreateDeletedCheck(recorder record.EventRecorder, phase apicommon.KeptnPhaseType, reconcileObject client.Object, recreations *int, name string, version string) bool {
+	if *recreations >= MaxCheckRecreations {
+		RecordEvent(recorder, phase, "Warning", reconcileObject, "CheckDeleted", fmt.Sprintf("has failed since %s has been deleted %d times", name, *recreations+1), version)
+		return false
+	}
+	*recreations++
+	RecordEvent(recorder, phase, "Warning", reconcileObject, "CheckRecreated", fmt.Sprintf("creates %s again since it has been deleted", name), version)
+	return true
+}
+
+// GetCheckLabels returns the labels of the KeptnTasks and KeptnEvaluations of a workload instance or, if the workload
+// is empty, of an app version
+func GetCheckLabels(appName string, workload string, version string, checkType apicommon.CheckType) map[string]string {
+	labels := map[string]string{
+		apicommon.AppAnnotation:     appName,
+		apicommon.VersionAnnotation: version,
+		apicommon.CheckTypeLabel:    string(checkType),
+	}
+	if workload != "" {
+		labels[apicommon.WorkloadAnnotation] = workload
+	}
+	return labels
+}
+
+// FindCreatedTask returns the name of a KeptnTask of the given definition that the owner has already created, but
+// whose name has not been stored in the status of the owner, e.g. since the status update has failed. An empty name
+// is returned if there is no such task.
+func FindCreatedTask(ctx context.Context, reader client.Reader, owner client.Object, labels map[string]string, taskDefinition string) (string, error) {
+	tasks := &klcv1alpha1.KeptnTaskList{}
+	if err := reader.List(ctx, tasks, client.InNamespace(owner.GetNamespace()), client.MatchingLabels(labels)); err != nil {
+		return "", err
+	}
+	for i := range tasks.Items {
+		task := &tasks.Items[i]
+		if task.GetTaskDefinitionReference() == taskDefinition && task.DeletionTimestamp.IsZero() && metav1.IsControlledBy(task, owner) {
+			return task.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// FindCreatedEvaluation returns the name of a KeptnEvaluation of the given definition that the owner has already
+// created, but whose name has not been stored in the status of the owner. An empty name is returned if there is no
+// such evaluation.
+func FindCreatedEvaluation(ctx context.Context, reader client.Reader, owner client.Object, labels map[string]string, evaluationDefinition string) (string, error) {
+	evaluations := &klcv1alpha1.KeptnEvaluationList{}
+	if err := reader.List(ctx, evaluations, client.InNamespace(owner.GetNamespace()), client.MatchingLabels(labels)); err != nil {
+		return "", err
+	}
+	for i := range evaluations.Items {
+		evaluation := &evaluations.Items[i]
+		if evaluation.Spec.EvaluationDefinition == evaluationDefinition && evaluation.DeletionTimestamp.IsZero() && metav1.IsControlledBy(evaluation, owner) {
+			return evaluation.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// CancelChecks deletes the KeptnTasks and KeptnEvaluations of the given statuses that have not finished yet and marks
+// them as failed. The Jobs of the tasks are removed by the garbage collector.
+func CancelChecks(ctx context.Context, c client.Client, namespace string, taskStatuses [][]klcv1alpha1.TaskStatus, evaluationStatuses [][]klcv1alpha1.EvaluationStatus) error {
+	for _, statuses := range taskStatuses {
+		for i := range statuses {
+			if statuses[i].Status.IsCompleted() || statuses[i].TaskName == "" {
+				continue
+			}
+			task := &klcv1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: statuses[i].TaskName}}
+			if err := c.Delete(ctx, task, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			statuses[i].Status = apicommon.StateFailed
+			statuses[i].SetEndTime()
+		}
+	}
+	for _, statuses := range evaluationStatuses {
+		for i := range statuses {
+			if statuses[i].Status.IsCompleted() || statuses[i].EvaluationName == "" {
+				continue
+			}
+			evaluation := &klcv1alpha1.KeptnEvaluation{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: statuses[i].EvaluationName}}
+			if err := c.Delete(ctx, evaluation); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			statuses[i].Status = apicommon.StateFailed
+			statuses[i].SetEndTime()
+		}
+	}
+	return nil
+}
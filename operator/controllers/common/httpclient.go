@@ -0,0 +1,20 @@
+package common
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// NewHTTPClient returns the client used for all outgoing HTTP requests of the operator.
+// It honors the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables and verifies
+// servers against the system certificate pool with at least TLS 1.2.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
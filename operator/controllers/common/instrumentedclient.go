@@ -0,0 +1,74 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	CreationResultSuccess   = "success"
+	CreationResultQuota     = "quota"
+	CreationResultForbidden = "forbidden"
+	CreationResultInvalid   = "invalid"
+	CreationResultConflict  = "conflict"
+	CreationResultOther     = "other"
+)
+
+// instrumentedClient records the outcome and the latency of every object created through it
+type instrumentedClient struct {
+	client.Client
+	created  syncint64.Counter
+	duration syncfloat64.Histogram
+}
+
+// NewInstrumentedClient returns a client that counts the objects created through it by kind and result, and records
+// the latency of each creation. Failures caused by RBAC, quotas or admission webhooks are otherwise only visible
+// in the logs of the reconciler that ran into them.
+func NewInstrumentedClient(c client.Client, created syncint64.Counter, duration syncfloat64.Histogram) client.Client {
+	return &instrumentedClient{Client: c, created: created, duration: duration}
+}
+
+func (c *instrumentedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	start := time.Now()
+	err := c.Client.Create(ctx, obj, opts...)
+
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, gvkErr := apiutil.GVKForObject(obj, c.Scheme()); gvkErr == nil {
+		kind = gvk.Kind
+	}
+	attrs := []attribute.KeyValue{
+		common.ObjectKind.String(kind),
+		common.CreationResult.String(ClassifyCreationError(err)),
+	}
+	c.created.Add(ctx, 1, attrs...)
+	c.duration.Record(ctx, time.Since(start).Seconds(), attrs...)
+	return err
+}
+
+// ClassifyCreationError maps the error returned by a create request to the result reported by the creation metrics
+func ClassifyCreationError(err error) string {
+	switch {
+	case err == nil:
+		return CreationResultSuccess
+	case apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		// the ResourceQuota admission plugin rejects requests with 403 Forbidden
+		return CreationResultQuota
+	case apierrors.IsForbidden(err):
+		return CreationResultForbidden
+	case apierrors.IsInvalid(err):
+		return CreationResultInvalid
+	case apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err):
+		return CreationResultConflict
+	default:
+		return CreationResultOther
+	}
+}
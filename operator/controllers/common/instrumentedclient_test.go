@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// failingCreateClient rejects every create request with the given error
+type failingCreateClient struct {
+	client.Client
+	err error
+}
+
+func (c *failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.err
+}
+
+func TestClassifyCreationError(t *testing.T) {
+	resource := schema.GroupResource{Group: "batch", Resource: "jobs"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "no error", err: nil, want: CreationResultSuccess},
+		{name: "quota", err: apierrors.NewForbidden(resource, "my-job", errors.New("exceeded quota: compute, requested: count/jobs.batch=1, used: count/jobs.batch=10, limited: count/jobs.batch=10")), want: CreationResultQuota},
+		{name: "forbidden", err: apierrors.NewForbidden(resource, "my-job", errors.New("cannot create resource")), want: CreationResultForbidden},
+		{name: "invalid", err: apierrors.NewInvalid(schema.GroupKind{Group: "batch", Kind: "Job"}, "my-job", nil), want: CreationResultInvalid},
+		{name: "conflict", err: apierrors.NewConflict(resource, "my-job", errors.New("conflict")), want: CreationResultConflict},
+		{name: "already exists", err: apierrors.NewAlreadyExists(resource, "my-job"), want: CreationResultConflict},
+		{name: "webhook timeout", err: apierrors.NewInternalError(errors.New("context deadline exceeded")), want: CreationResultOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ClassifyCreationError(tt.err))
+		})
+	}
+}
+
+func TestInstrumentedClient_Create(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	meter := metric.NewNoopMeterProvider().Meter("test")
+	created, err := meter.SyncInt64().Counter("created")
+	require.Nil(t, err)
+	duration, err := meter.SyncFloat64().Histogram("duration")
+	require.Nil(t, err)
+
+	c := NewInstrumentedClient(fake.NewClientBuilder().WithScheme(scheme).Build(), created, duration)
+	require.Nil(t, c.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cm"}}))
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "my-cm"}, &corev1.ConfigMap{}))
+
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "other-cm", errors.New("cannot create resource"))
+	c = NewInstrumentedClient(&failingCreateClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), err: forbidden}, created, duration)
+	require.Equal(t, forbidden, c.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-cm"}}))
+}
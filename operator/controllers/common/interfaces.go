@@ -20,6 +20,28 @@ type PhaseItem interface {
 	Complete()
 }
 
+// FailedChecksReporter is implemented by PhaseItems that can name the checks that made a phase fail
+type FailedChecksReporter interface {
+	GetFailedChecks() []string
+}
+
+// FailureMessageReporter is implemented by PhaseItems that can describe why a phase has failed
+type FailureMessageReporter interface {
+	GetFailureMessage() string
+}
+
+// MetadataReporter is implemented by objects carrying metadata of the user, which their events are annotated with
+type MetadataReporter interface {
+	GetUserMetadata() map[string]string
+}
+
+// SpanLinker is implemented by PhaseItems whose phase spans link to the spans of their tasks, which may end up in
+// separate traces, e.g. if the tasks are executed in a remote cluster
+type SpanLinker interface {
+	GetTaskSpanContexts(phase string) []map[string]string
+	SetPhaseSpanContext(spanContext map[string]string)
+}
+
 type PhaseItemWrapper struct {
 	Obj PhaseItem
 }
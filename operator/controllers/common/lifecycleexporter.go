@@ -0,0 +1,244 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	apicommon "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultLifecycleExportQueueSize       = 1000
+	DefaultLifecycleExportShutdownTimeout = 5 * time.Second
+	lifecycleExportBatchSize              = 100
+	lifecycleExportFlushInterval          = 10 * time.Second
+	lifecycleExportRetries                = 3
+	lifecycleExportRetryInterval          = 2 * time.Second
+)
+
+// LifecycleRecord is the flattened record of a KeptnWorkloadInstance or KeptnAppVersion that reached a terminal state
+type LifecycleRecord struct {
+	Kind            string            `json:"kind"`
+	Namespace       string            `json:"namespace"`
+	App             string            `json:"app"`
+	Workload        string            `json:"workload,omitempty"`
+	Version         string            `json:"version"`
+	Outcome         string            `json:"outcome"`
+	FailureReason   string            `json:"failureReason,omitempty"`
+	Phases          map[string]string `json:"phases"`
+	StartTime       time.Time         `json:"startTime"`
+	EndTime         time.Time         `json:"endTime"`
+	DurationSeconds float64           `json:"durationSeconds"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// LifecycleSink delivers a batch of LifecycleRecords to the storage they are exported to
+type LifecycleSink interface {
+	Write(ctx context.Context, batch []LifecycleRecord) error
+}
+
+// LifecycleExporter sends LifecycleRecords to a LifecycleSink.
+// Records are queued in memory and sent in batches by Start, so that exporting never blocks a reconciliation.
+// Records that do not fit into the queue, or cannot be delivered after retrying, are dropped and counted.
+// A nil *LifecycleExporter is valid and does not export anything.
+type LifecycleExporter struct {
+	Sink    LifecycleSink
+	Log     logr.Logger
+	Dropped syncint64.Counter
+	// ShutdownTimeout bounds the time spent on sending the queued records once Start is stopped
+	ShutdownTimeout time.Duration
+
+	queue chan LifecycleRecord
+}
+
+func NewLifecycleExporter(sink LifecycleSink, queueSize int, dropped syncint64.Counter, log logr.Logger) *LifecycleExporter {
+	return &LifecycleExporter{
+		Sink:            sink,
+		Log:             log,
+		Dropped:         dropped,
+		ShutdownTimeout: DefaultLifecycleExportShutdownTimeout,
+		queue:           make(chan LifecycleRecord, queueSize),
+	}
+}
+
+// Export queues the record without blocking. It returns false if the record has been dropped.
+func (e *LifecycleExporter) Export(record LifecycleRecord) bool {
+	if e == nil {
+		return false
+	}
+	select {
+	case e.queue <- record:
+		return true
+	default:
+		e.drop(1)
+		return false
+	}
+}
+
+// Start sends the queued records until the given context is cancelled. It implements manager.Runnable.
+func (e *LifecycleExporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(lifecycleExportFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]LifecycleRecord, 0, lifecycleExportBatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			e.flush(batch)
+			if closer, ok := e.Sink.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					e.Log.Error(err, "could not close lifecycle sink")
+				}
+			}
+			return nil
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) < lifecycleExportBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		e.send(ctx, batch)
+		batch = batch[:0]
+	}
+}
+
+// flush is a best effort attempt to send what has been queued so far. It is bounded by ShutdownTimeout, so that an
+// unavailable endpoint cannot delay the shutdown; the records that could not be sent in time are dropped.
+func (e *LifecycleExporter) flush(batch []LifecycleRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+	defer cancel()
+	for len(e.queue) > 0 {
+		batch = append(batch, <-e.queue)
+		if len(batch) == lifecycleExportBatchSize {
+			e.send(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+	e.send(ctx, batch)
+}
+
+func (e *LifecycleExporter) send(ctx context.Context, batch []LifecycleRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	var err error
+	for attempt := 0; attempt < lifecycleExportRetries; attempt++ {
+		if err = e.Sink.Write(ctx, batch); err == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			attempt = lifecycleExportRetries
+		case <-time.After(lifecycleExportRetryInterval * time.Duration(attempt+1)):
+		}
+	}
+	e.Log.Error(err, "could not export lifecycle records", "records", len(batch))
+	e.drop(int64(len(batch)))
+}
+
+func (e *LifecycleExporter) drop(count int64) {
+	if e.Dropped != nil {
+		e.Dropped.Add(context.Background(), count)
+	}
+}
+
+// HTTPLifecycleSink posts the records of a batch as newline delimited JSON to an HTTP endpoint
+type HTTPLifecycleSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPLifecycleSink(url string) *HTTPLifecycleSink {
+	return &HTTPLifecycleSink{
+		URL:    url,
+		Client: NewHTTPClient(10 * time.Second),
+	}
+}
+
+func (s *HTTPLifecycleSink) Write(ctx context.Context, batch []LifecycleRecord) error {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+	for _, record := range batch {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("could not encode lifecycle record: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func NewWorkloadInstanceRecord(workloadInstance klcv1alpha1.KeptnWorkloadInstance) LifecycleRecord {
+	phases := map[string]apicommon.KeptnState{
+		apicommon.PhaseWorkloadPreDeployment.ShortName:  workloadInstance.Status.PreDeploymentStatus,
+		apicommon.PhaseWorkloadPreEvaluation.ShortName:  workloadInstance.Status.PreDeploymentEvaluationStatus,
+		apicommon.PhaseWorkloadDeployment.ShortName:     workloadInstance.Status.DeploymentStatus,
+		apicommon.PhaseWorkloadPostDeployment.ShortName: workloadInstance.Status.PostDeploymentStatus,
+		apicommon.PhaseWorkloadPostEvaluation.ShortName: workloadInstance.Status.PostDeploymentEvaluationStatus,
+	}
+	record := newLifecycleRecord("KeptnWorkloadInstance", phases, workloadInstance.Status.Status, workloadInstance.Status.StartTime, workloadInstance.Status.EndTime)
+	record.Namespace = workloadInstance.Namespace
+	record.App = workloadInstance.Spec.AppName
+	record.Workload = workloadInstance.Spec.WorkloadName
+	record.Version = workloadInstance.Spec.Version
+	record.Metadata = workloadInstance.Spec.Metadata
+	return record
+}
+
+func NewAppVersionRecord(appVersion klcv1alpha1.KeptnAppVersion) LifecycleRecord {
+	phases := map[string]apicommon.KeptnState{
+		apicommon.PhaseAppPreDeployment.ShortName:  appVersion.Status.PreDeploymentStatus,
+		apicommon.PhaseAppPreEvaluation.ShortName:  appVersion.Status.PreDeploymentEvaluationStatus,
+		apicommon.PhaseAppDeployment.ShortName:     appVersion.Status.WorkloadOverallStatus,
+		apicommon.PhaseAppPostDeployment.ShortName: appVersion.Status.PostDeploymentStatus,
+		apicommon.PhaseAppPostEvaluation.ShortName: appVersion.Status.PostDeploymentEvaluationStatus,
+	}
+	record := newLifecycleRecord("KeptnAppVersion", phases, appVersion.Status.Status, appVersion.Status.StartTime, appVersion.Status.EndTime)
+	record.Namespace = appVersion.Namespace
+	record.App = appVersion.Spec.AppName
+	record.Version = appVersion.Spec.Version
+	return record
+}
+
+func newLifecycleRecord(kind string, phases map[string]apicommon.KeptnState, outcome apicommon.KeptnState, start metav1.Time, end metav1.Time) LifecycleRecord {
+	record := LifecycleRecord{
+		Kind:            kind,
+		Outcome:         string(outcome),
+		Phases:          map[string]string{},
+		StartTime:       start.UTC(),
+		EndTime:         end.UTC(),
+		DurationSeconds: apicommon.Duration(start, end).Seconds(),
+	}
+	for phase, state := range phases {
+		record.Phases[phase] = string(state)
+		if state.IsFailed() {
+			record.FailureReason = fmt.Sprintf("%s has failed", phase)
+		}
+	}
+	return record
+}
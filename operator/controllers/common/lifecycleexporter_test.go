@@ -0,0 +1,114 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	apicommon "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLifecycleExporter_Export(t *testing.T) {
+	received := make(chan LifecycleRecord, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			record := LifecycleRecord{}
+			require.Nil(t, json.Unmarshal(scanner.Bytes(), &record))
+			received <- record
+		}
+	}))
+	defer server.Close()
+
+	exporter := NewLifecycleExporter(NewHTTPLifecycleSink(server.URL), 10, nil, logr.Discard())
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		require.Nil(t, exporter.Start(ctx))
+		close(done)
+	}()
+
+	require.True(t, exporter.Export(LifecycleRecord{App: "my-app", Version: "1.0.0"}))
+	require.True(t, exporter.Export(LifecycleRecord{App: "my-app", Version: "2.0.0"}))
+
+	// stopping the exporter flushes the pending batch
+	cancel()
+	<-done
+
+	require.Equal(t, "1.0.0", (<-received).Version)
+	require.Equal(t, "2.0.0", (<-received).Version)
+}
+
+func TestLifecycleExporter_FlushIsBounded(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lines := 0
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines++
+		}
+		require.LessOrEqual(t, lines, lifecycleExportBatchSize)
+		atomic.AddInt32(&requests, 1)
+		// the endpoint hangs, so only the shutdown timeout ends the flush
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	exporter := NewLifecycleExporter(NewHTTPLifecycleSink(server.URL), 250, nil, logr.Discard())
+	exporter.ShutdownTimeout = 100 * time.Millisecond
+	for i := 0; i < 250; i++ {
+		require.True(t, exporter.Export(LifecycleRecord{App: "my-app"}))
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	start := time.Now()
+	require.Nil(t, exporter.Start(ctx))
+
+	// without the bound, every batch would be retried against the hanging endpoint
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	require.Empty(t, exporter.queue)
+}
+
+func TestLifecycleExporter_ExportDoesNotBlock(t *testing.T) {
+	exporter := NewLifecycleExporter(NewHTTPLifecycleSink("http://localhost"), 1, nil, logr.Discard())
+	require.True(t, exporter.Export(LifecycleRecord{}))
+	require.False(t, exporter.Export(LifecycleRecord{}))
+
+	var disabled *LifecycleExporter
+	require.False(t, disabled.Export(LifecycleRecord{}))
+}
+
+func TestNewWorkloadInstanceRecord(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	record := NewWorkloadInstanceRecord(klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+		Spec: klcv1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: klcv1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+		Status: klcv1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentStatus: apicommon.StateSucceeded,
+			DeploymentStatus:    apicommon.StateFailed,
+			Status:              apicommon.StateFailed,
+			StartTime:           metav1.NewTime(start),
+			EndTime:             metav1.NewTime(start.Add(time.Minute)),
+		},
+	})
+	require.Equal(t, "my-app-my-workload", record.Workload)
+	require.Equal(t, string(apicommon.StateFailed), record.Outcome)
+	require.Equal(t, "WorkloadDeploy has failed", record.FailureReason)
+	require.Equal(t, float64(60), record.DurationSeconds)
+	require.Len(t, record.Phases, 5)
+}
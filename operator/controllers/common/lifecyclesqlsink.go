@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LifecycleRecordsTable is the table the SQLLifecycleSink inserts the LifecycleRecords into. It is expected to exist:
+//
+//	CREATE TABLE keptn_lifecycle_records (
+//	  kind text NOT NULL,
+//	  namespace text NOT NULL,
+//	  app text NOT NULL,
+//	  workload text NOT NULL,
+//	  version text NOT NULL,
+//	  outcome text NOT NULL,
+//	  failure_reason text NOT NULL,
+//	  phases jsonb NOT NULL,
+//	  start_time timestamptz NOT NULL,
+//	  end_time timestamptz NOT NULL,
+//	  duration_seconds double precision NOT NULL,
+//	  metadata jsonb
+//	);
+const LifecycleRecordsTable = "keptn_lifecycle_records"
+
+var lifecycleRecordColumns = []string{
+	"kind",
+	"namespace",
+	"app",
+	"workload",
+	"version",
+	"outcome",
+	"failure_reason",
+	"phases",
+	"start_time",
+	"end_time",
+	"duration_seconds",
+	"metadata",
+}
+
+// SQLLifecycleSink inserts the records of a batch into a PostgreSQL database with a single INSERT statement.
+// The operator does not link a database driver itself: a driver implementing database/sql, e.g. the stdlib package
+// of pgx, has to be registered by importing it into the binary, and is selected by the name it is registered with.
+type SQLLifecycleSink struct {
+	DB *sql.DB
+}
+
+func NewSQLLifecycleSink(driverName string, dataSourceName string) (*SQLLifecycleSink, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lifecycle export database with driver %s: %w", driverName, err)
+	}
+	return &SQLLifecycleSink{DB: db}, nil
+}
+
+func (s *SQLLifecycleSink) Write(ctx context.Context, batch []LifecycleRecord) error {
+	query := &strings.Builder{}
+	fmt.Fprintf(query, "INSERT INTO %s (%s) VALUES ", LifecycleRecordsTable, strings.Join(lifecycleRecordColumns, ", "))
+	args := make([]interface{}, 0, len(batch)*len(lifecycleRecordColumns))
+	for i, record := range batch {
+		phases, err := json.Marshal(record.Phases)
+		if err != nil {
+			return fmt.Errorf("could not encode phases of lifecycle record: %w", err)
+		}
+		metadata, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return fmt.Errorf("could not encode metadata of lifecycle record: %w", err)
+		}
+
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		placeholders := make([]string, len(lifecycleRecordColumns))
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", len(args)+j+1)
+		}
+		fmt.Fprintf(query, "(%s)", strings.Join(placeholders, ", "))
+
+		args = append(args,
+			record.Kind,
+			record.Namespace,
+			record.App,
+			record.Workload,
+			record.Version,
+			record.Outcome,
+			record.FailureReason,
+			string(phases),
+			record.StartTime,
+			record.EndTime,
+			record.DurationSeconds,
+			string(metadata),
+		)
+	}
+	if _, err := s.DB.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("could not insert lifecycle records: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database, it is called once the LifecycleExporter has been stopped
+func (s *SQLLifecycleSink) Close() error {
+	return s.DB.Close()
+}
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriver is a database/sql driver recording the statements executed against it
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+	err        error
+}
+
+type recordedStatement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if c.driver.err != nil {
+		return nil, c.driver.err
+	}
+	c.driver.statements = append(c.driver.statements, recordedStatement{query: query, args: args})
+	return driver.RowsAffected(len(args) / len(lifecycleRecordColumns)), nil
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+var (
+	testDriver         = &recordingDriver{}
+	registerTestDriver sync.Once
+)
+
+func newTestSQLLifecycleSink(t *testing.T) *SQLLifecycleSink {
+	registerTestDriver.Do(func() {
+		sql.Register("lifecycle-test", testDriver)
+	})
+	testDriver.mu.Lock()
+	testDriver.statements = nil
+	testDriver.err = nil
+	testDriver.mu.Unlock()
+
+	sink, err := NewSQLLifecycleSink("lifecycle-test", "")
+	require.Nil(t, err)
+	return sink
+}
+
+func TestSQLLifecycleSink_Write(t *testing.T) {
+	sink := newTestSQLLifecycleSink(t)
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Nil(t, sink.Write(context.TODO(), []LifecycleRecord{
+		{Kind: "KeptnWorkloadInstance", App: "my-app", Workload: "my-app-my-workload", Version: "1.0.0", Phases: map[string]string{"WorkloadDeploy": "Succeeded"}, StartTime: start},
+		{Kind: "KeptnAppVersion", App: "my-app", Version: "1.0.0"},
+	}))
+
+	// the batch is inserted by a single statement
+	require.Len(t, testDriver.statements, 1)
+	statement := testDriver.statements[0]
+	require.True(t, strings.HasPrefix(statement.query, "INSERT INTO keptn_lifecycle_records (kind, namespace, app, workload, version, "))
+	require.Contains(t, statement.query, "($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12), ($13, ")
+	require.True(t, strings.HasSuffix(statement.query, "$24)"))
+	require.Len(t, statement.args, 24)
+	require.Equal(t, "my-app-my-workload", statement.args[3].Value)
+	require.Equal(t, `{"WorkloadDeploy":"Succeeded"}`, statement.args[7].Value)
+	require.Equal(t, start, statement.args[8].Value)
+	require.Equal(t, "KeptnAppVersion", statement.args[12].Value)
+}
+
+func TestSQLLifecycleSink_UnknownDriver(t *testing.T) {
+	_, err := NewSQLLifecycleSink("not-linked", "")
+	require.NotNil(t, err)
+}
+
+func TestLifecycleExporter_ExportToSQLSink(t *testing.T) {
+	sink := newTestSQLLifecycleSink(t)
+	exporter := NewLifecycleExporter(sink, 10, nil, logr.Discard())
+	require.True(t, exporter.Export(LifecycleRecord{App: "my-app", Version: "1.0.0"}))
+	require.True(t, exporter.Export(LifecycleRecord{App: "my-app", Version: "2.0.0"}))
+
+	// stopping the exporter flushes the pending batch and closes the database
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	require.Nil(t, exporter.Start(ctx))
+
+	require.Len(t, testDriver.statements, 1)
+	require.Len(t, testDriver.statements[0].args, 24)
+	require.NotNil(t, sink.DB.Ping())
+}
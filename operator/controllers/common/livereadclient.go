@@ -0,0 +1,31 @@
+package common
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// liveReadClient reads objects from the given reader instead of the informer cache of the manager.
+// All writes, including status updates, go through the wrapped client.
+type liveReadClient struct {
+	client.Client
+	reader client.Reader
+}
+
+// NewLiveReadClient returns a client whose Get and List calls are served by the given reader, usually the
+// API reader of the manager. By default, reconcilers read from the informer cache of the manager, which may lag
+// behind the API server for a short time. This is fine for the reconcilers, since they are triggered again by the
+// watch event that brings the cache up to date, and writes based on stale reads fail with a conflict and are retried.
+// Clusters that need every reconciliation to see the latest state can pay for the additional API requests instead.
+func NewLiveReadClient(c client.Client, reader client.Reader) client.Client {
+	return &liveReadClient{Client: c, reader: reader}
+}
+
+func (c *liveReadClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.reader.Get(ctx, key, obj, opts...)
+}
+
+func (c *liveReadClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.reader.List(ctx, list, opts...)
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// countingClient counts the reads served by the wrapped client
+type countingClient struct {
+	client.Client
+	reads int
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.reads++
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *countingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.reads++
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestLiveReadClient(t *testing.T) {
+	for _, liveReads := range []bool{false, true} {
+		backend := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-config"}}).Build()
+		cache := &countingClient{Client: backend}
+		apiReader := &countingClient{Client: backend}
+		var c client.Client = cache
+		if liveReads {
+			c = NewLiveReadClient(cache, apiReader)
+		}
+
+		configMap := &corev1.ConfigMap{}
+		require.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-config"}, configMap))
+		require.Nil(t, c.List(context.TODO(), &corev1.ConfigMapList{}))
+
+		if liveReads {
+			require.Zero(t, cache.reads)
+			require.Equal(t, 2, apiReader.reads)
+		} else {
+			require.Equal(t, 2, cache.reads)
+			require.Zero(t, apiReader.reads)
+		}
+
+		// writes still go through the wrapped client
+		configMap.Data = map[string]string{"key": "value"}
+		require.Nil(t, c.Update(context.TODO(), configMap))
+		result := &corev1.ConfigMap{}
+		require.Nil(t, backend.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-config"}, result))
+		require.Equal(t, "value", result.Data["key"])
+	}
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -14,6 +15,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DefaultPhaseRequeueInterval is the interval a phase that has not finished yet is reconciled again in
+const DefaultPhaseRequeueInterval = 5 * time.Second
+
 type PhaseHandler struct {
 	client.Client
 	Recorder    record.EventRecorder
@@ -27,11 +31,36 @@ type PhaseResult struct {
 }
 
 func RecordEvent(recorder record.EventRecorder, phase common.KeptnPhaseType, eventType string, reconcileObject client.Object, shortReason string, longReason string, version string) {
-	recorder.Event(reconcileObject, eventType, fmt.Sprintf("%s%s", phase.ShortName, shortReason), fmt.Sprintf("%s %s / Namespace: %s, Name: %s, Version: %s ", phase.LongName, longReason, reconcileObject.GetNamespace(), reconcileObject.GetName(), version))
+	reason := fmt.Sprintf("%s%s", phase.ShortName, shortReason)
+	message := fmt.Sprintf("%s %s / Namespace: %s, Name: %s, Version: %s ", phase.LongName, longReason, reconcileObject.GetNamespace(), reconcileObject.GetName(), version)
+	if reporter, ok := reconcileObject.(MetadataReporter); ok {
+		if annotations := common.GetMetadataEventAnnotations(reporter.GetUserMetadata()); len(annotations) > 0 {
+			recorder.AnnotatedEventf(reconcileObject, annotations, eventType, reason, "%s", message)
+			return
+		}
+	}
+	recorder.Event(reconcileObject, eventType, reason, message)
+}
+
+// failureReason names the failed checks of the object in the event of a failed phase and describes why they have
+// failed, if it can report them
+func failureReason(reconcileObject client.Object) string {
+	reason := "has failed"
+	if reporter, ok := reconcileObject.(FailedChecksReporter); ok {
+		if failed := reporter.GetFailedChecks(); len(failed) > 0 {
+			reason = fmt.Sprintf("%s (failed checks: %s)", reason, strings.Join(failed, ", "))
+		}
+	}
+	if reporter, ok := reconcileObject.(FailureMessageReporter); ok {
+		if message := reporter.GetFailureMessage(); message != "" {
+			reason = fmt.Sprintf("%s: %s", reason, message)
+		}
+	}
+	return reason
 }
 
 func (r PhaseHandler) HandlePhase(ctx context.Context, ctxAppTrace context.Context, tracer trace.Tracer, reconcileObject client.Object, phase common.KeptnPhaseType, span trace.Span, reconcilePhase func() (common.KeptnState, error)) (*PhaseResult, error) {
-	requeueResult := ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}
+	requeueResult := ctrl.Result{Requeue: true, RequeueAfter: DefaultPhaseRequeueInterval}
 	piWrapper, err := NewPhaseItemWrapperFromClientObject(reconcileObject)
 	if err != nil {
 		return &PhaseResult{Continue: false, Result: ctrl.Result{Requeue: true}}, err
@@ -41,12 +70,23 @@ func (r PhaseHandler) HandlePhase(ctx context.Context, ctxAppTrace context.Conte
 	piWrapper.SetCurrentPhase(phase.ShortName)
 
 	r.Log.Info(phase.LongName + " not finished")
-	ctxAppTrace, spanAppTrace, err := r.SpanHandler.GetSpan(ctxAppTrace, tracer, reconcileObject, phase.ShortName)
+	var spanOpts []trace.SpanStartOption
+	linker, isLinker := reconcileObject.(SpanLinker)
+	if isLinker {
+		spanOpts = append(spanOpts, trace.WithLinks(GetSpanLinks(linker.GetTaskSpanContexts(phase.ShortName)...)...))
+	}
+	ctxAppTrace, spanAppTrace, err := r.SpanHandler.GetSpan(ctxAppTrace, tracer, reconcileObject, phase.ShortName, spanOpts...)
 	if err != nil {
 		r.Log.Error(err, "could not get span")
+	} else if isLinker {
+		linker.SetPhaseSpanContext(GetSpanContext(trace.ContextWithSpan(ctxAppTrace, spanAppTrace)))
 	}
 
 	state, err := reconcilePhase()
+	if result, throttled := ThrottledRequeue(err); throttled {
+		r.Log.Info(phase.LongName+" has been throttled by the API server", "requeueAfter", result.RequeueAfter)
+		return &PhaseResult{Continue: false, Result: result}, nil
+	}
 	if err != nil {
 		spanAppTrace.AddEvent(phase.LongName + " could not get reconciled")
 		RecordEvent(r.Recorder, phase, "Warning", reconcileObject, "ReconcileErrored", "could not get reconciled", piWrapper.GetVersion())
@@ -61,11 +101,11 @@ func (r PhaseHandler) HandlePhase(ctx context.Context, ctxAppTrace context.Conte
 	defer func(oldStatus common.KeptnState, oldPhase string, reconcileObject client.Object) {
 		piWrapper, _ := NewPhaseItemWrapperFromClientObject(reconcileObject)
 		if oldStatus != piWrapper.GetState() || oldPhase != piWrapper.GetCurrentPhase() {
-			ctx, spanAppTrace, err = r.SpanHandler.GetSpan(ctxAppTrace, tracer, reconcileObject, piWrapper.GetCurrentPhase())
+			_, spanAppTrace, err = r.SpanHandler.GetSpan(ctxAppTrace, tracer, reconcileObject, piWrapper.GetCurrentPhase())
 			if err != nil {
 				r.Log.Error(err, "could not get span")
 			}
-			if err := r.Status().Update(ctx, reconcileObject); err != nil {
+			if err := UpdateStatus(ctx, r.Client, reconcileObject); err != nil {
 				r.Log.Error(err, "could not update status")
 			}
 		}
@@ -81,7 +121,7 @@ func (r PhaseHandler) HandlePhase(ctx context.Context, ctxAppTrace context.Conte
 			if err := r.SpanHandler.UnbindSpan(reconcileObject, phase.ShortName); err != nil {
 				r.Log.Error(err, "cannot unbind span")
 			}
-			RecordEvent(r.Recorder, phase, "Warning", reconcileObject, "Failed", "has failed", piWrapper.GetVersion())
+			RecordEvent(r.Recorder, phase, "Warning", reconcileObject, "Failed", failureReason(reconcileObject), piWrapper.GetVersion())
 			return &PhaseResult{Continue: false, Result: ctrl.Result{}}, nil
 		}
 
@@ -98,7 +138,7 @@ func (r PhaseHandler) HandlePhase(ctx context.Context, ctxAppTrace context.Conte
 	}
 
 	piWrapper.SetState(common.StateProgressing)
-	RecordEvent(r.Recorder, phase, "Warning", reconcileObject, "NotFinished", "has not finished", piWrapper.GetVersion())
+	RecordEvent(r.Recorder, phase, "Normal", reconcileObject, "NotFinished", "has not finished", piWrapper.GetVersion())
 
 	return &PhaseResult{Continue: false, Result: requeueResult}, nil
 }
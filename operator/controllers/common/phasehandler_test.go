@@ -0,0 +1,152 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPhaseHandler_HandlePhaseEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     common.KeptnState
+		tasks     []v1alpha1.TaskStatus
+		message   string
+		wantEvent string
+	}{
+		{
+			name:      "succeeded",
+			state:     common.StateSucceeded,
+			tasks:     []v1alpha1.TaskStatus{{TaskDefinitionName: "load-test", Status: common.StateSucceeded}},
+			wantEvent: "Normal WorkloadPreDeployTasksSucceeded Workload Pre-Deployment Tasks has succeeded",
+		},
+		{
+			name:  "failed",
+			state: common.StateFailed,
+			tasks: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "load-test", Status: common.StateSucceeded},
+				{TaskDefinitionName: "security-scan", Status: common.StateFailed},
+			},
+			wantEvent: "Warning WorkloadPreDeployTasksFailed Workload Pre-Deployment Tasks has failed (failed checks: security-scan)",
+		},
+		{
+			name:      "timed out",
+			state:     common.StateFailed,
+			tasks:     []v1alpha1.TaskStatus{{TaskDefinitionName: "migration", Status: common.StateFailed, Reason: "DeadlineExceeded"}},
+			wantEvent: "Warning WorkloadPreDeployTasksFailed Workload Pre-Deployment Tasks has failed (failed checks: migration)",
+		},
+		{
+			name:      "failed with message",
+			state:     common.StateFailed,
+			tasks:     []v1alpha1.TaskStatus{{TaskDefinitionName: "security-scan", Status: common.StateFailed}},
+			message:   "task security-scan has failed: container exited with code 1: 3 critical vulnerabilities found",
+			wantEvent: "Warning WorkloadPreDeployTasksFailed Workload Pre-Deployment Tasks has failed (failed checks: security-scan): task security-scan has failed: container exited with code 1: 3 critical vulnerabilities found",
+		},
+		{
+			name:      "not finished",
+			state:     common.StateProgressing,
+			tasks:     []v1alpha1.TaskStatus{{TaskDefinitionName: "load-test", Status: common.StateProgressing}},
+			wantEvent: "Normal WorkloadPreDeployTasksNotFinished Workload Pre-Deployment Tasks has not finished",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Status:     v1alpha1.KeptnWorkloadInstanceStatus{PreDeploymentTaskStatus: tt.tasks, PreDeploymentMessage: tt.message},
+			}
+			scheme := runtime.NewScheme()
+			require.Nil(t, v1alpha1.AddToScheme(scheme))
+			recorder := record.NewFakeRecorder(100)
+			r := PhaseHandler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance).Build(),
+				Recorder: recorder,
+				Log:      logr.Discard(),
+			}
+			tracer := trace.NewNoopTracerProvider().Tracer("test")
+			_, span := tracer.Start(context.TODO(), "test")
+
+			_, err := r.HandlePhase(context.TODO(), context.TODO(), tracer, workloadInstance, common.PhaseWorkloadPreDeployment, span, func() (common.KeptnState, error) {
+				return tt.state, nil
+			})
+			require.Nil(t, err)
+			require.Len(t, recorder.Events, 1)
+			require.Contains(t, <-recorder.Events, tt.wantEvent)
+		})
+	}
+}
+
+func TestPhaseHandler_HandlePhaseLinksTaskSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	// the tasks have been executed in traces of their own
+	_, loadTestSpan := tracer.Start(context.TODO(), "reconcile_task")
+	loadTestSpan.End()
+	_, securityScanSpan := tracer.Start(context.TODO(), "reconcile_task")
+	securityScanSpan.End()
+	exporter.Reset()
+
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "load-test", Status: common.StateSucceeded, SpanContext: spanContextOf(loadTestSpan.SpanContext())},
+				{TaskDefinitionName: "security-scan", Status: common.StateSucceeded, SpanContext: spanContextOf(securityScanSpan.SpanContext())},
+				{TaskDefinitionName: "not-started", Status: common.StateSucceeded},
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1alpha1.AddToScheme(scheme))
+	r := PhaseHandler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance).Build(),
+		Recorder: record.NewFakeRecorder(100),
+		Log:      logr.Discard(),
+	}
+	ctxAppTrace, appSpan := tracer.Start(context.TODO(), "app")
+	_, span := tracer.Start(context.TODO(), "reconcile_workload_instance")
+
+	_, err := r.HandlePhase(context.TODO(), ctxAppTrace, tracer, workloadInstance, common.PhaseWorkloadPreDeployment, span, func() (common.KeptnState, error) {
+		return common.StateSucceeded, nil
+	})
+	require.Nil(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	phaseSpan := spans[0]
+	require.Equal(t, common.PhaseWorkloadPreDeployment.ShortName, phaseSpan.Name)
+	require.Equal(t, appSpan.SpanContext().TraceID(), phaseSpan.SpanContext.TraceID())
+	require.Len(t, phaseSpan.Links, 2)
+	require.Equal(t, loadTestSpan.SpanContext().TraceID(), phaseSpan.Links[0].SpanContext.TraceID())
+	require.Equal(t, loadTestSpan.SpanContext().SpanID(), phaseSpan.Links[0].SpanContext.SpanID())
+	require.Equal(t, securityScanSpan.SpanContext().TraceID(), phaseSpan.Links[1].SpanContext.TraceID())
+
+	// the tasks created in the phase link back to it
+	require.Equal(t, spanContextOf(phaseSpan.SpanContext), workloadInstance.Status.PhaseSpanContext)
+}
+
+func TestGetSpanLinks(t *testing.T) {
+	require.Empty(t, GetSpanLinks(nil, map[string]string{"traceparent": "invalid"}))
+	links := GetSpanLinks(map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"})
+	require.Len(t, links, 1)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", links[0].SpanContext.TraceID().String())
+	require.Equal(t, "b7ad6b7169203331", links[0].SpanContext.SpanID().String())
+
+	require.Nil(t, GetSpanContext(context.TODO()))
+}
+
+func spanContextOf(sc trace.SpanContext) map[string]string {
+	return GetSpanContext(trace.ContextWithSpanContext(context.TODO(), sc))
+}
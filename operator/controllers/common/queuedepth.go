@@ -0,0 +1,83 @@
+package common
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const DefaultQueueDepthInterval = time.Second
+
+// workQueueDepthMetric is the gauge controller-runtime reports the depth of the work queue of every controller with
+const workQueueDepthMetric = "workqueue_depth"
+
+// QueueDepth samples the depth of the work queue of a controller from the metrics controller-runtime registers
+// in the given gatherer, usually metrics.Registry.
+// A nil *QueueDepth reports an empty queue.
+type QueueDepth struct {
+	Gatherer prometheus.Gatherer
+	// Name is the name of the controller, which is the lower-case kind of its reconciled object by default
+	Name     string
+	Log      logr.Logger
+	Interval time.Duration
+
+	depth int64
+}
+
+func NewQueueDepth(gatherer prometheus.Gatherer, name string, log logr.Logger) *QueueDepth {
+	return &QueueDepth{
+		Gatherer: gatherer,
+		Name:     name,
+		Log:      log,
+		Interval: DefaultQueueDepthInterval,
+	}
+}
+
+// Depth returns the number of requests waiting in the work queue when it has last been sampled
+func (q *QueueDepth) Depth() int {
+	if q == nil {
+		return 0
+	}
+	return int(atomic.LoadInt64(&q.depth))
+}
+
+// Refresh samples the depth of the work queue
+func (q *QueueDepth) Refresh() error {
+	families, err := q.Gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	for _, family := range families {
+		if family.GetName() != workQueueDepthMetric {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == q.Name {
+					atomic.StoreInt64(&q.depth, int64(metric.GetGauge().GetValue()))
+					return nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Start samples the depth of the work queue until the given context is cancelled. It implements manager.Runnable.
+func (q *QueueDepth) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := q.Refresh(); err != nil {
+			q.Log.Error(err, "could not sample the depth of the work queue", "controller", q.Name)
+		}
+	}, q.Interval)
+	return nil
+}
+
+// NeedLeaderElection returns false, since sampling does not modify any objects
+func (q *QueueDepth) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueDepth_Refresh(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Subsystem: "workqueue", Name: "depth"}, []string{"name"})
+	registry.MustRegister(depth)
+	depth.WithLabelValues("keptnworkloadinstance").Set(120)
+	depth.WithLabelValues("keptntask").Set(3)
+
+	queueDepth := NewQueueDepth(registry, "keptnworkloadinstance", logr.Discard())
+	require.Nil(t, queueDepth.Refresh())
+	require.Equal(t, 120, queueDepth.Depth())
+
+	depth.WithLabelValues("keptnworkloadinstance").Set(0)
+	require.Nil(t, queueDepth.Refresh())
+	require.Equal(t, 0, queueDepth.Depth())
+}
+
+func TestQueueDepth_Nil(t *testing.T) {
+	var queueDepth *QueueDepth
+	require.Equal(t, 0, queueDepth.Depth())
+}
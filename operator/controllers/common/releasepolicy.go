@@ -0,0 +1,118 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultReleasePolicyTimeout = 5 * time.Second
+
+	// ReleasePolicyFailureAllow releases the pods if the policy endpoint cannot be reached or answers with an error
+	ReleasePolicyFailureAllow = "allow"
+	// ReleasePolicyFailureDeny keeps the pods gated if the policy endpoint cannot be reached or answers with an error
+	ReleasePolicyFailureDeny = "deny"
+)
+
+// ReleasePolicyInput is sent to the release policy endpoint as the input of the decision
+type ReleasePolicyInput struct {
+	App       string               `json:"app"`
+	Workload  string               `json:"workload"`
+	Version   string               `json:"version"`
+	Namespace string               `json:"namespace"`
+	Checks    []ReleasePolicyCheck `json:"checks"`
+}
+
+// ReleasePolicyCheck is the result of a pre-deployment task or evaluation of the workload
+type ReleasePolicyCheck struct {
+	Name string `json:"name"`
+	// Type is either task or evaluation
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// ReleasePolicyDecision is the answer of the release policy endpoint
+type ReleasePolicyDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// releasePolicyResponse accepts both a plain decision and a decision wrapped into the result of the OPA data API
+type releasePolicyResponse struct {
+	ReleasePolicyDecision
+	Result *ReleasePolicyDecision `json:"result,omitempty"`
+}
+
+// ReleasePolicy asks an external HTTP endpoint, e.g. an OPA server, whether the pods of a workload may be released
+// after its pre-deployment checks have succeeded. The input is posted as {"input": ...}, the endpoint answers with
+// {"allowed": bool, "reason": string}, optionally wrapped into {"result": ...}.
+// A nil *ReleasePolicy allows every release.
+type ReleasePolicy struct {
+	URL           string
+	FailurePolicy string
+	Client        *http.Client
+}
+
+// NewReleasePolicy returns nil if no URL is given
+func NewReleasePolicy(url string, timeout time.Duration, failurePolicy string) (*ReleasePolicy, error) {
+	if url == "" {
+		return nil, nil
+	}
+	if failurePolicy != ReleasePolicyFailureAllow && failurePolicy != ReleasePolicyFailureDeny {
+		return nil, fmt.Errorf("unknown release policy failure policy %s, must be %s or %s", failurePolicy, ReleasePolicyFailureAllow, ReleasePolicyFailureDeny)
+	}
+	return &ReleasePolicy{
+		URL:           url,
+		FailurePolicy: failurePolicy,
+		Client:        NewHTTPClient(timeout),
+	}, nil
+}
+
+// Evaluate returns the decision of the policy endpoint. If the endpoint fails, the decision follows the failure
+// policy and the error is returned along with it.
+func (p *ReleasePolicy) Evaluate(ctx context.Context, input ReleasePolicyInput) (ReleasePolicyDecision, error) {
+	if p == nil {
+		return ReleasePolicyDecision{Allowed: true}, nil
+	}
+	decision, err := p.post(ctx, input)
+	if err != nil {
+		return ReleasePolicyDecision{
+			Allowed: p.FailurePolicy == ReleasePolicyFailureAllow,
+			Reason:  fmt.Sprintf("release policy could not be evaluated, failure policy is %s", p.FailurePolicy),
+		}, err
+	}
+	return decision, nil
+}
+
+func (p *ReleasePolicy) post(ctx context.Context, input ReleasePolicyInput) (ReleasePolicyDecision, error) {
+	body, err := json.Marshal(map[string]ReleasePolicyInput{"input": input})
+	if err != nil {
+		return ReleasePolicyDecision{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return ReleasePolicyDecision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return ReleasePolicyDecision{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return ReleasePolicyDecision{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	response := releasePolicyResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return ReleasePolicyDecision{}, fmt.Errorf("could not decode release policy decision: %w", err)
+	}
+	if response.Result != nil {
+		return *response.Result, nil
+	}
+	return response.ReleasePolicyDecision, nil
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleasePolicy_Evaluate(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		response      string
+		failurePolicy string
+		want          ReleasePolicyDecision
+		wantErr       bool
+	}{
+		{
+			name:          "allowed",
+			status:        http.StatusOK,
+			response:      `{"allowed": true}`,
+			failurePolicy: ReleasePolicyFailureDeny,
+			want:          ReleasePolicyDecision{Allowed: true},
+		},
+		{
+			name:          "denied with reason",
+			status:        http.StatusOK,
+			response:      `{"allowed": false, "reason": "change freeze"}`,
+			failurePolicy: ReleasePolicyFailureAllow,
+			want:          ReleasePolicyDecision{Allowed: false, Reason: "change freeze"},
+		},
+		{
+			name:          "OPA result",
+			status:        http.StatusOK,
+			response:      `{"result": {"allowed": false, "reason": "change freeze"}}`,
+			failurePolicy: ReleasePolicyFailureAllow,
+			want:          ReleasePolicyDecision{Allowed: false, Reason: "change freeze"},
+		},
+		{
+			name:          "error with failure policy allow",
+			status:        http.StatusInternalServerError,
+			failurePolicy: ReleasePolicyFailureAllow,
+			want:          ReleasePolicyDecision{Allowed: true, Reason: "release policy could not be evaluated, failure policy is allow"},
+			wantErr:       true,
+		},
+		{
+			name:          "invalid response with failure policy deny",
+			status:        http.StatusOK,
+			response:      `not json`,
+			failurePolicy: ReleasePolicyFailureDeny,
+			want:          ReleasePolicyDecision{Allowed: false, Reason: "release policy could not be evaluated, failure policy is deny"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received map[string]ReleasePolicyInput
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			policy, err := NewReleasePolicy(server.URL, time.Second, tt.failurePolicy)
+			require.Nil(t, err)
+
+			input := ReleasePolicyInput{App: "my-app", Workload: "my-workload", Version: "1.0.0", Namespace: "default"}
+			decision, err := policy.Evaluate(context.TODO(), input)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, decision)
+			require.Equal(t, input, received["input"])
+		})
+	}
+}
+
+func TestReleasePolicy_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	policy, err := NewReleasePolicy(server.URL, time.Second, ReleasePolicyFailureDeny)
+	require.Nil(t, err)
+
+	decision, err := policy.Evaluate(context.TODO(), ReleasePolicyInput{})
+	require.NotNil(t, err)
+	require.False(t, decision.Allowed)
+}
+
+func TestNewReleasePolicy(t *testing.T) {
+	policy, err := NewReleasePolicy("", time.Second, "")
+	require.Nil(t, err)
+	require.Nil(t, policy)
+
+	decision, err := policy.Evaluate(context.TODO(), ReleasePolicyInput{})
+	require.Nil(t, err)
+	require.True(t, decision.Allowed)
+
+	_, err = NewReleasePolicy("http://opa:8181/v1/data/keptn/release", time.Second, "ignore")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IsScaledToZero returns true if the referenced workload is scaled to zero replicas. For a ReplicaSet, the replicas of
+// the Deployment owning it are checked. A DaemonSet is scaled to zero if none of the nodes is selected for its pods.
+// Workloads referencing a single Pod and workloads that cannot be found are never considered to be scaled to zero.
+func IsScaledToZero(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (bool, error) {
+	switch reference.Kind {
+	case "StatefulSet":
+		sts, err := getStatefulSet(ctx, c, namespace, reference)
+		if err != nil || sts == nil {
+			return false, err
+		}
+		return sts.Spec.Replicas != nil && *sts.Spec.Replicas == 0, nil
+	case "DaemonSet":
+		ds, err := getDaemonSet(ctx, c, namespace, reference)
+		if err != nil || ds == nil {
+			return false, err
+		}
+		// the number of scheduled pods is only known once the DaemonSet controller has observed the current spec
+		return ds.Status.ObservedGeneration >= ds.Generation && ds.Status.DesiredNumberScheduled == 0, nil
+	}
+	rs, err := getReplicaSet(ctx, c, namespace, reference)
+	if err != nil || rs == nil {
+		return false, err
+	}
+	deployment, err := getOwningDeployment(ctx, c, rs)
+	if err != nil {
+		return false, err
+	}
+	if deployment != nil {
+		return deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0, nil
+	}
+	return rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0, nil
+}
+
+// IsWorkloadDeleted returns true if the referenced ReplicaSet, or the Deployment owning it, is gone or being deleted.
+// Workloads referencing a single Pod are never considered to be deleted.
+func IsWorkloadDeleted(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (bool, error) {
+	if reference.Kind != "ReplicaSet" {
+		return false, nil
+	}
+	rs, err := getReplicaSet(ctx, c, namespace, reference)
+	if err != nil {
+		return false, err
+	}
+	if rs == nil || rs.DeletionTimestamp != nil {
+		return true, nil
+	}
+	deployment, err := getOwningDeployment(ctx, c, rs)
+	if errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return deployment != nil && deployment.DeletionTimestamp != nil, nil
+}
+
+// GetDeployment returns the Deployment owning the referenced ReplicaSet, or nil if there is none
+func GetDeployment(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (*appsv1.Deployment, error) {
+	rs, err := getReplicaSet(ctx, c, namespace, reference)
+	if err != nil || rs == nil {
+		return nil, err
+	}
+	return getOwningDeployment(ctx, c, rs)
+}
+
+func getReplicaSet(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (*appsv1.ReplicaSet, error) {
+	if reference.Kind != "ReplicaSet" {
+		return nil, nil
+	}
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := c.List(ctx, replicaSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range replicaSets.Items {
+		if replicaSets.Items[i].UID == reference.UID {
+			return &replicaSets.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func getStatefulSet(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (*appsv1.StatefulSet, error) {
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		if statefulSets.Items[i].UID == reference.UID {
+			return &statefulSets.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func getDaemonSet(ctx context.Context, c client.Client, namespace string, reference klcv1alpha1.ResourceReference) (*appsv1.DaemonSet, error) {
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		if daemonSets.Items[i].UID == reference.UID {
+			return &daemonSets.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func getOwningDeployment(ctx context.Context, c client.Client, rs *appsv1.ReplicaSet) (*appsv1.Deployment, error) {
+	for _, owner := range rs.OwnerReferences {
+		if owner.Kind != "Deployment" {
+			continue
+		}
+		deployment := &appsv1.Deployment{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: rs.Namespace, Name: owner.Name}, deployment); err != nil {
+			return nil, err
+		}
+		return deployment, nil
+	}
+	return nil, nil
+}
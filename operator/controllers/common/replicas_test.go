@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsScaledToZero(t *testing.T) {
+	tests := []struct {
+		name               string
+		deploymentReplicas int32
+		reference          v1alpha1.ResourceReference
+		want               bool
+	}{
+		{name: "deployment with replicas", deploymentReplicas: 2, reference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"}, want: false},
+		{name: "deployment scaled to zero", deploymentReplicas: 0, reference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"}, want: true},
+		{name: "unknown replicaset", deploymentReplicas: 0, reference: v1alpha1.ResourceReference{UID: "other-uid", Kind: "ReplicaSet"}, want: false},
+		{name: "pod reference", deploymentReplicas: 0, reference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "Pod"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(makeScaledDeployment("default", "my-deployment", "rs-uid", tt.deploymentReplicas)...).Build()
+			got, err := IsScaledToZero(context.TODO(), c, "default", tt.reference)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsScaledToZero_ReplicaSetWithoutOwner(t *testing.T) {
+	replicas := int32(0)
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-rs", UID: "rs-uid"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}
+	c := fake.NewClientBuilder().WithObjects(rs).Build()
+	got, err := IsScaledToZero(context.TODO(), c, "default", v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"})
+	require.Nil(t, err)
+	require.True(t, got)
+}
+
+func TestIsScaledToZero_StatefulSetAndDaemonSet(t *testing.T) {
+	zero := int32(0)
+	two := int32(2)
+	tests := []struct {
+		name   string
+		object client.Object
+		kind   string
+		want   bool
+	}{
+		{
+			name:   "statefulset with replicas",
+			object: &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-sts", UID: "uid"}, Spec: appsv1.StatefulSetSpec{Replicas: &two}},
+			kind:   "StatefulSet",
+		},
+		{
+			name:   "statefulset scaled to zero",
+			object: &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-sts", UID: "uid"}, Spec: appsv1.StatefulSetSpec{Replicas: &zero}},
+			kind:   "StatefulSet",
+			want:   true,
+		},
+		{
+			name:   "daemonset with scheduled pods",
+			object: &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-ds", UID: "uid", Generation: 1}, Status: appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3}},
+			kind:   "DaemonSet",
+		},
+		{
+			name:   "daemonset without selected nodes",
+			object: &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-ds", UID: "uid", Generation: 1}, Status: appsv1.DaemonSetStatus{ObservedGeneration: 1}},
+			kind:   "DaemonSet",
+			want:   true,
+		},
+		{
+			name:   "daemonset not yet observed",
+			object: &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-ds", UID: "uid", Generation: 2}, Status: appsv1.DaemonSetStatus{ObservedGeneration: 1}},
+			kind:   "DaemonSet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.object).Build()
+			got, err := IsScaledToZero(context.TODO(), c, "default", v1alpha1.ResourceReference{UID: "uid", Kind: tt.kind})
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func makeScaledDeployment(namespace string, name string, replicaSetUID types.UID, replicas int32) []client.Object {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name + "-rs",
+			UID:       replicaSetUID,
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: name},
+			},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}
+	return []client.Object{deployment, rs}
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// RequeueBackoff computes the interval an object whose phase has not finished yet is reconciled again in.
+// The interval starts at Interval and doubles with every reconciliation that observes the object in the same phase,
+// up to MaxInterval. It starts over as soon as the object is observed in another phase.
+// A nil *RequeueBackoff always returns DefaultPhaseRequeueInterval.
+type RequeueBackoff struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+
+	mu       sync.Mutex
+	observed map[string]observedPhase
+}
+
+type observedPhase struct {
+	phase    string
+	interval time.Duration
+}
+
+// NewRequeueBackoff returns a backoff starting at interval and growing up to maxInterval.
+// If maxInterval is not larger than interval, objects are always requeued after interval.
+func NewRequeueBackoff(interval time.Duration, maxInterval time.Duration) *RequeueBackoff {
+	if interval <= 0 {
+		interval = DefaultPhaseRequeueInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return &RequeueBackoff{
+		Interval:    interval,
+		MaxInterval: maxInterval,
+		observed:    map[string]observedPhase{},
+	}
+}
+
+// Next returns the interval the object with the given key is reconciled again in, if it is still in the given phase
+func (b *RequeueBackoff) Next(key string, phase string) time.Duration {
+	if b == nil {
+		return DefaultPhaseRequeueInterval
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	observed, ok := b.observed[key]
+	if !ok || observed.phase != phase {
+		observed = observedPhase{phase: phase, interval: b.Interval}
+	} else if observed.interval < b.MaxInterval {
+		observed.interval *= 2
+		if observed.interval > b.MaxInterval {
+			observed.interval = b.MaxInterval
+		}
+	}
+	b.observed[key] = observed
+	return observed.interval
+}
+
+// Forget drops the backoff of the object with the given key, e.g. once it has completed
+func (b *RequeueBackoff) Forget(key string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.observed, key)
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	backoff := NewRequeueBackoff(5*time.Second, 30*time.Second)
+
+	// the interval doubles as long as the object stays in the same phase, up to the maximum
+	require.Equal(t, 5*time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+	require.Equal(t, 10*time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+	require.Equal(t, 20*time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+	require.Equal(t, 30*time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+	require.Equal(t, 30*time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+
+	// other objects have their own backoff
+	require.Equal(t, 5*time.Second, backoff.Next("default/other", "PreDeployTasks"))
+
+	// a new phase starts over
+	require.Equal(t, 5*time.Second, backoff.Next("default/wi", "WorkloadDeploy"))
+	require.Equal(t, 10*time.Second, backoff.Next("default/wi", "WorkloadDeploy"))
+
+	backoff.Forget("default/wi")
+	require.Equal(t, 5*time.Second, backoff.Next("default/wi", "WorkloadDeploy"))
+}
+
+func TestRequeueBackoffConstant(t *testing.T) {
+	backoff := NewRequeueBackoff(time.Second, 0)
+	require.Equal(t, time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+	require.Equal(t, time.Second, backoff.Next("default/wi", "PreDeployTasks"))
+
+	var disabled *RequeueBackoff
+	require.Equal(t, DefaultPhaseRequeueInterval, disabled.Next("default/wi", "PreDeployTasks"))
+	disabled.Forget("default/wi")
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -11,7 +12,10 @@ type SpanHandler struct {
 	bindCRDSpan map[string]trace.Span
 }
 
-func (r SpanHandler) GetSpan(ctx context.Context, tracer trace.Tracer, reconcileObject client.Object, phase string) (context.Context, trace.Span, error) {
+// spanContextPropagator persists span contexts in the W3C format, independent of the propagator of the operator
+var spanContextPropagator = propagation.TraceContext{}
+
+func (r SpanHandler) GetSpan(ctx context.Context, tracer trace.Tracer, reconcileObject client.Object, phase string, opts ...trace.SpanStartOption) (context.Context, trace.Span, error) {
 	piWrapper, err := NewPhaseItemWrapperFromClientObject(reconcileObject)
 	if err != nil {
 		return nil, nil, err
@@ -23,7 +27,7 @@ func (r SpanHandler) GetSpan(ctx context.Context, tracer trace.Tracer, reconcile
 	if span, ok := r.bindCRDSpan[appvName]; ok {
 		return ctx, span, nil
 	}
-	ctx, span := tracer.Start(ctx, phase, trace.WithSpanKind(trace.SpanKindConsumer))
+	ctx, span := tracer.Start(ctx, phase, append([]trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindConsumer)}, opts...)...)
 	r.bindCRDSpan[appvName] = span
 	return ctx, span, nil
 }
@@ -36,3 +40,26 @@ func (r SpanHandler) UnbindSpan(reconcileObject client.Object, phase string) err
 	delete(r.bindCRDSpan, piWrapper.GetSpanName(phase))
 	return nil
 }
+
+// GetSpanContext returns the trace context of the span of ctx, which can be persisted in the status of an object,
+// or nil if ctx has no valid span
+func GetSpanContext(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	spanContextPropagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// GetSpanLinks returns links to the spans of the given trace contexts. Invalid trace contexts are skipped.
+func GetSpanLinks(spanContexts ...map[string]string) []trace.Link {
+	var links []trace.Link
+	for _, spanContext := range spanContexts {
+		ctx := spanContextPropagator.Extract(context.Background(), propagation.MapCarrier(spanContext))
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return links
+}
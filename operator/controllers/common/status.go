@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type statusBaseKey struct{}
+
+// WithStatusBase returns a context carrying a copy of the object as it has been read by the reconciliation, which
+// UpdateStatus uses as the base of its status patches
+func WithStatusBase(ctx context.Context, obj client.Object) context.Context {
+	return context.WithValue(ctx, statusBaseKey{}, obj.DeepCopyObject())
+}
+
+// UpdateStatus writes the status of the object. If the context carries the object as it has been read (see
+// WithStatusBase), only the fields changed since then are sent as a merge patch. Changes of other writers since the
+// read, e.g. of the metadata by the webhook or of the Stuck condition by the stuck sweeper, then neither cause a
+// conflict nor are they overwritten, unless the reconciliation has changed the same field. Lists, such as the
+// conditions, are replaced as a whole once they have been changed. The patch only carries the status, so that writes of
+// the reconciliation itself since the read, e.g. of a finalizer, do not put the resourceVersion into the patch and
+// turn optimistic locking back on.
+// Without a base, the status is updated with optimistic locking and conflicts are returned to the caller.
+func UpdateStatus(ctx context.Context, c client.Client, obj client.Object) error {
+	base, ok := ctx.Value(statusBaseKey{}).(client.Object)
+	if !ok || reflect.TypeOf(base) != reflect.TypeOf(obj) || client.ObjectKeyFromObject(base) != client.ObjectKeyFromObject(obj) || base.GetUID() != obj.GetUID() {
+		return c.Status().Update(ctx, obj)
+	}
+	patchBase, err := getStatusPatchBase(base, obj)
+	if err != nil {
+		return err
+	}
+	return c.Status().Patch(ctx, obj, client.MergeFrom(patchBase))
+}
+
+// getStatusPatchBase returns a copy of obj with the status of base, so that a merge patch between them only differs
+// in the status
+func getStatusPatchBase(base client.Object, obj client.Object) (client.Object, error) {
+	from, err := runtime.DefaultUnstructuredConverter.ToUnstructured(base)
+	if err != nil {
+		return nil, err
+	}
+	to, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := from["status"]; ok {
+		to["status"] = status
+	} else {
+		delete(to, "status")
+	}
+	patchBase := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+	return patchBase, runtime.DefaultUnstructuredConverter.FromUnstructured(to, patchBase)
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// conflictingStatusClient fails the first status write with a conflict
+type conflictingStatusClient struct {
+	client.Client
+	writes int
+}
+
+func (c *conflictingStatusClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingStatusClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.client.writes++
+	if w.client.writes == 1 {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "keptnworkloadinstances"}, obj.GetName(), nil)
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestUpdateStatus_ReturnsConflictWithoutBase(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-instance"}}
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1alpha1.AddToScheme(scheme))
+	c := &conflictingStatusClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance).Build()}
+
+	// the status is not written on top of a version of the object the caller has not seen
+	workloadInstance.Status.Status = common.StateProgressing
+	require.True(t, apierrors.IsConflict(UpdateStatus(context.TODO(), c, workloadInstance)))
+	require.Equal(t, 1, c.writes)
+}
+
+func TestUpdateStatus_KeepsConcurrentChanges(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-instance"}}
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance).Build()
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), workloadInstance))
+	ctx := WithStatusBase(context.TODO(), workloadInstance)
+
+	// other writers change the instance after the reconciler has read it
+	other := workloadInstance.DeepCopy()
+	other.Annotations = map[string]string{"owner": "someone-else"}
+	require.Nil(t, c.Update(context.TODO(), other))
+	other.Status.Status = common.StateFailed
+	other.Status.Conditions = []metav1.Condition{{Type: v1alpha1.StuckConditionType, Status: metav1.ConditionTrue, Reason: "Stuck", LastTransitionTime: metav1.Now()}}
+	require.Nil(t, c.Status().Update(context.TODO(), other))
+
+	workloadInstance.Status.CurrentPhase = common.PhaseWorkloadPreDeployment.ShortName
+	require.Nil(t, UpdateStatus(ctx, c, workloadInstance))
+
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+	require.Equal(t, common.PhaseWorkloadPreDeployment.ShortName, stored.Status.CurrentPhase)
+	require.Equal(t, common.StateFailed, stored.Status.Status)
+	require.Equal(t, "someone-else", stored.Annotations["owner"])
+	require.Len(t, stored.Status.Conditions, 1)
+}
+
+func TestUpdateStatus_KeepsConcurrentChangesAfterOwnUpdate(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-instance"}}
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance).Build()
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), workloadInstance))
+	ctx := WithStatusBase(context.TODO(), workloadInstance)
+
+	// the reconciler writes the instance itself, e.g. to add a finalizer, which changes its resourceVersion
+	workloadInstance.Finalizers = []string{"keptn.sh/cancel"}
+	require.Nil(t, c.Update(context.TODO(), workloadInstance))
+
+	// another writer changes the status in between
+	other := &v1alpha1.KeptnWorkloadInstance{}
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), other))
+	other.Status.Conditions = []metav1.Condition{{Type: v1alpha1.StuckConditionType, Status: metav1.ConditionTrue, Reason: "Stuck", LastTransitionTime: metav1.Now()}}
+	require.Nil(t, c.Status().Update(context.TODO(), other))
+
+	workloadInstance.Status.CurrentPhase = common.PhaseWorkloadPreDeployment.ShortName
+	require.Nil(t, UpdateStatus(ctx, c, workloadInstance))
+
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	require.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+	require.Equal(t, common.PhaseWorkloadPreDeployment.ShortName, stored.Status.CurrentPhase)
+	require.Len(t, stored.Status.Conditions, 1)
+	require.Equal(t, []string{"keptn.sh/cancel"}, stored.Finalizers)
+}
@@ -49,17 +49,23 @@ import (
 type KeptnAppVersionReconciler struct {
 	Scheme *runtime.Scheme
 	client.Client
-	Log         logr.Logger
-	Recorder    record.EventRecorder
-	Tracer      trace.Tracer
-	Meters      common.KeptnMeters
-	SpanHandler controllercommon.SpanHandler
+	Log             logr.Logger
+	Recorder        record.EventRecorder
+	Tracer          trace.Tracer
+	Meters          common.KeptnMeters
+	SpanHandler     controllercommon.SpanHandler
+	Exporter        *controllercommon.LifecycleExporter
+	CloudEvents     *controllercommon.CloudEventSender
+	CreationLimiter *controllercommon.CreationLimiter
 }
 
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnappversions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnappversions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnappversions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnworkloadinstances,verbs=get;list;watch;update;delete
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnworkloadinstances/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnworkloads,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -85,6 +91,19 @@ func (r *KeptnAppVersionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return reconcile.Result{}, fmt.Errorf("could not fetch KeptnappVersion: %+v", err)
 	}
 
+	deleted, err := r.reconcileWorkloadInstanceOwnership(ctx, appVersion)
+	if err != nil {
+		r.Log.Error(err, "could not reconcile the owner references of the workload instances")
+		return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, err
+	}
+	if deleted {
+		return reconcile.Result{}, nil
+	}
+
+	if appVersion.IsEndTimeSet() {
+		return reconcile.Result{}, nil
+	}
+
 	appVersion.SetStartTime()
 
 	traceContextCarrier := propagation.MapCarrier(appVersion.Annotations)
@@ -97,6 +116,9 @@ func (r *KeptnAppVersionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	defer func(span trace.Span, appVersion *klcv1alpha1.KeptnAppVersion) {
 		if appVersion.IsEndTimeSet() {
+			record := controllercommon.NewAppVersionRecord(*appVersion)
+			r.Exporter.Export(record)
+			r.CloudEvents.Send(controllercommon.NewCloudEvent(controllercommon.CloudEventTypeAppVersionFinished, appVersion.Namespace+"/"+appVersion.Name, record))
 			r.Log.Info("Increasing app count")
 			attrs := appVersion.GetMetricsAttributes()
 			r.Meters.AppCount.Add(ctx, 1, attrs...)
@@ -116,6 +138,15 @@ func (r *KeptnAppVersionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 
 	if appVersion.Status.CurrentPhase == "" {
+		waiting, err := r.reconcileConcurrency(ctx, appVersion)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, err
+		}
+		if waiting {
+			return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, nil
+		}
+
 		if err := r.SpanHandler.UnbindSpan(appVersion, phase.ShortName); err != nil {
 			r.Log.Error(err, "cannot unbind span")
 		}
@@ -206,8 +237,9 @@ func (r *KeptnAppVersionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	attrs := appVersion.GetMetricsAttributes()
 
 	// metrics: add app duration
-	duration := appVersion.Status.EndTime.Time.Sub(appVersion.Status.StartTime.Time)
+	duration := common.Duration(appVersion.Status.StartTime, appVersion.Status.EndTime)
 	r.Meters.AppDuration.Record(ctx, duration.Seconds(), attrs...)
+	r.Log.Info("KeptnAppVersion has finished", "duration", duration.String())
 
 	return ctrl.Result{}, nil
 }
@@ -216,6 +248,9 @@ func (r *KeptnAppVersionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 func (r *KeptnAppVersionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&klcv1alpha1.KeptnAppVersion{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// reconcile as soon as one of the checks of the app version changes, instead of waiting for the next requeue
+		Owns(&klcv1alpha1.KeptnTask{}).
+		Owns(&klcv1alpha1.KeptnEvaluation{}).
 		Complete(r)
 }
 
@@ -260,7 +295,7 @@ func (r *KeptnAppVersionReconciler) GetDeploymentInterval(ctx context.Context) (
 			if err != nil {
 				r.Log.Error(err, "Previous App Version not found")
 			} else {
-				previousInterval := appInstance.Status.StartTime.Time.Sub(previousAppVersion.Status.EndTime.Time)
+				previousInterval := common.Duration(previousAppVersion.Status.EndTime, appInstance.Status.StartTime)
 				res = append(res, common.GaugeFloatValue{
 					Value:      previousInterval.Seconds(),
 					Attributes: appInstance.GetDurationMetricsAttributes(),
@@ -283,7 +318,7 @@ func (r *KeptnAppVersionReconciler) GetDeploymentDuration(ctx context.Context) (
 
 	for _, appInstance := range appInstances.Items {
 		if appInstance.IsEndTimeSet() {
-			duration := appInstance.Status.EndTime.Time.Sub(appInstance.Status.StartTime.Time)
+			duration := common.Duration(appInstance.Status.StartTime, appInstance.Status.EndTime)
 			res = append(res, common.GaugeFloatValue{
 				Value:      duration.Seconds(),
 				Attributes: appInstance.GetDurationMetricsAttributes(),
@@ -0,0 +1,71 @@
+package keptnappversion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeptnAppVersionReconciler_reconcilePrePostDeploymentAllowFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowFailure bool
+		wantState    common.KeptnState
+	}{
+		{
+			name:         "failure is allowed",
+			allowFailure: true,
+			wantState:    common.StateSucceeded,
+		},
+		{
+			name:         "failure blocks the deployment",
+			allowFailure: false,
+			wantState:    common.StateFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appVersion := makeAppVersion("myapp-1.0.0", "1.0.0", time.Now())
+			appVersion.Spec.PreDeploymentTasks = []string{"smoke-test"}
+			appVersion.Status.PreDeploymentTaskStatus = []klcv1alpha1.TaskStatus{
+				{TaskDefinitionName: "smoke-test", TaskName: "pre-smoke-test-12345", Status: common.StateProgressing},
+			}
+			r := newOwnershipTestReconciler(t,
+				&appVersion,
+				&klcv1alpha1.KeptnTaskDefinition{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "smoke-test"},
+					Spec:       klcv1alpha1.KeptnTaskDefinitionSpec{AllowFailure: tt.allowFailure},
+				},
+				&klcv1alpha1.KeptnTask{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-smoke-test-12345"},
+					Spec:       klcv1alpha1.KeptnTaskSpec{TaskDefinition: "smoke-test"},
+					Status:     klcv1alpha1.KeptnTaskStatus{Status: common.StateFailed},
+				},
+			)
+
+			state, err := r.reconcilePrePostDeployment(context.TODO(), &appVersion, common.PreDeploymentCheckType)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantState, state)
+
+			// the task keeps its failed state either way
+			taskStatus := appVersion.Status.PreDeploymentTaskStatus[0]
+			require.Equal(t, common.StateFailed, taskStatus.Status)
+			require.Equal(t, tt.allowFailure, taskStatus.IsFailureAllowed())
+
+			condition := meta.FindStatusCondition(appVersion.Status.Conditions, klcv1alpha1.TasksFailureAllowedConditionType)
+			if !tt.allowFailure {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, metav1.ConditionTrue, condition.Status)
+			require.Contains(t, condition.Message, "smoke-test")
+		})
+	}
+}
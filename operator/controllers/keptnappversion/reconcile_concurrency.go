@@ -0,0 +1,108 @@
+package keptnappversion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultConcurrencyWaitTimeout is the time a KeptnAppVersion waits for older versions of the app to complete, unless
+// the app sets concurrencyWaitTimeout
+const DefaultConcurrencyWaitTimeout = time.Hour
+
+// reconcileConcurrency applies the concurrency policy of the app to the given KeptnAppVersion.
+// It returns true if the KeptnAppVersion has to wait for an older version of the same app to complete.
+func (r *KeptnAppVersionReconciler) reconcileConcurrency(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) (bool, error) {
+	running, err := r.getRunningPreviousVersions(ctx, appVersion)
+	if err != nil {
+		return false, err
+	}
+
+	// a version that has waited too long cancels the older versions, so that a stuck version cannot block all newer ones
+	waitTimeout := DefaultConcurrencyWaitTimeout
+	if appVersion.Spec.ConcurrencyWaitTimeout != nil {
+		waitTimeout = appVersion.Spec.ConcurrencyWaitTimeout.Duration
+	}
+	if appVersion.Spec.ConcurrencyPolicy == klcv1alpha1.ConcurrencyPolicyLatestWins || (len(running) > 0 && common.Since(appVersion.CreationTimestamp) > waitTimeout) {
+		for i := range running {
+			if err := r.cancelAppVersion(ctx, &running[i], appVersion); err != nil {
+				return false, err
+			}
+		}
+		running = nil
+	}
+
+	if len(running) == 0 {
+		appVersion.SetWaitingForPreviousVersion(false, "")
+		return false, nil
+	}
+
+	// the condition only changes when another version is waited for, so that requeues do not write the status
+	before := meta.FindStatusCondition(appVersion.Status.Conditions, klcv1alpha1.WaitingForPreviousVersionConditionType)
+	if before != nil {
+		before = before.DeepCopy()
+	}
+	appVersion.SetWaitingForPreviousVersion(true, running[0].Name)
+	after := meta.FindStatusCondition(appVersion.Status.Conditions, klcv1alpha1.WaitingForPreviousVersionConditionType)
+	if before != nil && before.Status == after.Status && before.Reason == after.Reason && before.Message == after.Message && before.ObservedGeneration == after.ObservedGeneration {
+		return true, nil
+	}
+	if err := r.Client.Status().Update(ctx, appVersion); err != nil {
+		return true, err
+	}
+	controllercommon.RecordEvent(r.Recorder, common.PhaseAppPreDeployment, "Normal", appVersion, "WaitingForPreviousVersion", "waits for the previous version of the app to complete", appVersion.GetVersion())
+	return true, nil
+}
+
+func (r *KeptnAppVersionReconciler) getRunningPreviousVersions(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) ([]klcv1alpha1.KeptnAppVersion, error) {
+	appVersions := &klcv1alpha1.KeptnAppVersionList{}
+	if err := r.Client.List(ctx, appVersions, client.InNamespace(appVersion.Namespace)); err != nil {
+		return nil, fmt.Errorf("could not retrieve app versions: %w", err)
+	}
+
+	var running []klcv1alpha1.KeptnAppVersion
+	for _, other := range appVersions.Items {
+		if other.Spec.AppName != appVersion.Spec.AppName || other.Name == appVersion.Name || other.IsEndTimeSet() {
+			continue
+		}
+		if isOlderAppVersion(other, *appVersion) {
+			running = append(running, other)
+		}
+	}
+	return running, nil
+}
+
+// cancelAppVersion fails an older KeptnAppVersion and cancels its KeptnTasks and KeptnEvaluations that are still
+// running, together with the Jobs of the tasks
+func (r *KeptnAppVersionReconciler) cancelAppVersion(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion, newerAppVersion *klcv1alpha1.KeptnAppVersion) error {
+	if err := controllercommon.CancelChecks(ctx, r.Client, appVersion.Namespace,
+		[][]klcv1alpha1.TaskStatus{appVersion.Status.PreDeploymentTaskStatus, appVersion.Status.PostDeploymentTaskStatus},
+		[][]klcv1alpha1.EvaluationStatus{appVersion.Status.PreDeploymentEvaluationTaskStatus, appVersion.Status.PostDeploymentEvaluationTaskStatus},
+	); err != nil {
+		return fmt.Errorf("could not cancel the checks of KeptnAppVersion %s: %w", appVersion.Name, err)
+	}
+	if err := r.SpanHandler.UnbindSpan(appVersion, appVersion.Status.CurrentPhase); err != nil {
+		r.Log.Error(err, "cannot unbind span")
+	}
+	appVersion.Status.Status = common.StateFailed
+	appVersion.Status.CurrentPhase = common.PhaseCompleted.ShortName
+	appVersion.Complete()
+	if err := r.Client.Status().Update(ctx, appVersion); err != nil {
+		return fmt.Errorf("could not cancel KeptnAppVersion %s: %w", appVersion.Name, err)
+	}
+	r.Recorder.Event(appVersion, "Warning", "Cancelled", fmt.Sprintf("Cancelled in favor of KeptnAppVersion %s / Namespace: %s, Name: %s ", newerAppVersion.Name, appVersion.Namespace, appVersion.Name))
+	return nil
+}
+
+func isOlderAppVersion(appVersion klcv1alpha1.KeptnAppVersion, than klcv1alpha1.KeptnAppVersion) bool {
+	if appVersion.CreationTimestamp.Equal(&than.CreationTimestamp) {
+		return appVersion.Name < than.Name
+	}
+	return appVersion.CreationTimestamp.Before(&than.CreationTimestamp)
+}
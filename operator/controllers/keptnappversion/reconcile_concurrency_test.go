@@ -0,0 +1,155 @@
+package keptnappversion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKeptnAppVersionReconciler_reconcileConcurrency(t *testing.T) {
+	now := time.Now()
+	older := makeAppVersion("myapp-1.0.0", "1.0.0", now.Add(-time.Minute))
+	finished := makeAppVersion("myapp-0.9.0", "0.9.0", now.Add(-time.Hour))
+	finished.Status.EndTime = metav1.NewTime(now.Add(-time.Minute))
+	otherApp := makeAppVersion("otherapp-1.0.0", "1.0.0", now.Add(-time.Minute))
+	otherApp.Spec.AppName = "otherapp"
+	newer := makeAppVersion("myapp-2.0.0", "2.0.0", now)
+
+	r := newTestReconciler(t, &older, &finished, &otherApp, &newer)
+
+	waiting, err := r.reconcileConcurrency(context.TODO(), &newer)
+	require.Nil(t, err)
+	require.True(t, waiting)
+	require.True(t, meta.IsStatusConditionTrue(newer.Status.Conditions, klcv1alpha1.WaitingForPreviousVersionConditionType))
+	require.Len(t, r.Recorder.(*record.FakeRecorder).Events, 1)
+
+	// requeues while waiting for the same version neither write the status nor record another event
+	resourceVersion := newer.ResourceVersion
+	waiting, err = r.reconcileConcurrency(context.TODO(), &newer)
+	require.Nil(t, err)
+	require.True(t, waiting)
+	require.Equal(t, resourceVersion, newer.ResourceVersion)
+	require.Len(t, r.Recorder.(*record.FakeRecorder).Events, 1)
+
+	waiting, err = r.reconcileConcurrency(context.TODO(), &older)
+	require.Nil(t, err)
+	require.False(t, waiting)
+	require.True(t, meta.IsStatusConditionFalse(older.Status.Conditions, klcv1alpha1.WaitingForPreviousVersionConditionType))
+}
+
+func TestKeptnAppVersionReconciler_reconcileConcurrencyLatestWins(t *testing.T) {
+	now := time.Now()
+	older := makeAppVersion("myapp-1.0.0", "1.0.0", now.Add(-time.Minute))
+	newer := makeAppVersion("myapp-2.0.0", "2.0.0", now)
+	newer.Spec.ConcurrencyPolicy = klcv1alpha1.ConcurrencyPolicyLatestWins
+
+	r := newTestReconciler(t, &older, &newer)
+
+	waiting, err := r.reconcileConcurrency(context.TODO(), &newer)
+	require.Nil(t, err)
+	require.False(t, waiting)
+
+	cancelled := &klcv1alpha1.KeptnAppVersion{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: older.Namespace, Name: older.Name}, cancelled)
+	require.Nil(t, err)
+	require.Equal(t, common.StateFailed, cancelled.Status.Status)
+	require.True(t, cancelled.IsEndTimeSet())
+}
+
+func TestKeptnAppVersionReconciler_reconcileConcurrencyCancelsChecks(t *testing.T) {
+	now := time.Now()
+	older := makeAppVersion("myapp-1.0.0", "1.0.0", now.Add(-time.Minute))
+	older.Status.PreDeploymentTaskStatus = []klcv1alpha1.TaskStatus{
+		{TaskDefinitionName: "check", TaskName: "pre-check", Status: common.StateProgressing},
+		{TaskDefinitionName: "done", TaskName: "pre-done", Status: common.StateSucceeded},
+	}
+	older.Status.PreDeploymentEvaluationTaskStatus = []klcv1alpha1.EvaluationStatus{
+		{EvaluationDefinitionName: "slo", EvaluationName: "pre-slo", Status: common.StatePending},
+	}
+	newer := makeAppVersion("myapp-2.0.0", "2.0.0", now)
+	newer.Spec.ConcurrencyPolicy = klcv1alpha1.ConcurrencyPolicyLatestWins
+
+	r := newTestReconciler(t, &older, &newer)
+	for _, obj := range []client.Object{
+		&klcv1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-check"}},
+		&klcv1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-done"}},
+		&klcv1alpha1.KeptnEvaluation{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-slo"}},
+	} {
+		require.Nil(t, r.Client.Create(context.TODO(), obj))
+	}
+
+	_, err := r.reconcileConcurrency(context.TODO(), &newer)
+	require.Nil(t, err)
+
+	// the unfinished checks of the older version are deleted, the finished ones are kept
+	require.True(t, errors.IsNotFound(r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "pre-check"}, &klcv1alpha1.KeptnTask{})))
+	require.True(t, errors.IsNotFound(r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "pre-slo"}, &klcv1alpha1.KeptnEvaluation{})))
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "pre-done"}, &klcv1alpha1.KeptnTask{}))
+
+	cancelled := &klcv1alpha1.KeptnAppVersion{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: older.Namespace, Name: older.Name}, cancelled))
+	require.Equal(t, common.StateFailed, cancelled.Status.PreDeploymentTaskStatus[0].Status)
+	require.Equal(t, common.StateSucceeded, cancelled.Status.PreDeploymentTaskStatus[1].Status)
+	require.Equal(t, common.StateFailed, cancelled.Status.PreDeploymentEvaluationTaskStatus[0].Status)
+}
+
+func TestKeptnAppVersionReconciler_reconcileConcurrencyWaitTimeout(t *testing.T) {
+	now := time.Now()
+	older := makeAppVersion("myapp-1.0.0", "1.0.0", now.Add(-time.Hour))
+	newer := makeAppVersion("myapp-2.0.0", "2.0.0", now.Add(-11*time.Minute))
+	newer.Spec.ConcurrencyWaitTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+
+	r := newTestReconciler(t, &older, &newer)
+
+	// the newer version has waited longer than the timeout, so the older version is cancelled
+	waiting, err := r.reconcileConcurrency(context.TODO(), &newer)
+	require.Nil(t, err)
+	require.False(t, waiting)
+
+	cancelled := &klcv1alpha1.KeptnAppVersion{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: older.Namespace, Name: older.Name}, cancelled))
+	require.Equal(t, common.StateFailed, cancelled.Status.Status)
+}
+
+func newTestReconciler(t *testing.T, objs ...*klcv1alpha1.KeptnAppVersion) *KeptnAppVersionReconciler {
+	scheme := runtime.NewScheme()
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, obj := range objs {
+		builder = builder.WithObjects(obj)
+	}
+	return &KeptnAppVersionReconciler{
+		Client:   builder.Build(),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(100),
+	}
+}
+
+func makeAppVersion(name string, version string, created time.Time) klcv1alpha1.KeptnAppVersion {
+	return klcv1alpha1.KeptnAppVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: klcv1alpha1.KeptnAppVersionSpec{
+			KeptnAppSpec: klcv1alpha1.KeptnAppSpec{
+				Version: version,
+			},
+			AppName: "myapp",
+		},
+	}
+}
@@ -27,12 +27,13 @@ func (r *KeptnAppVersionReconciler) reconcilePrePostDeployment(ctx context.Conte
 
 	switch checkType {
 	case common.PreDeploymentCheckType:
-		appVersion.Status.PreDeploymentStatus = overallState
+		overallState = common.SetPhaseState(&appVersion.Status.PreDeploymentStatus, overallState)
 		appVersion.Status.PreDeploymentTaskStatus = newStatus
 	case common.PostDeploymentCheckType:
-		appVersion.Status.PostDeploymentStatus = overallState
+		overallState = common.SetPhaseState(&appVersion.Status.PostDeploymentStatus, overallState)
 		appVersion.Status.PostDeploymentTaskStatus = newStatus
 	}
+	appVersion.SetAllowedTaskFailures()
 
 	// Write Status Field
 	err = r.Client.Status().Update(ctx, appVersion)
@@ -86,15 +87,38 @@ func (r *KeptnAppVersionReconciler) reconcileTasks(ctx context.Context, checkTyp
 			continue
 		}
 
+		// Adopt a Task whose name could not be stored in the status, instead of creating it again
+		if taskStatus.TaskName == "" {
+			taskName, err := controllercommon.FindCreatedTask(ctx, r.Client, appVersion, controllercommon.GetCheckLabels(appVersion.Spec.AppName, "", appVersion.Spec.Version, checkType), taskDefinitionName)
+			if err != nil {
+				return nil, summary, err
+			}
+			if taskName != "" {
+				taskStatus.TaskName = taskName
+				taskStatus.SetStartTime()
+			}
+		}
+
 		// Check if Task is already created
 		if taskStatus.TaskName != "" {
 			err := r.Client.Get(ctx, types.NamespacedName{Name: taskStatus.TaskName, Namespace: appVersion.Namespace}, task)
-			if err != nil && errors.IsNotFound(err) {
+			if err == nil {
+				taskExists = true
+			} else if errors.IsNotFound(err) {
+				// the task has been deleted while it was running and is created again, unless it keeps being deleted
+				if !controllercommon.RecreateDeletedCheck(r.Recorder, phase, appVersion, &taskStatus.Recreations, taskStatus.TaskName, appVersion.GetVersion()) {
+					taskStatus.Status = common.StateFailed
+					if err := r.allowTaskFailure(ctx, appVersion, &taskStatus, phase); err != nil {
+						return nil, summary, err
+					}
+					taskStatus.SetEndTime()
+					newStatus = append(newStatus, taskStatus)
+					continue
+				}
 				taskStatus.TaskName = ""
-			} else if err != nil {
+			} else {
 				return nil, summary, err
 			}
-			taskExists = true
 		}
 
 		// Create new Task if it does not exist
@@ -108,6 +132,11 @@ func (r *KeptnAppVersionReconciler) reconcileTasks(ctx context.Context, checkTyp
 		} else {
 			// Update state of Task if it is already created
 			taskStatus.Status = task.Status.Status
+			if taskStatus.Status.IsFailed() {
+				if err := r.allowTaskFailure(ctx, appVersion, &taskStatus, phase); err != nil {
+					return nil, summary, err
+				}
+			}
 			if taskStatus.Status.IsCompleted() {
 				taskStatus.SetEndTime()
 			}
@@ -117,6 +146,10 @@ func (r *KeptnAppVersionReconciler) reconcileTasks(ctx context.Context, checkTyp
 	}
 
 	for _, ns := range newStatus {
+		if ns.IsFailureAllowed() {
+			summary = common.UpdateStatusSummary(common.StateSucceeded, summary)
+			continue
+		}
 		summary = common.UpdateStatusSummary(ns.Status, summary)
 	}
 	if common.GetOverallState(summary) != common.StateSucceeded {
@@ -142,26 +175,29 @@ func (r *KeptnAppVersionReconciler) createKeptnTask(ctx context.Context, namespa
 		LongName:  "Keptn Task Create",
 	}
 
+	definitionNamespace, definitionName := common.ParseTaskDefinitionReference(taskDefinition)
 	newTask := &klcv1alpha1.KeptnTask{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        common.GenerateTaskName(checkType, taskDefinition),
+			Name:        common.GenerateTaskName(checkType, definitionName),
 			Namespace:   namespace,
+			Labels:      controllercommon.GetCheckLabels(appVersion.Spec.AppName, "", appVersion.Spec.Version, checkType),
 			Annotations: traceContextCarrier,
 		},
 		Spec: klcv1alpha1.KeptnTaskSpec{
-			AppVersion:       appVersion.Spec.Version,
-			AppName:          appVersion.Spec.AppName,
-			TaskDefinition:   taskDefinition,
-			Parameters:       klcv1alpha1.TaskParameters{},
-			SecureParameters: klcv1alpha1.SecureParameters{},
-			Type:             checkType,
+			AppVersion:              appVersion.Spec.Version,
+			AppName:                 appVersion.Spec.AppName,
+			TaskDefinition:          definitionName,
+			TaskDefinitionNamespace: definitionNamespace,
+			Parameters:              klcv1alpha1.TaskParameters{},
+			SecureParameters:        klcv1alpha1.SecureParameters{},
+			Type:                    checkType,
 		},
 	}
 	err := controllerutil.SetControllerReference(appVersion, newTask, r.Scheme)
 	if err != nil {
 		r.Log.Error(err, "could not set controller reference:")
 	}
-	err = r.Client.Create(ctx, newTask)
+	err = r.CreationLimiter.Create(ctx, r.Client, newTask)
 	if err != nil {
 		r.Log.Error(err, "could not create KeptnTask")
 		controllercommon.RecordEvent(r.Recorder, phase, "Warning", appVersion, "CreateFailed", "could not create KeptnTask", appVersion.GetVersion())
@@ -3,7 +3,6 @@ package keptnappversion
 import (
 	"context"
 	"fmt"
-	"time"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
@@ -27,10 +26,10 @@ func (r *KeptnAppVersionReconciler) reconcilePrePostEvaluation(ctx context.Conte
 
 	switch checkType {
 	case common.PreDeploymentEvaluationCheckType:
-		appVersion.Status.PreDeploymentEvaluationStatus = overallState
+		overallState = common.SetPhaseState(&appVersion.Status.PreDeploymentEvaluationStatus, overallState)
 		appVersion.Status.PreDeploymentEvaluationTaskStatus = newStatus
 	case common.PostDeploymentEvaluationCheckType:
-		appVersion.Status.PostDeploymentEvaluationStatus = overallState
+		overallState = common.SetPhaseState(&appVersion.Status.PostDeploymentEvaluationStatus, overallState)
 		appVersion.Status.PostDeploymentEvaluationTaskStatus = newStatus
 	}
 
@@ -86,15 +85,35 @@ func (r *KeptnAppVersionReconciler) reconcileEvaluations(ctx context.Context, ch
 			continue
 		}
 
+		// Adopt an Evaluation whose name could not be stored in the status, instead of creating it again
+		if evaluationStatus.EvaluationName == "" {
+			name, err := controllercommon.FindCreatedEvaluation(ctx, r.Client, appVersion, controllercommon.GetCheckLabels(appVersion.Spec.AppName, "", appVersion.Spec.Version, checkType), evaluationName)
+			if err != nil {
+				return nil, summary, err
+			}
+			if name != "" {
+				evaluationStatus.EvaluationName = name
+				evaluationStatus.SetStartTime()
+			}
+		}
+
 		// Check if Evaluation is already created
 		if evaluationStatus.EvaluationName != "" {
 			err := r.Client.Get(ctx, types.NamespacedName{Name: evaluationStatus.EvaluationName, Namespace: appVersion.Namespace}, evaluation)
-			if err != nil && errors.IsNotFound(err) {
+			if err == nil {
+				evaluationExists = true
+			} else if errors.IsNotFound(err) {
+				// the evaluation has been deleted while it was running and is created again, unless it keeps being deleted
+				if !controllercommon.RecreateDeletedCheck(r.Recorder, phase, appVersion, &evaluationStatus.Recreations, evaluationStatus.EvaluationName, appVersion.GetVersion()) {
+					evaluationStatus.Status = common.StateFailed
+					evaluationStatus.SetEndTime()
+					newStatus = append(newStatus, evaluationStatus)
+					continue
+				}
 				evaluationStatus.EvaluationName = ""
-			} else if err != nil {
+			} else {
 				return nil, summary, err
 			}
-			evaluationExists = true
 		}
 
 		// Create new Evaluation if it does not exist
@@ -146,6 +165,7 @@ func (r *KeptnAppVersionReconciler) createKeptnEvaluation(ctx context.Context, n
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        common.GenerateEvaluationName(checkType, evaluationDefinition),
 			Namespace:   namespace,
+			Labels:      controllercommon.GetCheckLabels(appVersion.Spec.AppName, "", appVersion.Spec.Version, checkType),
 			Annotations: traceContextCarrier,
 		},
 		Spec: klcv1alpha1.KeptnEvaluationSpec{
@@ -154,7 +174,7 @@ func (r *KeptnAppVersionReconciler) createKeptnEvaluation(ctx context.Context, n
 			EvaluationDefinition: evaluationDefinition,
 			Type:                 checkType,
 			RetryInterval: metav1.Duration{
-				Duration: 5 * time.Second,
+				Duration: klcv1alpha1.DefaultRetryInterval,
 			},
 		},
 	}
@@ -162,7 +182,7 @@ func (r *KeptnAppVersionReconciler) createKeptnEvaluation(ctx context.Context, n
 	if err != nil {
 		r.Log.Error(err, "could not set controller reference:")
 	}
-	err = r.Client.Create(ctx, newEvaluation)
+	err = r.CreationLimiter.Create(ctx, r.Client, newEvaluation)
 	if err != nil {
 		r.Log.Error(err, "could not create KeptnEvaluation")
 		controllercommon.RecordEvent(r.Recorder, phase, "Warning", appVersion, "CreateFailed", "could not create KeptnEvaluation", appVersion.GetVersion())
@@ -0,0 +1,118 @@
+package keptnappversion
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// cascadeFinalizer keeps a KeptnAppVersion with the Cascade propagation policy until its workload instances are deleted
+const cascadeFinalizer = "keptn.sh/cascade-workload-instances"
+
+// reconcileWorkloadInstanceOwnership links the KeptnWorkloadInstances of the members of the app version to it and
+// applies the propagation policy of the app version. It returns true if the app version is being deleted and must not
+// be reconciled any further.
+//
+// The instances are created by their KeptnWorkload, which stays their controller. The app version is added as an
+// additional owner, so instances that have been created before the app version are adopted as well. Instances that
+// are already owned by another KeptnAppVersion are left alone.
+func (r *KeptnAppVersionReconciler) reconcileWorkloadInstanceOwnership(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) (bool, error) {
+	if !appVersion.DeletionTimestamp.IsZero() {
+		return true, r.finalizeWorkloadInstances(ctx, appVersion)
+	}
+
+	if err := r.reconcileCascadeFinalizer(ctx, appVersion); err != nil {
+		return false, err
+	}
+
+	for _, w := range appVersion.Spec.Workloads {
+		workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{}
+		err := r.Get(ctx, getWorkloadInstanceName(appVersion.Namespace, appVersion.Spec.AppName, w.Name, w.Version), workloadInstance)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("could not fetch KeptnWorkloadInstance of workload %s: %w", w.Name, err)
+		}
+		if err := r.adoptWorkloadInstance(ctx, appVersion, workloadInstance); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (r *KeptnAppVersionReconciler) adoptWorkloadInstance(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	owner, found := getAppVersionOwner(workloadInstance)
+	if found && owner.UID == appVersion.UID {
+		return nil
+	}
+	if found {
+		r.Log.Info("KeptnWorkloadInstance is already owned by another KeptnAppVersion", "workloadInstance", workloadInstance.Name, "owner", owner.Name)
+		return nil
+	}
+
+	workloadInstance.OwnerReferences = append(workloadInstance.OwnerReferences, metav1.OwnerReference{
+		APIVersion: klcv1alpha1.GroupVersion.String(),
+		Kind:       "KeptnAppVersion",
+		Name:       appVersion.Name,
+		UID:        appVersion.UID,
+	})
+	if err := r.Update(ctx, workloadInstance); err != nil {
+		return fmt.Errorf("could not adopt KeptnWorkloadInstance %s: %w", workloadInstance.Name, err)
+	}
+	r.Log.Info("Adopted KeptnWorkloadInstance", "workloadInstance", workloadInstance.Name)
+	return nil
+}
+
+func (r *KeptnAppVersionReconciler) reconcileCascadeFinalizer(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) error {
+	cascade := appVersion.Spec.PropagationPolicy == klcv1alpha1.PropagationPolicyCascade
+	if cascade == controllerutil.ContainsFinalizer(appVersion, cascadeFinalizer) {
+		return nil
+	}
+	if cascade {
+		controllerutil.AddFinalizer(appVersion, cascadeFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(appVersion, cascadeFinalizer)
+	}
+	return r.Update(ctx, appVersion)
+}
+
+// finalizeWorkloadInstances deletes the KeptnWorkloadInstances owned by the app version if it has been created with
+// the Cascade propagation policy. The instances are otherwise orphaned by the garbage collector, since their
+// KeptnWorkload still owns them.
+func (r *KeptnAppVersionReconciler) finalizeWorkloadInstances(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) error {
+	if !controllerutil.ContainsFinalizer(appVersion, cascadeFinalizer) {
+		return nil
+	}
+
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := r.List(ctx, workloadInstances, client.InNamespace(appVersion.Namespace)); err != nil {
+		return fmt.Errorf("could not retrieve workload instances: %w", err)
+	}
+	for i := range workloadInstances.Items {
+		workloadInstance := &workloadInstances.Items[i]
+		if owner, found := getAppVersionOwner(workloadInstance); !found || owner.UID != appVersion.UID {
+			continue
+		}
+		if err := r.Delete(ctx, workloadInstance); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not delete KeptnWorkloadInstance %s: %w", workloadInstance.Name, err)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(appVersion, cascadeFinalizer)
+	return r.Update(ctx, appVersion)
+}
+
+func getAppVersionOwner(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (metav1.OwnerReference, bool) {
+	for _, owner := range workloadInstance.OwnerReferences {
+		if owner.Kind == "KeptnAppVersion" && owner.APIVersion == klcv1alpha1.GroupVersion.String() {
+			return owner, true
+		}
+	}
+	return metav1.OwnerReference{}, false
+}
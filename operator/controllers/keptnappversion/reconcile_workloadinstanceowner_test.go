@@ -0,0 +1,139 @@
+package keptnappversion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestKeptnAppVersionReconciler_reconcileWorkloadInstanceOwnershipAdopts(t *testing.T) {
+	appVersion := makeOwningAppVersion("myapp-1.0.0", "uid-1")
+	workloadInstance := makeWorkloadInstance("myapp-mywl-1.0.0")
+
+	r := newOwnershipTestReconciler(t, appVersion, workloadInstance)
+
+	deleted, err := r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+	require.False(t, deleted)
+
+	adopted := getWorkloadInstance(t, r, workloadInstance.Name)
+	owner, found := getAppVersionOwner(adopted)
+	require.True(t, found)
+	require.Equal(t, appVersion.UID, owner.UID)
+
+	// adopting the instance again does not add a second owner reference
+	_, err = r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+	require.Len(t, getWorkloadInstance(t, r, workloadInstance.Name).OwnerReferences, 1)
+}
+
+func TestKeptnAppVersionReconciler_reconcileWorkloadInstanceOwnershipDoesNotSteal(t *testing.T) {
+	appVersion := makeOwningAppVersion("myapp-1.0.0", "uid-1")
+	workloadInstance := makeWorkloadInstance("myapp-mywl-1.0.0")
+	workloadInstance.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: klcv1alpha1.GroupVersion.String(),
+		Kind:       "KeptnAppVersion",
+		Name:       "myapp-1.0.0-other",
+		UID:        "uid-2",
+	}}
+
+	r := newOwnershipTestReconciler(t, appVersion, workloadInstance)
+
+	_, err := r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+
+	claimed := getWorkloadInstance(t, r, workloadInstance.Name)
+	require.Len(t, claimed.OwnerReferences, 1)
+	require.Equal(t, types.UID("uid-2"), claimed.OwnerReferences[0].UID)
+}
+
+func TestKeptnAppVersionReconciler_reconcileWorkloadInstanceOwnershipCascade(t *testing.T) {
+	appVersion := makeOwningAppVersion("myapp-1.0.0", "uid-1")
+	appVersion.Spec.PropagationPolicy = klcv1alpha1.PropagationPolicyCascade
+	workloadInstance := makeWorkloadInstance("myapp-mywl-1.0.0")
+	foreign := makeWorkloadInstance("myapp-otherwl-1.0.0")
+
+	r := newOwnershipTestReconciler(t, appVersion, workloadInstance, foreign)
+
+	_, err := r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+	require.True(t, controllerutil.ContainsFinalizer(appVersion, cascadeFinalizer))
+
+	require.Nil(t, r.Delete(context.TODO(), appVersion))
+	require.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(appVersion), appVersion))
+
+	deleted, err := r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+	require.True(t, deleted)
+
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), &klcv1alpha1.KeptnWorkloadInstance{})
+	require.True(t, errors.IsNotFound(err))
+	// instances of other workloads are not owned by the app version
+	getWorkloadInstance(t, r, foreign.Name)
+
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(appVersion), &klcv1alpha1.KeptnAppVersion{})
+	require.True(t, errors.IsNotFound(err))
+}
+
+func TestKeptnAppVersionReconciler_reconcileWorkloadInstanceOwnershipOrphan(t *testing.T) {
+	appVersion := makeOwningAppVersion("myapp-1.0.0", "uid-1")
+	appVersion.Spec.PropagationPolicy = klcv1alpha1.PropagationPolicyOrphan
+	controllerutil.AddFinalizer(appVersion, cascadeFinalizer)
+	workloadInstance := makeWorkloadInstance("myapp-mywl-1.0.0")
+
+	r := newOwnershipTestReconciler(t, appVersion, workloadInstance)
+
+	// switching to Orphan removes the finalizer, so the app version is deleted right away
+	_, err := r.reconcileWorkloadInstanceOwnership(context.TODO(), appVersion)
+	require.Nil(t, err)
+	require.False(t, controllerutil.ContainsFinalizer(appVersion, cascadeFinalizer))
+
+	require.Nil(t, r.Delete(context.TODO(), appVersion))
+	getWorkloadInstance(t, r, workloadInstance.Name)
+}
+
+func newOwnershipTestReconciler(t *testing.T, objs ...client.Object) *KeptnAppVersionReconciler {
+	scheme := runtime.NewScheme()
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+
+	return &KeptnAppVersionReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme:   scheme,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(100),
+	}
+}
+
+func makeOwningAppVersion(name string, uid types.UID) *klcv1alpha1.KeptnAppVersion {
+	appVersion := makeAppVersion(name, "1.0.0", time.Now())
+	appVersion.UID = uid
+	appVersion.Spec.Workloads = []klcv1alpha1.KeptnWorkloadRef{{Name: "mywl", Version: "1.0.0"}}
+	return &appVersion
+}
+
+func makeWorkloadInstance(name string) *klcv1alpha1.KeptnWorkloadInstance {
+	return &klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+}
+
+func getWorkloadInstance(t *testing.T, r *KeptnAppVersionReconciler, name string) *klcv1alpha1.KeptnWorkloadInstance {
+	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{}
+	require.Nil(t, r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, workloadInstance))
+	return workloadInstance
+}
@@ -2,14 +2,21 @@ package keptnappversion
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// scaledToZeroGracePeriod is the time a KeptnAppVersion waits for instances of workloads that are scaled to zero,
+// measured from when the scale-down has first been observed
+const scaledToZeroGracePeriod = 30 * time.Second
+
 func (r *KeptnAppVersionReconciler) reconcileWorkloads(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion) (common.KeptnState, error) {
 	r.Log.Info("Reconciling Workloads")
 	var summary common.StatusSummary
@@ -21,10 +28,32 @@ func (r *KeptnAppVersionReconciler) reconcileWorkloads(ctx context.Context, appV
 	}
 
 	var newStatus []klcv1alpha1.WorkloadStatus
+	var scaledToZero []string
 	for _, w := range appVersion.Spec.Workloads {
 		r.Log.Info("Reconciling workload " + w.Name)
 		workload, err := r.getWorkloadInstance(ctx, getWorkloadInstanceName(appVersion.Namespace, appVersion.Spec.AppName, w.Name, w.Version))
 		if err != nil && errors.IsNotFound(err) {
+			if r.isMemberScaledToZero(ctx, appVersion, w) {
+				memberStatus := klcv1alpha1.WorkloadStatus{
+					Workload:         w,
+					Status:           common.StatePending,
+					ScaledToZeroTime: getScaledToZeroTime(appVersion, w),
+				}
+				if memberStatus.ScaledToZeroTime.IsZero() {
+					memberStatus.ScaledToZeroTime = metav1.NewTime(time.Now().UTC())
+					controllercommon.RecordEvent(r.Recorder, phase, "Normal", appVersion, "MemberScaledToZero", fmt.Sprintf("workload %s is scaled to zero and does not participate unless it is scaled up within %s", w.Name, scaledToZeroGracePeriod), appVersion.GetVersion())
+				}
+				newStatus = append(newStatus, memberStatus)
+				// workloads without replicas never get an instance, so they are not waited for once the scale-down
+				// has lasted for the grace period
+				if common.Since(memberStatus.ScaledToZeroTime) >= scaledToZeroGracePeriod {
+					scaledToZero = append(scaledToZero, w.Name)
+					summary.Total--
+				} else {
+					summary = common.UpdateStatusSummary(common.StatePending, summary)
+				}
+				continue
+			}
 			controllercommon.RecordEvent(r.Recorder, phase, "Warning", appVersion, "NotFound", "workloadInstance not found", appVersion.GetVersion())
 			workload.Status.Status = common.StatePending
 		} else if err != nil {
@@ -41,10 +70,11 @@ func (r *KeptnAppVersionReconciler) reconcileWorkloads(ctx context.Context, appV
 	}
 
 	overallState := common.GetOverallState(summary)
-	appVersion.Status.WorkloadOverallStatus = overallState
+	overallState = common.SetPhaseState(&appVersion.Status.WorkloadOverallStatus, overallState)
 	r.Log.Info("Overall state of workloads", "state", appVersion.Status.WorkloadOverallStatus)
 
 	appVersion.Status.WorkloadStatus = newStatus
+	appVersion.SetMemberScaledToZero(scaledToZero)
 	r.Log.Info("Workload status", "status", appVersion.Status.WorkloadStatus)
 
 	// Write Status Field
@@ -52,6 +82,31 @@ func (r *KeptnAppVersionReconciler) reconcileWorkloads(ctx context.Context, appV
 	return overallState, err
 }
 
+// isMemberScaledToZero returns true if the KeptnWorkload of the given member is scaled to zero replicas
+func (r *KeptnAppVersionReconciler) isMemberScaledToZero(ctx context.Context, appVersion *klcv1alpha1.KeptnAppVersion, member klcv1alpha1.KeptnWorkloadRef) bool {
+	workload := &klcv1alpha1.KeptnWorkload{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: appVersion.Namespace, Name: appVersion.Spec.AppName + "-" + member.Name}, workload); err != nil {
+		return false
+	}
+	scaledToZero, err := controllercommon.IsScaledToZero(ctx, r.Client, workload.Namespace, workload.Spec.ResourceReference)
+	if err != nil {
+		r.Log.Error(err, "could not check if workload is scaled to zero")
+		return false
+	}
+	return scaledToZero
+}
+
+// getScaledToZeroTime returns the time the given member has first been observed to be scaled to zero, or the zero time
+// if it has not been scaled to zero at the last reconciliation
+func getScaledToZeroTime(appVersion *klcv1alpha1.KeptnAppVersion, member klcv1alpha1.KeptnWorkloadRef) metav1.Time {
+	for _, status := range appVersion.Status.WorkloadStatus {
+		if status.Workload == member {
+			return status.ScaledToZeroTime
+		}
+	}
+	return metav1.Time{}
+}
+
 func (r *KeptnAppVersionReconciler) getWorkloadInstance(ctx context.Context, workload types.NamespacedName) (klcv1alpha1.KeptnWorkloadInstance, error) {
 	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{}
 	err := r.Get(ctx, workload, workloadInstance)
@@ -0,0 +1,108 @@
+package keptnappversion
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKeptnAppVersionReconciler_reconcileWorkloadsScaledToZero(t *testing.T) {
+	tests := []struct {
+		name         string
+		replicas     int32
+		scaledSince  time.Time
+		wantState    common.KeptnState
+		wantScaled   bool
+		wantObserved bool
+		wantEvent    bool
+	}{
+		{name: "member with replicas is waited for", replicas: 1, wantState: common.StatePending},
+		{name: "member scaled up again is waited for", replicas: 1, scaledSince: time.Now().Add(-time.Hour), wantState: common.StatePending},
+		{name: "member scaled to zero is waited for during the grace period", replicas: 0, wantState: common.StatePending, wantObserved: true, wantEvent: true},
+		{name: "member scaled to zero recently is waited for", replicas: 0, scaledSince: time.Now(), wantState: common.StatePending, wantObserved: true},
+		{name: "member scaled to zero does not participate", replicas: 0, scaledSince: time.Now().Add(-time.Hour), wantState: common.StateSucceeded, wantScaled: true, wantObserved: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the grace period is measured from the scale-down, not from the creation of the app version
+			appVersion := makeAppVersion("myapp-1.0.0", "1.0.0", time.Now().Add(-time.Hour))
+			appVersion.Spec.Workloads = []klcv1alpha1.KeptnWorkloadRef{
+				{Name: "running", Version: "1.0.0"},
+				{Name: "scaled", Version: "1.0.0"},
+			}
+			if !tt.scaledSince.IsZero() {
+				appVersion.Status.WorkloadStatus = []klcv1alpha1.WorkloadStatus{
+					{Workload: appVersion.Spec.Workloads[1], Status: common.StatePending, ScaledToZeroTime: metav1.NewTime(tt.scaledSince)},
+				}
+			}
+			running := &klcv1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "myapp-running-1.0.0"},
+				Status:     klcv1alpha1.KeptnWorkloadInstanceStatus{Status: common.StateSucceeded},
+			}
+			workload := &klcv1alpha1.KeptnWorkload{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "myapp-scaled"},
+				Spec: klcv1alpha1.KeptnWorkloadSpec{
+					ResourceReference: klcv1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+				},
+			}
+
+			objs := append([]client.Object{&appVersion, running, workload}, makeDeployment("scaled", "rs-uid", tt.replicas)...)
+			scheme := runtime.NewScheme()
+			require.Nil(t, clientgoscheme.AddToScheme(scheme))
+			require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+			r := &KeptnAppVersionReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				Scheme:   scheme,
+				Log:      logr.Discard(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			state, err := r.reconcileWorkloads(context.TODO(), &appVersion)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantState, state)
+			require.Equal(t, tt.wantScaled, meta.IsStatusConditionTrue(appVersion.Status.Conditions, klcv1alpha1.MemberScaledToZeroConditionType))
+			require.Equal(t, tt.wantObserved, !appVersion.Status.WorkloadStatus[1].ScaledToZeroTime.IsZero())
+
+			// the scale-down is only reported when it is first observed
+			recorded := false
+			for len(r.Recorder.(*record.FakeRecorder).Events) > 0 {
+				if strings.Contains(<-r.Recorder.(*record.FakeRecorder).Events, "MemberScaledToZero") {
+					recorded = true
+				}
+			}
+			require.Equal(t, tt.wantEvent, recorded)
+		})
+	}
+}
+
+func makeDeployment(name string, replicaSetUID string, replicas int32) []client.Object {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name + "-rs",
+			UID:             types.UID(replicaSetUID),
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: name}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}
+	return []client.Object{deployment, rs}
+}
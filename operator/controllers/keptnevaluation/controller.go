@@ -22,12 +22,9 @@ import (
 	"time"
 
 	"math"
-	"net/http"
 	"strconv"
+	"strings"
 
-	promapi "github.com/prometheus/client_golang/api"
-	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
-	"github.com/prometheus/common/model"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
@@ -45,6 +42,7 @@ import (
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/semconv"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
 )
 
 // KeptnEvaluationReconciler reconciles a KeptnEvaluation object
@@ -55,6 +53,8 @@ type KeptnEvaluationReconciler struct {
 	Log      logr.Logger
 	Meters   common.KeptnMeters
 	Tracer   trace.Tracer
+	// Breakers stop querying a KeptnEvaluationProvider after consecutive failures, shared by all evaluations
+	Breakers *controllercommon.CircuitBreakers
 }
 
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnevaluations,verbs=get;list;watch;create;update;patch;delete
@@ -120,6 +120,20 @@ func (r *KeptnEvaluationReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			return ctrl.Result{}, nil
 		}
 
+		provider := evaluationProvider.Namespace + "/" + evaluationProvider.Name
+		if !r.Breakers.Allow(provider) {
+			// the evaluation is retried later without consuming its retry budget
+			r.Log.Info("Provider is unavailable, skipping queries", "provider", provider)
+			if evaluation.SetProviderUnavailable(true, provider) {
+				r.recordEvent("Warning", evaluation, "ProviderUnavailable", "provider "+provider+" is not queried after consecutive failures")
+				if err := r.Client.Status().Update(ctx, evaluation); err != nil {
+					span.SetStatus(codes.Error, err.Error())
+					return ctrl.Result{Requeue: true}, err
+				}
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: evaluation.GetRetryInterval()}, nil
+		}
+
 		statusSummary := common.StatusSummary{}
 		statusSummary.Total = len(evaluationDefinition.Spec.Objectives)
 		newStatus := make(map[string]klcv1alpha1.EvaluationStatusItem)
@@ -128,6 +142,8 @@ func (r *KeptnEvaluationReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			evaluation.Status.EvaluationStatus = make(map[string]klcv1alpha1.EvaluationStatusItem)
 		}
 
+		queried := false
+		providerFailed := false
 		for _, query := range evaluationDefinition.Spec.Objectives {
 			if _, ok := evaluation.Status.EvaluationStatus[query.Name]; !ok {
 				evaluation.AddEvaluationStatus(query)
@@ -137,11 +153,18 @@ func (r *KeptnEvaluationReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				newStatus[query.Name] = evaluation.Status.EvaluationStatus[query.Name]
 				continue
 			}
-			statusItem := r.queryEvaluation(query, *evaluationProvider)
+			statusItem, err := r.queryEvaluation(ctx, query, *evaluationProvider)
+			queried = true
+			providerFailed = providerFailed || err != nil
 			statusSummary = common.UpdateStatusSummary(statusItem.Status, statusSummary)
 			newStatus[query.Name] = *statusItem
 		}
 
+		if queried {
+			r.Breakers.Record(provider, !providerFailed)
+		}
+		evaluation.SetProviderUnavailable(r.Breakers.State(provider) == controllercommon.BreakerOpen, provider)
+
 		evaluation.Status.RetryCount++
 		evaluation.Status.EvaluationStatus = newStatus
 		if common.GetOverallState(statusSummary) == common.StateSucceeded {
@@ -163,7 +186,7 @@ func (r *KeptnEvaluationReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 		r.recordEvent("Normal", evaluation, "NotFinished", "has not finished")
 
-		return ctrl.Result{Requeue: true, RequeueAfter: evaluation.Spec.RetryInterval.Duration}, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: evaluation.GetRetryInterval()}, nil
 
 	}
 
@@ -195,8 +218,9 @@ func (r *KeptnEvaluationReconciler) updateFinishedEvaluationMetrics(ctx context.
 	r.Meters.EvaluationCount.Add(ctx, 1, attrs...)
 
 	// metrics: add evaluation duration
-	duration := evaluation.Status.EndTime.Time.Sub(evaluation.Status.StartTime.Time)
+	duration := common.Duration(evaluation.Status.StartTime, evaluation.Status.EndTime)
 	r.Meters.EvaluationDuration.Record(ctx, duration.Seconds(), attrs...)
+	r.Log.Info("KeptnEvaluation has finished", "duration", duration.String())
 	return nil
 }
 
@@ -228,64 +252,53 @@ func (r *KeptnEvaluationReconciler) fetchDefinitionAndProvider(ctx context.Conte
 	return evaluationDefinition, evaluationProvider, nil
 }
 
-func (r *KeptnEvaluationReconciler) queryEvaluation(objective klcv1alpha1.Objective, provider klcv1alpha1.KeptnEvaluationProvider) *klcv1alpha1.EvaluationStatusItem {
+// queryEvaluation runs the query of the objective against the provider and checks its result.
+// An error is returned only if the provider could not be queried, so that it counts towards its circuit breaker.
+func (r *KeptnEvaluationReconciler) queryEvaluation(ctx context.Context, objective klcv1alpha1.Objective, evaluationProvider klcv1alpha1.KeptnEvaluationProvider) (*klcv1alpha1.EvaluationStatusItem, error) {
 	query := &klcv1alpha1.EvaluationStatusItem{
 		Value:  "",
 		Status: common.StateFailed, //setting status per default to failed
 	}
 
-	queryTime := time.Now().UTC()
-	r.Log.Info("Running query: /api/v1/query?query=" + objective.Query + "&time=" + queryTime.String())
-
-	client, err := promapi.NewClient(promapi.Config{Address: provider.Spec.TargetServer, Client: &http.Client{}})
-	api := prometheus.NewAPI(client)
-	result, w, err := api.Query(
-		context.Background(),
-		objective.Query,
-		queryTime,
-		[]prometheus.Option{}...,
-	)
-
+	provider, err := NewProvider(evaluationProvider, r.Log)
 	if err != nil {
 		query.Message = err.Error()
-		return query
+		return query, nil
 	}
 
-	if len(w) != 0 {
-		query.Message = w[0]
-		r.Log.Info("Prometheus API returned warnings: " + w[0])
-	}
-
-	// check if we can cast the result to a vector, it might be another data struct which we can't process
-	resultVector, ok := result.(model.Vector)
-	if !ok {
-		query.Message = "could not cast result"
-		return query
+	result, err := provider.Query(ctx, objective.Query, objective.Window.Duration, time.Now().UTC())
+	if err != nil {
+		query.Message = err.Error()
+		r.Log.Info(err.Error())
+		if _, ok := err.(invalidResultError); ok {
+			return query, nil
+		}
+		return query, err
 	}
 
-	// We are only allowed to return one value, if not the query may be malformed
-	// we are using two different errors to give the user more information about the result
-	if len(resultVector) == 0 {
-		r.Log.Info("No values in query result")
-		query.Message = "No values in query result"
-		return query
-	} else if len(resultVector) > 1 {
-		r.Log.Info("Too many values in the query result")
-		query.Message = "Too many values in the query result"
-		return query
+	if len(result.Warnings) != 0 {
+		query.Message = result.Warnings[0]
 	}
 
-	query.Value = resultVector[0].Value.String()
-	check, err := r.checkValue(objective, query)
-
-	if err != nil {
-		query.Message = err.Error()
-		r.Log.Error(err, "Could not check query result")
-	}
-	if check {
-		query.Status = common.StateSucceeded
+	// every value within the window must meet the target, the first one that does not is reported
+	for _, value := range result.Values {
+		query.Value = value
+		check, err := r.checkValue(objective, query)
+		if err != nil {
+			query.Message = err.Error()
+			r.Log.Error(err, "Could not check query result")
+			return query, nil
+		}
+		if !check {
+			query.Message = fmt.Sprintf("value %s does not meet the target %s", value, objective.EvaluationTarget)
+			if objective.Window.Duration > 0 {
+				query.Message += " within the window of " + objective.Window.Duration.String()
+			}
+			return query, nil
+		}
 	}
-	return query
+	query.Status = common.StateSucceeded
+	return query, nil
 }
 
 func (r *KeptnEvaluationReconciler) checkValue(objective klcv1alpha1.Objective, query *klcv1alpha1.EvaluationStatusItem) (bool, error) {
@@ -294,18 +307,27 @@ func (r *KeptnEvaluationReconciler) checkValue(objective klcv1alpha1.Objective,
 		return false, fmt.Errorf("no values")
 	}
 
-	eval := objective.EvaluationTarget[1:]
 	sign := objective.EvaluationTarget[:1]
+	if strings.HasPrefix(objective.EvaluationTarget[1:], "=") {
+		sign = objective.EvaluationTarget[:2]
+	}
+	eval := strings.TrimSpace(objective.EvaluationTarget[len(sign):])
 
 	resultValue, err := strconv.ParseFloat(query.Value, 64)
-	if err != nil || math.IsNaN(resultValue) {
+	if err != nil {
 		return false, err
 	}
+	if math.IsNaN(resultValue) {
+		return false, fmt.Errorf("query result is not a number")
+	}
 
 	compareValue, err := strconv.ParseFloat(eval, 64)
-	if err != nil || math.IsNaN(compareValue) {
+	if err != nil {
 		return false, err
 	}
+	if math.IsNaN(compareValue) {
+		return false, fmt.Errorf("evaluation target is not a number")
+	}
 
 	// choose comparator
 	switch sign {
@@ -313,6 +335,10 @@ func (r *KeptnEvaluationReconciler) checkValue(objective klcv1alpha1.Objective,
 		return resultValue > compareValue, nil
 	case "<":
 		return resultValue < compareValue, nil
+	case ">=":
+		return resultValue >= compareValue, nil
+	case "<=":
+		return resultValue <= compareValue, nil
 	default:
 		return false, fmt.Errorf("invalid operator")
 	}
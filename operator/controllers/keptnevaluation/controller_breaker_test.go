@@ -0,0 +1,102 @@
+package keptnevaluation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newBreakerTestReconciler(t *testing.T, targetServer string) *KeptnEvaluationReconciler {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	return &KeptnEvaluationReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&klcv1alpha1.KeptnEvaluation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-evaluation"},
+				Spec:       klcv1alpha1.KeptnEvaluationSpec{EvaluationDefinition: "my-definition", Retries: 10},
+			},
+			&klcv1alpha1.KeptnEvaluationDefinition{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-definition"},
+				Spec: klcv1alpha1.KeptnEvaluationDefinitionSpec{
+					Source:     "prometheus",
+					Objectives: []klcv1alpha1.Objective{{Name: "cpu", Query: "cpu", EvaluationTarget: "<1"}},
+				},
+			},
+			&klcv1alpha1.KeptnEvaluationProvider{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "prometheus"},
+				Spec: klcv1alpha1.KeptnEvaluationProviderSpec{
+					TargetServer: targetServer,
+					QueryTimeout: metav1.Duration{Duration: time.Second},
+				},
+			},
+		).Build(),
+		Scheme:   scheme,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(100),
+		Tracer:   trace.NewNoopTracerProvider().Tracer("test"),
+		Breakers: controllercommon.NewCircuitBreakers(1, time.Hour),
+	}
+}
+
+func TestKeptnEvaluationReconciler_ReconcileProviderUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	r := newBreakerTestReconciler(t, server.URL)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-evaluation"}}
+
+	// the failed query opens the breaker
+	_, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	evaluation := &klcv1alpha1.KeptnEvaluation{}
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, evaluation))
+	require.Equal(t, 1, evaluation.Status.RetryCount)
+	require.True(t, meta.IsStatusConditionTrue(evaluation.Status.Conditions, klcv1alpha1.ProviderUnavailableConditionType))
+	require.Equal(t, controllercommon.BreakerOpen, r.Breakers.State("default/prometheus"))
+
+	// the open breaker skips the query without consuming the retry budget
+	result, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	require.Equal(t, evaluation.GetRetryInterval(), result.RequeueAfter)
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, evaluation))
+	require.Equal(t, 1, evaluation.Status.RetryCount)
+}
+
+func TestKeptnEvaluationReconciler_ReconcileMalformedQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer server.Close()
+	r := newBreakerTestReconciler(t, server.URL)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-evaluation"}}
+
+	// the query of the objective is rejected, but the provider itself is available to other evaluations
+	_, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	evaluation := &klcv1alpha1.KeptnEvaluation{}
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, evaluation))
+	require.Equal(t, 1, evaluation.Status.RetryCount)
+	require.False(t, meta.IsStatusConditionTrue(evaluation.Status.Conditions, klcv1alpha1.ProviderUnavailableConditionType))
+	require.Equal(t, controllercommon.BreakerClosed, r.Breakers.State("default/prometheus"))
+	require.Contains(t, evaluation.Status.EvaluationStatus["cpu"].Message, "parse error")
+}
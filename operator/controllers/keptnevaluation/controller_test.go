@@ -0,0 +1,74 @@
+package keptnevaluation
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeptnEvaluationReconciler_checkValue(t *testing.T) {
+	tests := []struct {
+		target  string
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{target: ">5", value: "10", want: true},
+		{target: "<5", value: "10", want: false},
+		{target: "> 5", value: "10", want: true},
+		{target: "<0.5", value: "0.25", want: true},
+		{target: ">=5", value: "5", want: true},
+		{target: "<= 5", value: "5.5", want: false},
+		{target: "=5", value: "5", wantErr: true},
+		{target: "==5", value: "5", wantErr: true},
+		{target: ">", value: "5", wantErr: true},
+		{target: ">5", value: "NaN", wantErr: true},
+		{target: ">NaN", value: "5", wantErr: true},
+		{target: "", value: "5", wantErr: true},
+		{target: ">5", value: "", wantErr: true},
+	}
+	r := &KeptnEvaluationReconciler{}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.value, func(t *testing.T) {
+			got, err := r.checkValue(klcv1alpha1.Objective{EvaluationTarget: tt.target}, &klcv1alpha1.EvaluationStatusItem{Value: tt.value})
+			if tt.wantErr {
+				require.NotNil(t, err)
+				require.False(t, got)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func FuzzCheckValue(f *testing.F) {
+	f.Add(">5", "10")
+	f.Add("<0.5", "0.25")
+	f.Add("> 1e3", "+Inf")
+	f.Add(">NaN", "NaN")
+	f.Add("", "")
+	f.Add("ä5", "1")
+
+	r := &KeptnEvaluationReconciler{}
+	f.Fuzz(func(t *testing.T, target string, value string) {
+		got, err := r.checkValue(klcv1alpha1.Objective{EvaluationTarget: target}, &klcv1alpha1.EvaluationStatusItem{Value: value})
+		if err != nil {
+			if got {
+				t.Errorf("check of %q against %q succeeded despite error %v", value, target, err)
+			}
+			return
+		}
+		if !strings.HasPrefix(target, ">") && !strings.HasPrefix(target, "<") {
+			t.Errorf("invalid operator in target %q has been accepted", target)
+		}
+		resultValue, parseErr := strconv.ParseFloat(value, 64)
+		if parseErr != nil || math.IsNaN(resultValue) {
+			t.Errorf("invalid value %q has been accepted", value)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package keptnevaluation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	promapi "github.com/prometheus/client_golang/api"
+	prometheus "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// windowSteps is the number of steps into which the window of an objective is divided
+const windowSteps = 10
+
+// PrometheusProvider runs PromQL queries against a Prometheus server
+type PrometheusProvider struct {
+	TargetServer string
+	Timeout      time.Duration
+	Log          logr.Logger
+}
+
+func (p *PrometheusProvider) Query(ctx context.Context, query string, window time.Duration, end time.Time) (QueryResult, error) {
+	client, err := promapi.NewClient(promapi.Config{Address: p.TargetServer, Client: &http.Client{}})
+	if err != nil {
+		return QueryResult{}, err
+	}
+	api := prometheus.NewAPI(client)
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	if window <= 0 {
+		p.Log.Info("Running query: /api/v1/query?query=" + query + "&time=" + end.String())
+		result, warnings, err := api.Query(ctx, query, end)
+		if err != nil {
+			return QueryResult{}, getQueryError(err)
+		}
+		p.logWarnings(warnings)
+		values, err := getVectorValues(result)
+		return QueryResult{Values: values, Warnings: warnings}, err
+	}
+
+	queryRange := prometheus.Range{Start: end.Add(-window), End: end, Step: getWindowStep(window)}
+	p.Log.Info("Running query: /api/v1/query_range?query=" + query + "&start=" + queryRange.Start.String() + "&end=" + end.String())
+	result, warnings, err := api.QueryRange(ctx, query, queryRange)
+	if err != nil {
+		return QueryResult{}, getQueryError(err)
+	}
+	p.logWarnings(warnings)
+	values, err := getMatrixValues(result)
+	return QueryResult{Values: values, Warnings: warnings}, err
+}
+
+// getQueryError turns the errors that Prometheus returns for the query itself, e.g. for malformed PromQL, into an
+// invalidResultError, so that they do not count as a failure of the provider. Only transport errors, timeouts and
+// server errors do.
+func getQueryError(err error) error {
+	var apiErr *prometheus.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Type {
+	case prometheus.ErrBadData, prometheus.ErrExec, prometheus.ErrClient:
+		return invalidResultError{message: err.Error()}
+	default:
+		return err
+	}
+}
+
+func (p *PrometheusProvider) logWarnings(warnings prometheus.Warnings) {
+	if len(warnings) != 0 {
+		p.Log.Info("Prometheus API returned warnings: " + warnings[0])
+	}
+}
+
+func getVectorValues(result model.Value) ([]string, error) {
+	// check if we can cast the result to a vector, it might be another data struct which we can't process
+	resultVector, ok := result.(model.Vector)
+	if !ok {
+		return nil, invalidResultError{message: "could not cast result"}
+	}
+
+	// We are only allowed to return one value, if not the query may be malformed
+	// we are using two different errors to give the user more information about the result
+	if len(resultVector) == 0 {
+		return nil, invalidResultError{message: "No values in query result"}
+	} else if len(resultVector) > 1 {
+		return nil, invalidResultError{message: "Too many values in the query result"}
+	}
+	return []string{resultVector[0].Value.String()}, nil
+}
+
+func getMatrixValues(result model.Value) ([]string, error) {
+	resultMatrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, invalidResultError{message: "could not cast result"}
+	}
+
+	// the values of a single series are evaluated, just like for queries without a window
+	if len(resultMatrix) == 0 || len(resultMatrix[0].Values) == 0 {
+		return nil, invalidResultError{message: "No values in query result"}
+	} else if len(resultMatrix) > 1 {
+		return nil, invalidResultError{message: "Too many values in the query result"}
+	}
+	values := make([]string, 0, len(resultMatrix[0].Values))
+	for _, sample := range resultMatrix[0].Values {
+		values = append(values, sample.Value.String())
+	}
+	return values, nil
+}
+
+// getWindowStep returns the resolution of a range query over the window, at least one second
+func getWindowStep(window time.Duration) time.Duration {
+	step := window / windowSteps
+	if step < time.Second {
+		return time.Second
+	}
+	return step
+}
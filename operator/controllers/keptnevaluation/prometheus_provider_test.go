@@ -0,0 +1,129 @@
+package keptnevaluation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const vectorResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1670000000,"0.5"]}]}}`
+const emptyVectorResponse = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+const matrixResponse = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1670000000,"0.1"],[1670000030,"0.7"],[1670000060,"0.2"]]}]}}`
+
+func newPrometheusServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Nil(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/query_range":
+			_, _ = w.Write([]byte(matrixResponse))
+		case r.Form.Get("query") == "empty":
+			_, _ = w.Write([]byte(emptyVectorResponse))
+		case r.Form.Get("query") == "malformed(":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+		case r.Form.Get("query") == "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(vectorResponse))
+		}
+	}))
+}
+
+func TestPrometheusProvider_Query(t *testing.T) {
+	server := newPrometheusServer(t)
+	defer server.Close()
+	provider, err := NewProvider(klcv1alpha1.KeptnEvaluationProvider{Spec: klcv1alpha1.KeptnEvaluationProviderSpec{TargetServer: server.URL}}, logr.Discard())
+	require.Nil(t, err)
+
+	result, err := provider.Query(context.TODO(), "error_rate", 0, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, []string{"0.5"}, result.Values)
+
+	result, err = provider.Query(context.TODO(), "error_rate", 5*time.Minute, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, []string{"0.1", "0.7", "0.2"}, result.Values)
+
+	_, err = provider.Query(context.TODO(), "empty", 0, time.Now())
+	require.Equal(t, invalidResultError{message: "No values in query result"}, err)
+
+	// a malformed query is the fault of the objective, not of the provider
+	_, err = provider.Query(context.TODO(), "malformed(", 0, time.Now())
+	require.IsType(t, invalidResultError{}, err)
+	require.ErrorContains(t, err, "parse error")
+
+	_, err = provider.Query(context.TODO(), "unavailable", 0, time.Now())
+	require.NotNil(t, err)
+	require.False(t, errors.As(err, &invalidResultError{}))
+}
+
+func TestNewProvider_UnsupportedType(t *testing.T) {
+	_, err := NewProvider(klcv1alpha1.KeptnEvaluationProvider{Spec: klcv1alpha1.KeptnEvaluationProviderSpec{Type: "datadog"}}, logr.Discard())
+	require.ErrorContains(t, err, "unsupported provider type datadog")
+}
+
+func TestKeptnEvaluationReconciler_queryEvaluation(t *testing.T) {
+	server := newPrometheusServer(t)
+	defer server.Close()
+	provider := klcv1alpha1.KeptnEvaluationProvider{Spec: klcv1alpha1.KeptnEvaluationProviderSpec{TargetServer: server.URL}}
+
+	tests := []struct {
+		name        string
+		objective   klcv1alpha1.Objective
+		wantStatus  common.KeptnState
+		wantValue   string
+		wantMessage string
+	}{
+		{
+			name:       "current value meets the target",
+			objective:  klcv1alpha1.Objective{Query: "error_rate", EvaluationTarget: "<1"},
+			wantStatus: common.StateSucceeded,
+			wantValue:  "0.5",
+		},
+		{
+			name:        "current value does not meet the target",
+			objective:   klcv1alpha1.Objective{Query: "error_rate", EvaluationTarget: "<0.05"},
+			wantStatus:  common.StateFailed,
+			wantValue:   "0.5",
+			wantMessage: "value 0.5 does not meet the target <0.05",
+		},
+		{
+			name:       "every value within the window meets the target",
+			objective:  klcv1alpha1.Objective{Query: "error_rate", EvaluationTarget: "<=0.7", Window: metav1.Duration{Duration: 5 * time.Minute}},
+			wantStatus: common.StateSucceeded,
+			wantValue:  "0.2",
+		},
+		{
+			name:        "a value within the window does not meet the target",
+			objective:   klcv1alpha1.Objective{Query: "error_rate", EvaluationTarget: "<0.5", Window: metav1.Duration{Duration: 5 * time.Minute}},
+			wantStatus:  common.StateFailed,
+			wantValue:   "0.7",
+			wantMessage: "value 0.7 does not meet the target <0.5 within the window of 5m0s",
+		},
+		{
+			name:        "no values",
+			objective:   klcv1alpha1.Objective{Query: "empty", EvaluationTarget: "<1"},
+			wantStatus:  common.StateFailed,
+			wantMessage: "No values in query result",
+		},
+	}
+	r := &KeptnEvaluationReconciler{Log: logr.Discard()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := r.queryEvaluation(context.TODO(), tt.objective, provider)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantStatus, item.Status)
+			require.Equal(t, tt.wantValue, item.Value)
+			require.Equal(t, tt.wantMessage, item.Message)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package keptnevaluation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+)
+
+// Provider runs the queries of objectives against a monitoring backend
+type Provider interface {
+	// Query returns the value of the query at the given time. If a window is given, it returns every value of the query
+	// within the window that ends at the given time.
+	Query(ctx context.Context, query string, window time.Duration, end time.Time) (QueryResult, error)
+}
+
+// QueryResult holds the values returned by a Provider and the warnings of the monitoring backend, if any
+type QueryResult struct {
+	Values   []string
+	Warnings []string
+}
+
+// invalidResultError is returned by a Provider that has been queried successfully, but whose result cannot be evaluated.
+// Unlike other errors, it does not count as a failure of the provider.
+type invalidResultError struct {
+	message string
+}
+
+func (e invalidResultError) Error() string {
+	return e.message
+}
+
+// NewProvider returns the Provider for the type of the KeptnEvaluationProvider
+func NewProvider(provider klcv1alpha1.KeptnEvaluationProvider, log logr.Logger) (Provider, error) {
+	switch provider.GetType() {
+	case klcv1alpha1.PrometheusProviderType:
+		return &PrometheusProvider{
+			TargetServer: provider.Spec.TargetServer,
+			Timeout:      provider.GetQueryTimeout(),
+			Log:          log,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider type %s", provider.GetType())
+	}
+}
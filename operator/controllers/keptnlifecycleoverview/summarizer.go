@@ -0,0 +1,127 @@
+package keptnlifecycleoverview
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const DefaultSummaryInterval = 30 * time.Second
+
+// Summarizer periodically recomputes the KeptnLifecycleOverview from the KeptnWorkloadInstances in the cache of the
+// manager. The overview is only written if the summary has changed, so an idle cluster causes no writes at all.
+// Instances are considered stuck if their Stuck condition has been set by the StuckSweeper.
+type Summarizer struct {
+	client.Client
+	Log      logr.Logger
+	Interval time.Duration
+}
+
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnlifecycleoverviews,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnlifecycleoverviews/status,verbs=get;update;patch
+
+// Start runs the summarizer until the given context is cancelled. It implements manager.Runnable.
+func (s *Summarizer) Start(ctx context.Context) error {
+	if s.Interval <= 0 {
+		s.Interval = DefaultSummaryInterval
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Summarize(ctx, time.Now()); err != nil {
+			s.Log.Error(err, "could not update the lifecycle overview")
+		}
+	}, s.Interval)
+	return nil
+}
+
+// Summarize recomputes the overview and writes it if it has changed
+func (s *Summarizer) Summarize(ctx context.Context, now time.Time) error {
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := s.List(ctx, workloadInstances); err != nil {
+		return fmt.Errorf("could not retrieve workload instances: %w", err)
+	}
+	status := summarize(workloadInstances.Items)
+
+	overview := &klcv1alpha1.KeptnLifecycleOverview{}
+	err := s.Get(ctx, types.NamespacedName{Name: klcv1alpha1.KeptnLifecycleOverviewName}, overview)
+	if errors.IsNotFound(err) {
+		overview.Name = klcv1alpha1.KeptnLifecycleOverviewName
+		if err := s.Create(ctx, overview); err != nil {
+			return fmt.Errorf("could not create lifecycle overview: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("could not retrieve lifecycle overview: %w", err)
+	}
+
+	status.LastChangeTime = overview.Status.LastChangeTime
+	if !overview.Status.LastChangeTime.IsZero() && equality.Semantic.DeepEqual(overview.Status, status) {
+		return nil
+	}
+	status.LastChangeTime = metav1.NewTime(now)
+	overview.Status = status
+	return s.Status().Update(ctx, overview)
+}
+
+func summarize(workloadInstances []klcv1alpha1.KeptnWorkloadInstance) klcv1alpha1.KeptnLifecycleOverviewStatus {
+	byNamespace := map[string]*klcv1alpha1.NamespaceLifecycleSummary{}
+	for _, workloadInstance := range workloadInstances {
+		summary, ok := byNamespace[workloadInstance.Namespace]
+		if !ok {
+			summary = &klcv1alpha1.NamespaceLifecycleSummary{Namespace: workloadInstance.Namespace}
+			byNamespace[workloadInstance.Namespace] = summary
+		}
+		addWorkloadInstance(summary, workloadInstance)
+	}
+
+	status := klcv1alpha1.KeptnLifecycleOverviewStatus{}
+	for _, summary := range byNamespace {
+		status.Running += summary.Running
+		status.Succeeded += summary.Succeeded
+		status.Failed += summary.Failed
+		status.Stuck += summary.Stuck
+		status.Namespaces = append(status.Namespaces, *summary)
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Namespace < status.Namespaces[j].Namespace
+	})
+	return status
+}
+
+func addWorkloadInstance(summary *klcv1alpha1.NamespaceLifecycleSummary, workloadInstance klcv1alpha1.KeptnWorkloadInstance) {
+	switch {
+	case !workloadInstance.IsCompleted():
+		summary.Running++
+		if !meta.IsStatusConditionTrue(workloadInstance.Status.Conditions, klcv1alpha1.StuckConditionType) {
+			return
+		}
+		summary.Stuck++
+		if summary.OldestStuckInstance == nil || workloadInstance.Status.PhaseStartTime.Before(&summary.OldestStuckInstance.Since) {
+			summary.OldestStuckInstance = &klcv1alpha1.InstanceReference{
+				Name:  workloadInstance.Name,
+				Phase: workloadInstance.Status.CurrentPhase,
+				Since: workloadInstance.Status.PhaseStartTime,
+			}
+		}
+	case workloadInstance.Status.Status.IsFailed():
+		summary.Failed++
+		if summary.LastFailure == nil || summary.LastFailure.Since.Before(&workloadInstance.Status.EndTime) {
+			summary.LastFailure = &klcv1alpha1.InstanceReference{
+				Name:  workloadInstance.Name,
+				Phase: workloadInstance.Status.CurrentPhase,
+				Since: workloadInstance.Status.EndTime,
+			}
+		}
+	default:
+		summary.Succeeded++
+	}
+}
@@ -0,0 +1,81 @@
+package keptnlifecycleoverview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSummarizer_Summarize(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	running := makeWorkloadInstance("ns1", "running", "")
+	stuck := makeWorkloadInstance("ns1", "stuck", "")
+	stuck.Status.PhaseStartTime = metav1.NewTime(now.Add(-2 * time.Hour))
+	stuck.SetStuck(true, time.Hour)
+	olderStuck := makeWorkloadInstance("ns1", "older-stuck", "")
+	olderStuck.Status.PhaseStartTime = metav1.NewTime(now.Add(-3 * time.Hour))
+	olderStuck.SetStuck(true, time.Hour)
+	succeeded := makeWorkloadInstance("ns2", "succeeded", common.StateSucceeded)
+	failed := makeWorkloadInstance("ns2", "failed", common.StateFailed)
+	failed.Status.EndTime = metav1.NewTime(now.Add(-time.Hour))
+	lastFailed := makeWorkloadInstance("ns2", "last-failed", common.StateFailed)
+	lastFailed.Status.EndTime = metav1.NewTime(now.Add(-time.Minute))
+
+	scheme := runtime.NewScheme()
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	s := &Summarizer{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&running, &stuck, &olderStuck, &succeeded, &failed, &lastFailed).Build(),
+		Log:    logr.Discard(),
+	}
+
+	require.Nil(t, s.Summarize(context.TODO(), now))
+
+	overview := &klcv1alpha1.KeptnLifecycleOverview{}
+	require.Nil(t, s.Get(context.TODO(), types.NamespacedName{Name: klcv1alpha1.KeptnLifecycleOverviewName}, overview))
+	require.Equal(t, 3, overview.Status.Running)
+	require.Equal(t, 2, overview.Status.Stuck)
+	require.Equal(t, 1, overview.Status.Succeeded)
+	require.Equal(t, 2, overview.Status.Failed)
+	require.Len(t, overview.Status.Namespaces, 2)
+	require.Equal(t, "ns1", overview.Status.Namespaces[0].Namespace)
+	require.Equal(t, "older-stuck", overview.Status.Namespaces[0].OldestStuckInstance.Name)
+	require.Nil(t, overview.Status.Namespaces[0].LastFailure)
+	require.Equal(t, "ns2", overview.Status.Namespaces[1].Namespace)
+	require.Equal(t, "last-failed", overview.Status.Namespaces[1].LastFailure.Name)
+	require.True(t, overview.Status.LastChangeTime.Time.Equal(now))
+
+	// the overview is not written again if nothing has changed
+	require.Nil(t, s.Summarize(context.TODO(), now.Add(time.Minute)))
+	require.Nil(t, s.Get(context.TODO(), types.NamespacedName{Name: klcv1alpha1.KeptnLifecycleOverviewName}, overview))
+	require.True(t, overview.Status.LastChangeTime.Time.Equal(now))
+
+	require.Nil(t, s.Delete(context.TODO(), &running))
+	require.Nil(t, s.Summarize(context.TODO(), now.Add(2*time.Minute)))
+	require.Nil(t, s.Get(context.TODO(), types.NamespacedName{Name: klcv1alpha1.KeptnLifecycleOverviewName}, overview))
+	require.Equal(t, 2, overview.Status.Running)
+	require.True(t, overview.Status.LastChangeTime.Time.Equal(now.Add(2*time.Minute)))
+}
+
+func makeWorkloadInstance(namespace string, name string, state common.KeptnState) klcv1alpha1.KeptnWorkloadInstance {
+	workloadInstance := klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status: klcv1alpha1.KeptnWorkloadInstanceStatus{
+			CurrentPhase: common.PhaseWorkloadPreDeployment.ShortName,
+			Status:       common.StateProgressing,
+		},
+	}
+	if state.IsCompleted() {
+		workloadInstance.Status.Status = state
+		workloadInstance.Complete()
+	}
+	return workloadInstance
+}
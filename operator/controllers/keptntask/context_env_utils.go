@@ -0,0 +1,59 @@
+package keptntask
+
+import (
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// environment variables that tell the containers of a Job which app, workload and version they check
+const (
+	keptnAppEnv       = "KEPTN_APP"
+	keptnWorkloadEnv  = "KEPTN_WORKLOAD"
+	keptnVersionEnv   = "KEPTN_VERSION"
+	keptnNamespaceEnv = "KEPTN_NAMESPACE"
+	keptnCheckTypeEnv = "KEPTN_CHECK_TYPE"
+)
+
+// getContextEnv returns the KEPTN_* environment variables of the task. KEPTN_WORKLOAD is only set for tasks of a
+// workload and KEPTN_VERSION is the version of the workload or app the task runs for.
+func getContextEnv(task klcv1alpha1.KeptnTask) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{{Name: keptnAppEnv, Value: task.Spec.AppName}}
+	if task.Spec.Workload != "" {
+		envVars = append(envVars,
+			corev1.EnvVar{Name: keptnWorkloadEnv, Value: task.Spec.Workload},
+			corev1.EnvVar{Name: keptnVersionEnv, Value: task.Spec.WorkloadVersion},
+		)
+	} else {
+		envVars = append(envVars, corev1.EnvVar{Name: keptnVersionEnv, Value: task.Spec.AppVersion})
+	}
+	envVars = append(envVars, corev1.EnvVar{Name: keptnNamespaceEnv, Value: task.Namespace})
+	if task.Spec.Type != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: keptnCheckTypeEnv, Value: string(task.Spec.Type)})
+	}
+	return envVars
+}
+
+// injectContextEnv adds the given environment variables to every container and init container of the pod.
+// Variables a container already defines keep their value, so that users can override them.
+func injectContextEnv(spec *corev1.PodSpec, envVars []corev1.EnvVar) {
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Env = mergeEnv(spec.InitContainers[i].Env, envVars)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].Env = mergeEnv(spec.Containers[i].Env, envVars)
+	}
+}
+
+// mergeEnv appends the variables that are not defined in env yet
+func mergeEnv(env []corev1.EnvVar, envVars []corev1.EnvVar) []corev1.EnvVar {
+	defined := make(map[string]bool, len(env))
+	for _, envVar := range env {
+		defined[envVar.Name] = true
+	}
+	for _, envVar := range envVars {
+		if !defined[envVar.Name] {
+			env = append(env, envVar)
+		}
+	}
+	return env
+}
@@ -0,0 +1,112 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGetContextEnv(t *testing.T) {
+	task := makeTask()
+	task.Spec.Type = common.PreDeploymentCheckType
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "KEPTN_APP", Value: "my-app"},
+		{Name: "KEPTN_WORKLOAD", Value: "my-app-my-workload"},
+		{Name: "KEPTN_VERSION", Value: "1.0.0"},
+		{Name: "KEPTN_NAMESPACE", Value: "default"},
+		{Name: "KEPTN_CHECK_TYPE", Value: "pre"},
+	}, getContextEnv(*task))
+
+	appTask := makeTask()
+	appTask.Spec.Workload = ""
+	appTask.Spec.WorkloadVersion = ""
+	appTask.Spec.AppVersion = "2.0.0"
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "KEPTN_APP", Value: "my-app"},
+		{Name: "KEPTN_VERSION", Value: "2.0.0"},
+		{Name: "KEPTN_NAMESPACE", Value: "default"},
+	}, getContextEnv(*appTask))
+}
+
+func TestInjectContextEnv(t *testing.T) {
+	envVars := []corev1.EnvVar{
+		{Name: "KEPTN_APP", Value: "my-app"},
+		{Name: "KEPTN_VERSION", Value: "1.0.0"},
+	}
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{Name: "init"},
+		},
+		Containers: []corev1.Container{
+			{Name: "check", Env: []corev1.EnvVar{{Name: "TARGET", Value: "http://my-service"}}},
+			{Name: "sidecar", Env: []corev1.EnvVar{
+				{Name: "KEPTN_VERSION", Value: "user-defined"},
+				{Name: "KEPTN_APP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels['app']"}}},
+			}},
+		},
+	}
+
+	injectContextEnv(spec, envVars)
+
+	require.Equal(t, envVars, spec.InitContainers[0].Env)
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "TARGET", Value: "http://my-service"},
+		{Name: "KEPTN_APP", Value: "my-app"},
+		{Name: "KEPTN_VERSION", Value: "1.0.0"},
+	}, spec.Containers[0].Env)
+	// the values defined by the user win
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "KEPTN_VERSION", Value: "user-defined"},
+		{Name: "KEPTN_APP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels['app']"}}},
+	}, spec.Containers[1].Env)
+
+	// injecting twice does not duplicate the variables
+	injectContextEnv(spec, envVars)
+	require.Len(t, spec.Containers[0].Env, 3)
+}
+
+func TestKeptnTaskReconciler_JobHasContextEnv(t *testing.T) {
+	task := makeTask()
+	task.Spec.Type = common.PostDeploymentCheckType
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	env := map[string]string{}
+	for _, envVar := range job.Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	require.Equal(t, "my-app", env["KEPTN_APP"])
+	require.Equal(t, "my-app-my-workload", env["KEPTN_WORKLOAD"])
+	require.Equal(t, "1.0.0", env["KEPTN_VERSION"])
+	require.Equal(t, "default", env["KEPTN_NAMESPACE"])
+	require.Equal(t, "post", env["KEPTN_CHECK_TYPE"])
+}
+
+func TestGenerateFunctionJob_MetadataEnvOverridesContextEnv(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	job, err := r.generateFunctionJob(task, FunctionExecutionParams{
+		URL:         "http://example.com/function.ts",
+		MetadataEnv: []corev1.EnvVar{{Name: "KEPTN_VERSION", Value: "1.0.0-rc.1"}},
+	})
+	require.Nil(t, err)
+
+	var versions []string
+	for _, envVar := range job.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name == "KEPTN_VERSION" {
+			versions = append(versions, envVar.Value)
+		}
+	}
+	require.Equal(t, []string{"1.0.0-rc.1"}, versions)
+}
@@ -25,6 +25,8 @@ import (
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/semconv"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
@@ -42,18 +44,36 @@ import (
 // KeptnTaskReconciler reconciles a KeptnTask object
 type KeptnTaskReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
-	Log      logr.Logger
-	Meters   common.KeptnMeters
-	Tracer   trace.Tracer
+	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
+	Log             logr.Logger
+	Meters          common.KeptnMeters
+	Tracer          trace.Tracer
+	CreationLimiter *controllercommon.CreationLimiter
+	// InfrastructureRetryLimit is the number of times a task is retried with a new Job after its pod has been
+	// removed by the infrastructure, e.g. by the cluster autoscaler scaling down its node
+	InfrastructureRetryLimit int
+	// FeatureGates enable optional behaviors, e.g. marking the pods of Jobs as not safe to evict
+	FeatureGates *featuregate.Gates
+	// Queue submits Jobs to a queueing system such as Kueue instead of running them right away
+	Queue QueueConfig
+	// CrossNamespaceReferences decides whether tasks may run KeptnTaskDefinitions of other namespaces
+	CrossNamespaceReferences *controllercommon.CrossNamespaceReferences
 }
 
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks/finalizers,verbs=update
-//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;get;update;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;get;update;list;watch;delete
 //+kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=create;get;list;watch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=create;get;list;watch
+
+// The ClusterRoles requested by KeptnTaskDefinitions are only known at runtime, so bind cannot be restricted by
+// resourceNames here. TASK_API_ACCESS_CLUSTER_ROLES is the only guard, see controllercommon.AllowedClusterRolesEnv.
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind
 
 func (r *KeptnTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	r.Log.Info("Reconciling KeptnTask")
@@ -72,12 +92,17 @@ func (r *KeptnTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	traceContextCarrier := propagation.MapCarrier(task.Annotations)
 	ctx = otel.GetTextMapPropagator().Extract(ctx, traceContextCarrier)
 
-	ctx, span := r.Tracer.Start(ctx, "reconcile_task", trace.WithSpanKind(trace.SpanKindConsumer))
+	// the span links to the phase that has created the task, which may not be its parent if the task is executed in
+	// a separate trace
+	ctx, span := r.Tracer.Start(ctx, "reconcile_task", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithLinks(controllercommon.GetSpanLinks(task.Spec.PhaseSpanContext)...))
 	defer span.End()
 
 	semconv.AddAttributeFromTask(span, *task)
 
 	task.SetStartTime()
+	if len(task.Status.SpanContext) == 0 {
+		task.Status.SpanContext = controllercommon.GetSpanContext(ctx)
+	}
 
 	if task.Status.Status.IsPending() {
 		task.Status.Status = common.StateProgressing
@@ -91,15 +116,15 @@ func (r *KeptnTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	}(task)
 
-	jobExists, err := r.JobExists(ctx, *task, req.Namespace)
-	if err != nil {
-		r.Log.Error(err, "Could not check if job is running")
-		span.SetStatus(codes.Error, err.Error())
-		return ctrl.Result{Requeue: true, RequeueAfter: 30 * time.Second}, nil
-	}
-
-	if !jobExists {
-		err = r.createJob(ctx, req, task)
+	if task.Status.JobName == "" && !task.Status.Status.IsCompleted() {
+		err := r.createJob(ctx, req, task)
+		if result, throttled := controllercommon.ThrottledRequeue(err); throttled {
+			r.Log.Info("Job creation has been throttled by the API server", "requeueAfter", result.RequeueAfter)
+			return result, nil
+		}
+		if isQuotaInsufficient(err) {
+			return ctrl.Result{Requeue: true, RequeueAfter: quotaRetryInterval}, nil
+		}
 		if err != nil {
 			span.SetStatus(codes.Error, err.Error())
 			return ctrl.Result{Requeue: true}, err
@@ -129,8 +154,18 @@ func (r *KeptnTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	r.Meters.TaskCount.Add(ctx, 1, attrs...)
 
 	// metrics: add task duration
-	duration := task.Status.EndTime.Time.Sub(task.Status.StartTime.Time)
+	duration := common.Duration(task.Status.StartTime, task.Status.EndTime)
 	r.Meters.TaskDuration.Record(ctx, duration.Seconds(), attrs...)
+	r.Log.Info("KeptnTask has finished", "duration", duration.String())
+
+	// metrics: split the duration of the Job into the time waiting for the container and the time running it
+	if task.Status.ExecutionDuration.Duration > 0 {
+		r.Meters.TaskSchedulingDuration.Record(ctx, task.Status.SchedulingDuration.Seconds(), attrs...)
+		r.Meters.TaskExecutionDuration.Record(ctx, task.Status.ExecutionDuration.Seconds(), attrs...)
+	}
+	if task.Status.Restarts > 0 {
+		r.Meters.TaskRestarts.Add(ctx, int64(task.Status.Restarts), attrs...)
+	}
 
 	return ctrl.Result{}, nil
 }
@@ -144,29 +179,6 @@ func (r *KeptnTaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-func (r *KeptnTaskReconciler) JobExists(ctx context.Context, task klcv1alpha1.KeptnTask, namespace string) (bool, error) {
-	jobList := &batchv1.JobList{}
-
-	jobLabels := client.MatchingLabels{}
-	for k, v := range createKeptnLabels(task) {
-		jobLabels[k] = v
-	}
-
-	if len(jobLabels) == 0 {
-		return false, fmt.Errorf("no labels found for task: %s", task.Name)
-	}
-
-	if err := r.Client.List(ctx, jobList, client.InNamespace(namespace), jobLabels); err != nil {
-		return false, err
-	}
-
-	if len(jobList.Items) > 0 {
-		return true, nil
-	}
-
-	return false, nil
-}
-
 func (r *KeptnTaskReconciler) GetActiveTasks(ctx context.Context) ([]common.GaugeValue, error) {
 	tasks := &klcv1alpha1.KeptnTaskList{}
 	err := r.List(ctx, tasks)
@@ -0,0 +1,51 @@
+package keptntask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errForeignSecureParameters is returned for KeptnTaskDefinitions of other namespaces that take their secure parameters
+// from a Secret, since the Job would mount a Secret of the same name from the namespace of the task instead
+var errForeignSecureParameters = errors.New("secure parameters of KeptnTaskDefinitions of other namespaces are not supported, the task has to name its own Secret")
+
+// snapshotForeignDefinition prepares the parameters of a KeptnTaskDefinition of another namespace for a Job in the
+// namespace of the task. Jobs can only mount ConfigMaps of their own namespace, so the function code is copied into a
+// ConfigMap owned by the task. The copy is never updated, so that a retried Job runs the same code as the first one,
+// even if the definition has changed in the meantime.
+func (r *KeptnTaskReconciler) snapshotForeignDefinition(ctx context.Context, task *klcv1alpha1.KeptnTask, namespace string, params *FunctionExecutionParams) error {
+	if params.SecureParameters != "" && task.Spec.SecureParameters.Secret == "" {
+		return errForeignSecureParameters
+	}
+	if params.ConfigMap == "" {
+		return nil
+	}
+
+	function := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: params.ConfigMap}, function); err != nil {
+		return fmt.Errorf("could not get function ConfigMap %s of namespace %s: %w", params.ConfigMap, namespace, err)
+	}
+	snapshot := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getFunctionSnapshotName(task),
+			Namespace: task.Namespace,
+			Labels:    createKeptnLabels(*task),
+		},
+		Data: function.Data,
+	}
+	if err := r.createOwnedObject(ctx, task, snapshot); err != nil {
+		return fmt.Errorf("could not create function snapshot: %w", err)
+	}
+	params.ConfigMap = snapshot.Name
+	return nil
+}
+
+func getFunctionSnapshotName(task *klcv1alpha1.KeptnTask) string {
+	return "keptnfn-" + task.Name
+}
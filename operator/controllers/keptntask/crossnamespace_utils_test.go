@@ -0,0 +1,155 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func makeForeignTask() *klcv1alpha1.KeptnTask {
+	task := makeTask()
+	task.Spec.TaskDefinition = "migrate"
+	task.Spec.TaskDefinitionNamespace = "platform"
+	return task
+}
+
+func makeForeignDefinition() []client.Object {
+	return []client.Object{
+		&klcv1alpha1.KeptnTaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "migrate"},
+			Spec: klcv1alpha1.KeptnTaskDefinitionSpec{
+				Function:  klcv1alpha1.FunctionSpec{Inline: klcv1alpha1.Inline{Code: "console.log('migrate')"}},
+				ApiAccess: klcv1alpha1.ApiAccess{ClusterRole: "view"},
+			},
+			Status: klcv1alpha1.KeptnTaskDefinitionStatus{Function: klcv1alpha1.FunctionStatus{ConfigMap: "keptnfn-migrate"}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "keptnfn-migrate"},
+			Data:       map[string]string{"code": "console.log('migrate')"},
+		},
+	}
+}
+
+func newCrossNamespaceTestReconciler(t *testing.T, enabled bool, objects ...client.Object) *KeptnTaskReconciler {
+	r := newJobTestReconciler(t, objects...)
+	gates := featuregate.New()
+	require.Nil(t, gates.SetEnabled(featuregate.CrossNamespaceReferences, enabled))
+	r.CrossNamespaceReferences = controllercommon.NewCrossNamespaceReferences(gates, "platform")
+	return r
+}
+
+func reconcileTask(t *testing.T, r *KeptnTaskReconciler, task *klcv1alpha1.KeptnTask) *klcv1alpha1.KeptnTask {
+	_, _ = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}})
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: task.Namespace, Name: task.Name}, result))
+	return result
+}
+
+func TestKeptnTaskReconciler_RunsForeignDefinition(t *testing.T) {
+	t.Setenv(controllercommon.AllowedClusterRolesEnv, "view")
+	task := makeForeignTask()
+	r := newCrossNamespaceTestReconciler(t, true, append(makeForeignDefinition(), task)...)
+
+	result := reconcileTask(t, r, task)
+	require.Equal(t, getJobName(task), result.Status.JobName)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+
+	// the function code is mounted from a snapshot in the namespace of the task
+	snapshot := &corev1.ConfigMap{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getFunctionSnapshotName(task)}, snapshot))
+	require.Equal(t, map[string]string{"code": "console.log('migrate')"}, snapshot.Data)
+	require.True(t, metav1.IsControlledBy(snapshot, task))
+	require.Equal(t, getFunctionSnapshotName(task), job.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	// the API access is granted in the namespace of the task, not in the one of the definition
+	roleBinding := &rbacv1.RoleBinding{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: task.Name}, roleBinding))
+	require.Equal(t, "default", roleBinding.Subjects[0].Namespace)
+	require.Equal(t, task.Name, job.Spec.Template.Spec.ServiceAccountName)
+	require.NotNil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "platform", Name: task.Name}, &rbacv1.RoleBinding{}))
+}
+
+func TestKeptnTaskReconciler_SnapshotIsFrozen(t *testing.T) {
+	task := makeForeignTask()
+	r := newCrossNamespaceTestReconciler(t, true, append(makeForeignDefinition(), task)...)
+	params := FunctionExecutionParams{ConfigMap: "keptnfn-migrate"}
+	require.Nil(t, r.snapshotForeignDefinition(context.TODO(), task, "platform", &params))
+	require.Equal(t, getFunctionSnapshotName(task), params.ConfigMap)
+
+	// the definition changes while the task is running, e.g. before its Job is retried
+	function := &corev1.ConfigMap{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "platform", Name: "keptnfn-migrate"}, function))
+	function.Data["code"] = "console.log('changed')"
+	require.Nil(t, r.Client.Update(context.TODO(), function))
+
+	params = FunctionExecutionParams{ConfigMap: "keptnfn-migrate"}
+	require.Nil(t, r.snapshotForeignDefinition(context.TODO(), task, "platform", &params))
+	snapshot := &corev1.ConfigMap{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: params.ConfigMap}, snapshot))
+	require.Equal(t, "console.log('migrate')", snapshot.Data["code"])
+
+	// secure parameters of the definition would be read from a Secret of the namespace of the task
+	params = FunctionExecutionParams{SecureParameters: "platform-token"}
+	require.ErrorIs(t, r.snapshotForeignDefinition(context.TODO(), task, "platform", &params), errForeignSecureParameters)
+	task.Spec.SecureParameters.Secret = "my-token"
+	require.Nil(t, r.snapshotForeignDefinition(context.TODO(), task, "platform", &params))
+}
+
+func TestKeptnTaskReconciler_DeniesForeignDefinition(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		namespace string
+		want      string
+	}{
+		{
+			name:      "feature gate disabled",
+			namespace: "platform",
+			want:      "Warning CrossNamespaceReferenceDenied Could not use KeptnTaskDefinition: cross-namespace task references are disabled / Namespace: platform, Name: migrate ",
+		},
+		{
+			name:      "namespace not listed as provider",
+			enabled:   true,
+			namespace: "team-b",
+			want:      "Warning CrossNamespaceReferenceDenied Could not use KeptnTaskDefinition: namespace team-b does not provide KeptnTaskDefinitions to other namespaces / Namespace: team-b, Name: migrate ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := makeForeignTask()
+			task.Spec.TaskDefinitionNamespace = tt.namespace
+			r := newCrossNamespaceTestReconciler(t, tt.enabled, append(makeForeignDefinition(), task)...)
+
+			result := reconcileTask(t, r, task)
+			require.Equal(t, common.StateFailed, result.Status.Status)
+			require.Empty(t, result.Status.JobName)
+			require.Equal(t, tt.want, <-r.Recorder.(*record.FakeRecorder).Events)
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_MissingForeignDefinition(t *testing.T) {
+	task := makeForeignTask()
+	task.Spec.TaskDefinition = "seed"
+	r := newCrossNamespaceTestReconciler(t, true, append(makeForeignDefinition(), task)...)
+
+	result := reconcileTask(t, r, task)
+	require.Empty(t, result.Status.JobName)
+	// the event names the namespace the definition has been searched in
+	require.Equal(t, "Warning TaskDefinitionNotFound Could not find KeptnTaskDefinition / Namespace: platform, Name: seed ", <-r.Recorder.(*record.FakeRecorder).Events)
+}
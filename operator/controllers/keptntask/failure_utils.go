@@ -0,0 +1,67 @@
+package keptntask
+
+import (
+	"fmt"
+	"strings"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// setJobFailure stores why the Job has failed in the status of the task
+func setJobFailure(task *klcv1alpha1.KeptnTask, job *batchv1.Job, pods []corev1.Pod) {
+	reason, message := getJobFailure(job, pods)
+	task.Status.Reason = reason
+	task.Status.Message = common.TruncateString(message, common.MaxFailureMessageLength)
+}
+
+// getJobFailure returns why a failed Job has failed. The reason of its failed condition tells whether it has used up
+// its retries or timed out. The container of its last pod explains the failure best, since its termination message
+// ends with the last lines of its log.
+func getJobFailure(job *batchv1.Job, pods []corev1.Pod) (string, string) {
+	var reason string
+	var messages []string
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			reason = condition.Reason
+			if condition.Message != "" {
+				messages = append(messages, condition.Message)
+			}
+		}
+	}
+	if terminated := getLastTermination(pods); terminated != nil {
+		if reason == "" {
+			reason = terminated.Reason
+		}
+		message := fmt.Sprintf("container exited with code %d", terminated.ExitCode)
+		if output := strings.TrimSpace(terminated.Message); output != "" {
+			message = fmt.Sprintf("%s: %s", message, output)
+		}
+		messages = append(messages, message)
+	}
+	return reason, strings.Join(messages, ", ")
+}
+
+// getLastTermination returns the state of the container of the last pod of a Job once it has terminated
+func getLastTermination(pods []corev1.Pod) *corev1.ContainerStateTerminated {
+	if len(pods) == 0 {
+		return nil
+	}
+	last := 0
+	for i := range pods {
+		if pods[last].CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			last = i
+		}
+	}
+	for _, status := range pods[last].Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			return status.State.Terminated
+		}
+		if status.LastTerminationState.Terminated != nil {
+			return status.LastTerminationState.Terminated
+		}
+	}
+	return nil
+}
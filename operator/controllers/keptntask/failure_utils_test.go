@@ -0,0 +1,96 @@
+package keptntask
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetJobFailure(t *testing.T) {
+	now := time.Now()
+	deadlineExceeded := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"}
+	backoffLimitExceeded := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}
+	terminatedPod := func(created time.Time, state corev1.ContainerState, lastState corev1.ContainerState) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "keptn-function-runner", State: state, LastTerminationState: lastState},
+			}},
+		}
+	}
+	tests := []struct {
+		name        string
+		conditions  []batchv1.JobCondition
+		pods        []corev1.Pod
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "timed out without pods",
+			conditions:  []batchv1.JobCondition{deadlineExceeded},
+			wantReason:  "DeadlineExceeded",
+			wantMessage: "Job was active longer than specified deadline",
+		},
+		{
+			name:       "retries used up",
+			conditions: []batchv1.JobCondition{backoffLimitExceeded},
+			pods: []corev1.Pod{
+				terminatedPod(now, corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: "error: connection refused\n"}}, corev1.ContainerState{}),
+				terminatedPod(now.Add(-time.Minute), corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}}, corev1.ContainerState{}),
+			},
+			wantReason:  "BackoffLimitExceeded",
+			wantMessage: "Job has reached the specified backoff limit, container exited with code 1: error: connection refused",
+		},
+		{
+			name:       "timed out while the container is restarted in place",
+			conditions: []batchv1.JobCondition{deadlineExceeded},
+			pods: []corev1.Pod{
+				terminatedPod(now, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}, corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}}),
+			},
+			wantReason:  "DeadlineExceeded",
+			wantMessage: "Job was active longer than specified deadline, container exited with code 137",
+		},
+		{
+			name:        "timed out before the container has started",
+			conditions:  []batchv1.JobCondition{deadlineExceeded},
+			pods:        []corev1.Pod{terminatedPod(now, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}, corev1.ContainerState{})},
+			wantReason:  "DeadlineExceeded",
+			wantMessage: "Job was active longer than specified deadline",
+		},
+		{
+			name: "failed condition without reason",
+			pods: []corev1.Pod{
+				terminatedPod(now, corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}}, corev1.ContainerState{}),
+			},
+			wantReason:  "OOMKilled",
+			wantMessage: "container exited with code 137",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}}
+			reason, message := getJobFailure(job, tt.pods)
+			require.Equal(t, tt.wantReason, reason)
+			require.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
+
+func TestSetJobFailure_TruncatesMessage(t *testing.T) {
+	task := &klcv1alpha1.KeptnTask{}
+	pods := []corev1.Pod{{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+		{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: strings.Repeat("x", 4096)}}},
+	}}}}
+
+	setJobFailure(task, &batchv1.Job{}, pods)
+	require.Equal(t, "Error", task.Status.Reason)
+	require.Len(t, task.Status.Message, common.MaxFailureMessageLength)
+	require.True(t, strings.HasPrefix(task.Status.Message, "container exited with code 1: "))
+}
@@ -3,11 +3,10 @@ package keptntask
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"os"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
-	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,23 +19,29 @@ type FunctionExecutionParams struct {
 	SecureParameters string
 	URL              string
 	Context          klcv1alpha1.TaskContext
+	EnvFromMetadata  []klcv1alpha1.EnvFromMetadata
+	MetadataEnv      []corev1.EnvVar
 }
 
 func (r *KeptnTaskReconciler) generateFunctionJob(task *klcv1alpha1.KeptnTask, params FunctionExecutionParams) (*batchv1.Job, error) {
-	randomId := rand.Intn(99999-10000) + 10000
-	jobId := fmt.Sprintf("klc-%s-%d", common.TruncateString(task.Name, common.MaxTaskNameLength), randomId)
+	retries := task.GetRetries()
+	timeoutSeconds := task.GetTimeoutSeconds()
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      jobId,
+			Name:      getJobName(task),
 			Namespace: task.Namespace,
 			Labels:    createKeptnLabels(*task),
 		},
 		Spec: batchv1.JobSpec{
+			BackoffLimit:          &retries,
+			ActiveDeadlineSeconds: &timeoutSeconds,
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
-					RestartPolicy: "OnFailure",
+					// a Job with a pod failure policy has to replace failed pods rather than restarting them
+					RestartPolicy: corev1.RestartPolicyNever,
 				},
 			},
+			PodFailurePolicy: getPodFailurePolicy(),
 		},
 	}
 	err := controllerutil.SetControllerReference(task, job, r.Scheme)
@@ -47,6 +52,8 @@ func (r *KeptnTaskReconciler) generateFunctionJob(task *klcv1alpha1.KeptnTask, p
 	container := corev1.Container{
 		Name:  "keptn-function-runner",
 		Image: os.Getenv("FUNCTION_RUNNER_IMAGE"),
+		// the last lines of the log explain why a function has failed
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 	}
 
 	var envVars []corev1.EnvVar
@@ -106,10 +113,17 @@ func (r *KeptnTaskReconciler) generateFunctionJob(task *klcv1alpha1.KeptnTask, p
 		envVars = append(envVars, corev1.EnvVar{Name: "SCRIPT", Value: params.URL})
 	}
 
+	envVars = append(envVars, params.MetadataEnv...)
+
 	container.Env = envVars
 	job.Spec.Template.Spec.Containers = []corev1.Container{
 		container,
 	}
+	injectContextEnv(&job.Spec.Template.Spec, getContextEnv(*task))
+	markPodTemplate(&job.Spec.Template, createKeptnLabels(*task))
+	if r.FeatureGates.Enabled(featuregate.PreventTaskEviction) {
+		preventEviction(&job.Spec.Template)
+	}
 	return job, nil
 }
 
@@ -146,5 +160,9 @@ func (r *KeptnTaskReconciler) parseFunctionTaskDefinition(definition *klcv1alpha
 	if definition.Spec.Function.SecureParameters.Secret != "" {
 		params.SecureParameters = definition.Spec.Function.SecureParameters.Secret
 	}
+
+	if len(definition.Spec.Function.EnvFromMetadata) > 0 {
+		params.EnvFromMetadata = definition.Spec.Function.EnvFromMetadata
+	}
 	return params, hasParent, nil
 }
@@ -0,0 +1,110 @@
+package keptntask
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultInfrastructureRetryLimit = 3
+
+	InterruptionReasonDisruption   = "disruption"
+	InterruptionReasonEviction     = "eviction"
+	InterruptionReasonNodeShutdown = "node-shutdown"
+
+	// safeToEvictAnnotation keeps the cluster autoscaler from removing the node a pod is running on
+	safeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+)
+
+// handleFailedJob fails the task, unless the Job has failed since one of its pods has been removed by the
+// infrastructure. In this case a new Job is created, as long as the infrastructure retry limit is not exceeded.
+// Retrying with a new Job does not use up the backoff limit of the Job on interruptions the check is not to blame for.
+func (r *KeptnTaskReconciler) handleFailedJob(ctx context.Context, task *klcv1alpha1.KeptnTask, job *batchv1.Job) error {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return err
+	}
+	if reason := getJobInterruption(pods.Items); reason != "" {
+		r.Meters.TaskInterruptions.Add(ctx, 1, append(task.GetActiveMetricsAttributes(), common.InterruptionReason.String(reason))...)
+		if task.Status.InfrastructureRetries < r.InfrastructureRetryLimit {
+			task.Status.InfrastructureRetries++
+			task.Status.JobAttempt++
+			task.Status.JobName = ""
+			r.Recorder.Event(task, "Warning", "JobInterrupted", fmt.Sprintf("Job %s has been interrupted by the infrastructure (%s), retrying with a new Job / Namespace: %s, Name: %s ", job.Name, reason, task.Namespace, task.Name))
+			return nil
+		}
+	}
+
+	task.Status.Status = common.StateFailed
+	setPodLatencies(task, pods.Items)
+	setJobFailure(task, job, pods.Items)
+	r.Recorder.Event(task, "Warning", "JobFailed", fmt.Sprintf("Job %s has failed: %s / Namespace: %s, Name: %s ", job.Name, task.Status.Message, task.Namespace, task.Name))
+	return nil
+}
+
+// getPodFailurePolicy keeps pods that have been disrupted by the infrastructure from using up the backoff limit of
+// a Job: the Job replaces them without counting them as failures. The policy is applied by clusters with the
+// JobPodFailurePolicy feature enabled, on other clusters interruptions are still retried by handleFailedJob.
+func getPodFailurePolicy() *batchv1.PodFailurePolicy {
+	return &batchv1.PodFailurePolicy{
+		Rules: []batchv1.PodFailurePolicyRule{
+			{
+				Action: batchv1.PodFailurePolicyActionIgnore,
+				OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+					{Type: corev1.AlphaNoCompatGuaranteeDisruptionTarget, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+	}
+}
+
+// getJobInterruption returns why the last pod of a failed Job has been removed by the infrastructure rather than
+// failing on its own, or an empty string if it has failed on its own
+func getJobInterruption(pods []corev1.Pod) string {
+	if len(pods) == 0 {
+		return ""
+	}
+	last := 0
+	for i := range pods {
+		if pods[last].CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			last = i
+		}
+	}
+	pod := pods[last]
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.AlphaNoCompatGuaranteeDisruptionTarget && condition.Status == corev1.ConditionTrue {
+			return InterruptionReasonDisruption
+		}
+	}
+	switch pod.Status.Reason {
+	case "Evicted":
+		return InterruptionReasonEviction
+	case "Terminated", "Shutdown", "NodeShutdown":
+		// set by the graceful node shutdown of the kubelet
+		return InterruptionReasonNodeShutdown
+	}
+	return ""
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// preventEviction asks the cluster autoscaler not to scale down the nodes the pods of a Job run on
+func preventEviction(template *corev1.PodTemplateSpec) {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[safeToEvictAnnotation] = "false"
+}
@@ -0,0 +1,177 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGetJobInterruption(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		pods []corev1.Pod
+		want string
+	}{
+		{
+			name: "no pods",
+			want: "",
+		},
+		{
+			name: "failed on its own",
+			pods: []corev1.Pod{{Status: corev1.PodStatus{Phase: corev1.PodFailed}}},
+			want: "",
+		},
+		{
+			name: "disruption target",
+			pods: []corev1.Pod{{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.AlphaNoCompatGuaranteeDisruptionTarget, Status: corev1.ConditionTrue},
+			}}}},
+			want: InterruptionReasonDisruption,
+		},
+		{
+			name: "evicted",
+			pods: []corev1.Pod{{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}}},
+			want: InterruptionReasonEviction,
+		},
+		{
+			name: "node shutdown",
+			pods: []corev1.Pod{{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Terminated"}}},
+			want: InterruptionReasonNodeShutdown,
+		},
+		{
+			name: "the last pod failed on its own after an eviction",
+			pods: []corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Minute))}, Status: corev1.PodStatus{Reason: "Evicted"}},
+				{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now)}, Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, getJobInterruption(tt.pods))
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_FailedJob(t *testing.T) {
+	tests := []struct {
+		name             string
+		podReason        string
+		retries          int
+		wantStatus       common.KeptnState
+		wantJobs         int
+		wantInfraRetries int
+	}{
+		{
+			name:             "interrupted job is retried",
+			podReason:        "Evicted",
+			wantStatus:       common.StatePending,
+			wantJobs:         2,
+			wantInfraRetries: 1,
+		},
+		{
+			name:             "interrupted job fails once the retries are used up",
+			podReason:        "Evicted",
+			retries:          DefaultInfrastructureRetryLimit,
+			wantStatus:       common.StateFailed,
+			wantJobs:         1,
+			wantInfraRetries: DefaultInfrastructureRetryLimit,
+		},
+		{
+			name:       "job failing on its own fails the task",
+			wantStatus: common.StateFailed,
+			wantJobs:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := makeTask()
+			task.Status = klcv1alpha1.KeptnTaskStatus{JobName: "failed-job", Status: common.StateProgressing, InfrastructureRetries: tt.retries}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "failed-job"},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+				}},
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "failed-job-12345", Labels: map[string]string{"job-name": "failed-job"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: tt.podReason},
+			}
+			r := newJobTestReconciler(t, task, job, pod)
+			r.InfrastructureRetryLimit = DefaultInfrastructureRetryLimit
+			meter := metric.NewNoopMeterProvider().Meter("test")
+			var err error
+			r.Meters.TaskInterruptions, err = meter.SyncInt64().Counter("interruptions")
+			require.Nil(t, err)
+			r.Meters.TaskCount, err = meter.SyncInt64().Counter("count")
+			require.Nil(t, err)
+			r.Meters.TaskDuration, err = meter.SyncFloat64().Histogram("duration")
+			require.Nil(t, err)
+
+			for i := 0; i < 2; i++ {
+				_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+				require.Nil(t, err)
+			}
+
+			result := &klcv1alpha1.KeptnTask{}
+			require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+			require.Equal(t, tt.wantStatus, result.Status.Status)
+			require.Equal(t, tt.wantInfraRetries, result.Status.InfrastructureRetries)
+			jobs := &batchv1.JobList{}
+			require.Nil(t, r.Client.List(context.TODO(), jobs))
+			require.Len(t, jobs.Items, tt.wantJobs)
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_PreventEviction(t *testing.T) {
+	for _, prevent := range []bool{false, true} {
+		task := makeTask()
+		r := newJobTestReconciler(t, task)
+		r.FeatureGates = featuregate.New()
+		require.Nil(t, r.FeatureGates.SetEnabled(featuregate.PreventTaskEviction, prevent))
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+		require.Nil(t, err)
+
+		job := &batchv1.Job{}
+		require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+		if prevent {
+			require.Equal(t, "false", job.Spec.Template.Annotations[safeToEvictAnnotation])
+		} else {
+			require.NotContains(t, job.Spec.Template.Annotations, safeToEvictAnnotation)
+		}
+	}
+}
+
+func TestKeptnTaskReconciler_PodFailurePolicy(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	require.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	require.NotNil(t, job.Spec.PodFailurePolicy)
+	require.Len(t, job.Spec.PodFailurePolicy.Rules, 1)
+	rule := job.Spec.PodFailurePolicy.Rules[0]
+	require.Equal(t, batchv1.PodFailurePolicyActionIgnore, rule.Action)
+	require.Equal(t, []batchv1.PodFailurePolicyOnPodConditionsPattern{
+		{Type: corev1.AlphaNoCompatGuaranteeDisruptionTarget, Status: corev1.ConditionTrue},
+	}, rule.OnPodConditions)
+}
@@ -2,28 +2,70 @@ package keptntask
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 
 	"github.com/imdario/mergo"
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 func (r *KeptnTaskReconciler) createJob(ctx context.Context, req ctrl.Request, task *klcv1alpha1.KeptnTask) error {
 	jobName := ""
-	definition, err := r.getTaskDefinition(ctx, task.Spec.TaskDefinition, req.Namespace)
+	definitionNamespace := task.GetTaskDefinitionNamespace()
+	if err := r.CrossNamespaceReferences.Check(task.Namespace, definitionNamespace); err != nil {
+		r.Recorder.Event(task, "Warning", "CrossNamespaceReferenceDenied", fmt.Sprintf("Could not use KeptnTaskDefinition: %s / Namespace: %s, Name: %s ", err.Error(), definitionNamespace, task.Spec.TaskDefinition))
+		task.Status.Status = common.StateFailed
+		return nil
+	}
+	definition, err := r.getTaskDefinition(ctx, task.Spec.TaskDefinition, definitionNamespace)
 	if err != nil {
-		r.Recorder.Event(task, "Warning", "TaskDefinitionNotFound", fmt.Sprintf("Could not find KeptnTaskDefinition / Namespace: %s, Name: %s ", task.Namespace, task.Spec.TaskDefinition))
+		r.Recorder.Event(task, "Warning", "TaskDefinitionNotFound", fmt.Sprintf("Could not find KeptnTaskDefinition / Namespace: %s, Name: %s ", definitionNamespace, task.Spec.TaskDefinition))
 		return err
 	}
 
+	if controllercommon.ValidateApiAccess(definition.Spec.ApiAccess.ClusterRole) != nil {
+		r.Recorder.Event(task, "Warning", "ApiAccessDenied", fmt.Sprintf("ClusterRole %s is not allowed for KeptnTaskDefinition / Namespace: %s, Name: %s ", definition.Spec.ApiAccess.ClusterRole, definitionNamespace, task.Spec.TaskDefinition))
+		task.Status.Status = common.StateFailed
+		return nil
+	}
+
 	if !reflect.DeepEqual(definition.Spec.Function, klcv1alpha1.FunctionSpec{}) {
 		jobName, err = r.createFunctionJob(ctx, req, task, definition)
+		if errors.Is(err, errParameterRendering) {
+			r.Recorder.Event(task, "Warning", "ParameterRenderingFailed", fmt.Sprintf("Could not render parameters: %s / Namespace: %s, Name: %s ", err.Error(), task.Namespace, task.Name))
+			task.Status.Status = common.StateFailed
+			return nil
+		}
+		if isQuotaInsufficient(err) {
+			if task.Spec.WaitForQuota {
+				r.Recorder.Event(task, "Normal", "WaitingForQuota", fmt.Sprintf("Waiting for quota: %s / Namespace: %s, Name: %s ", err.Error(), task.Namespace, task.Name))
+				return err
+			}
+			r.Recorder.Event(task, "Warning", QuotaInsufficientReason, fmt.Sprintf("Could not create Job: %s / Namespace: %s, Name: %s ", err.Error(), task.Namespace, task.Name))
+			task.Status.Status = common.StateFailed
+			return nil
+		}
+		if errors.Is(err, errForeignSecureParameters) {
+			r.Recorder.Event(task, "Warning", "SecureParametersDenied", fmt.Sprintf("Could not use KeptnTaskDefinition: %s / Namespace: %s, Name: %s ", err.Error(), definitionNamespace, task.Spec.TaskDefinition))
+			task.Status.Status = common.StateFailed
+			return nil
+		}
+		if errors.Is(err, errEnvFromMetadata) {
+			r.Recorder.Event(task, "Warning", "EnvFromMetadataFailed", fmt.Sprintf("Could not resolve environment variables: %s / Namespace: %s, Name: %s ", err.Error(), task.Namespace, task.Name))
+			task.Status.Status = common.StateFailed
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -46,9 +88,10 @@ func (r *KeptnTaskReconciler) createFunctionJob(ctx context.Context, req ctrl.Re
 		return "", err
 	}
 	if hasParent {
-		parentDefinition, err := r.getTaskDefinition(ctx, definition.Spec.Function.FunctionReference.Name, req.Namespace)
+		// the parent is searched next to the definition, which may belong to another namespace than the task
+		parentDefinition, err := r.getTaskDefinition(ctx, definition.Spec.Function.FunctionReference.Name, definition.Namespace)
 		if err != nil {
-			r.Recorder.Event(task, "Warning", "TaskDefinitionNotFound", fmt.Sprintf("Could not find KeptnTaskDefinition / Namespace: %s, Name: %s ", task.Namespace, task.Spec.TaskDefinition))
+			r.Recorder.Event(task, "Warning", "TaskDefinitionNotFound", fmt.Sprintf("Could not find KeptnTaskDefinition / Namespace: %s, Name: %s ", definition.Namespace, definition.Spec.Function.FunctionReference.Name))
 			return "", err
 		}
 		parentJobParams, _, err = r.parseFunctionTaskDefinition(parentDefinition)
@@ -85,15 +128,61 @@ func (r *KeptnTaskReconciler) createFunctionJob(ctx context.Context, req ctrl.Re
 		}
 	}
 
+	// the owner is only fetched if its metadata is actually needed
+	var owner metav1.Object = &metav1.ObjectMeta{}
+	if hasTemplates(params.Parameters) || len(params.EnvFromMetadata) > 0 {
+		owner, err = r.getTaskOwnerMetadata(ctx, task)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	params.Parameters, err = renderParameters(params.Parameters, newTaskTemplateData(task, owner))
+	if err != nil {
+		return "", err
+	}
+
+	if definition.Namespace != task.Namespace {
+		if err := r.snapshotForeignDefinition(ctx, task, definition.Namespace, &params); err != nil {
+			return "", err
+		}
+	}
+
 	if task.Spec.SecureParameters.Secret != "" {
 		params.SecureParameters = task.Spec.SecureParameters.Secret
 	}
 
+	if len(params.EnvFromMetadata) > 0 {
+		params.MetadataEnv, err = resolveEnvFromMetadata(params.EnvFromMetadata, owner)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	job, err := r.generateFunctionJob(task, params)
 	if err != nil {
 		return "", err
 	}
-	err = r.Client.Create(ctx, job)
+	injectTraceContext(ctx, job)
+	r.Queue.queueJob(job, definition)
+
+	if err := r.checkQuota(ctx, job); err != nil {
+		return "", err
+	}
+
+	if definition.Spec.ApiAccess.ClusterRole != "" {
+		serviceAccountName, err := r.createApiAccess(ctx, task, definition.Spec.ApiAccess.ClusterRole)
+		if err != nil {
+			r.Recorder.Event(task, "Warning", "ApiAccessNotCreated", fmt.Sprintf("Could not create API access for Job / Namespace: %s, Name: %s ", task.Namespace, task.Name))
+			return "", err
+		}
+		job.Spec.Template.Spec.ServiceAccountName = serviceAccountName
+	}
+
+	err = r.CreationLimiter.Create(ctx, r.Client, job)
+	if apierrors.IsAlreadyExists(err) {
+		return r.adoptJob(ctx, task, job.Name)
+	}
 	if err != nil {
 		r.Log.Error(err, "could not create job")
 		r.Recorder.Event(task, "Warning", "JobNotCreated", fmt.Sprintf("Could not create Job / Namespace: %s, Name: %s ", task.Namespace, task.Name))
@@ -108,6 +197,7 @@ func (r *KeptnTaskReconciler) updateJob(ctx context.Context, req ctrl.Request, t
 	job, err := r.getJob(ctx, task.Status.JobName, req.Namespace)
 	if err != nil {
 		task.Status.JobName = ""
+		task.Status.JobAttempt++
 		r.Recorder.Event(task, "Warning", "JobReferenceRemoved", fmt.Sprintf("Removed Job Reference as Job could not be found / Namespace: %s, TaskName: %s ", task.Namespace, task.Name))
 		err = r.Client.Status().Update(ctx, task)
 		if err != nil {
@@ -115,15 +205,55 @@ func (r *KeptnTaskReconciler) updateJob(ctx context.Context, req ctrl.Request, t
 		}
 		return err
 	}
+	if isJobQueued(job) {
+		return r.handleQueuedJob(ctx, task, job)
+	}
+	if task.Status.Reason == QueuedBySchedulerReason {
+		// the Job has been admitted by its queue
+		task.Status.Reason = ""
+		task.Status.Message = ""
+	}
 	if job.Status.Succeeded > 0 {
 		task.Status.Status = common.StateSucceeded
+		if err := r.setJobLatencies(ctx, task, job); err != nil {
+			r.Log.Error(err, "could not determine latencies of job for: "+task.Name)
+		}
 		err = r.Client.Status().Update(ctx, task)
 		if err != nil {
 			r.Log.Error(err, "could not update job status for: "+task.Name)
 		}
+		return nil
+	}
+	if isJobFailed(job) {
+		return r.handleFailedJob(ctx, task, job)
 	}
 	return nil
 }
+
+// adoptJob continues with a Job that has already been created for the task, e.g. by a reconciliation that did
+// not get to record the name of the Job in the status of the task before the controller has been restarted
+func (r *KeptnTaskReconciler) adoptJob(ctx context.Context, task *klcv1alpha1.KeptnTask, jobName string) (string, error) {
+	job, err := r.getJob(ctx, jobName, task.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if !metav1.IsControlledBy(job, task) {
+		r.Recorder.Event(task, "Warning", "JobNotCreated", fmt.Sprintf("Job %s already exists and does not belong to the task / Namespace: %s, Name: %s ", jobName, task.Namespace, task.Name))
+		return "", fmt.Errorf("job %s already exists and is not controlled by KeptnTask %s", jobName, task.Name)
+	}
+	r.Recorder.Event(task, "Normal", "JobAdopted", fmt.Sprintf("Adopted existing Job %s / Namespace: %s, Name: %s ", jobName, task.Namespace, task.Name))
+	return job.Name, nil
+}
+
+// getJobName returns the name of the Job running the current attempt of the task. The name is derived from the UID
+// of the task, so that a Job that has been created before the controller restarted is found again instead of
+// being created a second time.
+func getJobName(task *klcv1alpha1.KeptnTask) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(fmt.Sprintf("%s-%d", task.UID, task.Status.JobAttempt)))
+	return fmt.Sprintf("klc-%s-%05d", common.TruncateString(task.Name, common.MaxTaskNameLength), hash.Sum32()%100000)
+}
+
 func (r *KeptnTaskReconciler) getJob(ctx context.Context, jobName string, namespace string) (*batchv1.Job, error) {
 	job := &batchv1.Job{}
 	err := r.Client.Get(ctx, types.NamespacedName{Name: jobName, Namespace: namespace}, job)
@@ -134,17 +264,49 @@ func (r *KeptnTaskReconciler) getJob(ctx context.Context, jobName string, namesp
 }
 
 func createKeptnLabels(task klcv1alpha1.KeptnTask) map[string]string {
-	if task.Spec.Workload != "" {
-		return map[string]string{
-			common.AppAnnotation:      task.Spec.AppName,
-			common.WorkloadAnnotation: task.Spec.Workload,
-			common.VersionAnnotation:  task.Spec.WorkloadVersion,
-			common.TaskNameAnnotation: task.Name,
-		}
-	}
-	return map[string]string{
+	labels := map[string]string{
 		common.AppAnnotation:      task.Spec.AppName,
 		common.VersionAnnotation:  task.Spec.AppVersion,
 		common.TaskNameAnnotation: task.Name,
+		common.ManagedByLabel:     common.ManagedByLifecycleToolkit,
+	}
+	if task.Spec.Workload != "" {
+		labels[common.WorkloadAnnotation] = task.Spec.Workload
+		labels[common.VersionAnnotation] = task.Spec.WorkloadVersion
+	}
+	if task.Spec.Type != "" {
+		labels[common.CheckTypeLabel] = string(task.Spec.Type)
+	}
+	return labels
+}
+
+// workloadMetadataKeys are the labels and annotations the webhook creates KeptnWorkloads and KeptnApps for
+var workloadMetadataKeys = []string{
+	common.WorkloadAnnotation,
+	common.VersionAnnotation,
+	common.AppAnnotation,
+	common.K8sRecommendedWorkloadAnnotations,
+	common.K8sRecommendedVersionAnnotations,
+	common.K8sRecommendedAppAnnotations,
+	common.PreDeploymentTaskAnnotation,
+	common.PostDeploymentTaskAnnotation,
+	common.PreDeploymentEvaluationAnnotation,
+	common.PostDeploymentEvaluationAnnotation,
+}
+
+// markPodTemplate removes the workload labels and annotations from the pods of a Job and labels them with the given
+// labels of the task instead, which mark them as managed by the lifecycle toolkit, so that the webhook does not start
+// a lifecycle for the pods of a check
+func markPodTemplate(template *corev1.PodTemplateSpec, labels map[string]string) {
+	for _, key := range workloadMetadataKeys {
+		delete(template.Labels, key)
+		delete(template.Annotations, key)
+	}
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		template.Labels[key] = value
 	}
+	template.Labels[common.ManagedByLabel] = common.ManagedByLifecycleToolkit
 }
@@ -0,0 +1,240 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestKeptnTaskReconciler_CreatesSingleJob(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+	// the next reconciliation continues with the Job that has been recorded
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	jobs := &batchv1.JobList{}
+	require.Nil(t, r.Client.List(context.TODO(), jobs))
+	require.Len(t, jobs.Items, 1)
+	require.Equal(t, getJobName(task), jobs.Items[0].Name)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.Equal(t, getJobName(task), result.Status.JobName)
+}
+
+func TestKeptnTaskReconciler_AdoptsJobCreatedBeforeCrash(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	// the Job has been created, but the controller crashed before the name has been written to the status
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: getJobName(task)}}
+	require.Nil(t, controllerutil.SetControllerReference(task, job, r.Scheme))
+	require.Nil(t, r.Client.Create(context.TODO(), job))
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	jobs := &batchv1.JobList{}
+	require.Nil(t, r.Client.List(context.TODO(), jobs))
+	require.Len(t, jobs.Items, 1)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.Equal(t, job.Name, result.Status.JobName)
+}
+
+func TestKeptnTaskReconciler_DoesNotAdoptForeignJob(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: getJobName(task)}}
+	require.Nil(t, r.Client.Create(context.TODO(), job))
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.NotNil(t, err)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.Empty(t, result.Status.JobName)
+}
+
+func TestGetJobName(t *testing.T) {
+	task := makeTask()
+	name := getJobName(task)
+	require.Equal(t, name, getJobName(task))
+
+	task.Status.JobAttempt++
+	require.NotEqual(t, name, getJobName(task))
+
+	task.UID = "other-uid"
+	task.Status.JobAttempt = 0
+	require.NotEqual(t, name, getJobName(task))
+}
+
+func TestMarkPodTemplate(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				common.WorkloadAnnotation:           "my-workload",
+				common.K8sRecommendedAppAnnotations: "my-app",
+				"team":                              "checks",
+			},
+			Annotations: map[string]string{
+				common.VersionAnnotation:           "1.0.0",
+				common.PreDeploymentTaskAnnotation: "check",
+				"description":                      "a check",
+			},
+		},
+	}
+
+	markPodTemplate(template, map[string]string{common.CheckTypeLabel: "pre"})
+
+	require.Equal(t, map[string]string{"team": "checks", common.CheckTypeLabel: "pre", common.ManagedByLabel: common.ManagedByLifecycleToolkit}, template.Labels)
+	require.Equal(t, map[string]string{"description": "a check"}, template.Annotations)
+}
+
+func TestKeptnTaskReconciler_JobIsManagedByToolkit(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	require.Equal(t, common.ManagedByLifecycleToolkit, job.Labels[common.ManagedByLabel])
+	require.Equal(t, common.ManagedByLifecycleToolkit, job.Spec.Template.Labels[common.ManagedByLabel])
+}
+
+func TestKeptnTaskReconciler_JobHasCheckLabels(t *testing.T) {
+	task := makeTask()
+	task.Spec.Type = common.PreDeploymentCheckType
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	for _, labels := range []map[string]string{job.Labels, job.Spec.Template.Labels} {
+		require.Equal(t, "my-app", labels[common.AppAnnotation])
+		require.Equal(t, "my-app-my-workload", labels[common.WorkloadAnnotation])
+		require.Equal(t, "1.0.0", labels[common.VersionAnnotation])
+		require.Equal(t, "pre", labels[common.CheckTypeLabel])
+	}
+}
+
+func TestKeptnTaskReconciler_JobHasRetriesAndTimeout(t *testing.T) {
+	retries := int32(2)
+	timeoutSeconds := int64(60)
+	tests := []struct {
+		name               string
+		retries            *int32
+		timeoutSeconds     *int64
+		wantBackoffLimit   int32
+		wantActiveDeadline int64
+	}{
+		{
+			name:               "defaults",
+			wantBackoffLimit:   klcv1alpha1.DefaultTaskRetries,
+			wantActiveDeadline: klcv1alpha1.DefaultTaskTimeoutSeconds,
+		},
+		{
+			name:               "set in the task",
+			retries:            &retries,
+			timeoutSeconds:     &timeoutSeconds,
+			wantBackoffLimit:   2,
+			wantActiveDeadline: 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := makeTask()
+			task.Spec.Retries = tt.retries
+			task.Spec.TimeoutSeconds = tt.timeoutSeconds
+			r := newJobTestReconciler(t, task)
+
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+			require.Nil(t, err)
+
+			job := &batchv1.Job{}
+			require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+			require.Equal(t, tt.wantBackoffLimit, *job.Spec.BackoffLimit)
+			require.Equal(t, tt.wantActiveDeadline, *job.Spec.ActiveDeadlineSeconds)
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_RendersParametersWithOwnerAnnotations(t *testing.T) {
+	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "my-app-my-workload-1.0.0",
+			UID:         "instance-uid",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+	}
+	task := makeTask()
+	task.Spec.Parameters.Inline = map[string]string{"target": "http://my-service/{{ .Annotations.foo }}"}
+	r := newJobTestReconciler(t, workloadInstance)
+	require.Nil(t, controllerutil.SetControllerReference(workloadInstance, task, r.Scheme))
+	require.Nil(t, r.Client.Create(context.TODO(), task))
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	require.Contains(t, job.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "DATA", Value: `{"target":"http://my-service/bar"}`})
+}
+
+func makeTask() *klcv1alpha1.KeptnTask {
+	return &klcv1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task", UID: "task-uid"},
+		Spec: klcv1alpha1.KeptnTaskSpec{
+			Workload:        "my-app-my-workload",
+			WorkloadVersion: "1.0.0",
+			AppName:         "my-app",
+			TaskDefinition:  "my-definition",
+		},
+	}
+}
+
+func newJobTestReconciler(t *testing.T, objects ...client.Object) *KeptnTaskReconciler {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	definition := &klcv1alpha1.KeptnTaskDefinition{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-definition"},
+		Spec: klcv1alpha1.KeptnTaskDefinitionSpec{
+			Function: klcv1alpha1.FunctionSpec{HttpReference: klcv1alpha1.HttpReference{Url: "http://example.com/function.ts"}},
+		},
+	}
+	return &KeptnTaskReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, definition)...).Build(),
+		Scheme:   scheme,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(100),
+		Tracer:   trace.NewNoopTracerProvider().Tracer("test"),
+	}
+}
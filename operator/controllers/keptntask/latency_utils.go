@@ -0,0 +1,84 @@
+package keptntask
+
+import (
+	"context"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// jobLatencies splits the runtime of a Job into the time its last attempt waited for the container to start
+// and the time the container has been running
+type jobLatencies struct {
+	Scheduling time.Duration
+	Execution  time.Duration
+	Restarts   int
+}
+
+// setJobLatencies stores the latencies of the finished Job in the status of the task
+func (r *KeptnTaskReconciler) setJobLatencies(ctx context.Context, task *klcv1alpha1.KeptnTask, job *batchv1.Job) error {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return err
+	}
+	setPodLatencies(task, pods.Items)
+	return nil
+}
+
+func setPodLatencies(task *klcv1alpha1.KeptnTask, pods []corev1.Pod) {
+	latencies := getJobLatencies(pods)
+	task.Status.SchedulingDuration = metav1.Duration{Duration: latencies.Scheduling}
+	task.Status.ExecutionDuration = metav1.Duration{Duration: latencies.Execution}
+	task.Status.Restarts = latencies.Restarts
+}
+
+// getJobLatencies computes the latencies of the last attempt of a Job from the state of its pods.
+// Every pod but the last one and every restart of a container in place count as a restart.
+// The scheduling latency of a container that has been restarted in place is the time between the end of the
+// previous attempt and the start of the last one.
+func getJobLatencies(pods []corev1.Pod) jobLatencies {
+	latencies := jobLatencies{}
+	if len(pods) == 0 {
+		return latencies
+	}
+
+	last := 0
+	for i := range pods {
+		latencies.Restarts += podRestarts(pods[i])
+		if pods[last].CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			last = i
+		}
+	}
+	latencies.Restarts += len(pods) - 1
+
+	pod := pods[last]
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return latencies
+	}
+	status := pod.Status.ContainerStatuses[0]
+	terminated := status.State.Terminated
+	if terminated == nil || terminated.StartedAt.IsZero() {
+		return latencies
+	}
+
+	waitingSince := pod.CreationTimestamp
+	if previous := status.LastTerminationState.Terminated; status.RestartCount > 0 && previous != nil {
+		waitingSince = previous.FinishedAt
+	}
+	latencies.Scheduling = common.Duration(waitingSince, terminated.StartedAt)
+	latencies.Execution = common.Duration(terminated.StartedAt, terminated.FinishedAt)
+	return latencies
+}
+
+func podRestarts(pod corev1.Pod) int {
+	restarts := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += int(status.RestartCount)
+	}
+	return restarts
+}
@@ -0,0 +1,110 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var latencyTestTime = time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+func makeJobPod(name string, created time.Duration, status corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			Labels:            map[string]string{"job-name": "my-job"},
+			CreationTimestamp: metav1.NewTime(latencyTestTime.Add(created)),
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+	}
+}
+
+func terminatedState(started time.Duration, finished time.Duration) *corev1.ContainerStateTerminated {
+	return &corev1.ContainerStateTerminated{
+		StartedAt:  metav1.NewTime(latencyTestTime.Add(started)),
+		FinishedAt: metav1.NewTime(latencyTestTime.Add(finished)),
+	}
+}
+
+func TestGetJobLatencies(t *testing.T) {
+	tests := []struct {
+		name string
+		pods []corev1.Pod
+		want jobLatencies
+	}{
+		{
+			name: "no pods",
+			want: jobLatencies{},
+		},
+		{
+			name: "single attempt",
+			pods: []corev1.Pod{
+				makeJobPod("pod-1", 0, corev1.ContainerStatus{State: corev1.ContainerState{Terminated: terminatedState(20*time.Second, 50*time.Second)}}),
+			},
+			want: jobLatencies{Scheduling: 20 * time.Second, Execution: 30 * time.Second},
+		},
+		{
+			name: "container still running",
+			pods: []corev1.Pod{
+				makeJobPod("pod-1", 0, corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}),
+			},
+			want: jobLatencies{},
+		},
+		{
+			name: "last of several pods",
+			pods: []corev1.Pod{
+				makeJobPod("pod-2", time.Minute, corev1.ContainerStatus{State: corev1.ContainerState{Terminated: terminatedState(70*time.Second, 80*time.Second)}}),
+				makeJobPod("pod-1", 0, corev1.ContainerStatus{State: corev1.ContainerState{Terminated: terminatedState(30*time.Second, 40*time.Second)}}),
+			},
+			want: jobLatencies{Scheduling: 10 * time.Second, Execution: 10 * time.Second, Restarts: 1},
+		},
+		{
+			name: "container restarted in place",
+			pods: []corev1.Pod{
+				makeJobPod("pod-1", 0, corev1.ContainerStatus{
+					RestartCount:         2,
+					LastTerminationState: corev1.ContainerState{Terminated: terminatedState(30*time.Second, 40*time.Second)},
+					State:                corev1.ContainerState{Terminated: terminatedState(45*time.Second, 60*time.Second)},
+				}),
+			},
+			want: jobLatencies{Scheduling: 5 * time.Second, Execution: 15 * time.Second, Restarts: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, getJobLatencies(tt.pods))
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_UpdateJobSetsLatencies(t *testing.T) {
+	task := makeTask()
+	task.Status.JobName = "my-job"
+	task.Status.Status = common.StateProgressing
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-job"},
+		Status:     batchv1.JobStatus{Succeeded: 1},
+	}
+	pod := makeJobPod("pod-1", 0, corev1.ContainerStatus{State: corev1.ContainerState{Terminated: terminatedState(20*time.Second, 50*time.Second)}})
+	r := newJobTestReconciler(t, task, job, &pod)
+
+	err := r.updateJob(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}}, task)
+	require.Nil(t, err)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.Equal(t, common.StateSucceeded, result.Status.Status)
+	require.Equal(t, 20*time.Second, result.Status.SchedulingDuration.Duration)
+	require.Equal(t, 30*time.Second, result.Status.ExecutionDuration.Duration)
+	require.Zero(t, result.Status.Restarts)
+}
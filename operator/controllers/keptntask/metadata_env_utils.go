@@ -0,0 +1,77 @@
+package keptntask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var errEnvFromMetadata = errors.New("could not resolve environment variables from metadata")
+
+// reservedEnvNames are set by the operator for the function runner and cannot be overridden
+var reservedEnvNames = map[string]bool{
+	"DATA":        true,
+	"CONTEXT":     true,
+	"SECURE_DATA": true,
+	"SCRIPT":      true,
+}
+
+// getTaskOwnerMetadata returns the metadata of the KeptnWorkloadInstance or KeptnAppVersion the task has been created for
+func (r *KeptnTaskReconciler) getTaskOwnerMetadata(ctx context.Context, task *klcv1alpha1.KeptnTask) (metav1.Object, error) {
+	owner := metav1.GetControllerOf(task)
+	if owner == nil {
+		return &metav1.ObjectMeta{}, nil
+	}
+
+	var obj client.Object
+	switch owner.Kind {
+	case "KeptnWorkloadInstance":
+		obj = &klcv1alpha1.KeptnWorkloadInstance{}
+	case "KeptnAppVersion":
+		obj = &klcv1alpha1.KeptnAppVersion{}
+	default:
+		return &metav1.ObjectMeta{}, nil
+	}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: task.Namespace, Name: owner.Name}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// resolveEnvFromMetadata maps the labels and annotations of the given object to environment variables.
+// Missing keys resolve to an empty value, unless the entry is required.
+func resolveEnvFromMetadata(entries []klcv1alpha1.EnvFromMetadata, obj metav1.Object) ([]corev1.EnvVar, error) {
+	envVars := make([]corev1.EnvVar, 0, len(entries))
+	for _, entry := range entries {
+		if errs := validation.IsEnvVarName(entry.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("%w: invalid environment variable name %s: %v", errEnvFromMetadata, entry.Name, errs)
+		}
+		if reservedEnvNames[entry.Name] {
+			return nil, fmt.Errorf("%w: environment variable name %s is reserved", errEnvFromMetadata, entry.Name)
+		}
+
+		var source map[string]string
+		switch entry.Source {
+		case klcv1alpha1.MetadataSourceLabel:
+			source = obj.GetLabels()
+		case klcv1alpha1.MetadataSourceAnnotation:
+			source = obj.GetAnnotations()
+		default:
+			return nil, fmt.Errorf("%w: unknown source %s for environment variable %s", errEnvFromMetadata, entry.Source, entry.Name)
+		}
+
+		value, ok := source[entry.Key]
+		if !ok && entry.Required {
+			return nil, fmt.Errorf("%w: required %s %s is missing", errEnvFromMetadata, entry.Source, entry.Key)
+		}
+		envVars = append(envVars, corev1.EnvVar{Name: entry.Name, Value: value})
+	}
+	return envVars, nil
+}
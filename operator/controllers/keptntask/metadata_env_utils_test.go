@@ -0,0 +1,70 @@
+package keptntask
+
+import (
+	"errors"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveEnvFromMetadata(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Labels:      map[string]string{"team": "my-team"},
+		Annotations: map[string]string{"example.com/owner": "me"},
+	}
+
+	tests := []struct {
+		name    string
+		entries []klcv1alpha1.EnvFromMetadata
+		want    []corev1.EnvVar
+		wantErr bool
+	}{
+		{
+			name: "label and annotation",
+			entries: []klcv1alpha1.EnvFromMetadata{
+				{Name: "TEAM", Source: klcv1alpha1.MetadataSourceLabel, Key: "team"},
+				{Name: "OWNER", Source: klcv1alpha1.MetadataSourceAnnotation, Key: "example.com/owner", Required: true},
+			},
+			want: []corev1.EnvVar{{Name: "TEAM", Value: "my-team"}, {Name: "OWNER", Value: "me"}},
+		},
+		{
+			name:    "missing optional key",
+			entries: []klcv1alpha1.EnvFromMetadata{{Name: "MISSING", Source: klcv1alpha1.MetadataSourceLabel, Key: "missing"}},
+			want:    []corev1.EnvVar{{Name: "MISSING", Value: ""}},
+		},
+		{
+			name:    "missing required key",
+			entries: []klcv1alpha1.EnvFromMetadata{{Name: "MISSING", Source: klcv1alpha1.MetadataSourceLabel, Key: "missing", Required: true}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			entries: []klcv1alpha1.EnvFromMetadata{{Name: "1=INVALID", Source: klcv1alpha1.MetadataSourceLabel, Key: "team"}},
+			wantErr: true,
+		},
+		{
+			name:    "reserved name",
+			entries: []klcv1alpha1.EnvFromMetadata{{Name: "SECURE_DATA", Source: klcv1alpha1.MetadataSourceLabel, Key: "team"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown source",
+			entries: []klcv1alpha1.EnvFromMetadata{{Name: "TEAM", Source: "spec", Key: "team"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveEnvFromMetadata(tt.entries, obj)
+			if tt.wantErr {
+				require.True(t, errors.Is(err, errEnvFromMetadata))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package keptntask
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultQueueLabel is the label Kueue reads the name of the LocalQueue of a Job from
+	DefaultQueueLabel = "kueue.x-k8s.io/queue-name"
+	// DefaultQueueTimeout is the time a Job may wait to be admitted by its queue if no timeout has been configured
+	DefaultQueueTimeout = time.Hour
+
+	// QueuedBySchedulerReason is the reason of a pending task whose Job waits to be admitted by its queue
+	QueuedBySchedulerReason = "QueuedByScheduler"
+	// QueueTimeoutExceededReason is the reason of a task whose Job has not been admitted by its queue in time
+	QueueTimeoutExceededReason = "QueueTimeoutExceeded"
+
+	// queueTimeoutAnnotation keeps the queue timeout of the task on its Job, so that it is known for as long as the
+	// Job is waiting, even if the KeptnTaskDefinition changes in the meantime
+	queueTimeoutAnnotation = "keptn.sh/queue-timeout"
+)
+
+// QueueConfig submits the Jobs of tasks to a queueing system such as Kueue. The queue of a KeptnTaskDefinition
+// takes precedence over the operator-wide defaults.
+type QueueConfig struct {
+	// Name is the queue of tasks whose definition does not set one. If empty, their Jobs run right away.
+	Name string
+	// Label is the label of the Job that holds the name of its queue, DefaultQueueLabel if empty
+	Label string
+	// Timeout is the time a Job may wait to be admitted if the definition of its task does not set one,
+	// DefaultQueueTimeout if 0
+	Timeout time.Duration
+}
+
+// getQueue returns the queue the Jobs of the task definition are submitted to and the time they may wait to be
+// admitted. An empty name means that the Jobs run right away.
+func (c QueueConfig) getQueue(definition *klcv1alpha1.KeptnTaskDefinition) (string, time.Duration) {
+	name, timeout := c.Name, c.Timeout
+	if definition.Spec.Queue != nil {
+		name = definition.Spec.Queue.Name
+		if definition.Spec.Queue.Timeout != nil {
+			timeout = definition.Spec.Queue.Timeout.Duration
+		}
+	}
+	if timeout <= 0 {
+		timeout = DefaultQueueTimeout
+	}
+	return name, timeout
+}
+
+func (c QueueConfig) getLabel() string {
+	if c.Label == "" {
+		return DefaultQueueLabel
+	}
+	return c.Label
+}
+
+// queueJob suspends the Job and labels it with the name of its queue, so that the queueing system admits it by
+// resuming it. Jobs of tasks without a queue are left untouched.
+func (c QueueConfig) queueJob(job *batchv1.Job, definition *klcv1alpha1.KeptnTaskDefinition) {
+	name, timeout := c.getQueue(definition)
+	if name == "" {
+		return
+	}
+	job.Spec.Suspend = pointer.Bool(true)
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[c.getLabel()] = name
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[queueTimeoutAnnotation] = timeout.String()
+}
+
+// isJobQueued returns true while the Job has not been admitted by its queue yet
+func isJobQueued(job *batchv1.Job) bool {
+	return job.Spec.Suspend != nil && *job.Spec.Suspend
+}
+
+// handleQueuedJob keeps the task pending while its Job waits to be admitted by its queue. Once the queue timeout has
+// passed, the Job is deleted, so that it is not admitted anymore, and the task fails.
+func (r *KeptnTaskReconciler) handleQueuedJob(ctx context.Context, task *klcv1alpha1.KeptnTask, job *batchv1.Job) error {
+	queue := job.Labels[r.Queue.getLabel()]
+	timeout, err := time.ParseDuration(job.Annotations[queueTimeoutAnnotation])
+	if err != nil || timeout <= 0 {
+		timeout = DefaultQueueTimeout
+	}
+
+	if common.Since(job.CreationTimestamp) < timeout {
+		task.Status.Status = common.StatePending
+		if task.Status.Reason != QueuedBySchedulerReason {
+			r.Recorder.Event(task, "Normal", QueuedBySchedulerReason, fmt.Sprintf("Job %s waits to be admitted by queue %s / Namespace: %s, Name: %s ", job.Name, queue, task.Namespace, task.Name))
+		}
+		task.Status.Reason = QueuedBySchedulerReason
+		task.Status.Message = fmt.Sprintf("Job %s waits to be admitted by queue %s", job.Name, queue)
+		return nil
+	}
+
+	if err := r.Client.Delete(ctx, job, client.PropagationPolicy("Background")); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	task.Status.Status = common.StateFailed
+	task.Status.Reason = QueueTimeoutExceededReason
+	task.Status.Message = fmt.Sprintf("Job %s has not been admitted by queue %s within %s", job.Name, queue, timeout.String())
+	r.Recorder.Event(task, "Warning", QueueTimeoutExceededReason, fmt.Sprintf("%s / Namespace: %s, Name: %s ", task.Status.Message, task.Namespace, task.Name))
+	return nil
+}
@@ -0,0 +1,124 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestQueueConfig_getQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      QueueConfig
+		queue       *klcv1alpha1.TaskQueue
+		wantName    string
+		wantTimeout time.Duration
+	}{
+		{
+			name:        "no queue",
+			wantTimeout: DefaultQueueTimeout,
+		},
+		{
+			name:        "operator default",
+			config:      QueueConfig{Name: "checks", Timeout: 10 * time.Minute},
+			wantName:    "checks",
+			wantTimeout: 10 * time.Minute,
+		},
+		{
+			name:        "queue of the definition",
+			config:      QueueConfig{Name: "checks", Timeout: 10 * time.Minute},
+			queue:       &klcv1alpha1.TaskQueue{Name: "load-tests", Timeout: &metav1.Duration{Duration: 2 * time.Hour}},
+			wantName:    "load-tests",
+			wantTimeout: 2 * time.Hour,
+		},
+		{
+			name:        "queue of the definition without timeout",
+			config:      QueueConfig{Timeout: 10 * time.Minute},
+			queue:       &klcv1alpha1.TaskQueue{Name: "load-tests"},
+			wantName:    "load-tests",
+			wantTimeout: 10 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := &klcv1alpha1.KeptnTaskDefinition{Spec: klcv1alpha1.KeptnTaskDefinitionSpec{Queue: tt.queue}}
+			name, timeout := tt.config.getQueue(definition)
+			require.Equal(t, tt.wantName, name)
+			require.Equal(t, tt.wantTimeout, timeout)
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_QueuedJob(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+	r.Queue = QueueConfig{Name: "checks"}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}}
+
+	_, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	require.Equal(t, pointer.Bool(true), job.Spec.Suspend)
+	require.Equal(t, "checks", job.Labels[DefaultQueueLabel])
+
+	// the task remains pending while the Job waits to be admitted
+	_, err = r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, task))
+	require.Equal(t, common.StatePending, task.Status.Status)
+	require.Equal(t, QueuedBySchedulerReason, task.Status.Reason)
+
+	// once the queue has admitted the Job, the task is running
+	job.Spec.Suspend = pointer.Bool(false)
+	require.Nil(t, r.Client.Update(context.TODO(), job))
+	_, err = r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, task))
+	require.Equal(t, common.StateProgressing, task.Status.Status)
+	require.Empty(t, task.Status.Reason)
+}
+
+func TestKeptnTaskReconciler_QueuedJobTimeout(t *testing.T) {
+	task := makeTask()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "my-job",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			Labels:            map[string]string{DefaultQueueLabel: "checks"},
+			Annotations:       map[string]string{queueTimeoutAnnotation: "30m0s"},
+		},
+		Spec: batchv1.JobSpec{Suspend: pointer.Bool(true)},
+	}
+	r := newJobTestReconciler(t, task, job)
+
+	require.Nil(t, r.handleQueuedJob(context.TODO(), task, job))
+	require.Equal(t, common.StateFailed, task.Status.Status)
+	require.Equal(t, QueueTimeoutExceededReason, task.Status.Reason)
+	require.Equal(t, "Job my-job has not been admitted by queue checks within 30m0s", task.Status.Message)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-job"}, &batchv1.Job{})
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestKeptnTaskReconciler_JobWithoutQueue(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+	job := &batchv1.Job{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: getJobName(task)}, job))
+	require.Nil(t, job.Spec.Suspend)
+	require.NotContains(t, job.Labels, DefaultQueueLabel)
+}
@@ -0,0 +1,190 @@
+package keptntask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// QuotaInsufficientReason is the reason of the event of a task whose Job does not fit into the quota of its namespace
+const QuotaInsufficientReason = "QuotaInsufficient"
+
+// quotaRetryInterval is the time a task that waits for quota checks the quota of its namespace again after
+const quotaRetryInterval = 30 * time.Second
+
+var errQuotaInsufficient = errors.New("job does not fit into the remaining quota")
+
+func isQuotaInsufficient(err error) bool {
+	return errors.Is(err, errQuotaInsufficient)
+}
+
+// checkQuota compares what the pod of the Job requests against the remaining quota of the ResourceQuotas of its
+// namespace, as reported in their status. It returns an error naming the resource and the amounts if the Job clearly
+// cannot be admitted. Compute resources the pod does not request are not checked, since a LimitRange may set defaults
+// for them. Namespaces without ResourceQuotas are not checked at all, and ResourceQuotas whose scopes do not select the
+// pod of the Job are skipped.
+func (r *KeptnTaskReconciler) checkQuota(ctx context.Context, job *batchv1.Job) error {
+	quotas := &corev1.ResourceQuotaList{}
+	if err := r.Client.List(ctx, quotas, client.InNamespace(job.Namespace)); err != nil {
+		return fmt.Errorf("could not retrieve resource quotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+
+	requests := getJobQuotaUsage(job)
+	for _, quota := range quotas.Items {
+		if !quotaSelectsPod(quota, &job.Spec.Template.Spec) {
+			continue
+		}
+		names := make([]string, 0, len(quota.Status.Hard))
+		for name := range quota.Status.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			requested, ok := requests[corev1.ResourceName(name)]
+			if !ok {
+				continue
+			}
+			hard := quota.Status.Hard[corev1.ResourceName(name)]
+			remaining := hard.DeepCopy()
+			remaining.Sub(quota.Status.Used[corev1.ResourceName(name)])
+			if requested.Cmp(remaining) > 0 {
+				return fmt.Errorf("%w: %s of ResourceQuota %s: requested %s, remaining %s of %s", errQuotaInsufficient, name, quota.Name, requested.String(), remaining.String(), hard.String())
+			}
+		}
+	}
+	return nil
+}
+
+// getJobQuotaUsage returns what the Job and its single pod count against the quota of the namespace
+func getJobQuotaUsage(job *batchv1.Job) corev1.ResourceList {
+	usage := corev1.ResourceList{
+		corev1.ResourcePods:                     resource.MustParse("1"),
+		corev1.ResourceName("count/pods"):       resource.MustParse("1"),
+		corev1.ResourceName("count/jobs.batch"): resource.MustParse("1"),
+	}
+	add := func(name corev1.ResourceName, quantity resource.Quantity) {
+		sum := usage[name]
+		sum.Add(quantity)
+		usage[name] = sum
+	}
+	for _, container := range job.Spec.Template.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			add(name, quantity)
+			add(corev1.ResourceName("requests."+string(name)), quantity)
+		}
+		for name, quantity := range container.Resources.Limits {
+			add(corev1.ResourceName("limits."+string(name)), quantity)
+		}
+	}
+	return usage
+}
+
+// quotaSelectsPod returns true if the pod matches all scopes of the ResourceQuota, given either as scopes or as
+// scope selector. A ResourceQuota without scopes selects every pod.
+func quotaSelectsPod(quota corev1.ResourceQuota, pod *corev1.PodSpec) bool {
+	requirements := make([]corev1.ScopedResourceSelectorRequirement, 0, len(quota.Spec.Scopes))
+	for _, scope := range quota.Spec.Scopes {
+		requirements = append(requirements, corev1.ScopedResourceSelectorRequirement{ScopeName: scope, Operator: corev1.ScopeSelectorOpExists})
+	}
+	if quota.Spec.ScopeSelector != nil {
+		requirements = append(requirements, quota.Spec.ScopeSelector.MatchExpressions...)
+	}
+	for _, requirement := range requirements {
+		if !podMatchesScope(requirement, pod) {
+			return false
+		}
+	}
+	return true
+}
+
+// podMatchesScope evaluates a single scope the way the quota admission of the API server does
+func podMatchesScope(requirement corev1.ScopedResourceSelectorRequirement, pod *corev1.PodSpec) bool {
+	switch requirement.ScopeName {
+	case corev1.ResourceQuotaScopeTerminating:
+		return isTerminating(pod)
+	case corev1.ResourceQuotaScopeNotTerminating:
+		return !isTerminating(pod)
+	case corev1.ResourceQuotaScopeBestEffort:
+		return isBestEffort(pod)
+	case corev1.ResourceQuotaScopeNotBestEffort:
+		return !isBestEffort(pod)
+	case corev1.ResourceQuotaScopePriorityClass:
+		switch requirement.Operator {
+		case corev1.ScopeSelectorOpIn:
+			return containsString(requirement.Values, pod.PriorityClassName)
+		case corev1.ScopeSelectorOpNotIn:
+			return !containsString(requirement.Values, pod.PriorityClassName)
+		case corev1.ScopeSelectorOpExists:
+			return pod.PriorityClassName != ""
+		case corev1.ScopeSelectorOpDoesNotExist:
+			return pod.PriorityClassName == ""
+		}
+	case corev1.ResourceQuotaScopeCrossNamespacePodAffinity:
+		return hasCrossNamespacePodAffinity(pod)
+	}
+	return false
+}
+
+func isTerminating(pod *corev1.PodSpec) bool {
+	return pod.ActiveDeadlineSeconds != nil && *pod.ActiveDeadlineSeconds >= 0
+}
+
+// isBestEffort returns true if no container of the pod requests or limits cpu or memory
+func isBestEffort(pod *corev1.PodSpec) bool {
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for _, container := range containers {
+			for _, list := range []corev1.ResourceList{container.Resources.Requests, container.Resources.Limits} {
+				for name := range list {
+					if name == corev1.ResourceCPU || name == corev1.ResourceMemory {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func hasCrossNamespacePodAffinity(pod *corev1.PodSpec) bool {
+	if pod.Affinity == nil {
+		return false
+	}
+	var terms []corev1.PodAffinityTerm
+	if affinity := pod.Affinity.PodAffinity; affinity != nil {
+		terms = append(terms, affinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			terms = append(terms, term.PodAffinityTerm)
+		}
+	}
+	if antiAffinity := pod.Affinity.PodAntiAffinity; antiAffinity != nil {
+		terms = append(terms, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		for _, term := range antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			terms = append(terms, term.PodAffinityTerm)
+		}
+	}
+	for _, term := range terms {
+		if len(term.Namespaces) > 0 || term.NamespaceSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func makeQuota(resourceName string, hard string, used string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-quota"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceName(resourceName): resource.MustParse(hard)},
+			Used: corev1.ResourceList{corev1.ResourceName(resourceName): resource.MustParse(used)},
+		},
+	}
+}
+
+func makeScopedQuota(resourceName string, hard string, used string, scopes []corev1.ResourceQuotaScope, selector ...corev1.ScopedResourceSelectorRequirement) *corev1.ResourceQuota {
+	quota := makeQuota(resourceName, hard, used)
+	quota.Spec.Scopes = scopes
+	if len(selector) > 0 {
+		quota.Spec.ScopeSelector = &corev1.ScopeSelector{MatchExpressions: selector}
+	}
+	return quota
+}
+
+func TestKeptnTaskReconciler_checkQuota(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-job"}}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name: "keptn-function-runner",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		quota   *corev1.ResourceQuota
+		wantErr string
+	}{
+		{
+			name: "no quota",
+		},
+		{
+			name:  "fits",
+			quota: makeQuota("pods", "10", "9"),
+		},
+		{
+			name:    "no pods left",
+			quota:   makeQuota("pods", "10", "10"),
+			wantErr: "pods of ResourceQuota my-quota: requested 1, remaining 0 of 10",
+		},
+		{
+			name:    "not enough memory left",
+			quota:   makeQuota("requests.memory", "1Gi", "960Mi"),
+			wantErr: "requests.memory of ResourceQuota my-quota: requested 128Mi, remaining 64Mi of 1Gi",
+		},
+		{
+			// a LimitRange may set a default for resources the pod does not request
+			name:  "not requested",
+			quota: makeQuota("requests.cpu", "1", "1"),
+		},
+		{
+			// the pod requests memory, so it is not BestEffort
+			name:  "best effort scope does not select the pod",
+			quota: makeScopedQuota("pods", "10", "10", []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}),
+		},
+		{
+			name:    "not best effort scope selects the pod",
+			quota:   makeScopedQuota("pods", "10", "10", []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotBestEffort, corev1.ResourceQuotaScopeNotTerminating}),
+			wantErr: "pods of ResourceQuota my-quota: requested 1, remaining 0 of 10",
+		},
+		{
+			name: "priority class scope does not select the pod",
+			quota: makeScopedQuota("pods", "10", "10", nil, corev1.ScopedResourceSelectorRequirement{
+				ScopeName: corev1.ResourceQuotaScopePriorityClass,
+				Operator:  corev1.ScopeSelectorOpIn,
+				Values:    []string{"high"},
+			}),
+		},
+		{
+			name: "priority class scope selects the pod",
+			quota: makeScopedQuota("pods", "10", "10", nil, corev1.ScopedResourceSelectorRequirement{
+				ScopeName: corev1.ResourceQuotaScopePriorityClass,
+				Operator:  corev1.ScopeSelectorOpDoesNotExist,
+			}),
+			wantErr: "pods of ResourceQuota my-quota: requested 1, remaining 0 of 10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newJobTestReconciler(t)
+			if tt.quota != nil {
+				require.Nil(t, r.Client.Create(context.TODO(), tt.quota))
+			}
+			err := r.checkQuota(context.TODO(), job)
+			if tt.wantErr == "" {
+				require.Nil(t, err)
+				return
+			}
+			require.True(t, isQuotaInsufficient(err))
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestKeptnTaskReconciler_QuotaInsufficient(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task, makeQuota("count/jobs.batch", "5", "5"))
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.Equal(t, common.StateFailed, result.Status.Status)
+	require.Empty(t, result.Status.JobName)
+	require.Contains(t, <-r.Recorder.(*record.FakeRecorder).Events, "Warning QuotaInsufficient Could not create Job: job does not fit into the remaining quota: count/jobs.batch")
+
+	jobs := &batchv1.JobList{}
+	require.Nil(t, r.Client.List(context.TODO(), jobs))
+	require.Empty(t, jobs.Items)
+}
+
+func TestKeptnTaskReconciler_WaitForQuota(t *testing.T) {
+	task := makeTask()
+	task.Spec.WaitForQuota = true
+	quota := makeQuota("count/jobs.batch", "5", "5")
+	r := newJobTestReconciler(t, task, quota)
+
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+	require.Equal(t, quotaRetryInterval, res.RequeueAfter)
+
+	result := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task"}, result))
+	require.False(t, result.Status.Status.IsCompleted())
+
+	// the Job is created once quota has been freed
+	quota.Status.Used[corev1.ResourceName("count/jobs.batch")] = resource.MustParse("4")
+	require.Nil(t, r.Client.Status().Update(context.TODO(), quota))
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}})
+	require.Nil(t, err)
+
+	jobs := &batchv1.JobList{}
+	require.Nil(t, r.Client.List(context.TODO(), jobs))
+	require.Len(t, jobs.Items, 1)
+}
@@ -0,0 +1,77 @@
+package keptntask
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// createApiAccess creates a ServiceAccount for the given task and binds the requested ClusterRole to it in the
+// namespace of the task. Both objects are owned by the task and are therefore removed together with it.
+// A KeptnTaskDefinition of another namespace is bound in the namespace of the task as well, so that its Job never
+// gains access to the namespace providing the definition.
+func (r *KeptnTaskReconciler) createApiAccess(ctx context.Context, task *klcv1alpha1.KeptnTask, clusterRole string) (string, error) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+			Labels:    createKeptnLabels(*task),
+		},
+	}
+	if err := r.createOwnedObject(ctx, task, serviceAccount); err != nil {
+		return "", fmt.Errorf("could not create service account: %w", err)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      task.Name,
+			Namespace: task.Namespace,
+			Labels:    createKeptnLabels(*task),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      serviceAccount.Name,
+				Namespace: serviceAccount.Namespace,
+			},
+		},
+	}
+	if err := r.createOwnedObject(ctx, task, roleBinding); err != nil {
+		return "", fmt.Errorf("could not create role binding: %w", err)
+	}
+
+	return serviceAccount.Name, nil
+}
+
+// createOwnedObject creates an object controlled by the task. An object of the same name that already exists is only
+// accepted if it is controlled by the task as well, e.g. after a restart of the operator. Otherwise a ServiceAccount or
+// RoleBinding prepared by someone else could be used by the Job of the task.
+func (r *KeptnTaskReconciler) createOwnedObject(ctx context.Context, task *klcv1alpha1.KeptnTask, obj client.Object) error {
+	if err := controllerutil.SetControllerReference(task, obj, r.Scheme); err != nil {
+		return fmt.Errorf("could not set controller reference: %w", err)
+	}
+	err := r.Client.Create(ctx, obj)
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(existing, task) {
+		return fmt.Errorf("%s already exists and is not controlled by the task", existing.GetName())
+	}
+	return nil
+}
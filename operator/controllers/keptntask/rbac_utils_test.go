@@ -0,0 +1,58 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKeptnTaskReconciler_CreateApiAccess(t *testing.T) {
+	task := makeTask()
+	r := newJobTestReconciler(t, task)
+
+	serviceAccountName, err := r.createApiAccess(context.TODO(), task, "view")
+	require.Nil(t, err)
+	require.Equal(t, task.Name, serviceAccountName)
+
+	serviceAccount := &corev1.ServiceAccount{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: task.Name}, serviceAccount))
+	require.True(t, metav1.IsControlledBy(serviceAccount, task))
+
+	roleBinding := &rbacv1.RoleBinding{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: task.Name}, roleBinding))
+	require.True(t, metav1.IsControlledBy(roleBinding, task))
+	require.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"}, roleBinding.RoleRef)
+	require.Equal(t, []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: task.Name, Namespace: "default"}}, roleBinding.Subjects)
+
+	// the objects of the task are adopted after a restart of the operator
+	serviceAccountName, err = r.createApiAccess(context.TODO(), task, "view")
+	require.Nil(t, err)
+	require.Equal(t, task.Name, serviceAccountName)
+}
+
+func TestKeptnTaskReconciler_CreateApiAccessNotOwned(t *testing.T) {
+	task := makeTask()
+	// a ServiceAccount of the same name, e.g. with a token mounted by someone else, must not be used by the Job
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: task.Name}}
+	r := newJobTestReconciler(t, task, serviceAccount)
+
+	_, err := r.createApiAccess(context.TODO(), task, "view")
+	require.ErrorContains(t, err, "my-task already exists and is not controlled by the task")
+	require.NotNil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: task.Name}, &rbacv1.RoleBinding{}))
+
+	// the same holds for a RoleBinding that binds another ClusterRole
+	task = makeTask()
+	task.Name = "other-task"
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: task.Name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+	r = newJobTestReconciler(t, task, roleBinding)
+	_, err = r.createApiAccess(context.TODO(), task, "view")
+	require.ErrorContains(t, err, "other-task already exists and is not controlled by the task")
+}
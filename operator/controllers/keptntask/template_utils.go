@@ -0,0 +1,108 @@
+package keptntask
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxRenderedParameterLength limits the size of a single rendered parameter value
+const maxRenderedParameterLength = 4096
+
+var errParameterRendering = errors.New("could not render task parameters")
+
+// TaskTemplateData is the data parameter values of a task are rendered against, e.g. {{ .Workload }}
+type TaskTemplateData struct {
+	Workload    string
+	Version     string
+	Namespace   string
+	App         string
+	Annotations map[string]string
+}
+
+// templateFuncs replaces the formatting builtins of text/template, since these allow to allocate arbitrary amounts
+// of memory with a single expression (e.g. {{ printf "%999999999d" 0 }}). text/template does not offer any
+// functions to access files or to execute commands, so these are the only builtins that need to be restricted.
+var templateFuncs = template.FuncMap{
+	"print":   forbiddenTemplateFunc("print"),
+	"printf":  forbiddenTemplateFunc("printf"),
+	"println": forbiddenTemplateFunc("println"),
+}
+
+func forbiddenTemplateFunc(name string) func(...interface{}) (string, error) {
+	return func(...interface{}) (string, error) {
+		return "", fmt.Errorf("function %s is not allowed in task parameters", name)
+	}
+}
+
+// newTaskTemplateData returns the template data of a task. The annotations are taken from the
+// KeptnWorkloadInstance or KeptnAppVersion the task runs for, since the task itself only carries the trace context.
+func newTaskTemplateData(task *klcv1alpha1.KeptnTask, owner metav1.Object) TaskTemplateData {
+	data := TaskTemplateData{
+		Workload:    task.Spec.Workload,
+		Version:     task.Spec.WorkloadVersion,
+		Namespace:   task.Namespace,
+		App:         task.Spec.AppName,
+		Annotations: owner.GetAnnotations(),
+	}
+	if task.Spec.Workload == "" {
+		data.Version = task.Spec.AppVersion
+	}
+	if data.Annotations == nil {
+		data.Annotations = map[string]string{}
+	}
+	return data
+}
+
+// hasTemplates returns true if any of the parameter values contains a template expression
+func hasTemplates(parameters map[string]string) bool {
+	for _, value := range parameters {
+		if strings.Contains(value, "{{") {
+			return true
+		}
+	}
+	return false
+}
+
+// renderParameters renders all parameter values containing a template expression against the given data.
+// Rendering is strict: references to unknown fields or missing annotations fail instead of rendering as empty values.
+// A literal "{{" can be produced with {{ "{{" }}.
+func renderParameters(parameters map[string]string, data TaskTemplateData) (map[string]string, error) {
+	if len(parameters) == 0 {
+		return parameters, nil
+	}
+	rendered := make(map[string]string, len(parameters))
+	for key, value := range parameters {
+		if !strings.Contains(value, "{{") {
+			rendered[key] = value
+			continue
+		}
+		tmpl, err := template.New(key).Funcs(templateFuncs).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %s: %v", errParameterRendering, key, err)
+		}
+		out := &limitedBuffer{max: maxRenderedParameterLength}
+		if err := tmpl.Execute(out, data); err != nil {
+			return nil, fmt.Errorf("%w: parameter %s: %v", errParameterRendering, key, err)
+		}
+		rendered[key] = out.String()
+	}
+	return rendered, nil
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, fmt.Errorf("rendered value exceeds %d bytes", b.max)
+	}
+	return b.Buffer.Write(p)
+}
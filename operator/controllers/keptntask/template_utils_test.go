@@ -0,0 +1,71 @@
+package keptntask
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderParameters(t *testing.T) {
+	data := newTaskTemplateData(&klcv1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "my-namespace",
+			Annotations: map[string]string{"traceparent": "00-0-0-01"},
+		},
+		Spec: klcv1alpha1.KeptnTaskSpec{
+			Workload:        "my-app-my-workload",
+			WorkloadVersion: "1.0.0",
+			AppName:         "my-app",
+		},
+	}, &metav1.ObjectMeta{Annotations: map[string]string{"foo": "bar"}})
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain value", value: "http://example.com", want: "http://example.com"},
+		{name: "single braces", value: "{not a template}", want: "{not a template}"},
+		{name: "all fields", value: "{{ .App }}/{{ .Workload }}/{{ .Version }}/{{ .Namespace }}", want: "my-app/my-app-my-workload/1.0.0/my-namespace"},
+		{name: "annotation", value: "{{ .Annotations.foo }}", want: "bar"},
+		{name: "escaped braces", value: `{{ "{{" }} .Version }}`, want: "{{ .Version }}"},
+		{name: "unknown field", value: "{{ .Unknown }}", wantErr: true},
+		{name: "missing annotation", value: "{{ .Annotations.missing }}", wantErr: true},
+		{name: "annotation of the task", value: "{{ .Annotations.traceparent }}", wantErr: true},
+		{name: "syntax error", value: "{{ .Version ", wantErr: true},
+		{name: "undefined function", value: `{{ exec "rm -rf /" }}`, wantErr: true},
+		{name: "readFile is not available", value: `{{ readFile "/etc/passwd" }}`, wantErr: true},
+		{name: "env is not available", value: `{{ env "HOME" }}`, wantErr: true},
+		{name: "call without function", value: "{{ call .Version }}", wantErr: true},
+		{name: "printf is not allowed", value: `{{ printf "%999999999d" 0 }}`, wantErr: true},
+		{name: "print is not allowed", value: `{{ print .Version }}`, wantErr: true},
+		{name: "output is limited", value: `{{ "` + strings.Repeat("a", maxRenderedParameterLength+1) + `" }}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderParameters(map[string]string{"param": tt.value}, data)
+			if tt.wantErr {
+				require.True(t, errors.Is(err, errParameterRendering))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got["param"])
+		})
+	}
+}
+
+func TestNewTaskTemplateData_AppTask(t *testing.T) {
+	data := newTaskTemplateData(&klcv1alpha1.KeptnTask{
+		Spec: klcv1alpha1.KeptnTaskSpec{
+			AppName:    "my-app",
+			AppVersion: "2.0.0",
+		},
+	}, &metav1.ObjectMeta{})
+	require.Equal(t, "2.0.0", data.Version)
+	require.NotNil(t, data.Annotations)
+}
@@ -0,0 +1,40 @@
+package keptntask
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// injectTraceContext passes the trace context of the task on to its Job, so that the checks can continue the trace of
+// the workload instance. The Job and its pods carry it in annotations such as traceparent, and the containers in
+// environment variables such as TRACEPARENT.
+func injectTraceContext(ctx context.Context, job *batchv1.Job) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	if job.Spec.Template.Annotations == nil {
+		job.Spec.Template.Annotations = map[string]string{}
+	}
+	envVars := make([]corev1.EnvVar, 0, len(carrier))
+	for _, key := range carrier.Keys() {
+		job.Annotations[key] = carrier[key]
+		job.Spec.Template.Annotations[key] = carrier[key]
+		envVars = append(envVars, corev1.EnvVar{Name: strings.ToUpper(key), Value: carrier[key]})
+	}
+	sort.Slice(envVars, func(i, j int) bool {
+		return envVars[i].Name < envVars[j].Name
+	})
+	injectContextEnv(&job.Spec.Template.Spec, envVars)
+}
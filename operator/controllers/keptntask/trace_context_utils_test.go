@@ -0,0 +1,83 @@
+package keptntask
+
+import (
+	"context"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestInjectTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "keptn-function-runner"}}
+	injectTraceContext(ctx, job)
+
+	require.Equal(t, traceparent, job.Annotations["traceparent"])
+	require.Equal(t, traceparent, job.Spec.Template.Annotations["traceparent"])
+	require.Equal(t, []corev1.EnvVar{{Name: "TRACEPARENT", Value: traceparent}}, job.Spec.Template.Spec.Containers[0].Env)
+
+	// without a trace, nothing is injected
+	job = &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "keptn-function-runner"}}
+	injectTraceContext(context.TODO(), job)
+	require.Nil(t, job.Annotations)
+	require.Nil(t, job.Spec.Template.Spec.Containers[0].Env)
+}
+
+func TestKeptnTaskReconciler_SpansLinkToPhase(t *testing.T) {
+	phaseTraceparent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	task := makeTask()
+	// the task has been created in another trace than the one it is executed in
+	task.Spec.PhaseSpanContext = map[string]string{"traceparent": phaseTraceparent}
+	r := newJobTestReconciler(t, task)
+	exporter := tracetest.NewInMemoryExporter()
+	r.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-task"}}
+	_, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	_, err = r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+
+	var spans tracetest.SpanStubs
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "reconcile_task" {
+			spans = append(spans, span)
+		}
+	}
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		require.Len(t, span.Links, 1)
+		require.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.Links[0].SpanContext.TraceID().String())
+		require.Equal(t, "b7ad6b7169203331", span.Links[0].SpanContext.SpanID().String())
+		require.NotEqual(t, span.Links[0].SpanContext.TraceID(), span.SpanContext.TraceID())
+	}
+
+	// the span context of the first reconciliation is kept in the status, for the phase to link to
+	stored := &klcv1alpha1.KeptnTask{}
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, stored))
+	first := spans[0].SpanContext
+	require.Equal(t, "00-"+first.TraceID().String()+"-"+first.SpanID().String()+"-01", stored.Status.SpanContext["traceparent"])
+}
@@ -107,12 +107,7 @@ func (r *KeptnWorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{}, err
 		}
 		r.Recorder.Event(workload, "Normal", "WorkloadInstanceCreated", fmt.Sprintf("Created KeptnWorkloadInstance / Namespace: %s, Name: %s ", workloadInstance.Namespace, workloadInstance.Name))
-		workload.Status.CurrentVersion = workload.Spec.Version
-		if err := r.Client.Status().Update(ctx, workload); err != nil {
-			r.Log.Error(err, "could not update Current Version of Workload")
-			return ctrl.Result{}, err
-		}
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.updateCurrentInstance(ctx, workload, workloadInstance.Name)
 	}
 	if err != nil {
 		r.Log.Error(err, "could not get Workload Instance")
@@ -120,7 +115,22 @@ func (r *KeptnWorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// workloads whose instance has been created before the current instance was recorded are updated
+	return ctrl.Result{}, r.updateCurrentInstance(ctx, workload, workloadInstance.Name)
+}
+
+// updateCurrentInstance records the current version of the workload and the name of its KeptnWorkloadInstance
+func (r *KeptnWorkloadReconciler) updateCurrentInstance(ctx context.Context, workload *klcv1alpha1.KeptnWorkload, instanceName string) error {
+	if workload.Status.CurrentVersion == workload.Spec.Version && workload.Status.CurrentInstance == instanceName {
+		return nil
+	}
+	workload.Status.CurrentVersion = workload.Spec.Version
+	workload.Status.CurrentInstance = instanceName
+	if err := r.Client.Status().Update(ctx, workload); err != nil {
+		r.Log.Error(err, "could not update Current Version of Workload")
+		return err
+	}
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
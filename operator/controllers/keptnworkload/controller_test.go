@@ -0,0 +1,68 @@
+package keptnworkload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKeptnWorkloadReconciler_CreatesVersionedInstances(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	workload := &klcv1alpha1.KeptnWorkload{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload", UID: "workload-uid"},
+		Spec: klcv1alpha1.KeptnWorkloadSpec{
+			AppName:            "my-app",
+			Version:            "1.0.0",
+			PreDeploymentTasks: []string{"my-task"},
+			ResourceReference:  klcv1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+		},
+	}
+	recorder := record.NewFakeRecorder(100)
+	r := &KeptnWorkloadReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(workload).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+		Log:      logr.Discard(),
+		Tracer:   trace.NewNoopTracerProvider().Tracer("test"),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-app-my-workload"}}
+
+	_, err := r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	instance := &klcv1alpha1.KeptnWorkloadInstance{}
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app-my-workload-1.0.0"}, instance))
+	require.Equal(t, []string{"my-task"}, instance.Spec.PreDeploymentTasks)
+	require.True(t, metav1.IsControlledBy(instance, workload))
+	require.Contains(t, <-recorder.Events, "WorkloadInstanceCreated")
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, workload))
+	require.Equal(t, "1.0.0", workload.Status.CurrentVersion)
+	require.Equal(t, "my-app-my-workload-1.0.0", workload.Status.CurrentInstance)
+
+	// the instance of a version is created only once
+	_, err = r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	require.Empty(t, recorder.Events)
+
+	workload.Spec.Version = "2.0.0"
+	require.Nil(t, r.Client.Update(context.TODO(), workload))
+	_, err = r.Reconcile(context.TODO(), req)
+	require.Nil(t, err)
+	require.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app-my-workload-2.0.0"}, instance))
+	require.Equal(t, "1.0.0", instance.Spec.PreviousVersion)
+	require.Contains(t, <-recorder.Events, "WorkloadInstanceCreated")
+	require.Nil(t, r.Client.Get(context.TODO(), req.NamespacedName, workload))
+	require.Equal(t, "my-app-my-workload-2.0.0", workload.Status.CurrentInstance)
+}
@@ -0,0 +1,48 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordCheckOutcome counts the result and records the duration of a pre-deployment task or evaluation of the
+// instance that has just completed. Post-deployment checks are not recorded.
+func (r *KeptnWorkloadInstanceReconciler) recordCheckOutcome(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, checkType common.CheckType, state common.KeptnState, startTime metav1.Time, endTime metav1.Time) {
+	if checkType != common.PreDeploymentCheckType && checkType != common.PreDeploymentEvaluationCheckType {
+		return
+	}
+	attrs := workloadInstance.GetPreDeploymentMetricsAttributes()
+	r.Meters.PreDeploymentChecks.Add(ctx, 1, append(attrs, common.CheckResult.String(string(state)))...)
+	if !startTime.IsZero() && !endTime.IsZero() {
+		r.Meters.PreDeploymentCheckDuration.Record(ctx, common.Duration(startTime, endTime).Seconds(), attrs...)
+	}
+}
+
+// GetBlockedDeployments reports the workload instances whose pods are held back by the scheduler, since their
+// pre-deployment checks are still running
+func (r *KeptnWorkloadInstanceReconciler) GetBlockedDeployments(ctx context.Context) ([]common.GaugeValue, error) {
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	err := r.List(ctx, workloadInstances)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve workload instances: %w", err)
+	}
+
+	res := []common.GaugeValue{}
+
+	for _, workloadInstance := range workloadInstances.Items {
+		gaugeValue := int64(0)
+		if !workloadInstance.IsEndTimeSet() && workloadInstance.Status.GateReleaseTime.IsZero() && workloadInstance.Status.Status == common.StateProgressing {
+			gaugeValue = int64(1)
+		}
+		res = append(res, common.GaugeValue{
+			Value:      gaugeValue,
+			Attributes: workloadInstance.GetActiveMetricsAttributes(),
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCheckTestMeters(t *testing.T, meter metric.Meter) common.KeptnMeters {
+	checks, err := meter.SyncInt64().Counter("keptn.predeployment.checks")
+	testrequire.Nil(t, err)
+	checkDuration, err := meter.SyncFloat64().Histogram("keptn.predeployment.check.duration", instrument.WithUnit(unit.Unit("s")))
+	testrequire.Nil(t, err)
+	gateBypasses, err := meter.SyncInt64().Counter("keptn.gate.bypass")
+	testrequire.Nil(t, err)
+	return common.KeptnMeters{
+		PreDeploymentChecks:        checks,
+		PreDeploymentCheckDuration: checkDuration,
+		GateBypasses:               gateBypasses,
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_recordsCheckOutcomes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:             "my-app",
+				Version:             "1.0.0",
+				PreDeploymentTasks:  []string{"my-task", "other-task"},
+				PostDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing, StartTime: startTime},
+				{TaskDefinitionName: "other-task", TaskName: "other-task-12345", Status: common.StateProgressing, StartTime: startTime},
+			},
+			PostDeploymentTaskStatus: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "my-task", TaskName: "my-task-67890", Status: common.StateProgressing, StartTime: startTime},
+			},
+		},
+	}
+	succeeded := &v1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task-12345"},
+		Status:     v1alpha1.KeptnTaskStatus{Status: common.StateSucceeded},
+	}
+	failed := &v1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-task-12345"},
+		Status:     v1alpha1.KeptnTaskStatus{Status: common.StateFailed},
+	}
+	postDeployment := &v1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task-67890"},
+		Status:     v1alpha1.KeptnTaskStatus{Status: common.StateSucceeded},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, succeeded, failed, postDeployment)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	r.Meters = newCheckTestMeters(t, meter)
+
+	_, err := r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PreDeploymentCheckType)
+	testrequire.Nil(t, err)
+	// post-deployment checks are not counted
+	_, err = r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PostDeploymentCheckType)
+	testrequire.Nil(t, err)
+	// completed checks are only counted once
+	_, err = r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PreDeploymentCheckType)
+	testrequire.Nil(t, err)
+
+	collected, err := reader.Collect(context.TODO())
+	testrequire.Nil(t, err)
+	checks := findMetric(collected, "keptn.predeployment.checks")
+	testrequire.NotNil(t, checks)
+	results := map[string]int64{}
+	for _, dp := range checks.Data.(metricdata.Sum[int64]).DataPoints {
+		app, _ := dp.Attributes.Value(common.AppName)
+		workload, _ := dp.Attributes.Value(common.WorkloadName)
+		result, _ := dp.Attributes.Value(common.CheckResult)
+		testrequire.Equal(t, "my-app", app.AsString())
+		testrequire.Equal(t, "my-app-my-workload", workload.AsString())
+		results[result.AsString()] = dp.Value
+	}
+	testrequire.Equal(t, map[string]int64{"Succeeded": 1, "Failed": 1}, results)
+
+	duration := findMetric(collected, "keptn.predeployment.check.duration")
+	testrequire.NotNil(t, duration)
+	dataPoints := duration.Data.(metricdata.Histogram).DataPoints
+	testrequire.Len(t, dataPoints, 1)
+	testrequire.Equal(t, uint64(2), dataPoints[0].Count)
+	testrequire.GreaterOrEqual(t, dataPoints[0].Sum, 2*time.Minute.Seconds())
+}
+
+func TestKeptnWorkloadInstanceReconciler_GetBlockedDeployments(t *testing.T) {
+	blocked := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Status:     v1alpha1.KeptnWorkloadInstanceStatus{Status: common.StateProgressing},
+	}
+	released := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-other-workload-1.0.0"},
+		Status:     v1alpha1.KeptnWorkloadInstanceStatus{Status: common.StateProgressing, GateReleaseTime: metav1.Now()},
+	}
+	r := newWorkloadInstanceTestReconciler(t, blocked, released)
+
+	values, err := r.GetBlockedDeployments(context.TODO())
+	testrequire.Nil(t, err)
+	testrequire.Len(t, values, 2)
+	var sum int64
+	for _, value := range values {
+		sum += value.Value
+	}
+	testrequire.Equal(t, int64(1), sum)
+}
+
+func findMetric(collected metricdata.ResourceMetrics, name string) *metricdata.Metrics {
+	for _, scope := range collected.ScopeMetrics {
+		for i := range scope.Metrics {
+			if scope.Metrics[i].Name == name {
+				return &scope.Metrics[i]
+			}
+		}
+	}
+	return nil
+}
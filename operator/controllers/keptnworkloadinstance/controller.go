@@ -30,16 +30,21 @@ import (
 
 	"github.com/go-logr/logr"
 	version "github.com/hashicorp/go-version"
+	appsv1 "k8s.io/api/apps/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -48,12 +53,35 @@ import (
 // KeptnWorkloadInstanceReconciler reconciles a KeptnWorkloadInstance object
 type KeptnWorkloadInstanceReconciler struct {
 	client.Client
-	Scheme      *runtime.Scheme
-	Recorder    record.EventRecorder
-	Log         logr.Logger
-	Meters      common.KeptnMeters
-	Tracer      trace.Tracer
-	SpanHandler controllercommon.SpanHandler
+	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
+	Log             logr.Logger
+	Meters          common.KeptnMeters
+	Tracer          trace.Tracer
+	SpanHandler     controllercommon.SpanHandler
+	Exporter        *controllercommon.LifecycleExporter
+	CloudEvents     *controllercommon.CloudEventSender
+	CreationLimiter *controllercommon.CreationLimiter
+	ReleasePolicy   *controllercommon.ReleasePolicy
+	// EnforcementRollout decides which workloads have their pods held back by their pre-deployment checks, if nil all are
+	EnforcementRollout *controllercommon.EnforcementRollout
+	Capabilities       *controllercommon.Capabilities
+	// LifecycleDeadline is the lifecycle deadline of instances whose workload and app do not define one
+	LifecycleDeadline time.Duration
+	// LifecycleDeadlineGatePolicy is either LifecycleDeadlineGatePolicyKeep or LifecycleDeadlineGatePolicyRelease
+	LifecycleDeadlineGatePolicy string
+	QueueDepth                  *controllercommon.QueueDepth
+	// LoadSheddingQueueDepth is the depth of the work queue above which instances that have not started yet are deferred, 0 disables load shedding
+	LoadSheddingQueueDepth int
+	// MaxActiveVersions is the number of versions of a workload that may be in flight at the same time, 0 disables the limit
+	MaxActiveVersions int
+	// RequeueBackoff is the interval phases that have not finished yet are reconciled again in, if nil they are reconciled every 5 seconds
+	RequeueBackoff *controllercommon.RequeueBackoff
+	// FeatureGates enable optional behaviors, e.g. removing the scheduling gates of the pods of released instances
+	FeatureGates *featuregate.Gates
+
+	// indexedReader is the informer cache of the manager, which indexes instances by the workload they reference
+	indexedReader client.Reader
 }
 
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnworkloadinstances,verbs=get;list;watch;create;update;patch;delete
@@ -62,9 +90,13 @@ type KeptnWorkloadInstanceReconciler struct {
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptntasks/finalizers,verbs=update
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnevaluations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;watch;patch
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
-//+kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets;daemonsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=patch
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -75,13 +107,14 @@ type KeptnWorkloadInstanceReconciler struct {
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.12.2/pkg/reconcile
-func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	r.Log.Info("Searching for Keptn Workload Instance")
 
 	//retrieve workload instance
 	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{}
-	err := r.Get(ctx, req.NamespacedName, workloadInstance)
+	err = r.Get(ctx, req.NamespacedName, workloadInstance)
 	if errors.IsNotFound(err) {
+		r.RequeueBackoff.Forget(req.NamespacedName.String())
 		return reconcile.Result{}, nil
 	}
 
@@ -89,6 +122,24 @@ func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctr
 		r.Log.Error(err, "Workload Instance not found")
 		return reconcile.Result{}, fmt.Errorf("could not fetch KeptnWorkloadInstance: %+v", err)
 	}
+	// status writes only send what this reconciliation has changed, see controllercommon.UpdateStatus
+	ctx = controllercommon.WithStatusBase(ctx, workloadInstance)
+
+	deleted, err := r.reconcileCancelFinalizer(ctx, workloadInstance)
+	if err != nil {
+		r.Log.Error(err, "could not reconcile the finalizer of the workload instance")
+		return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, err
+	}
+	if deleted {
+		r.RequeueBackoff.Forget(req.NamespacedName.String())
+		return ctrl.Result{}, nil
+	}
+	// instances that complete in this reconciliation do not need the finalizer anymore
+	defer func() {
+		if _, err := r.reconcileCancelFinalizer(ctx, workloadInstance); err != nil {
+			r.Log.Error(err, "could not remove the finalizer of the completed workload instance")
+		}
+	}()
 
 	//setup otel
 	traceContextCarrier := propagation.MapCarrier(workloadInstance.Annotations)
@@ -99,10 +150,50 @@ func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctr
 
 	semconv.AddAttributeFromWorkloadInstance(span, *workloadInstance)
 
+	if workloadInstance.IsCompleted() {
+		r.RequeueBackoff.Forget(req.NamespacedName.String())
+		// instances completed before the Completed condition existed are migrated on their next reconciliation
+		if workloadInstance.Status.CompletedAt.IsZero() {
+			workloadInstance.Complete()
+			if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+				span.SetStatus(codes.Error, err.Error())
+				return ctrl.Result{Requeue: true}, err
+			}
+		}
+		// retries the propagation to the scale-up guard if it has failed when the instance completed
+		if err := r.propagateScaleUpGuard(ctx, workloadInstance); err != nil {
+			r.Log.Error(err, "could not propagate the result to the scale-up guard of the Deployment")
+			return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if r.shouldDeferStart(workloadInstance) {
+		r.Log.Info("Deferring the start of the workload instance since the work queue is backed up", "workloadInstance", workloadInstance.Name, "queueDepth", r.QueueDepth.Depth())
+		r.Meters.DeferredStarts.Add(ctx, 1, workloadInstance.GetActiveMetricsAttributes()...)
+		// the time the start is deferred does not count against the lifecycle deadline
+		if err := r.pauseLifecycle(ctx, workloadInstance); err != nil {
+			r.Log.Error(err, "could not pause the lifecycle of the deferred workload instance")
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: deferredStartRequeueInterval}, nil
+	}
+
 	workloadInstance.SetStartTime()
 
 	defer func(span trace.Span, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) {
 		if workloadInstance.IsEndTimeSet() {
+			if propagateErr := r.propagateScaleUpGuard(ctx, workloadInstance); propagateErr != nil {
+				r.Log.Error(propagateErr, "could not propagate the result to the scale-up guard of the Deployment")
+				// the instance is completed now, so the propagation is retried by the next reconciliation
+				if err == nil && !result.Requeue {
+					result = ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+				}
+			}
+			record := controllercommon.NewWorkloadInstanceRecord(*workloadInstance)
+			r.Exporter.Export(record)
+			event := controllercommon.NewCloudEvent(controllercommon.CloudEventTypeWorkloadInstanceFinished, workloadInstance.Namespace+"/"+workloadInstance.Name, record)
+			event.Extensions = common.GetMetadataCloudEventExtensions(workloadInstance.Spec.Metadata)
+			r.CloudEvents.Send(event)
 			r.Log.Info("Increasing deployment count")
 			attrs := workloadInstance.GetMetricsAttributes()
 			r.Meters.AppCount.Add(ctx, 1, attrs...)
@@ -110,6 +201,45 @@ func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctr
 		span.End()
 	}(span, workloadInstance)
 
+	cancelled, err := r.cancelIfWorkloadDeleted(ctx, workloadInstance)
+	if err != nil {
+		r.Log.Error(err, "could not check if workload has been deleted")
+	} else if cancelled {
+		return ctrl.Result{}, nil
+	}
+
+	cancelled, err = r.cancelIfLifecycleDeadlineExceeded(ctx, workloadInstance)
+	if err != nil {
+		r.Log.Error(err, "could not check the lifecycle deadline of the workload instance")
+	} else if cancelled {
+		return ctrl.Result{}, nil
+	}
+
+	completed, err := r.completeIfScaledToZero(ctx, workloadInstance)
+	if err != nil {
+		r.Log.Error(err, "could not check if workload is scaled to zero")
+	} else if completed {
+		return ctrl.Result{}, nil
+	}
+
+	parked, err := r.parkIfTooManyActiveVersions(ctx, workloadInstance)
+	if err != nil {
+		r.Log.Error(err, "could not check the number of active versions of the workload")
+	} else if parked {
+		return ctrl.Result{Requeue: true, RequeueAfter: parkedRequeueInterval}, nil
+	}
+	if err := r.resumeLifecycle(ctx, workloadInstance); err != nil {
+		r.Log.Error(err, "could not resume the lifecycle of the workload instance")
+	}
+
+	if err := r.decideEnforcement(ctx, workloadInstance); err != nil {
+		r.Log.Error(err, "could not record the enforcement decision of the workload instance")
+	}
+
+	if err := r.skipIfAlreadyDeployed(ctx, workloadInstance); err != nil {
+		r.Log.Error(err, "could not check if workload is already deployed")
+	}
+
 	//Wait for pre-evaluation checks of App
 	phase := common.PhaseAppPreEvaluation
 
@@ -170,72 +300,25 @@ func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctr
 		controllercommon.RecordEvent(r.Recorder, phase, "Normal", workloadInstance, "Started", "have started", workloadInstance.GetVersion())
 	}
 
-	if !workloadInstance.IsPreDeploymentSucceeded() {
-		reconcilePre := func() (common.KeptnState, error) {
-			return r.reconcilePrePostDeployment(ctx, workloadInstance, common.PreDeploymentCheckType)
-		}
-		result, err := phaseHandler.HandlePhase(ctx, ctxAppTrace, r.Tracer, workloadInstance, phase, span, reconcilePre)
-		if !result.Continue {
-			return result.Result, err
-		}
-	}
-
-	//Wait for pre-evaluation checks of Workload
-	phase = common.PhaseAppPreEvaluation
-	if !workloadInstance.IsPreDeploymentEvaluationSucceeded() {
-		reconcilePreEval := func() (common.KeptnState, error) {
-			return r.reconcilePrePostEvaluation(ctx, workloadInstance, common.PreDeploymentEvaluationCheckType)
-		}
-		result, err := phaseHandler.HandlePhase(ctx, ctxAppTrace, r.Tracer, workloadInstance, phase, span, reconcilePreEval)
-		if !result.Continue {
-			return result.Result, err
-		}
-	}
-
-	//Wait for deployment of Workload
-	phase = common.PhaseWorkloadDeployment
-	if !workloadInstance.IsDeploymentSucceeded() {
-		reconcileWorkloadInstance := func() (common.KeptnState, error) {
-			return r.reconcileDeployment(ctx, workloadInstance)
-		}
-		result, err := phaseHandler.HandlePhase(ctx, ctxAppTrace, r.Tracer, workloadInstance, phase, span, reconcileWorkloadInstance)
-		if !result.Continue {
-			return result.Result, err
-		}
+	lifecycle := &lifecycleRun{
+		workloadInstance: workloadInstance,
+		ctxAppTrace:      ctxAppTrace,
+		span:             span,
+		phaseHandler:     phaseHandler,
 	}
-
-	//Wait for post-deployment checks of Workload
-	phase = common.PhaseWorkloadPostDeployment
-	if !workloadInstance.IsPostDeploymentSucceeded() {
-		reconcilePostDeployment := func() (common.KeptnState, error) {
-			return r.reconcilePrePostDeployment(ctx, workloadInstance, common.PostDeploymentCheckType)
-		}
-		result, err := phaseHandler.HandlePhase(ctx, ctxAppTrace, r.Tracer, workloadInstance, phase, span, reconcilePostDeployment)
-		if !result.Continue {
-			return result.Result, err
-		}
-	}
-
-	//Wait for post-evaluation checks of Workload
-	phase = common.PhaseAppPostEvaluation
-	if !workloadInstance.IsPostDeploymentEvaluationSucceeded() {
-		reconcilePostEval := func() (common.KeptnState, error) {
-			return r.reconcilePrePostEvaluation(ctx, workloadInstance, common.PostDeploymentEvaluationCheckType)
-		}
-		result, err := phaseHandler.HandlePhase(ctx, ctxAppTrace, r.Tracer, workloadInstance, phase, span, reconcilePostEval)
-		if !result.Continue {
-			return result.Result, err
-		}
+	if result, done, err := r.runLifecycle(ctx, lifecycle); !done {
+		return r.capAtLifecycleDeadline(ctx, workloadInstance, result), err
 	}
 
 	// WorkloadInstance is completed at this place
+	r.RequeueBackoff.Forget(req.NamespacedName.String())
 	if !workloadInstance.IsEndTimeSet() {
 		workloadInstance.Status.CurrentPhase = common.PhaseCompleted.ShortName
 		workloadInstance.Status.Status = common.StateSucceeded
-		workloadInstance.SetEndTime()
+		workloadInstance.Complete()
 	}
 
-	err = r.Client.Status().Update(ctx, workloadInstance)
+	err = controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return ctrl.Result{Requeue: true}, err
@@ -244,14 +327,28 @@ func (r *KeptnWorkloadInstanceReconciler) Reconcile(ctx context.Context, req ctr
 	attrs := workloadInstance.GetMetricsAttributes()
 
 	// metrics: add deployment duration
-	duration := workloadInstance.Status.EndTime.Time.Sub(workloadInstance.Status.StartTime.Time)
+	duration := common.Duration(workloadInstance.Status.StartTime, workloadInstance.Status.EndTime)
 	r.Meters.DeploymentDuration.Record(ctx, duration.Seconds(), attrs...)
+	r.Log.Info("KeptnWorkloadInstance has finished", "duration", duration.String())
 
-	controllercommon.RecordEvent(r.Recorder, phase, "Normal", workloadInstance, "Finished", "is finished", workloadInstance.GetVersion())
+	// the instance finishes with its last phase
+	controllercommon.RecordEvent(r.Recorder, common.PhaseAppPostEvaluation, "Normal", workloadInstance, "Finished", finishedReason(workloadInstance), workloadInstance.GetVersion())
 
 	return ctrl.Result{}, nil
 }
 
+// finishedReason reports when the pre-deployment checks of the finished instance have started and ended
+func finishedReason(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) string {
+	if workloadInstance.Status.PreDeploymentEndTime.IsZero() {
+		return "is finished"
+	}
+	return fmt.Sprintf("is finished, pre-deployment checks took %s (%s - %s)",
+		workloadInstance.GetPreDeploymentDuration(),
+		workloadInstance.Status.PreDeploymentStartTime.Format(time.RFC3339),
+		workloadInstance.Status.PreDeploymentEndTime.Format(time.RFC3339),
+	)
+}
+
 func (r *KeptnWorkloadInstanceReconciler) GetActiveDeployments(ctx context.Context) ([]common.GaugeValue, error) {
 	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
 	err := r.List(ctx, workloadInstances)
@@ -277,9 +374,23 @@ func (r *KeptnWorkloadInstanceReconciler) GetActiveDeployments(ctx context.Conte
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *KeptnWorkloadInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &klcv1alpha1.KeptnWorkloadInstance{}, resourceReferenceUIDField, indexResourceReferenceUID); err != nil {
+		return err
+	}
+	r.indexedReader = mgr.GetCache()
 	return ctrl.NewControllerManagedBy(mgr).
 		// predicate disabling the auto reconciliation after updating the object status
 		For(&klcv1alpha1.KeptnWorkloadInstance{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// reconcile as soon as one of the checks of the instance changes, instead of waiting for the next requeue
+		Watches(&source.Kind{Type: &klcv1alpha1.KeptnTask{}}, ownedCheckHandler()).
+		Watches(&source.Kind{Type: &klcv1alpha1.KeptnEvaluation{}}, ownedCheckHandler()).
+		// cancel instances whose workload is deleted while they are in progress, and continue as soon as the pods of
+		// their workload become ready, instead of waiting for the next requeue
+		Watches(&source.Kind{Type: &appsv1.ReplicaSet{}}, handler.EnqueueRequestsFromMapFunc(r.workloadInstancesForWorkload), builder.WithPredicates(predicate.Or(workloadDeletedPredicate, workloadReadinessPredicate))).
+		Watches(&source.Kind{Type: &appsv1.StatefulSet{}}, handler.EnqueueRequestsFromMapFunc(r.workloadInstancesForWorkload), builder.WithPredicates(workloadReadinessPredicate)).
+		Watches(&source.Kind{Type: &appsv1.DaemonSet{}}, handler.EnqueueRequestsFromMapFunc(r.workloadInstancesForWorkload), builder.WithPredicates(workloadReadinessPredicate)).
+		// continue as soon as the endpoints of the Service a deployment phase waits for change
+		Watches(&source.Kind{Type: &discoveryv1.EndpointSlice{}}, handler.EnqueueRequestsFromMapFunc(r.workloadInstancesForEndpointSlice), builder.WithPredicates(endpointSliceReadinessPredicate)).
 		Complete(r)
 }
 
@@ -346,7 +457,7 @@ func (r *KeptnWorkloadInstanceReconciler) GetDeploymentInterval(ctx context.Cont
 			if err != nil {
 				r.Log.Error(err, "Previous WorkloadInstance not found")
 			} else if workloadInstance.IsEndTimeSet() {
-				previousInterval := workloadInstance.Status.StartTime.Time.Sub(previousWorkloadInstance.Status.EndTime.Time)
+				previousInterval := common.Duration(previousWorkloadInstance.Status.EndTime, workloadInstance.Status.StartTime)
 				res = append(res, common.GaugeFloatValue{
 					Value:      previousInterval.Seconds(),
 					Attributes: workloadInstance.GetIntervalMetricsAttributes(),
@@ -368,7 +479,7 @@ func (r *KeptnWorkloadInstanceReconciler) GetDeploymentDuration(ctx context.Cont
 
 	for _, workloadInstance := range workloadInstances.Items {
 		if workloadInstance.IsEndTimeSet() {
-			duration := workloadInstance.Status.EndTime.Time.Sub(workloadInstance.Status.StartTime.Time)
+			duration := common.Duration(workloadInstance.Status.StartTime, workloadInstance.Status.EndTime)
 			res = append(res, common.GaugeFloatValue{
 				Value:      duration.Seconds(),
 				Attributes: workloadInstance.GetIntervalMetricsAttributes(),
@@ -0,0 +1,50 @@
+package keptnworkloadinstance
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newWorkloadInstanceTestReconciler returns a reconciler with a fake client holding the given objects
+func newWorkloadInstanceTestReconciler(t *testing.T, objects ...client.Object) *KeptnWorkloadInstanceReconciler {
+	scheme := runtime.NewScheme()
+	testrequire.Nil(t, clientgoscheme.AddToScheme(scheme))
+	testrequire.Nil(t, v1alpha1.AddToScheme(scheme))
+	return &KeptnWorkloadInstanceReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme:   scheme,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(100),
+		Meters:   newCheckTestMeters(t, metric.NewNoopMeterProvider().Meter("test")),
+	}
+}
+
+// makeDeployment returns the Deployment my-deployment and its ReplicaSet with the UID rs-uid, which the test
+// workload instances reference. Replicas are only set if given.
+func makeDeployment(replicas *int32) (*appsv1.Deployment, *appsv1.ReplicaSet) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-deployment"},
+		Spec:       appsv1.DeploymentSpec{Replicas: replicas},
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "my-deployment-rs",
+			UID:             "rs-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "my-deployment"}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: replicas},
+	}
+	return deployment, replicaSet
+}
@@ -0,0 +1,226 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Step is a step of the lifecycle of a KeptnWorkloadInstance
+type Step string
+
+const (
+	StepPreDeployment            Step = "PreDeployment"
+	StepPreDeploymentEvaluation  Step = "PreDeploymentEvaluation"
+	StepGateRelease              Step = "GateRelease"
+	StepDeployment               Step = "Deployment"
+	StepPostDeployment           Step = "PostDeployment"
+	StepTrafficSwitch            Step = "TrafficSwitch"
+	StepPostDeploymentEvaluation Step = "PostDeploymentEvaluation"
+)
+
+// Transition runs its step as long as the observed state of the instance says the step is pending.
+// A step that has not finished stops the reconciliation, a finished one hands over to the next transition.
+type Transition struct {
+	Step    Step
+	Pending func(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) bool
+	run     func(r *KeptnWorkloadInstanceReconciler, ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error)
+}
+
+// Transitions is the lifecycle of a KeptnWorkloadInstance. Once no step is pending anymore, the instance is completed.
+var Transitions = []Transition{
+	{
+		Step:    StepPreDeployment,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return !wi.IsPreDeploymentSucceeded() },
+		run:     (*KeptnWorkloadInstanceReconciler).runPreDeployment,
+	},
+	{
+		Step:    StepPreDeploymentEvaluation,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return !wi.IsPreDeploymentEvaluationSucceeded() },
+		run:     (*KeptnWorkloadInstanceReconciler).runPreDeploymentEvaluation,
+	},
+	{
+		// the scheduler releases the pods of the workload as soon as the pre-deployment checks have succeeded
+		Step:    StepGateRelease,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return wi.Status.GateReleaseTime.IsZero() },
+		run:     (*KeptnWorkloadInstanceReconciler).runGateRelease,
+	},
+	{
+		Step:    StepDeployment,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return !wi.IsDeploymentSucceeded() },
+		run:     (*KeptnWorkloadInstanceReconciler).runDeployment,
+	},
+	{
+		Step:    StepPostDeployment,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return !wi.IsPostDeploymentSucceeded() },
+		run:     (*KeptnWorkloadInstanceReconciler).runPostDeployment,
+	},
+	{
+		// switch traffic to the new version once the post-deployment tasks have succeeded
+		Step:    StepTrafficSwitch,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return wi.IsTrafficSwitchPending() },
+		run:     (*KeptnWorkloadInstanceReconciler).runTrafficSwitch,
+	},
+	{
+		Step:    StepPostDeploymentEvaluation,
+		Pending: func(wi *klcv1alpha1.KeptnWorkloadInstance) bool { return !wi.IsPostDeploymentEvaluationSucceeded() },
+		run:     (*KeptnWorkloadInstanceReconciler).runPostDeploymentEvaluation,
+	},
+}
+
+// NextStep returns the first step of the lifecycle that is pending for the instance, or false if all steps are done
+func NextStep(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (Step, bool) {
+	for _, transition := range Transitions {
+		if transition.Pending(workloadInstance) {
+			return transition.Step, true
+		}
+	}
+	return "", false
+}
+
+// lifecycleRun holds what the steps of a single reconciliation of an instance share
+type lifecycleRun struct {
+	workloadInstance *klcv1alpha1.KeptnWorkloadInstance
+	ctxAppTrace      context.Context
+	span             trace.Span
+	phaseHandler     controllercommon.PhaseHandler
+}
+
+// runLifecycle runs the pending steps of the instance in order, until a step has not finished.
+// It returns true if all steps are done and the instance can be completed.
+func (r *KeptnWorkloadInstanceReconciler) runLifecycle(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	for _, transition := range Transitions {
+		if !transition.Pending(l.workloadInstance) {
+			continue
+		}
+		if result, proceed, err := transition.run(r, ctx, l); !proceed {
+			return result, false, err
+		}
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// runCheckPhase runs a phase of checks and stops the reconciliation if it has not finished
+func (r *KeptnWorkloadInstanceReconciler) runCheckPhase(ctx context.Context, l *lifecycleRun, phase common.KeptnPhaseType, reconcilePhase func() (common.KeptnState, error), uncreatedChecks func() bool) (ctrl.Result, bool, error) {
+	result, err := l.phaseHandler.HandlePhase(ctx, l.ctxAppTrace, r.Tracer, l.workloadInstance, phase, l.span, reconcilePhase)
+	if !result.Continue {
+		return r.backoffPhase(l.workloadInstance, resyncCheckPhase(result, err, uncreatedChecks())), false, err
+	}
+	return ctrl.Result{}, true, nil
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runPreDeployment(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	return r.runCheckPhase(ctx, l, common.PhaseWorkloadPreDeployment, func() (common.KeptnState, error) {
+		l.workloadInstance.StartPreDeployment()
+		state, err := r.reconcilePrePostDeployment(ctx, l.workloadInstance, common.PreDeploymentCheckType)
+		if state.IsFailed() {
+			r.endPreDeployment(ctx, l.workloadInstance, state, "pre-deployment tasks have failed")
+		}
+		return state, err
+	}, func() bool {
+		return hasUncreatedTasks(l.workloadInstance.Status.PreDeploymentTaskStatus)
+	})
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runPreDeploymentEvaluation(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	return r.runCheckPhase(ctx, l, common.PhaseAppPreEvaluation, func() (common.KeptnState, error) {
+		state, err := r.reconcilePrePostEvaluation(ctx, l.workloadInstance, common.PreDeploymentEvaluationCheckType)
+		if state.IsSucceeded() {
+			r.endPreDeployment(ctx, l.workloadInstance, state, "pre-deployment checks have succeeded")
+		} else if state.IsCompleted() {
+			r.endPreDeployment(ctx, l.workloadInstance, state, "pre-deployment evaluations have failed")
+		}
+		return state, err
+	}, func() bool {
+		return hasUncreatedEvaluations(l.workloadInstance.Status.PreDeploymentEvaluationTaskStatus)
+	})
+}
+
+// endPreDeployment records the end of the pre-deployment checks, which are over once the pre-deployment evaluations
+// have completed or the pre-deployment tasks have failed
+func (r *KeptnWorkloadInstanceReconciler) endPreDeployment(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, state common.KeptnState, message string) {
+	if workloadInstance.EndPreDeployment() {
+		workloadInstance.SetPreDeploymentResult(state, message)
+		r.Meters.PreDeploymentDuration.Record(ctx, workloadInstance.GetPreDeploymentDuration().Seconds(), workloadInstance.GetPreDeploymentMetricsAttributes()...)
+	}
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runGateRelease(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	if err := r.releaseGate(ctx, l.workloadInstance); err != nil {
+		r.Log.Error(err, "could not release the pods of the workload instance")
+		return ctrl.Result{Requeue: true}, false, err
+	}
+	r.ungatePods(ctx, l.workloadInstance)
+	return ctrl.Result{}, true, nil
+}
+
+// ungatePods removes the scheduling gates of the pods of a released instance. Pods that are created while the gate
+// is released are picked up by the reconciliations of the deployment phase, which waits for them.
+func (r *KeptnWorkloadInstanceReconciler) ungatePods(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) {
+	if !r.FeatureGates.Enabled(featuregate.SchedulingGates) {
+		return
+	}
+	if err := r.removeSchedulingGates(ctx, workloadInstance); err != nil {
+		r.Log.Error(err, "could not remove the scheduling gates of the pods of the workload instance")
+	}
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runDeployment(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	r.ungatePods(ctx, l.workloadInstance)
+	result, err := l.phaseHandler.HandlePhase(ctx, l.ctxAppTrace, r.Tracer, l.workloadInstance, common.PhaseWorkloadDeployment, l.span, func() (common.KeptnState, error) {
+		return r.reconcileDeployment(ctx, l.workloadInstance)
+	})
+	if !result.Continue {
+		return r.backoffPhase(l.workloadInstance, result.Result), false, err
+	}
+	return ctrl.Result{}, true, nil
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runPostDeployment(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	return r.runCheckPhase(ctx, l, common.PhaseWorkloadPostDeployment, func() (common.KeptnState, error) {
+		return r.reconcilePrePostDeployment(ctx, l.workloadInstance, common.PostDeploymentCheckType)
+	}, func() bool {
+		return hasUncreatedTasks(l.workloadInstance.Status.PostDeploymentTaskStatus)
+	})
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runTrafficSwitch(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	workloadInstance := l.workloadInstance
+	entered := workloadInstance.Status.CurrentPhase != trafficSwitchPhase.ShortName
+	workloadInstance.SetCurrentPhase(trafficSwitchPhase.ShortName)
+	err := r.switchTraffic(ctx, workloadInstance)
+	if err == nil {
+		controllercommon.RecordEvent(r.Recorder, trafficSwitchPhase, "Normal", workloadInstance, "Succeeded", "switched traffic of Service "+workloadInstance.Spec.TrafficSwitch.ServiceName, workloadInstance.GetVersion())
+		return ctrl.Result{}, true, nil
+	}
+
+	l.span.SetStatus(codes.Error, err.Error())
+	// a missing Service is waited for, since it may be created together with the workload, but not forever
+	if errors.IsNotFound(err) && common.Since(workloadInstance.Status.PhaseStartTime) > trafficSwitchServiceTimeout {
+		return ctrl.Result{}, false, r.failTrafficSwitch(ctx, workloadInstance)
+	}
+	controllercommon.RecordEvent(r.Recorder, trafficSwitchPhase, "Warning", workloadInstance, "Failed", "could not switch traffic of Service "+workloadInstance.Spec.TrafficSwitch.ServiceName, workloadInstance.GetVersion())
+	if entered {
+		// the start of the phase bounds the time the Service is waited for
+		if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+			r.Log.Error(err, "could not update status")
+		}
+	}
+	return ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}, false, err
+}
+
+func (r *KeptnWorkloadInstanceReconciler) runPostDeploymentEvaluation(ctx context.Context, l *lifecycleRun) (ctrl.Result, bool, error) {
+	return r.runCheckPhase(ctx, l, common.PhaseAppPostEvaluation, func() (common.KeptnState, error) {
+		return r.reconcilePrePostEvaluation(ctx, l.workloadInstance, common.PostDeploymentEvaluationCheckType)
+	}, func() bool {
+		return hasUncreatedEvaluations(l.workloadInstance.Status.PostDeploymentEvaluationTaskStatus)
+	})
+}
@@ -0,0 +1,201 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var allSteps = []Step{
+	StepPreDeployment,
+	StepPreDeploymentEvaluation,
+	StepGateRelease,
+	StepDeployment,
+	StepPostDeployment,
+	StepTrafficSwitch,
+	StepPostDeploymentEvaluation,
+}
+
+// finishStep sets the status the instance reports once the step has been run
+func finishStep(workloadInstance *v1alpha1.KeptnWorkloadInstance, step Step) {
+	switch step {
+	case StepPreDeployment:
+		workloadInstance.Status.PreDeploymentStatus = common.StateSucceeded
+	case StepPreDeploymentEvaluation:
+		workloadInstance.Status.PreDeploymentEvaluationStatus = common.StateSucceeded
+	case StepGateRelease:
+		workloadInstance.Status.GateReleaseTime = metav1.NewTime(time.Now())
+	case StepDeployment:
+		workloadInstance.Status.DeploymentStatus = common.StateSucceeded
+	case StepPostDeployment:
+		workloadInstance.Status.PostDeploymentStatus = common.StateSucceeded
+	case StepTrafficSwitch:
+		workloadInstance.Status.TrafficSwitchTime = metav1.NewTime(time.Now())
+	case StepPostDeploymentEvaluation:
+		workloadInstance.Status.PostDeploymentEvaluationStatus = common.StateSucceeded
+	}
+}
+
+// newLifecycleTestInstance returns an instance on which every step whose bit is set in finished has been run
+func newLifecycleTestInstance(finished int) *v1alpha1.KeptnWorkloadInstance {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				TrafficSwitch: v1alpha1.TrafficSwitch{ServiceName: "my-service"},
+			},
+		},
+	}
+	for i, step := range allSteps {
+		if finished&(1<<i) != 0 {
+			finishStep(workloadInstance, step)
+		}
+	}
+	return workloadInstance
+}
+
+func stepIndex(step Step) int {
+	for i := range allSteps {
+		if allSteps[i] == step {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestTransitions_CoverEveryStepOnce(t *testing.T) {
+	testrequire.Len(t, Transitions, len(allSteps))
+	for i, transition := range Transitions {
+		testrequire.Equal(t, allSteps[i], transition.Step)
+		testrequire.NotNil(t, transition.Pending)
+		testrequire.NotNil(t, transition.run)
+	}
+}
+
+func TestNextStep_EveryStepIsReachable(t *testing.T) {
+	for i, step := range allSteps {
+		// all steps before the step have been run
+		next, found := NextStep(newLifecycleTestInstance(1<<i - 1))
+		testrequire.True(t, found)
+		testrequire.Equal(t, step, next)
+	}
+	_, found := NextStep(newLifecycleTestInstance(1<<len(allSteps) - 1))
+	testrequire.False(t, found)
+}
+
+func TestNextStep_NoDeadStates(t *testing.T) {
+	for finished := 0; finished < 1<<len(allSteps); finished++ {
+		workloadInstance := newLifecycleTestInstance(finished)
+		previous := -1
+		// every observed state leads to completion, running each step at most once and in order
+		for run := 0; ; run++ {
+			testrequire.LessOrEqual(t, run, len(allSteps), "state %b does not complete", finished)
+			step, found := NextStep(workloadInstance)
+			if !found {
+				break
+			}
+			index := stepIndex(step)
+			testrequire.Greater(t, index, previous, "state %b runs %s again", finished, step)
+			testrequire.Zero(t, finished&(1<<index), "state %b runs %s although it has been run", finished, step)
+			finishStep(workloadInstance, step)
+			previous = index
+		}
+	}
+}
+
+func TestNextStep_TrafficSwitchIsSkippedWithoutService(t *testing.T) {
+	workloadInstance := newLifecycleTestInstance(1<<stepIndex(StepTrafficSwitch) - 1)
+	workloadInstance.Spec.TrafficSwitch = v1alpha1.TrafficSwitch{}
+
+	next, found := NextStep(workloadInstance)
+	testrequire.True(t, found)
+	testrequire.Equal(t, StepPostDeploymentEvaluation, next)
+}
+
+func TestKeptnWorkloadInstanceReconciler_recordsPreDeploymentTimes(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	preDeploymentDuration, err := metric.NewNoopMeterProvider().Meter("test").SyncFloat64().Histogram("predeployment")
+	testrequire.Nil(t, err)
+	r.Meters = common.KeptnMeters{PreDeploymentDuration: preDeploymentDuration}
+	_, span := r.Tracer.Start(context.TODO(), "test")
+	l := &lifecycleRun{
+		workloadInstance: workloadInstance,
+		ctxAppTrace:      context.TODO(),
+		span:             span,
+		phaseHandler:     controllercommon.PhaseHandler{Client: r.Client, Recorder: r.Recorder, Log: r.Log},
+	}
+
+	_, proceed, err := r.runPreDeployment(context.TODO(), l)
+	testrequire.Nil(t, err)
+	testrequire.True(t, proceed)
+	startTime := workloadInstance.Status.PreDeploymentStartTime
+	testrequire.False(t, startTime.IsZero())
+	testrequire.True(t, workloadInstance.Status.PreDeploymentEndTime.IsZero())
+	testrequire.Equal(t, metav1.ConditionUnknown, workloadInstance.GetCondition(v1alpha1.PreDeploymentChecksSucceededConditionType).Status)
+
+	_, proceed, err = r.runPreDeploymentEvaluation(context.TODO(), l)
+	testrequire.Nil(t, err)
+	testrequire.True(t, proceed)
+	testrequire.Equal(t, startTime, workloadInstance.Status.PreDeploymentStartTime)
+	testrequire.False(t, workloadInstance.Status.PreDeploymentEndTime.IsZero())
+	testrequire.Contains(t, finishedReason(workloadInstance), "pre-deployment checks took")
+
+	// the times are persisted with the status of the instance
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+	testrequire.False(t, stored.Status.PreDeploymentEndTime.IsZero())
+	testrequire.Equal(t, metav1.ConditionTrue, stored.GetCondition(v1alpha1.PreDeploymentChecksStartedConditionType).Status)
+	testrequire.Equal(t, metav1.ConditionTrue, stored.GetCondition(v1alpha1.PreDeploymentChecksSucceededConditionType).Status)
+}
+
+func TestKeptnWorkloadInstanceReconciler_failedPreDeploymentTasksFailCondition(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0", PreDeploymentTasks: []string{"my-task"}},
+			WorkloadName:      "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing}},
+		},
+	}
+	task := &v1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task-12345"},
+		Status:     v1alpha1.KeptnTaskStatus{Status: common.StateFailed},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, task)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	preDeploymentDuration, err := metric.NewNoopMeterProvider().Meter("test").SyncFloat64().Histogram("predeployment")
+	testrequire.Nil(t, err)
+	r.Meters.PreDeploymentDuration = preDeploymentDuration
+	_, span := r.Tracer.Start(context.TODO(), "test")
+	l := &lifecycleRun{
+		workloadInstance: workloadInstance,
+		ctxAppTrace:      context.TODO(),
+		span:             span,
+		phaseHandler:     controllercommon.PhaseHandler{Client: r.Client, Recorder: r.Recorder, Log: r.Log},
+	}
+
+	_, proceed, _ := r.runPreDeployment(context.TODO(), l)
+	testrequire.False(t, proceed)
+	condition := workloadInstance.GetCondition(v1alpha1.PreDeploymentChecksSucceededConditionType)
+	testrequire.Equal(t, metav1.ConditionFalse, condition.Status)
+	testrequire.Equal(t, string(common.StateFailed), condition.Reason)
+	testrequire.Equal(t, "pre-deployment tasks have failed", condition.Message)
+}
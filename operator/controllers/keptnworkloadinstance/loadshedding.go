@@ -0,0 +1,20 @@
+package keptnworkloadinstance
+
+import (
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+)
+
+// deferredStartRequeueInterval is the time after which an instance whose start has been deferred is reconciled again
+const deferredStartRequeueInterval = 5 * time.Second
+
+// shouldDeferStart returns true if the instance has not started any phase yet while the work queue is backed up.
+// Finishing the lifecycles that are in flight has priority over starting new ones, which would create further
+// tasks and evaluations and add to the load. The lifecycle of a deferred instance is paused, see pauseLifecycle.
+func (r *KeptnWorkloadInstanceReconciler) shouldDeferStart(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) bool {
+	if r.LoadSheddingQueueDepth <= 0 || workloadInstance.Status.CurrentPhase != "" {
+		return false
+	}
+	return r.QueueDepth.Depth() > r.LoadSheddingQueueDepth
+}
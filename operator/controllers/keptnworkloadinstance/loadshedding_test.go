@@ -0,0 +1,41 @@
+package keptnworkloadinstance
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/prometheus/client_golang/prometheus"
+	testrequire "github.com/stretchr/testify/require"
+)
+
+func TestKeptnWorkloadInstanceReconciler_shouldDeferStart(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Subsystem: "workqueue", Name: "depth"}, []string{"name"})
+	registry.MustRegister(depth)
+	queueDepth := controllercommon.NewQueueDepth(registry, "keptnworkloadinstance", logr.Discard())
+
+	notStarted := &v1alpha1.KeptnWorkloadInstance{}
+	inFlight := &v1alpha1.KeptnWorkloadInstance{Status: v1alpha1.KeptnWorkloadInstanceStatus{CurrentPhase: common.PhaseWorkloadPostDeployment.ShortName}}
+
+	r := &KeptnWorkloadInstanceReconciler{QueueDepth: queueDepth, LoadSheddingQueueDepth: 50}
+
+	// a synthetic backlog of requests
+	depth.WithLabelValues("keptnworkloadinstance").Set(200)
+	testrequire.Nil(t, queueDepth.Refresh())
+	testrequire.True(t, r.shouldDeferStart(notStarted))
+	testrequire.False(t, r.shouldDeferStart(inFlight))
+
+	// the backlog has been worked off
+	depth.WithLabelValues("keptnworkloadinstance").Set(10)
+	testrequire.Nil(t, queueDepth.Refresh())
+	testrequire.False(t, r.shouldDeferStart(notStarted))
+
+	// load shedding is disabled
+	depth.WithLabelValues("keptnworkloadinstance").Set(200)
+	testrequire.Nil(t, queueDepth.Refresh())
+	r.LoadSheddingQueueDepth = 0
+	testrequire.False(t, r.shouldDeferStart(notStarted))
+}
@@ -0,0 +1,58 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMaxActiveVersions is the number of versions of a workload whose lifecycle may be in flight at the same time
+const DefaultMaxActiveVersions = 10
+
+// parkedRequeueInterval is the time after which an instance that is held back by MaxActiveVersions is reconciled again
+const parkedRequeueInterval = 30 * time.Second
+
+// parkIfTooManyActiveVersions holds back an instance that has not started yet, as long as at least MaxActiveVersions
+// older instances of the same workload have not completed. Instances start in the order they have been created, so
+// the newest version proceeds once the older ones have completed or have been cancelled, e.g. by the lifecycle
+// deadline. The time an instance is parked does not count against its own lifecycle deadline, so that it is not
+// cancelled before it has started. The instances are counted from the cache, so the limit may be exceeded slightly while
+// instances are created concurrently.
+func (r *KeptnWorkloadInstanceReconciler) parkIfTooManyActiveVersions(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	if r.MaxActiveVersions <= 0 || workloadInstance.Status.CurrentPhase != "" {
+		return false, nil
+	}
+
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := r.Client.List(ctx, workloadInstances, client.InNamespace(workloadInstance.Namespace)); err != nil {
+		return false, err
+	}
+	older := 0
+	for _, other := range workloadInstances.Items {
+		if other.Spec.WorkloadName != workloadInstance.Spec.WorkloadName || other.Name == workloadInstance.Name || other.IsCompleted() {
+			continue
+		}
+		if other.CreationTimestamp.Before(&workloadInstance.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&workloadInstance.CreationTimestamp) && other.Name < workloadInstance.Name) {
+			older++
+		}
+	}
+
+	parked := older >= r.MaxActiveVersions
+	message := fmt.Sprintf("%d older versions of workload %s have not completed yet, at most %d may be active at the same time", older, workloadInstance.Spec.WorkloadName, r.MaxActiveVersions)
+	changed := workloadInstance.SetTooManyActiveVersions(parked, message)
+	if changed && parked {
+		controllercommon.RecordEvent(r.Recorder, common.PhaseWorkloadPreDeployment, "Warning", workloadInstance, klcv1alpha1.TooManyActiveVersionsConditionType, message, workloadInstance.GetVersion())
+		r.Meters.ParkedVersions.Add(ctx, 1, workloadInstance.GetActiveMetricsAttributes()...)
+	}
+	paused := parked && workloadInstance.PauseLifecycle()
+	if !changed && !paused {
+		return parked, nil
+	}
+	return parked, controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+}
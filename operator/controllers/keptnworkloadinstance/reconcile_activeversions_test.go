@@ -0,0 +1,93 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newActiveVersionsTestInstance(version string, created time.Time) *v1alpha1.KeptnWorkloadInstance {
+	return &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "my-app-my-workload-" + version,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: version},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_parkIfTooManyActiveVersions(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var older []client.Object
+	for i := 0; i < 2; i++ {
+		older = append(older, newActiveVersionsTestInstance(fmt.Sprintf("0.%d.0", i), now.Add(time.Duration(i-10)*time.Minute)))
+	}
+	otherWorkload := newActiveVersionsTestInstance("0.9.0", now.Add(-time.Hour))
+	otherWorkload.Spec.WorkloadName = "my-app-other-workload"
+	newest := newActiveVersionsTestInstance("1.0.0", now)
+
+	r := newWorkloadInstanceTestReconciler(t, append(older, otherWorkload, newest)...)
+	parkedVersions, err := metric.NewNoopMeterProvider().Meter("test").SyncInt64().Counter("keptn.deployment.parked")
+	testrequire.Nil(t, err)
+	r.Meters.ParkedVersions = parkedVersions
+	r.MaxActiveVersions = 2
+
+	// two older versions are active
+	parked, err := r.parkIfTooManyActiveVersions(context.TODO(), newest)
+	testrequire.Nil(t, err)
+	testrequire.True(t, parked)
+	condition := newest.GetCondition(v1alpha1.TooManyActiveVersionsConditionType)
+	testrequire.NotNil(t, condition)
+	testrequire.Equal(t, metav1.ConditionTrue, condition.Status)
+	testrequire.Equal(t, v1alpha1.TooManyActiveVersionsConditionType, condition.Reason)
+	testrequire.Len(t, r.Recorder.(*record.FakeRecorder).Events, 1)
+	// the time the version is parked does not count against its lifecycle deadline
+	testrequire.True(t, newest.IsLifecyclePaused())
+
+	// the oldest version is not held back by newer ones
+	oldest := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(older[0]), oldest))
+	parked, err = r.parkIfTooManyActiveVersions(context.TODO(), oldest)
+	testrequire.Nil(t, err)
+	testrequire.False(t, parked)
+	testrequire.Nil(t, oldest.GetCondition(v1alpha1.TooManyActiveVersionsConditionType))
+
+	// the warning is only recorded once
+	parked, err = r.parkIfTooManyActiveVersions(context.TODO(), newest)
+	testrequire.Nil(t, err)
+	testrequire.True(t, parked)
+	testrequire.Len(t, r.Recorder.(*record.FakeRecorder).Events, 1)
+
+	// the newest version proceeds once an older one has been cancelled
+	oldest.CompleteWithReason("Cancelled", "cancelled")
+	testrequire.Nil(t, r.Client.Status().Update(context.TODO(), oldest))
+	parked, err = r.parkIfTooManyActiveVersions(context.TODO(), newest)
+	testrequire.Nil(t, err)
+	testrequire.False(t, parked)
+	testrequire.Equal(t, metav1.ConditionFalse, newest.GetCondition(v1alpha1.TooManyActiveVersionsConditionType).Status)
+	testrequire.Nil(t, r.resumeLifecycle(context.TODO(), newest))
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(newest), stored))
+	testrequire.False(t, stored.IsLifecyclePaused())
+	testrequire.False(t, stored.Status.ActiveSince.IsZero())
+
+	// instances that have already started are never held back
+	r.MaxActiveVersions = 1
+	started := newActiveVersionsTestInstance("1.1.0", now.Add(time.Minute))
+	started.Status.CurrentPhase = "WorkloadPreDeployTasks"
+	parked, err = r.parkIfTooManyActiveVersions(context.TODO(), started)
+	testrequire.Nil(t, err)
+	testrequire.False(t, parked)
+}
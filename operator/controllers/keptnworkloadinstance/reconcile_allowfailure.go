@@ -0,0 +1,30 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// allowTaskFailure marks a failed task as not blocking the deployment if its KeptnTaskDefinition allows it to fail.
+// The task keeps its failed state, so that the failure remains visible in the status and metrics.
+func (r *KeptnWorkloadInstanceReconciler) allowTaskFailure(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, taskStatus *klcv1alpha1.TaskStatus, phase common.KeptnPhaseType) error {
+	definition := &klcv1alpha1.KeptnTaskDefinition{}
+	err := r.Client.Get(ctx, controllercommon.GetTaskDefinitionKey(workloadInstance.Namespace, taskStatus.TaskDefinitionName), definition)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !definition.Spec.AllowFailure {
+		return nil
+	}
+	taskStatus.Reason = klcv1alpha1.TaskFailureAllowedReason
+	controllercommon.RecordEvent(r.Recorder, phase, "Warning", workloadInstance, "FailureAllowed", fmt.Sprintf("task %s has failed, the deployment proceeds since its definition allows it to fail", taskStatus.TaskDefinitionName), workloadInstance.GetVersion())
+	return nil
+}
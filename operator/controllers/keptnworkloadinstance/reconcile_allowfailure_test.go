@@ -0,0 +1,97 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_reconcilePrePostDeploymentAllowFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowFailure bool
+		wantState    common.KeptnState
+	}{
+		{
+			name:         "failure is allowed",
+			allowFailure: true,
+			wantState:    common.StateSucceeded,
+		},
+		{
+			name:         "failure blocks the deployment",
+			allowFailure: false,
+			wantState:    common.StateFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-2.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						AppName:            "my-app",
+						Version:            "2.0.0",
+						PreDeploymentTasks: []string{"smoke-test"},
+					},
+					WorkloadName: "my-app-my-workload",
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{
+					PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+						{TaskDefinitionName: "smoke-test", TaskName: "pre-smoke-test-12345", Status: common.StateProgressing},
+					},
+				},
+			}
+			r := newWorkloadInstanceTestReconciler(t,
+				workloadInstance,
+				&v1alpha1.KeptnTaskDefinition{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "smoke-test"},
+					Spec:       v1alpha1.KeptnTaskDefinitionSpec{AllowFailure: tt.allowFailure},
+				},
+				&v1alpha1.KeptnTask{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-smoke-test-12345"},
+					Spec:       v1alpha1.KeptnTaskSpec{TaskDefinition: "smoke-test"},
+					Status:     v1alpha1.KeptnTaskStatus{Status: common.StateFailed, Reason: "Error", Message: "container exited with code 1: connection refused"},
+				},
+			)
+			r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+			state, err := r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PreDeploymentCheckType)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, tt.wantState, state)
+
+			// the task keeps its failed state either way
+			taskStatus := workloadInstance.Status.PreDeploymentTaskStatus[0]
+			testrequire.Equal(t, common.StateFailed, taskStatus.Status)
+			testrequire.Equal(t, tt.allowFailure, taskStatus.IsFailureAllowed())
+
+			condition := workloadInstance.GetCondition(v1alpha1.TasksFailureAllowedConditionType)
+			if !tt.allowFailure {
+				testrequire.Nil(t, condition)
+				// the failure details of the task are surfaced on the workload instance
+				testrequire.Equal(t, "Error", workloadInstance.Status.PreDeploymentReason)
+				testrequire.Equal(t, "task smoke-test has failed: container exited with code 1: connection refused", workloadInstance.Status.PreDeploymentMessage)
+				return
+			}
+			testrequire.Empty(t, workloadInstance.Status.PreDeploymentMessage)
+			testrequire.NotNil(t, condition)
+			testrequire.Equal(t, metav1.ConditionTrue, condition.Status)
+			testrequire.Contains(t, condition.Message, "smoke-test")
+
+			allowed := false
+			for len(r.Recorder.(*record.FakeRecorder).Events) > 0 {
+				event := <-r.Recorder.(*record.FakeRecorder).Events
+				if strings.HasPrefix(event, "Warning") && strings.Contains(event, "FailureAllowed") {
+					allowed = true
+				}
+			}
+			testrequire.True(t, allowed)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+)
+
+// AlreadyDeployedReason is the reason of the pre-deployment checks of instances whose pods were already running
+// when the instance has been created, e.g. since the workload has been onboarded while it was running
+const AlreadyDeployedReason = "AlreadyDeployed"
+
+// skipIfAlreadyDeployed releases the pods of a KeptnWorkloadInstance that has not started yet, if the pods of its
+// version are already running. Holding back the pods is moot for such versions, so their pre-deployment checks are
+// skipped and only the post-deployment checks run. Workloads setting their pre-deployment checks to Always still run
+// them, but without holding back their pods.
+func (r *KeptnWorkloadInstanceReconciler) skipIfAlreadyDeployed(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if workloadInstance.Status.CurrentPhase != "" || !workloadInstance.Status.GateReleaseTime.IsZero() {
+		return nil
+	}
+	deployed, err := r.isAlreadyDeployed(ctx, workloadInstance)
+	if err != nil || !deployed {
+		return err
+	}
+
+	message := "has been skipped since the workload is already deployed"
+	if workloadInstance.Spec.PreDeploymentChecks == klcv1alpha1.PreDeploymentChecksAlways {
+		message = "runs without holding back the pods since the workload is already deployed"
+	} else {
+		workloadInstance.SkipPreDeployment(AlreadyDeployedReason, "pods of the version were already running when the instance has been created")
+	}
+	return r.bypassGate(ctx, workloadInstance, AlreadyDeployedReason, message)
+}
+
+func (r *KeptnWorkloadInstanceReconciler) isAlreadyDeployed(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	if workloadInstance.Spec.ResourceReference.Kind == "Pod" {
+		return r.isPodRunning(ctx, workloadInstance.Spec.ResourceReference, workloadInstance.Namespace)
+	}
+	// workloads that are scaled to zero have already been completed by completeIfScaledToZero
+	return r.isReplicaSetRunning(ctx, workloadInstance.Spec.ResourceReference, workloadInstance.Namespace)
+}
@@ -0,0 +1,84 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestKeptnWorkloadInstanceReconciler_skipIfAlreadyDeployed(t *testing.T) {
+	tests := []struct {
+		name                string
+		readyReplicas       int32
+		currentPhase        string
+		preDeploymentChecks v1alpha1.PreDeploymentChecksMode
+		wantReleased        bool
+		wantSkipped         bool
+	}{
+		{
+			name:          "pods are not running yet",
+			readyReplicas: 0,
+		},
+		{
+			name:          "pods are already running",
+			readyReplicas: 2,
+			wantReleased:  true,
+			wantSkipped:   true,
+		},
+		{
+			name:                "pods are already running, checks forced",
+			readyReplicas:       2,
+			preDeploymentChecks: v1alpha1.PreDeploymentChecksAlways,
+			wantReleased:        true,
+		},
+		{
+			name:          "instance has already started",
+			readyReplicas: 2,
+			currentPhase:  common.PhaseWorkloadPreDeployment.ShortName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						Version:             "1.0.0",
+						PreDeploymentTasks:  []string{"my-task"},
+						ResourceReference:   v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+						PreDeploymentChecks: tt.preDeploymentChecks,
+					},
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{CurrentPhase: tt.currentPhase},
+			}
+			deployment, replicaSet := makeDeployment(pointer.Int32(2))
+			replicaSet.Status.ReadyReplicas = tt.readyReplicas
+			r := newWorkloadInstanceTestReconciler(t, workloadInstance, deployment, replicaSet)
+
+			testrequire.Nil(t, r.skipIfAlreadyDeployed(context.TODO(), workloadInstance))
+			testrequire.Equal(t, tt.wantReleased, !workloadInstance.Status.GateReleaseTime.IsZero())
+			testrequire.Equal(t, tt.wantSkipped, workloadInstance.IsPreDeploymentSucceeded())
+			testrequire.Equal(t, tt.wantSkipped, workloadInstance.IsPreDeploymentEvaluationSucceeded())
+			if tt.wantSkipped {
+				condition := workloadInstance.GetCondition(v1alpha1.PreDeploymentChecksSucceededConditionType)
+				testrequire.Equal(t, metav1.ConditionTrue, condition.Status)
+				testrequire.Equal(t, AlreadyDeployedReason, condition.Reason)
+				// only the post-deployment checks are left
+				next, found := NextStep(workloadInstance)
+				testrequire.True(t, found)
+				testrequire.Equal(t, StepDeployment, next)
+			}
+			if tt.wantReleased {
+				testrequire.Contains(t, <-r.Recorder.(*record.FakeRecorder).Events, AlreadyDeployedReason)
+			} else {
+				testrequire.Empty(t, r.Recorder.(*record.FakeRecorder).Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// cancelChecksFinalizer keeps a KeptnWorkloadInstance that is being deleted until its running checks have been cancelled
+const cancelChecksFinalizer = "keptn.sh/cancel-checks"
+
+// reconcileCancelFinalizer adds the finalizer to instances in flight and removes it once they have completed, so that
+// completed instances can be deleted without the operator. It returns true if the instance is being deleted and must
+// not be reconciled any further.
+func (r *KeptnWorkloadInstanceReconciler) reconcileCancelFinalizer(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	if !workloadInstance.DeletionTimestamp.IsZero() {
+		return true, r.finalizeChecks(ctx, workloadInstance)
+	}
+
+	inFlight := !workloadInstance.IsCompleted()
+	if inFlight == controllerutil.ContainsFinalizer(workloadInstance, cancelChecksFinalizer) {
+		return false, nil
+	}
+	if inFlight {
+		controllerutil.AddFinalizer(workloadInstance, cancelChecksFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(workloadInstance, cancelChecksFinalizer)
+	}
+	return false, r.Update(ctx, workloadInstance)
+}
+
+// finalizeChecks deletes the KeptnTasks and KeptnEvaluations of a deleted instance that are still running, so that
+// their Jobs do not keep running to completion. Checks that are already gone are skipped, and the finalizer is kept if
+// a check cannot be deleted, so that the deletion is retried.
+func (r *KeptnWorkloadInstanceReconciler) finalizeChecks(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if !controllerutil.ContainsFinalizer(workloadInstance, cancelChecksFinalizer) {
+		return nil
+	}
+
+	if !workloadInstance.IsCompleted() {
+		if err := r.cancelChecks(ctx, workloadInstance); err != nil {
+			return fmt.Errorf("could not cancel the checks of KeptnWorkloadInstance %s: %w", workloadInstance.Name, err)
+		}
+		controllercommon.RecordEvent(r.Recorder, common.PhaseCancelled, "Warning", workloadInstance, "Cancelled", "has been cancelled since the workload instance has been deleted", workloadInstance.GetVersion())
+	}
+
+	controllerutil.RemoveFinalizer(workloadInstance, cancelChecksFinalizer)
+	return r.Update(ctx, workloadInstance)
+}
@@ -0,0 +1,96 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// failingDeleteClient fails to delete KeptnTasks, like an unavailable API server
+type failingDeleteClient struct {
+	client.Client
+}
+
+func (c failingDeleteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*v1alpha1.KeptnTask); ok {
+		return errors.New("connection refused")
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func newCancelFinalizerTestInstance() *v1alpha1.KeptnWorkloadInstance {
+	return &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{Version: "1.0.0"},
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "load-test", TaskName: "load-test-12345", Status: common.StateProgressing},
+			},
+			PreDeploymentEvaluationTaskStatus: []v1alpha1.EvaluationStatus{
+				// already deleted
+				{EvaluationDefinitionName: "slo", EvaluationName: "slo-12345", Status: common.StateProgressing},
+			},
+		},
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileCancelFinalizer(t *testing.T) {
+	workloadInstance := newCancelFinalizerTestInstance()
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+
+	deleted, err := r.reconcileCancelFinalizer(context.TODO(), workloadInstance)
+	testrequire.Nil(t, err)
+	testrequire.False(t, deleted)
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+	testrequire.True(t, controllerutil.ContainsFinalizer(stored, cancelChecksFinalizer))
+
+	// completed instances can be deleted without the operator
+	stored.Complete()
+	deleted, err = r.reconcileCancelFinalizer(context.TODO(), stored)
+	testrequire.Nil(t, err)
+	testrequire.False(t, deleted)
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+	testrequire.False(t, controllerutil.ContainsFinalizer(stored, cancelChecksFinalizer))
+}
+
+func TestKeptnWorkloadInstanceReconciler_finalizeChecks(t *testing.T) {
+	workloadInstance := newCancelFinalizerTestInstance()
+	workloadInstance.Finalizers = []string{cancelChecksFinalizer}
+	task := &v1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "load-test-12345"}}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, task)
+	testrequire.Nil(t, r.Client.Delete(context.TODO(), workloadInstance))
+	testrequire.Nil(t, r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), workloadInstance))
+	testrequire.False(t, workloadInstance.DeletionTimestamp.IsZero())
+
+	// the finalizer is kept as long as the task cannot be deleted
+	c := r.Client
+	r.Client = failingDeleteClient{Client: c}
+	deleted, err := r.reconcileCancelFinalizer(context.TODO(), workloadInstance)
+	testrequire.True(t, deleted)
+	testrequire.ErrorContains(t, err, "connection refused")
+	testrequire.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), workloadInstance))
+	testrequire.True(t, controllerutil.ContainsFinalizer(workloadInstance, cancelChecksFinalizer))
+	testrequire.Empty(t, r.Recorder.(*record.FakeRecorder).Events)
+
+	r.Client = c
+	deleted, err = r.reconcileCancelFinalizer(context.TODO(), workloadInstance)
+	testrequire.True(t, deleted)
+	testrequire.Nil(t, err)
+	testrequire.True(t, apierrors.IsNotFound(r.Client.Get(context.TODO(), client.ObjectKeyFromObject(task), &v1alpha1.KeptnTask{})))
+	testrequire.Contains(t, <-r.Recorder.(*record.FakeRecorder).Events, "has been cancelled since the workload instance has been deleted")
+
+	// the instance is gone once the finalizer has been removed
+	testrequire.True(t, apierrors.IsNotFound(r.Client.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), workloadInstance)))
+}
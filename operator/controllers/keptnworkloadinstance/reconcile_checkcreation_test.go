@@ -0,0 +1,94 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// failingStatusClient fails to update the status of any object, like an API server that times out
+type failingStatusClient struct {
+	client.Client
+}
+
+func (c failingStatusClient) Status() client.StatusWriter {
+	return failingStatusWriter{StatusWriter: c.Client.Status()}
+}
+
+type failingStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w failingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return errors.New("the server was unable to return a response in the time allotted")
+}
+
+func TestKeptnWorkloadInstanceReconciler_checkCreationSurvivesFailedStatusUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		reconcile func(r *KeptnWorkloadInstanceReconciler, workloadInstance *v1alpha1.KeptnWorkloadInstance) error
+		count     func(c client.Client) int
+	}{
+		{
+			name: "task",
+			reconcile: func(r *KeptnWorkloadInstanceReconciler, workloadInstance *v1alpha1.KeptnWorkloadInstance) error {
+				_, err := r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PreDeploymentCheckType)
+				return err
+			},
+			count: func(c client.Client) int {
+				tasks := &v1alpha1.KeptnTaskList{}
+				testrequire.Nil(t, c.List(context.TODO(), tasks))
+				return len(tasks.Items)
+			},
+		},
+		{
+			name: "evaluation",
+			reconcile: func(r *KeptnWorkloadInstanceReconciler, workloadInstance *v1alpha1.KeptnWorkloadInstance) error {
+				_, err := r.reconcilePrePostEvaluation(context.TODO(), workloadInstance, common.PreDeploymentEvaluationCheckType)
+				return err
+			},
+			count: func(c client.Client) int {
+				evaluations := &v1alpha1.KeptnEvaluationList{}
+				testrequire.Nil(t, c.List(context.TODO(), evaluations))
+				return len(evaluations.Items)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0", UID: "instance-uid"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						AppName:                  "my-app",
+						Version:                  "1.0.0",
+						PreDeploymentTasks:       []string{"my-task"},
+						PreDeploymentEvaluations: []string{"my-evaluation"},
+					},
+					WorkloadName: "my-app-my-workload",
+				},
+			}
+			r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+			r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+			c := r.Client
+
+			// the check is created, but its name cannot be stored in the status
+			r.Client = failingStatusClient{Client: c}
+			testrequire.NotNil(t, tt.reconcile(r, workloadInstance.DeepCopy()))
+			testrequire.Equal(t, 1, tt.count(c))
+
+			r.Client = c
+			stored := &v1alpha1.KeptnWorkloadInstance{}
+			testrequire.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(workloadInstance), stored))
+			testrequire.Nil(t, tt.reconcile(r, stored))
+			testrequire.Equal(t, 1, tt.count(c))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CoolingDownReason is set on the status of a task whose creation is delayed by the cooldown of its KeptnTaskDefinition
+const CoolingDownReason = "CoolingDown"
+
+// getTaskCooldownEnd returns the time until which the task definition must not run for the workload of the instance.
+// It is derived from the KeptnTasks that have been created for other versions of the workload, so that the cooldown
+// survives restarts of the operator. The zero time is returned if the task definition has no cooldown.
+func (r *KeptnWorkloadInstanceReconciler) getTaskCooldownEnd(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, taskDefinitionName string) (time.Time, error) {
+	definition := &klcv1alpha1.KeptnTaskDefinition{}
+	err := r.Client.Get(ctx, controllercommon.GetTaskDefinitionKey(workloadInstance.Namespace, taskDefinitionName), definition)
+	if errors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if definition.Spec.Cooldown == nil || definition.Spec.Cooldown.Duration <= 0 {
+		return time.Time{}, nil
+	}
+
+	tasks := &klcv1alpha1.KeptnTaskList{}
+	if err := r.Client.List(ctx, tasks, client.InNamespace(workloadInstance.Namespace)); err != nil {
+		return time.Time{}, err
+	}
+	var lastRun time.Time
+	for _, task := range tasks.Items {
+		if task.Spec.Workload != workloadInstance.Spec.WorkloadName || task.GetTaskDefinitionReference() != taskDefinitionName || task.Spec.WorkloadVersion == workloadInstance.Spec.Version {
+			continue
+		}
+		if task.CreationTimestamp.After(lastRun) {
+			lastRun = task.CreationTimestamp.Time
+		}
+	}
+	if lastRun.IsZero() {
+		return time.Time{}, nil
+	}
+	return lastRun.Add(definition.Spec.Cooldown.Duration), nil
+}
@@ -0,0 +1,89 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestKeptnWorkloadInstanceReconciler_reconcileTasksCooldown(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastRunAgo   time.Duration
+		lastVersion  string
+		wantCooldown bool
+	}{
+		{
+			name:         "another version ran within the cooldown",
+			lastRunAgo:   time.Minute,
+			lastVersion:  "1.0.0",
+			wantCooldown: true,
+		},
+		{
+			name:         "another version ran before the cooldown",
+			lastRunAgo:   20 * time.Minute,
+			lastVersion:  "1.0.0",
+			wantCooldown: false,
+		},
+		{
+			name:         "the same version does not cool down",
+			lastRunAgo:   time.Minute,
+			lastVersion:  "2.0.0",
+			wantCooldown: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-2.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						AppName:            "my-app",
+						Version:            "2.0.0",
+						PreDeploymentTasks: []string{"load-test"},
+					},
+					WorkloadName: "my-app-my-workload",
+				},
+			}
+			lastRun := time.Now().Add(-tt.lastRunAgo)
+			objects := []client.Object{
+				workloadInstance,
+				&v1alpha1.KeptnTaskDefinition{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "load-test"},
+					Spec:       v1alpha1.KeptnTaskDefinitionSpec{Cooldown: &metav1.Duration{Duration: 10 * time.Minute}},
+				},
+				&v1alpha1.KeptnTask{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pre-load-test-12345", CreationTimestamp: metav1.NewTime(lastRun)},
+					Spec:       v1alpha1.KeptnTaskSpec{Workload: "my-app-my-workload", WorkloadVersion: tt.lastVersion, TaskDefinition: "load-test"},
+				},
+			}
+			r := newWorkloadInstanceTestReconciler(t, objects...)
+			r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+			statuses, _, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance)
+			testrequire.Nil(t, err)
+			testrequire.Len(t, statuses, 1)
+
+			tasks := &v1alpha1.KeptnTaskList{}
+			testrequire.Nil(t, r.Client.List(context.TODO(), tasks))
+			if tt.wantCooldown {
+				testrequire.Equal(t, common.StatePending, statuses[0].Status)
+				testrequire.Equal(t, CoolingDownReason, statuses[0].Reason)
+				testrequire.Empty(t, statuses[0].TaskName)
+				testrequire.WithinDuration(t, lastRun.Add(10*time.Minute), statuses[0].EarliestStartTime.Time, time.Second)
+				testrequire.Len(t, tasks.Items, 1)
+			} else {
+				testrequire.Empty(t, statuses[0].Reason)
+				testrequire.NotEmpty(t, statuses[0].TaskName)
+				testrequire.Len(t, tasks.Items, 2)
+			}
+		})
+	}
+}
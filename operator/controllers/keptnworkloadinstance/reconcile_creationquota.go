@@ -0,0 +1,29 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+)
+
+// recordCreationQuota keeps the CreationQuotaExceeded condition of the instance in line with the outcome of the last
+// creation of one of its checks. Creations rejected by a ResourceQuota keep failing on every retry until a human
+// raises the quota or frees up resources, so they are surfaced on the instance instead of only in the logs.
+func (r *KeptnWorkloadInstanceReconciler) recordCreationQuota(ctx context.Context, phase common.KeptnPhaseType, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, err error) {
+	exceeded := controllercommon.ClassifyCreationError(err) == controllercommon.CreationResultQuota
+	message := ""
+	if exceeded {
+		message = err.Error()
+	}
+	if !workloadInstance.SetCreationQuotaExceeded(exceeded, message) || !exceeded {
+		// a cleared condition is written together with the status of the created check
+		return
+	}
+	controllercommon.RecordEvent(r.Recorder, phase, "Warning", workloadInstance, "CreationQuotaExceeded", "could not create check since a ResourceQuota is exceeded", workloadInstance.GetVersion())
+	// the failed reconciliation does not write the status otherwise
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		r.Log.Error(err, "could not update the CreationQuotaExceeded condition")
+	}
+}
@@ -0,0 +1,57 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// failingCreateClient rejects every create request with the given error
+type failingCreateClient struct {
+	client.Client
+	err error
+}
+
+func (c *failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.err
+}
+
+func TestKeptnWorkloadInstanceReconciler_createKeptnTaskQuotaExceeded(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	fakeClient := r.Client
+	r.Client = &failingCreateClient{
+		Client: fakeClient,
+		err:    apierrors.NewForbidden(schema.GroupResource{Group: "lifecycle.keptn.sh", Resource: "keptntasks"}, "", errors.New("exceeded quota: checks")),
+	}
+
+	_, err := r.createKeptnTask(context.TODO(), "default", workloadInstance, "load-test", common.PreDeploymentCheckType)
+	testrequire.NotNil(t, err)
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, stored))
+	testrequire.True(t, meta.IsStatusConditionTrue(stored.Status.Conditions, v1alpha1.CreationQuotaExceededConditionType))
+
+	// the condition is cleared once a check can be created again
+	r.Client = fakeClient
+	_, err = r.createKeptnTask(context.TODO(), "default", workloadInstance, "load-test", common.PreDeploymentCheckType)
+	testrequire.Nil(t, err)
+	testrequire.False(t, meta.IsStatusConditionTrue(workloadInstance.Status.Conditions, v1alpha1.CreationQuotaExceededConditionType))
+}
@@ -5,6 +5,7 @@ import (
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,24 +19,36 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileDeployment(ctx context.Contex
 		if err != nil {
 			return common.StateUnknown, err
 		}
+		if isPodRunning && workloadInstance.WaitsForEndpoints() {
+			isPodRunning, err = r.areEndpointsReady(ctx, workloadInstance)
+			if err != nil {
+				return common.StateUnknown, err
+			}
+		}
 		if isPodRunning {
-			workloadInstance.Status.DeploymentStatus = common.StateSucceeded
+			common.SetPhaseState(&workloadInstance.Status.DeploymentStatus, common.StateSucceeded)
 		} else {
-			workloadInstance.Status.DeploymentStatus = common.StateProgressing
+			common.SetPhaseState(&workloadInstance.Status.DeploymentStatus, common.StateProgressing)
 		}
 	} else {
 		isReplicaRunning, err := r.isReplicaSetRunning(ctx, workloadInstance.Spec.ResourceReference, workloadInstance.Namespace)
 		if err != nil {
 			return common.StateUnknown, err
 		}
+		if isReplicaRunning && workloadInstance.WaitsForEndpoints() {
+			isReplicaRunning, err = r.areEndpointsReady(ctx, workloadInstance)
+			if err != nil {
+				return common.StateUnknown, err
+			}
+		}
 		if isReplicaRunning {
-			workloadInstance.Status.DeploymentStatus = common.StateSucceeded
+			common.SetPhaseState(&workloadInstance.Status.DeploymentStatus, common.StateSucceeded)
 		} else {
-			workloadInstance.Status.DeploymentStatus = common.StateProgressing
+			common.SetPhaseState(&workloadInstance.Status.DeploymentStatus, common.StateProgressing)
 		}
 	}
 
-	err := r.Client.Status().Update(ctx, workloadInstance)
+	err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
 	if err != nil {
 		return common.StateUnknown, err
 	}
@@ -49,6 +62,9 @@ func (r *KeptnWorkloadInstanceReconciler) isReplicaSetRunning(ctx context.Contex
 	}
 	for _, re := range replica.Items {
 		if re.UID == resource.UID {
+			if len(re.OwnerReferences) == 0 {
+				return re.Spec.Replicas != nil && re.Status.ReadyReplicas == *re.Spec.Replicas, nil
+			}
 			replicas, err := r.getDesiredReplicas(ctx, re.OwnerReferences[0], namespace)
 			if err != nil {
 				return false, err
@@ -97,6 +113,10 @@ func (r *KeptnWorkloadInstanceReconciler) getDesiredReplicas(ctx context.Context
 		}
 		replicas = sts.Spec.Replicas
 	}
+	if replicas == nil {
+		// the API server defaults the replicas of Deployments and StatefulSets to 1
+		return 1, nil
+	}
 
 	return *replicas, nil
 
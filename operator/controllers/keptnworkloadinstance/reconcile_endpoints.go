@@ -0,0 +1,192 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// areEndpointsReady reports whether enough pods of the version of the KeptnWorkloadInstance are ready and serving
+// endpoints of the Service named in its readiness. Endpoints of pods of other versions the Service selects during a
+// rollout are not counted.
+func (r *KeptnWorkloadInstanceReconciler) areEndpointsReady(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	pods, err := r.getVersionPods(ctx, workloadInstance)
+	if err != nil {
+		return false, err
+	}
+	versionPods := map[types.UID]bool{}
+	for _, pod := range pods {
+		versionPods[pod.UID] = true
+	}
+
+	slices := &discoveryv1.EndpointSliceList{}
+	if err := r.Client.List(ctx, slices, client.InNamespace(workloadInstance.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: workloadInstance.Spec.Readiness.ServiceName}); err != nil {
+		return false, err
+	}
+	// a pod is listed in one EndpointSlice per address type, so ready pods are counted rather than endpoints
+	readyPods := map[types.UID]bool{}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" || !versionPods[endpoint.TargetRef.UID] {
+				continue
+			}
+			if isEndpointReady(endpoint) {
+				readyPods[endpoint.TargetRef.UID] = true
+			}
+		}
+	}
+
+	required := int(workloadInstance.Spec.Readiness.MinReadyEndpoints)
+	if required <= 0 {
+		required = len(pods)
+	}
+	return len(readyPods) > 0 && len(readyPods) >= required, nil
+}
+
+func isEndpointReady(endpoint discoveryv1.Endpoint) bool {
+	// unset conditions are to be interpreted as true
+	if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+		return false
+	}
+	return endpoint.Conditions.Serving == nil || *endpoint.Conditions.Serving
+}
+
+// getVersionPods returns the pods of the version of the KeptnWorkloadInstance. The pods of a ReplicaSet are
+// selected by its pod template hash and the pods of a StatefulSet by its update revision.
+func (r *KeptnWorkloadInstanceReconciler) getVersionPods(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) ([]corev1.Pod, error) {
+	resource := workloadInstance.Spec.ResourceReference
+	selector := client.MatchingLabels{}
+	switch resource.Kind {
+	case "ReplicaSet":
+		replicaSets := &appsv1.ReplicaSetList{}
+		if err := r.Client.List(ctx, replicaSets, client.InNamespace(workloadInstance.Namespace)); err != nil {
+			return nil, err
+		}
+		for _, replicaSet := range replicaSets.Items {
+			if replicaSet.UID == resource.UID && replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey] != "" {
+				selector[appsv1.DefaultDeploymentUniqueLabelKey] = replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+			}
+		}
+	case "StatefulSet":
+		statefulSets := &appsv1.StatefulSetList{}
+		if err := r.Client.List(ctx, statefulSets, client.InNamespace(workloadInstance.Namespace)); err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range statefulSets.Items {
+			if statefulSet.UID == resource.UID && statefulSet.Status.UpdateRevision != "" {
+				selector[appsv1.ControllerRevisionHashLabelKey] = statefulSet.Status.UpdateRevision
+			}
+		}
+	}
+
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList, client.InNamespace(workloadInstance.Namespace), selector); err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.UID == resource.UID || isOwnedBy(pod, resource.UID) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func isOwnedBy(pod corev1.Pod, uid types.UID) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointSliceReadinessPredicate passes EndpointSlices of a Service that are created with ready endpoints, and
+// updates that change which of their endpoints are ready. Slices that do not belong to a Service, and all other
+// events, e.g. updates of the addresses or the topology of the endpoints, are dropped before they are mapped to
+// workload instances.
+var endpointSliceReadinessPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		slice, ok := e.Object.(*discoveryv1.EndpointSlice)
+		return ok && hasService(slice) && len(getReadyEndpoints(slice)) > 0
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSlice, ok := e.ObjectOld.(*discoveryv1.EndpointSlice)
+		if !ok {
+			return false
+		}
+		newSlice, ok := e.ObjectNew.(*discoveryv1.EndpointSlice)
+		if !ok || !hasService(newSlice) {
+			return false
+		}
+		oldReady := getReadyEndpoints(oldSlice)
+		newReady := getReadyEndpoints(newSlice)
+		if len(oldReady) != len(newReady) {
+			return true
+		}
+		for endpoint := range newReady {
+			if !oldReady[endpoint] {
+				return true
+			}
+		}
+		return false
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+func hasService(slice *discoveryv1.EndpointSlice) bool {
+	return slice.Labels[discoveryv1.LabelServiceName] != ""
+}
+
+// getReadyEndpoints returns the ready endpoints of the slice, identified by the UID of their target or, if they do
+// not have one, by their first address
+func getReadyEndpoints(slice *discoveryv1.EndpointSlice) map[string]bool {
+	ready := map[string]bool{}
+	for _, endpoint := range slice.Endpoints {
+		if !isEndpointReady(endpoint) {
+			continue
+		}
+		switch {
+		case endpoint.TargetRef != nil:
+			ready[string(endpoint.TargetRef.UID)] = true
+		case len(endpoint.Addresses) > 0:
+			ready[endpoint.Addresses[0]] = true
+		}
+	}
+	return ready
+}
+
+// workloadInstancesForEndpointSlice returns the requests for all KeptnWorkloadInstances whose deployment phase waits
+// for the endpoints of the Service of the EndpointSlice
+func (r *KeptnWorkloadInstanceReconciler) workloadInstancesForEndpointSlice(obj client.Object) []reconcile.Request {
+	serviceName := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if serviceName == "" {
+		return nil
+	}
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := r.Client.List(context.TODO(), workloadInstances, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "could not list workload instances", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, workloadInstance := range workloadInstances.Items {
+		if !workloadInstance.WaitsForEndpoints() || workloadInstance.Spec.Readiness.ServiceName != serviceName || workloadInstance.Status.DeploymentStatus.IsCompleted() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: workloadInstance.Namespace, Name: workloadInstance.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,208 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func makeEndpointsTestPod(name string, hash string, owner types.UID) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		Name:            name,
+		UID:             types.UID(name + "-uid"),
+		Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "my-deployment-" + hash, UID: owner}},
+	}}
+}
+
+func makeEndpoint(pod string, ready bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{"10.0.0.1"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod, UID: types.UID(pod + "-uid")},
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileDeploymentWaitsForEndpoints(t *testing.T) {
+	replicas := int32(2)
+	tests := []struct {
+		name              string
+		minReadyEndpoints int32
+		endpoints         []discoveryv1.Endpoint
+		want              common.KeptnState
+	}{
+		{
+			name: "all pods of the version are ready endpoints",
+			endpoints: []discoveryv1.Endpoint{
+				makeEndpoint("new-1", true),
+				makeEndpoint("new-2", true),
+			},
+			want: common.StateSucceeded,
+		},
+		{
+			name: "endpoints of the previous version are not counted",
+			endpoints: []discoveryv1.Endpoint{
+				makeEndpoint("old-1", true),
+				makeEndpoint("new-1", true),
+				makeEndpoint("new-2", false),
+			},
+			want: common.StateProgressing,
+		},
+		{
+			name:              "minimum number of ready endpoints",
+			minReadyEndpoints: 1,
+			endpoints: []discoveryv1.Endpoint{
+				makeEndpoint("old-1", true),
+				makeEndpoint("new-1", true),
+				makeEndpoint("new-2", false),
+			},
+			want: common.StateSucceeded,
+		},
+		{
+			name: "endpoints have not been propagated yet",
+			want: common.StateProgressing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-2.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						Version:           "2.0.0",
+						ResourceReference: v1alpha1.ResourceReference{UID: "rs-new", Kind: "ReplicaSet"},
+					},
+					Readiness: v1alpha1.Readiness{Mode: v1alpha1.ReadinessModeEndpoints, ServiceName: "my-service", MinReadyEndpoints: tt.minReadyEndpoints},
+				},
+			}
+			deployment, replicaSet := makeDeployment(&replicas)
+			replicaSet.UID = "rs-new"
+			replicaSet.Labels = map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "new"}
+			replicaSet.Status.ReadyReplicas = 2
+			objects := []client.Object{
+				workloadInstance,
+				deployment,
+				replicaSet,
+				makeEndpointsTestPod("old-1", "old", "rs-old"),
+				makeEndpointsTestPod("new-1", "new", "rs-new"),
+				makeEndpointsTestPod("new-2", "new", "rs-new"),
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "default", Name: "my-service-abcde", Labels: map[string]string{discoveryv1.LabelServiceName: "my-service"}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   tt.endpoints,
+				},
+				// a dual-stack Service lists every pod a second time
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "default", Name: "my-service-fghij", Labels: map[string]string{discoveryv1.LabelServiceName: "my-service"}},
+					AddressType: discoveryv1.AddressTypeIPv6,
+					Endpoints:   tt.endpoints,
+				},
+				// endpoints of other Services are ignored
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "default", Name: "other-service-abcde", Labels: map[string]string{discoveryv1.LabelServiceName: "other-service"}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   []discoveryv1.Endpoint{makeEndpoint("new-1", true), makeEndpoint("new-2", true)},
+				},
+			}
+			r := newWorkloadInstanceTestReconciler(t, objects...)
+
+			state, err := r.reconcileDeployment(context.TODO(), workloadInstance)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, tt.want, state)
+		})
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_workloadInstancesForEndpointSlice(t *testing.T) {
+	waiting := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "waiting"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			Readiness: v1alpha1.Readiness{Mode: v1alpha1.ReadinessModeEndpoints, ServiceName: "my-service"},
+		},
+	}
+	deployed := waiting.DeepCopy()
+	deployed.Name = "deployed"
+	deployed.Status.DeploymentStatus = common.StateSucceeded
+	podReadiness := waiting.DeepCopy()
+	podReadiness.Name = "pod-readiness"
+	podReadiness.Spec.Readiness.Mode = v1alpha1.ReadinessModePods
+
+	r := newWorkloadInstanceTestReconciler(t, waiting, deployed, podReadiness)
+
+	requests := r.workloadInstancesForEndpointSlice(&discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-service-abcde", Labels: map[string]string{discoveryv1.LabelServiceName: "my-service"}},
+	})
+	testrequire.Len(t, requests, 1)
+	testrequire.Equal(t, "waiting", requests[0].Name)
+
+	testrequire.Empty(t, r.workloadInstancesForEndpointSlice(&discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-service-abcde", Labels: map[string]string{discoveryv1.LabelServiceName: "other-service"}},
+	}))
+}
+
+func TestEndpointSliceReadinessPredicate(t *testing.T) {
+	makeSlice := func(service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		slice := &discoveryv1.EndpointSlice{Endpoints: endpoints}
+		if service != "" {
+			slice.Labels = map[string]string{discoveryv1.LabelServiceName: service}
+		}
+		return slice
+	}
+	makeEndpoint := func(pod string, ready bool, address string) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(ready)},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", UID: types.UID(pod)},
+		}
+	}
+	tests := []struct {
+		name   string
+		old    client.Object
+		new    client.Object
+		passes bool
+	}{
+		{
+			name:   "endpoint becomes ready",
+			old:    makeSlice("my-service", makeEndpoint("a", false, "10.0.0.1")),
+			new:    makeSlice("my-service", makeEndpoint("a", true, "10.0.0.1")),
+			passes: true,
+		},
+		{
+			name:   "ready endpoint is replaced",
+			old:    makeSlice("my-service", makeEndpoint("a", true, "10.0.0.1")),
+			new:    makeSlice("my-service", makeEndpoint("b", true, "10.0.0.2")),
+			passes: true,
+		},
+		{
+			name: "readiness unchanged",
+			old:  makeSlice("my-service", makeEndpoint("a", true, "10.0.0.1")),
+			new:  makeSlice("my-service", makeEndpoint("a", true, "10.0.0.3")),
+		},
+		{
+			name: "slice without a service",
+			old:  makeSlice("", makeEndpoint("a", false, "10.0.0.1")),
+			new:  makeSlice("", makeEndpoint("a", true, "10.0.0.1")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testrequire.Equal(t, tt.passes, endpointSliceReadinessPredicate.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}))
+		})
+	}
+	testrequire.True(t, endpointSliceReadinessPredicate.Create(event.CreateEvent{Object: makeSlice("my-service", makeEndpoint("a", true, "10.0.0.1"))}))
+	testrequire.False(t, endpointSliceReadinessPredicate.Create(event.CreateEvent{Object: makeSlice("my-service", makeEndpoint("a", false, "10.0.0.1"))}))
+	testrequire.False(t, endpointSliceReadinessPredicate.Create(event.CreateEvent{Object: makeSlice("", makeEndpoint("a", true, "10.0.0.1"))}))
+	testrequire.False(t, endpointSliceReadinessPredicate.Delete(event.DeleteEvent{Object: makeSlice("my-service")}))
+}
@@ -0,0 +1,61 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AuditOnlyReason is the reason of the pre-deployment checks of instances that are not enforced
+const AuditOnlyReason = "AuditOnly"
+
+// decideEnforcement records once, when the instance starts, whether its pods are held back until its pre-deployment
+// checks have succeeded. The pods of instances that are not enforced are released right away, while their checks
+// still run. Since the decision is kept in the status, changing the enforcement percentage only affects new instances.
+// Instances that have started before the decision has been recorded remain enforced.
+func (r *KeptnWorkloadInstanceReconciler) decideEnforcement(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if workloadInstance.Status.Enforcement != nil {
+		return nil
+	}
+	enforced, bucket := r.EnforcementRollout.Decide(workloadInstance.Namespace, workloadInstance.Spec.WorkloadName)
+	if workloadInstance.Status.CurrentPhase != "" {
+		enforced = true
+	}
+	workloadInstance.Status.Enforcement = &klcv1alpha1.EnforcementStatus{Enforced: enforced, Bucket: bucket}
+	if enforced {
+		return controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+	}
+	message := fmt.Sprintf("runs without holding back the pods since bucket %d of the workload is not below the enforcement percentage %d", bucket, r.EnforcementRollout.Percentage)
+	return r.bypassGate(ctx, workloadInstance, AuditOnlyReason, message)
+}
+
+// GetEnforcementSplit reports the number of workload instances in flight whose pods are held back by their
+// pre-deployment checks (enforced) and whose checks run in audit mode (not enforced)
+func (r *KeptnWorkloadInstanceReconciler) GetEnforcementSplit(ctx context.Context) ([]common.GaugeValue, error) {
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	err := r.List(ctx, workloadInstances)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve workload instances: %w", err)
+	}
+
+	enforced, audited := int64(0), int64(0)
+	for _, workloadInstance := range workloadInstances.Items {
+		if workloadInstance.IsEndTimeSet() || workloadInstance.Status.Enforcement == nil {
+			continue
+		}
+		if workloadInstance.Status.Enforcement.Enforced {
+			enforced++
+		} else {
+			audited++
+		}
+	}
+
+	return []common.GaugeValue{
+		{Value: enforced, Attributes: []attribute.KeyValue{common.Enforced.Bool(true)}},
+		{Value: audited, Attributes: []attribute.KeyValue{common.Enforced.Bool(false)}},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_decideEnforcement(t *testing.T) {
+	bucket := controllercommon.GetEnforcementBucket("default", "my-app-my-workload")
+	tests := []struct {
+		name         string
+		rollout      *controllercommon.EnforcementRollout
+		currentPhase string
+		enforcement  *v1alpha1.EnforcementStatus
+		wantEnforced bool
+	}{
+		{
+			name:         "all workloads are enforced",
+			wantEnforced: true,
+		},
+		{
+			name:         "bucket is below the percentage",
+			rollout:      &controllercommon.EnforcementRollout{Percentage: bucket + 1},
+			wantEnforced: true,
+		},
+		{
+			name:    "bucket is not below the percentage",
+			rollout: &controllercommon.EnforcementRollout{Percentage: bucket},
+		},
+		{
+			name:         "instance has started before the decision has been recorded",
+			rollout:      &controllercommon.EnforcementRollout{Percentage: 0},
+			currentPhase: common.PhaseWorkloadPreDeployment.ShortName,
+			wantEnforced: true,
+		},
+		{
+			name:         "decision of an instance in flight is kept",
+			rollout:      &controllercommon.EnforcementRollout{Percentage: 0},
+			enforcement:  &v1alpha1.EnforcementStatus{Enforced: true, Bucket: bucket},
+			wantEnforced: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{Version: "1.0.0"},
+					WorkloadName:      "my-app-my-workload",
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{CurrentPhase: tt.currentPhase, Enforcement: tt.enforcement},
+			}
+			r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+			r.EnforcementRollout = tt.rollout
+
+			testrequire.Nil(t, r.decideEnforcement(context.TODO(), workloadInstance))
+			testrequire.Equal(t, &v1alpha1.EnforcementStatus{Enforced: tt.wantEnforced, Bucket: bucket}, workloadInstance.Status.Enforcement)
+			testrequire.Equal(t, !tt.wantEnforced, !workloadInstance.Status.GateReleaseTime.IsZero())
+			if tt.wantEnforced {
+				testrequire.Empty(t, r.Recorder.(*record.FakeRecorder).Events)
+			} else {
+				testrequire.Contains(t, <-r.Recorder.(*record.FakeRecorder).Events, AuditOnlyReason)
+			}
+
+			// the decision is stored, so that changing the percentage does not flip the instance
+			stored := &v1alpha1.KeptnWorkloadInstance{}
+			testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app-my-workload-1.0.0"}, stored))
+			testrequire.Equal(t, workloadInstance.Status.Enforcement, stored.Status.Enforcement)
+		})
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_GetEnforcementSplit(t *testing.T) {
+	newInstance := func(name string, enforcement *v1alpha1.EnforcementStatus) *v1alpha1.KeptnWorkloadInstance {
+		return &v1alpha1.KeptnWorkloadInstance{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Status:     v1alpha1.KeptnWorkloadInstanceStatus{Enforcement: enforcement},
+		}
+	}
+	finished := newInstance("finished", &v1alpha1.EnforcementStatus{Enforced: false})
+	finished.Status.EndTime = metav1.Now()
+	r := newWorkloadInstanceTestReconciler(t,
+		newInstance("enforced-1", &v1alpha1.EnforcementStatus{Enforced: true}),
+		newInstance("enforced-2", &v1alpha1.EnforcementStatus{Enforced: true}),
+		newInstance("audited", &v1alpha1.EnforcementStatus{Enforced: false}),
+		newInstance("undecided", nil),
+		finished,
+	)
+
+	split, err := r.GetEnforcementSplit(context.TODO())
+	testrequire.Nil(t, err)
+	testrequire.Equal(t, []common.GaugeValue{
+		{Value: 2, Attributes: []attribute.KeyValue{common.Enforced.Bool(true)}},
+		{Value: 1, Attributes: []attribute.KeyValue{common.Enforced.Bool(false)}},
+	}, split)
+}
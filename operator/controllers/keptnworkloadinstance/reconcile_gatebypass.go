@@ -0,0 +1,27 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+)
+
+// bypassGate releases the pods of an instance before its pre-deployment checks have succeeded and persists the status
+// of the instance together with the release. All bypasses go through here, so that each one is audited the same way:
+// it is counted by the keptn.gate.bypass counter, reported with an event and logged, stating the reason.
+func (r *KeptnWorkloadInstanceReconciler) bypassGate(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, reason string, message string) error {
+	held := workloadInstance.Status.GateReleaseTime.IsZero()
+	workloadInstance.ReleaseGate()
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return err
+	}
+	if !held {
+		return nil
+	}
+	r.Meters.GateBypasses.Add(ctx, 1, workloadInstance.GetGateWaitMetricsAttributes(reason)...)
+	controllercommon.RecordEvent(r.Recorder, common.PhaseWorkloadPreDeployment, "Warning", workloadInstance, reason, message, workloadInstance.GetVersion())
+	r.Log.Info("Released the pods before the pre-deployment checks have succeeded", "namespace", workloadInstance.Namespace, "name", workloadInstance.Name, "workload", workloadInstance.Spec.WorkloadName, "version", workloadInstance.Spec.Version, "reason", reason)
+	return nil
+}
@@ -0,0 +1,52 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_bypassGate(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Meters = newCheckTestMeters(t, meter)
+
+	testrequire.Nil(t, r.bypassGate(context.TODO(), workloadInstance, AuditOnlyReason, "runs without holding back the pods"))
+	// an instance whose pods have already been released is not audited again
+	testrequire.Nil(t, r.bypassGate(context.TODO(), workloadInstance, AuditOnlyReason, "runs without holding back the pods"))
+
+	result := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, result))
+	testrequire.False(t, result.Status.GateReleaseTime.IsZero())
+
+	recorder := r.Recorder.(*record.FakeRecorder)
+	testrequire.Len(t, recorder.Events, 1)
+	testrequire.Contains(t, <-recorder.Events, "Warning WorkloadPreDeployTasksAuditOnly")
+
+	collected, err := reader.Collect(context.TODO())
+	testrequire.Nil(t, err)
+	bypasses := findMetric(collected, "keptn.gate.bypass")
+	testrequire.NotNil(t, bypasses)
+	dataPoints := bypasses.Data.(metricdata.Sum[int64]).DataPoints
+	testrequire.Len(t, dataPoints, 1)
+	testrequire.Equal(t, int64(1), dataPoints[0].Value)
+	reason, _ := dataPoints[0].Attributes.Value(common.GateWaitReason)
+	testrequire.Equal(t, AuditOnlyReason, reason.AsString())
+}
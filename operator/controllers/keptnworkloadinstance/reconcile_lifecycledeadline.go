@@ -0,0 +1,120 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// LifecycleDeadlineGatePolicyKeep rejects the pods of an instance whose lifecycle deadline is exceeded before its pre-deployment checks have succeeded
+	LifecycleDeadlineGatePolicyKeep = "keep"
+	// LifecycleDeadlineGatePolicyRelease lets the scheduler bind the pods of an instance whose lifecycle deadline is exceeded before its pre-deployment checks have succeeded
+	LifecycleDeadlineGatePolicyRelease = "release"
+)
+
+// LifecycleDeadlineExceededReason is the reason of instances that are cancelled since their lifecycle deadline is exceeded
+const LifecycleDeadlineExceededReason = "LifecycleDeadlineExceeded"
+
+// cancelIfLifecycleDeadlineExceeded fails an in-flight KeptnWorkloadInstance whose lifecycle has not finished within its
+// lifecycle deadline, and cancels the checks that are still running. The deadline is compared against the active time
+// of the lifecycle, so that the time it has been paused, e.g. while it has been parked, does not count.
+func (r *KeptnWorkloadInstanceReconciler) cancelIfLifecycleDeadlineExceeded(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	deadline, err := r.getLifecycleDeadline(ctx, workloadInstance)
+	if err != nil || deadline <= 0 {
+		return false, err
+	}
+	if workloadInstance.GetActiveDuration() <= deadline {
+		return false, nil
+	}
+
+	if err := r.cancelChecks(ctx, workloadInstance); err != nil {
+		return false, err
+	}
+	if err := r.SpanHandler.UnbindSpan(workloadInstance, workloadInstance.Status.CurrentPhase); err != nil {
+		r.Log.Error(err, "cannot unbind span")
+	}
+	release := false
+	if workloadInstance.Status.GateReleaseTime.IsZero() {
+		if r.LifecycleDeadlineGatePolicy == LifecycleDeadlineGatePolicyRelease {
+			release = true
+		} else if !workloadInstance.Status.PreDeploymentEvaluationStatus.IsCompleted() {
+			// the scheduler rejects the pods of the workload
+			workloadInstance.Status.PreDeploymentEvaluationStatus = common.StateFailed
+		}
+	}
+	workloadInstance.Status.CurrentPhase = common.PhaseCancelled.ShortName
+	workloadInstance.Status.Status = common.StateFailed
+	workloadInstance.CompleteWithReason(LifecycleDeadlineExceededReason, fmt.Sprintf("lifecycle has not finished within %s", deadline))
+	if release {
+		if err := r.bypassGate(ctx, workloadInstance, LifecycleDeadlineExceededReason, fmt.Sprintf("has released the pods since its lifecycle has not finished within %s", deadline)); err != nil {
+			return false, err
+		}
+		r.Meters.GateWaitDuration.Record(ctx, workloadInstance.Status.GateWaitDuration.Seconds(), workloadInstance.GetGateWaitMetricsAttributes(common.GateWaitReasonLifecycleDeadline)...)
+	} else if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return false, err
+	}
+	controllercommon.RecordEvent(r.Recorder, common.PhaseCancelled, "Warning", workloadInstance, LifecycleDeadlineExceededReason, fmt.Sprintf("has been cancelled since its lifecycle has not finished within %s", deadline), workloadInstance.GetVersion())
+	return true, nil
+}
+
+// capAtLifecycleDeadline makes sure that an instance whose lifecycle has not finished is reconciled again once its
+// lifecycle deadline is exceeded, even if it would otherwise only be resynced after a longer interval
+func (r *KeptnWorkloadInstanceReconciler) capAtLifecycleDeadline(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, res ctrl.Result) ctrl.Result {
+	if res.RequeueAfter <= 0 {
+		return res
+	}
+	deadline, err := r.getLifecycleDeadline(ctx, workloadInstance)
+	if err != nil || deadline <= 0 {
+		return res
+	}
+	// the deadline is exceeded once the remaining time has passed, not when it is reached
+	left := deadline - workloadInstance.GetActiveDuration() + time.Second
+	if left < time.Second {
+		left = time.Second
+	}
+	if res.RequeueAfter > left {
+		res.RequeueAfter = left
+	}
+	return res
+}
+
+// pauseLifecycle stops the clock of the lifecycle deadline while the start of the instance is held back
+func (r *KeptnWorkloadInstanceReconciler) pauseLifecycle(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if !workloadInstance.PauseLifecycle() {
+		return nil
+	}
+	return controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+}
+
+// resumeLifecycle starts the clock of the lifecycle deadline again once the instance is no longer held back
+func (r *KeptnWorkloadInstanceReconciler) resumeLifecycle(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if !workloadInstance.ResumeLifecycle() {
+		return nil
+	}
+	return controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+}
+
+// getLifecycleDeadline returns the lifecycle deadline of the instance, which defaults to the one of its KeptnApp
+// and finally to the lifecycle deadline of the operator. Zero means that the lifecycle is not limited.
+func (r *KeptnWorkloadInstanceReconciler) getLifecycleDeadline(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (time.Duration, error) {
+	if workloadInstance.Spec.LifecycleDeadline != nil {
+		return workloadInstance.Spec.LifecycleDeadline.Duration, nil
+	}
+	app := &klcv1alpha1.KeptnApp{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: workloadInstance.Namespace, Name: workloadInstance.Spec.AppName}, app)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	if err == nil && app.Spec.LifecycleDeadline != nil {
+		return app.Spec.LifecycleDeadline.Duration, nil
+	}
+	return r.LifecycleDeadline, nil
+}
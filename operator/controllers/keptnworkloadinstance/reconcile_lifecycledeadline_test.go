@@ -0,0 +1,131 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKeptnWorkloadInstanceReconciler_cancelIfLifecycleDeadlineExceeded(t *testing.T) {
+	tests := []struct {
+		name             string
+		age              time.Duration
+		activeDuration   *metav1.Duration
+		appDeadline      *metav1.Duration
+		instanceDeadline *metav1.Duration
+		defaultDeadline  time.Duration
+		gatePolicy       string
+		wantCancelled    bool
+	}{
+		{
+			name:          "no deadline",
+			age:           24 * time.Hour,
+			wantCancelled: false,
+		},
+		{
+			name:            "within the default deadline",
+			age:             30 * time.Minute,
+			defaultDeadline: time.Hour,
+			wantCancelled:   false,
+		},
+		{
+			name:            "default deadline exceeded",
+			age:             2 * time.Hour,
+			defaultDeadline: time.Hour,
+			gatePolicy:      LifecycleDeadlineGatePolicyKeep,
+			wantCancelled:   true,
+		},
+		{
+			name:            "app deadline overrides the default deadline",
+			age:             2 * time.Hour,
+			appDeadline:     &metav1.Duration{Duration: 3 * time.Hour},
+			defaultDeadline: time.Hour,
+			wantCancelled:   false,
+		},
+		{
+			name:             "instance deadline overrides the app deadline",
+			age:              2 * time.Hour,
+			appDeadline:      &metav1.Duration{Duration: 3 * time.Hour},
+			instanceDeadline: &metav1.Duration{Duration: time.Hour},
+			gatePolicy:       LifecycleDeadlineGatePolicyRelease,
+			wantCancelled:    true,
+		},
+		{
+			name:            "the time the lifecycle has been paused does not count",
+			age:             2 * time.Hour,
+			activeDuration:  &metav1.Duration{Duration: 10 * time.Minute},
+			defaultDeadline: time.Hour,
+			wantCancelled:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "default",
+					Name:              "my-app-my-workload-1.0.0",
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-tt.age)),
+				},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						AppName:           "my-app",
+						LifecycleDeadline: tt.instanceDeadline,
+					},
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{
+					ActiveDuration:                tt.activeDuration,
+					CurrentPhase:                  common.PhaseWorkloadPreDeployment.ShortName,
+					Status:                        common.StateProgressing,
+					PreDeploymentStatus:           common.StateProgressing,
+					PreDeploymentEvaluationStatus: common.StatePending,
+					PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+						{TaskDefinitionName: "running", TaskName: "running-task", Status: common.StateProgressing},
+					},
+				},
+			}
+			app := &v1alpha1.KeptnApp{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app"},
+				Spec:       v1alpha1.KeptnAppSpec{LifecycleDeadline: tt.appDeadline},
+			}
+			task := &v1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running-task"}}
+			r := newWorkloadInstanceTestReconciler(t, workloadInstance, app, task)
+			r.LifecycleDeadline = tt.defaultDeadline
+			r.LifecycleDeadlineGatePolicy = tt.gatePolicy
+			gateWaitDuration, err := metric.NewNoopMeterProvider().Meter("test").SyncFloat64().Histogram("gate")
+			testrequire.Nil(t, err)
+			r.Meters.GateWaitDuration = gateWaitDuration
+
+			cancelled, err := r.cancelIfLifecycleDeadlineExceeded(context.TODO(), workloadInstance)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, tt.wantCancelled, cancelled)
+			testrequire.Equal(t, tt.wantCancelled, workloadInstance.IsCompleted())
+
+			err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "running-task"}, &v1alpha1.KeptnTask{})
+			testrequire.Equal(t, tt.wantCancelled, errors.IsNotFound(err))
+			if !tt.wantCancelled {
+				return
+			}
+
+			condition := meta.FindStatusCondition(workloadInstance.Status.Conditions, v1alpha1.CompletedConditionType)
+			testrequire.Equal(t, "LifecycleDeadlineExceeded", condition.Reason)
+			testrequire.Equal(t, common.StateFailed, workloadInstance.Status.Status)
+			testrequire.Equal(t, common.StateFailed, workloadInstance.Status.PreDeploymentTaskStatus[0].Status)
+			if tt.gatePolicy == LifecycleDeadlineGatePolicyRelease {
+				testrequire.False(t, workloadInstance.Status.GateReleaseTime.IsZero())
+				testrequire.Equal(t, common.StatePending, workloadInstance.Status.PreDeploymentEvaluationStatus)
+			} else {
+				testrequire.True(t, workloadInstance.Status.GateReleaseTime.IsZero())
+				testrequire.Equal(t, common.StateFailed, workloadInstance.Status.PreDeploymentEvaluationStatus)
+			}
+		})
+	}
+}
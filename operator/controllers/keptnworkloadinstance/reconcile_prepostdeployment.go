@@ -3,6 +3,7 @@ package keptnworkloadinstance
 import (
 	"context"
 	"fmt"
+	"time"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
@@ -26,15 +27,16 @@ func (r *KeptnWorkloadInstanceReconciler) reconcilePrePostDeployment(ctx context
 
 	switch checkType {
 	case common.PreDeploymentCheckType:
-		workloadInstance.Status.PreDeploymentStatus = overallState
+		overallState = common.SetPhaseState(&workloadInstance.Status.PreDeploymentStatus, overallState)
 		workloadInstance.Status.PreDeploymentTaskStatus = newStatus
 	case common.PostDeploymentCheckType:
-		workloadInstance.Status.PostDeploymentStatus = overallState
+		overallState = common.SetPhaseState(&workloadInstance.Status.PostDeploymentStatus, overallState)
 		workloadInstance.Status.PostDeploymentTaskStatus = newStatus
 	}
+	workloadInstance.SetAllowedTaskFailures()
 
 	// Write Status Field
-	err = r.Client.Status().Update(ctx, workloadInstance)
+	err = controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
 	if err != nil {
 		return common.StateUnknown, err
 	}
@@ -57,27 +59,35 @@ func (r *KeptnWorkloadInstanceReconciler) createKeptnTask(ctx context.Context, n
 		LongName:  "Keptn Task Create",
 	}
 
+	settings := workloadInstance.GetTaskSettings(taskDefinition)
+	definitionNamespace, definitionName := common.ParseTaskDefinitionReference(taskDefinition)
 	newTask := &klcv1alpha1.KeptnTask{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        common.GenerateTaskName(checkType, taskDefinition),
+			Name:        common.GenerateTaskName(checkType, definitionName),
 			Namespace:   namespace,
+			Labels:      controllercommon.GetCheckLabels(workloadInstance.Spec.AppName, workloadInstance.Spec.WorkloadName, workloadInstance.Spec.Version, checkType),
 			Annotations: traceContextCarrier,
 		},
 		Spec: klcv1alpha1.KeptnTaskSpec{
-			AppName:          workloadInstance.Spec.AppName,
-			WorkloadVersion:  workloadInstance.Spec.Version,
-			Workload:         workloadInstance.Spec.WorkloadName,
-			TaskDefinition:   taskDefinition,
-			Parameters:       klcv1alpha1.TaskParameters{},
-			SecureParameters: klcv1alpha1.SecureParameters{},
-			Type:             checkType,
+			AppName:                 workloadInstance.Spec.AppName,
+			WorkloadVersion:         workloadInstance.Spec.Version,
+			Workload:                workloadInstance.Spec.WorkloadName,
+			TaskDefinition:          definitionName,
+			TaskDefinitionNamespace: definitionNamespace,
+			Parameters:              klcv1alpha1.TaskParameters{},
+			SecureParameters:        klcv1alpha1.SecureParameters{},
+			Type:                    checkType,
+			Retries:                 settings.Retries,
+			TimeoutSeconds:          settings.TimeoutSeconds,
+			PhaseSpanContext:        workloadInstance.Status.PhaseSpanContext,
 		},
 	}
 	err := controllerutil.SetControllerReference(workloadInstance, newTask, r.Scheme)
 	if err != nil {
 		r.Log.Error(err, "could not set controller reference:")
 	}
-	err = r.Client.Create(ctx, newTask)
+	err = r.CreationLimiter.Create(ctx, r.Client, newTask)
+	r.recordCreationQuota(ctx, phase, workloadInstance, err)
 	if err != nil {
 		r.Log.Error(err, "could not create KeptnTask")
 		controllercommon.RecordEvent(r.Recorder, phase, "Warning", workloadInstance, "CreateFailed", "could not create KeptnTask", workloadInstance.GetVersion())
@@ -133,19 +143,59 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileTasks(ctx context.Context, ch
 			continue
 		}
 
+		// Adopt a Task whose name could not be stored in the status, instead of creating it again
+		if taskStatus.TaskName == "" {
+			taskName, err := controllercommon.FindCreatedTask(ctx, r.Client, workloadInstance, controllercommon.GetCheckLabels(workloadInstance.Spec.AppName, workloadInstance.Spec.WorkloadName, workloadInstance.Spec.Version, checkType), taskDefinitionName)
+			if err != nil {
+				return nil, summary, err
+			}
+			if taskName != "" {
+				taskStatus.TaskName = taskName
+				taskStatus.SetStartTime()
+			}
+		}
+
 		// Check if Task is already created
 		if taskStatus.TaskName != "" {
 			err := r.Client.Get(ctx, types.NamespacedName{Name: taskStatus.TaskName, Namespace: workloadInstance.Namespace}, task)
-			if err != nil && errors.IsNotFound(err) {
+			if err == nil {
+				taskExists = true
+			} else if errors.IsNotFound(err) {
+				// the task has been deleted while it was running and is created again, unless it keeps being deleted
+				if !controllercommon.RecreateDeletedCheck(r.Recorder, phase, workloadInstance, &taskStatus.Recreations, taskStatus.TaskName, workloadInstance.GetVersion()) {
+					taskStatus.Status = common.StateFailed
+					if err := r.allowTaskFailure(ctx, workloadInstance, &taskStatus, phase); err != nil {
+						return nil, summary, err
+					}
+					taskStatus.SetEndTime()
+					r.recordCheckOutcome(ctx, workloadInstance, checkType, taskStatus.Status, taskStatus.StartTime, taskStatus.EndTime)
+					newStatus = append(newStatus, taskStatus)
+					continue
+				}
 				taskStatus.TaskName = ""
-			} else if err != nil {
+			} else {
 				return nil, summary, err
 			}
-			taskExists = true
 		}
 
 		// Create new Task if it does not exist
 		if !taskExists {
+			cooldownEnd, err := r.getTaskCooldownEnd(ctx, workloadInstance, taskDefinitionName)
+			if err != nil {
+				return nil, summary, err
+			}
+			if time.Now().Before(cooldownEnd) {
+				if taskStatus.Reason != CoolingDownReason {
+					controllercommon.RecordEvent(r.Recorder, phase, "Normal", workloadInstance, "CoolingDown", fmt.Sprintf("task %s is delayed until %s since it has recently run for another version", taskDefinitionName, cooldownEnd.UTC().Format(time.RFC3339)), workloadInstance.GetVersion())
+				}
+				taskStatus.Status = common.StatePending
+				taskStatus.Reason = CoolingDownReason
+				taskStatus.EarliestStartTime = metav1.NewTime(cooldownEnd.UTC())
+				newStatus = append(newStatus, taskStatus)
+				continue
+			}
+			taskStatus.Reason = ""
+			taskStatus.EarliestStartTime = metav1.Time{}
 			taskName, err := r.createKeptnTask(ctx, workloadInstance.Namespace, workloadInstance, taskDefinitionName, checkType)
 			if err != nil {
 				return nil, summary, err
@@ -155,8 +205,20 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileTasks(ctx context.Context, ch
 		} else {
 			// Update state of Task if it is already created
 			taskStatus.Status = task.Status.Status
+			if len(task.Status.SpanContext) > 0 {
+				taskStatus.SpanContext = task.Status.SpanContext
+			}
+			if taskStatus.Status.IsFailed() {
+				if err := r.allowTaskFailure(ctx, workloadInstance, &taskStatus, phase); err != nil {
+					return nil, summary, err
+				}
+				if checkType == common.PreDeploymentCheckType && !taskStatus.IsFailureAllowed() {
+					setPreDeploymentFailure(workloadInstance, task)
+				}
+			}
 			if taskStatus.Status.IsCompleted() {
 				taskStatus.SetEndTime()
+				r.recordCheckOutcome(ctx, workloadInstance, checkType, taskStatus.Status, taskStatus.StartTime, taskStatus.EndTime)
 			}
 		}
 		// Update state of the Check
@@ -164,6 +226,10 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileTasks(ctx context.Context, ch
 	}
 
 	for _, ns := range newStatus {
+		if ns.IsFailureAllowed() {
+			summary = common.UpdateStatusSummary(common.StateSucceeded, summary)
+			continue
+		}
 		summary = common.UpdateStatusSummary(ns.Status, summary)
 	}
 	if common.GetOverallState(summary) != common.StateSucceeded {
@@ -171,3 +237,17 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileTasks(ctx context.Context, ch
 	}
 	return newStatus, summary, nil
 }
+
+// setPreDeploymentFailure copies why a pre-deployment task has failed into the status of the KeptnWorkloadInstance,
+// unless another task has failed before
+func setPreDeploymentFailure(workloadInstance *klcv1alpha1.KeptnWorkloadInstance, task *klcv1alpha1.KeptnTask) {
+	if workloadInstance.Status.PreDeploymentMessage != "" {
+		return
+	}
+	message := fmt.Sprintf("task %s has failed", task.Spec.TaskDefinition)
+	if task.Status.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, task.Status.Message)
+	}
+	workloadInstance.Status.PreDeploymentReason = task.Status.Reason
+	workloadInstance.Status.PreDeploymentMessage = common.TruncateString(message, common.MaxFailureMessageLength)
+}
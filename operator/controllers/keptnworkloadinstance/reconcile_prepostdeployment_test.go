@@ -0,0 +1,229 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKeptnWorkloadInstanceReconciler_reconcilePrePostDeploymentDoesNotRegress(t *testing.T) {
+	for _, checkType := range []common.CheckType{common.PreDeploymentCheckType, common.PostDeploymentCheckType} {
+		for _, terminal := range []common.KeptnState{common.StateSucceeded, common.StateFailed} {
+			taskStatus := []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing}}
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						PreDeploymentTasks:  []string{"my-task"},
+						PostDeploymentTasks: []string{"my-task"},
+					},
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{
+					PreDeploymentStatus:      terminal,
+					PostDeploymentStatus:     terminal,
+					PreDeploymentTaskStatus:  taskStatus,
+					PostDeploymentTaskStatus: taskStatus,
+				},
+			}
+			// the task has been re-applied by an external actor and starts over
+			recreatedTask := &v1alpha1.KeptnTask{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task-12345"},
+				Status:     v1alpha1.KeptnTaskStatus{Status: common.StatePending},
+			}
+
+			scheme := runtime.NewScheme()
+			testrequire.Nil(t, v1alpha1.AddToScheme(scheme))
+			r := &KeptnWorkloadInstanceReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(workloadInstance, recreatedTask).Build(),
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			state, err := r.reconcilePrePostDeployment(context.TODO(), workloadInstance, checkType)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, terminal, state)
+			testrequire.Equal(t, terminal, workloadInstance.Status.PreDeploymentStatus)
+			testrequire.Equal(t, terminal, workloadInstance.Status.PostDeploymentStatus)
+		}
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileTasksRecreatesDeletedTask(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:            "my-app",
+				Version:            "1.0.0",
+				PreDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			// the task has been deleted while it was running
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing}},
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	statuses, _, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance)
+	testrequire.Nil(t, err)
+	testrequire.Len(t, statuses, 1)
+	testrequire.NotEmpty(t, statuses[0].TaskName)
+	testrequire.NotEqual(t, "my-task-12345", statuses[0].TaskName)
+
+	tasks := &v1alpha1.KeptnTaskList{}
+	testrequire.Nil(t, r.Client.List(context.TODO(), tasks))
+	testrequire.Len(t, tasks.Items, 1)
+	testrequire.Equal(t, statuses[0].TaskName, tasks.Items[0].Name)
+	testrequire.Equal(t, 1, statuses[0].Recreations)
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileTasksAdoptsCreatedTask(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0", UID: "instance-uid"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:            "my-app",
+				Version:            "1.0.0",
+				PreDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	statuses, _, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance.DeepCopy())
+	testrequire.Nil(t, err)
+	testrequire.Len(t, statuses, 1)
+
+	tasks := &v1alpha1.KeptnTaskList{}
+	testrequire.Nil(t, r.Client.List(context.TODO(), tasks))
+	testrequire.Len(t, tasks.Items, 1)
+	testrequire.Equal(t, map[string]string{
+		common.AppAnnotation:      "my-app",
+		common.WorkloadAnnotation: "my-app-my-workload",
+		common.VersionAnnotation:  "1.0.0",
+		common.CheckTypeLabel:     "pre",
+	}, tasks.Items[0].Labels)
+
+	// the name of the task has not been stored in the status
+	adopted, _, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance.DeepCopy())
+	testrequire.Nil(t, err)
+	testrequire.Len(t, adopted, 1)
+	testrequire.Equal(t, statuses[0].TaskName, adopted[0].TaskName)
+
+	testrequire.Nil(t, r.Client.List(context.TODO(), tasks))
+	testrequire.Len(t, tasks.Items, 1)
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileTasksFailsRepeatedlyDeletedTask(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:            "my-app",
+				Version:            "1.0.0",
+				PreDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			// the task keeps being deleted, e.g. by a cleanup job
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing, Recreations: controllercommon.MaxCheckRecreations}},
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	statuses, summary, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance)
+	testrequire.Nil(t, err)
+	testrequire.Len(t, statuses, 1)
+	testrequire.Equal(t, common.StateFailed, statuses[0].Status)
+	testrequire.Equal(t, common.StateFailed, common.GetOverallState(summary))
+
+	tasks := &v1alpha1.KeptnTaskList{}
+	testrequire.Nil(t, r.Client.List(context.TODO(), tasks))
+	testrequire.Empty(t, tasks.Items)
+}
+
+func TestKeptnWorkloadInstanceReconciler_reconcileTasksPersistsSpanContexts(t *testing.T) {
+	phaseSpanContext := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+	taskSpanContext := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:            "my-app",
+				Version:            "1.0.0",
+				PreDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{PhaseSpanContext: phaseSpanContext},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	statuses, _, err := r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance)
+	testrequire.Nil(t, err)
+	testrequire.Len(t, statuses, 1)
+
+	// the task links back to the phase that has created it
+	task := &v1alpha1.KeptnTask{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: statuses[0].TaskName}, task))
+	testrequire.Equal(t, phaseSpanContext, task.Spec.PhaseSpanContext)
+
+	// the span context of the task is copied into the status of the instance, for the phase to link to
+	task.Status.SpanContext = taskSpanContext
+	testrequire.Nil(t, r.Client.Status().Update(context.TODO(), task))
+	workloadInstance.Status.PreDeploymentTaskStatus = statuses
+	statuses, _, err = r.reconcileTasks(context.TODO(), common.PreDeploymentCheckType, workloadInstance)
+	testrequire.Nil(t, err)
+	testrequire.Equal(t, taskSpanContext, statuses[0].SpanContext)
+	workloadInstance.Status.PreDeploymentTaskStatus = statuses
+	testrequire.Equal(t, []map[string]string{taskSpanContext}, workloadInstance.GetTaskSpanContexts(common.PhaseWorkloadPreDeployment.ShortName))
+	testrequire.Empty(t, workloadInstance.GetTaskSpanContexts(common.PhaseWorkloadPostDeployment.ShortName))
+}
+
+func TestKeptnWorkloadInstanceReconciler_createKeptnTaskOfOtherNamespace(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+
+	name, err := r.createKeptnTask(context.TODO(), "default", workloadInstance, "platform/migrate", common.PreDeploymentCheckType)
+	testrequire.Nil(t, err)
+
+	task := &v1alpha1.KeptnTask{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, task))
+	testrequire.Equal(t, "migrate", task.Spec.TaskDefinition)
+	testrequire.Equal(t, "platform", task.Spec.TaskDefinitionNamespace)
+	testrequire.Equal(t, "platform/migrate", task.GetTaskDefinitionReference())
+
+	// the task is found again by the reference it has been created for
+	labels := controllercommon.GetCheckLabels("my-app", "my-app-my-workload", "1.0.0", common.PreDeploymentCheckType)
+	found, err := controllercommon.FindCreatedTask(context.TODO(), r.Client, workloadInstance, labels, "platform/migrate")
+	testrequire.Nil(t, err)
+	testrequire.Equal(t, name, found)
+	found, err = controllercommon.FindCreatedTask(context.TODO(), r.Client, workloadInstance, labels, "migrate")
+	testrequire.Nil(t, err)
+	testrequire.Empty(t, found)
+}
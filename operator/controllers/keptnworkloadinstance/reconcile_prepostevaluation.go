@@ -3,7 +3,6 @@ package keptnworkloadinstance
 import (
 	"context"
 	"fmt"
-	"time"
 
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
@@ -27,15 +26,20 @@ func (r *KeptnWorkloadInstanceReconciler) reconcilePrePostEvaluation(ctx context
 
 	switch checkType {
 	case common.PreDeploymentEvaluationCheckType:
-		workloadInstance.Status.PreDeploymentEvaluationStatus = overallState
 		workloadInstance.Status.PreDeploymentEvaluationTaskStatus = newStatus
+		// the scheduler releases the pods as soon as the pre-deployment evaluations have succeeded,
+		// so the release policy has the final say before they are marked as such
+		if overallState.IsSucceeded() && !r.checkReleasePolicy(ctx, workloadInstance) {
+			overallState = common.StateProgressing
+		}
+		overallState = common.SetPhaseState(&workloadInstance.Status.PreDeploymentEvaluationStatus, overallState)
 	case common.PostDeploymentEvaluationCheckType:
-		workloadInstance.Status.PostDeploymentEvaluationStatus = overallState
+		overallState = common.SetPhaseState(&workloadInstance.Status.PostDeploymentEvaluationStatus, overallState)
 		workloadInstance.Status.PostDeploymentEvaluationTaskStatus = newStatus
 	}
 
 	// Write Status Field
-	err = r.Client.Status().Update(ctx, workloadInstance)
+	err = controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
 	if err != nil {
 		return common.StateUnknown, err
 	}
@@ -86,15 +90,36 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileEvaluations(ctx context.Conte
 			continue
 		}
 
+		// Adopt an Evaluation whose name could not be stored in the status, instead of creating it again
+		if evaluationStatus.EvaluationName == "" {
+			name, err := controllercommon.FindCreatedEvaluation(ctx, r.Client, workloadInstance, controllercommon.GetCheckLabels(workloadInstance.Spec.AppName, workloadInstance.Spec.WorkloadName, workloadInstance.Spec.Version, checkType), evaluationName)
+			if err != nil {
+				return nil, summary, err
+			}
+			if name != "" {
+				evaluationStatus.EvaluationName = name
+				evaluationStatus.SetStartTime()
+			}
+		}
+
 		// Check if Evaluation is already created
 		if evaluationStatus.EvaluationName != "" {
 			err := r.Client.Get(ctx, types.NamespacedName{Name: evaluationStatus.EvaluationName, Namespace: workloadInstance.Namespace}, evaluation)
-			if err != nil && errors.IsNotFound(err) {
+			if err == nil {
+				evaluationExists = true
+			} else if errors.IsNotFound(err) {
+				// the evaluation has been deleted while it was running and is created again, unless it keeps being deleted
+				if !controllercommon.RecreateDeletedCheck(r.Recorder, phase, workloadInstance, &evaluationStatus.Recreations, evaluationStatus.EvaluationName, workloadInstance.GetVersion()) {
+					evaluationStatus.Status = common.StateFailed
+					evaluationStatus.SetEndTime()
+					r.recordCheckOutcome(ctx, workloadInstance, checkType, evaluationStatus.Status, evaluationStatus.StartTime, evaluationStatus.EndTime)
+					newStatus = append(newStatus, evaluationStatus)
+					continue
+				}
 				evaluationStatus.EvaluationName = ""
-			} else if err != nil {
+			} else {
 				return nil, summary, err
 			}
-			evaluationExists = true
 		}
 
 		// Create new Evaluation if it does not exist
@@ -110,6 +135,7 @@ func (r *KeptnWorkloadInstanceReconciler) reconcileEvaluations(ctx context.Conte
 			evaluationStatus.Status = evaluation.Status.OverallStatus
 			if evaluationStatus.Status.IsCompleted() {
 				evaluationStatus.SetEndTime()
+				r.recordCheckOutcome(ctx, workloadInstance, checkType, evaluationStatus.Status, evaluationStatus.StartTime, evaluationStatus.EndTime)
 			}
 		}
 		// Update state of the Check
@@ -146,6 +172,7 @@ func (r *KeptnWorkloadInstanceReconciler) createKeptnEvaluation(ctx context.Cont
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        common.GenerateEvaluationName(checkType, evaluationDefinition),
 			Namespace:   namespace,
+			Labels:      controllercommon.GetCheckLabels(workloadInstance.Spec.AppName, workloadInstance.Spec.WorkloadName, workloadInstance.Spec.Version, checkType),
 			Annotations: traceContextCarrier,
 		},
 		Spec: klcv1alpha1.KeptnEvaluationSpec{
@@ -154,7 +181,7 @@ func (r *KeptnWorkloadInstanceReconciler) createKeptnEvaluation(ctx context.Cont
 			EvaluationDefinition: evaluationDefinition,
 			Type:                 checkType,
 			RetryInterval: metav1.Duration{
-				Duration: 5 * time.Second,
+				Duration: klcv1alpha1.DefaultRetryInterval,
 			},
 		},
 	}
@@ -162,7 +189,8 @@ func (r *KeptnWorkloadInstanceReconciler) createKeptnEvaluation(ctx context.Cont
 	if err != nil {
 		r.Log.Error(err, "could not set controller reference:")
 	}
-	err = r.Client.Create(ctx, newEvaluation)
+	err = r.CreationLimiter.Create(ctx, r.Client, newEvaluation)
+	r.recordCreationQuota(ctx, phase, workloadInstance, err)
 	if err != nil {
 		r.Log.Error(err, "could not create KeptnEvaluation")
 		controllercommon.RecordEvent(r.Recorder, phase, "Warning", workloadInstance, "CreateFailed", "could not create KeptnEvaluation", workloadInstance.GetVersion())
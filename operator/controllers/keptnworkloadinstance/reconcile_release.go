@@ -0,0 +1,54 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// releaseGate lets the scheduler bind the pods of an instance whose pre-deployment checks have succeeded.
+// The release is persisted before it is announced by the WorkloadReleased event, so that audits see exactly one
+// event per instance, naming the checks that have passed.
+func (r *KeptnWorkloadInstanceReconciler) releaseGate(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	workloadInstance.ReleaseGate()
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return err
+	}
+	r.Meters.GateWaitDuration.Record(ctx, workloadInstance.Status.GateWaitDuration.Seconds(), workloadInstance.GetGateWaitMetricsAttributes(common.GateWaitReasonChecks)...)
+
+	checks := workloadInstance.GetPassedPreDeploymentChecks()
+	passed := "none"
+	if len(checks) > 0 {
+		passed = strings.Join(checks, ",")
+	}
+	releasedAt := workloadInstance.Status.GateReleaseTime.UTC().Format(time.RFC3339)
+	r.Recorder.Event(workloadInstance, "Normal", "WorkloadReleased", fmt.Sprintf("Version %s of workload %s has been released at %s after checks: %s / Namespace: %s, Name: %s ", workloadInstance.Spec.Version, workloadInstance.Spec.WorkloadName, releasedAt, passed, workloadInstance.Namespace, workloadInstance.Name))
+
+	if err := r.annotateReleasedDeployment(ctx, workloadInstance, releasedAt, passed); err != nil {
+		r.Log.Error(err, "could not annotate the released Deployment")
+	}
+	return nil
+}
+
+// annotateReleasedDeployment records the last release on the Deployment of the instance.
+// Only Deployments opting in via the keptn.sh/release-audit annotation are changed.
+func (r *KeptnWorkloadInstanceReconciler) annotateReleasedDeployment(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, releasedAt string, passed string) error {
+	deployment, err := controllercommon.GetDeployment(ctx, r.Client, workloadInstance.Namespace, workloadInstance.Spec.ResourceReference)
+	if err != nil || deployment == nil {
+		return err
+	}
+	if deployment.Annotations[common.ReleaseAuditAnnotation] != "enabled" {
+		return nil
+	}
+	patch := client.MergeFrom(deployment.DeepCopy())
+	deployment.Annotations[common.ReleasedVersionAnnotation] = workloadInstance.Spec.Version
+	deployment.Annotations[common.ReleasedAtAnnotation] = releasedAt
+	deployment.Annotations[common.ReleasedAfterChecksAnnotation] = passed
+	return r.Client.Patch(ctx, deployment, patch)
+}
@@ -0,0 +1,59 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_releaseGate(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:           "my-app",
+				Version:           "1.0.0",
+				ResourceReference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+				{TaskDefinitionName: "load-test", Status: common.StateSucceeded},
+			},
+			PreDeploymentEvaluationTaskStatus: []v1alpha1.EvaluationStatus{
+				{EvaluationDefinitionName: "error-rate", Status: common.StateSucceeded},
+			},
+		},
+	}
+	deployment, replicaSet := makeDeployment(nil)
+	deployment.Annotations = map[string]string{common.ReleaseAuditAnnotation: "enabled"}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, deployment, replicaSet)
+	gateWaitDuration, err := metric.NewNoopMeterProvider().Meter("test").SyncFloat64().Histogram("gate")
+	testrequire.Nil(t, err)
+	r.Meters.GateWaitDuration = gateWaitDuration
+
+	testrequire.Nil(t, r.releaseGate(context.TODO(), workloadInstance))
+
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, stored))
+	testrequire.False(t, stored.Status.GateReleaseTime.IsZero())
+
+	recorder := r.Recorder.(*record.FakeRecorder)
+	testrequire.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	testrequire.Contains(t, event, "Normal WorkloadReleased")
+	testrequire.Contains(t, event, "after checks: load-test,error-rate")
+
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-deployment"}, deployment))
+	testrequire.Equal(t, "1.0.0", deployment.Annotations[common.ReleasedVersionAnnotation])
+	testrequire.Equal(t, "load-test,error-rate", deployment.Annotations[common.ReleasedAfterChecksAnnotation])
+	testrequire.NotEmpty(t, deployment.Annotations[common.ReleasedAtAnnotation])
+}
@@ -0,0 +1,68 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+)
+
+// checkReleasePolicy returns true if the release policy allows releasing the pods of the workload instance.
+// An allow decision is recorded in the ReleasePolicyAllowed condition and not requested again, while a denied
+// release is requested again on the next reconciliation. A release allowed by the failure policy, since the release
+// policy could not be evaluated, is not recorded, as the policy has not decided on it.
+func (r *KeptnWorkloadInstanceReconciler) checkReleasePolicy(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) bool {
+	if r.ReleasePolicy == nil || workloadInstance.IsReleasePolicyAllowed() {
+		return true
+	}
+
+	decision, err := r.ReleasePolicy.Evaluate(ctx, newReleasePolicyInput(workloadInstance))
+	if err != nil {
+		r.Log.Error(err, "could not evaluate release policy", "workloadInstance", workloadInstance.Name)
+	}
+	if decision.Allowed {
+		if err == nil {
+			workloadInstance.SetReleasePolicyDecision(true, "Allowed", messageOrDefault(decision.Reason, "release has been allowed by the policy"))
+		}
+		return true
+	}
+
+	reason := messageOrDefault(decision.Reason, "release has been denied by the policy")
+	if workloadInstance.SetReleasePolicyDecision(false, "Denied", reason) {
+		controllercommon.RecordEvent(r.Recorder, common.PhaseWorkloadPreEvaluation, "Warning", workloadInstance, "ReleasePolicyDenied", "are blocked by the release policy: "+reason, workloadInstance.GetVersion())
+	}
+	return false
+}
+
+func newReleasePolicyInput(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) controllercommon.ReleasePolicyInput {
+	input := controllercommon.ReleasePolicyInput{
+		App:       workloadInstance.Spec.AppName,
+		Workload:  workloadInstance.Spec.WorkloadName,
+		Version:   workloadInstance.Spec.Version,
+		Namespace: workloadInstance.Namespace,
+		Checks:    []controllercommon.ReleasePolicyCheck{},
+	}
+	for _, task := range workloadInstance.Status.PreDeploymentTaskStatus {
+		input.Checks = append(input.Checks, controllercommon.ReleasePolicyCheck{
+			Name:   task.TaskDefinitionName,
+			Type:   "task",
+			Status: string(task.Status),
+		})
+	}
+	for _, evaluation := range workloadInstance.Status.PreDeploymentEvaluationTaskStatus {
+		input.Checks = append(input.Checks, controllercommon.ReleasePolicyCheck{
+			Name:   evaluation.EvaluationDefinitionName,
+			Type:   "evaluation",
+			Status: string(evaluation.Status),
+		})
+	}
+	return input
+}
+
+func messageOrDefault(message string, defaultMessage string) string {
+	if message == "" {
+		return defaultMessage
+	}
+	return message
+}
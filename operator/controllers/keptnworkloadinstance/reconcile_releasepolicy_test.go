@@ -0,0 +1,96 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_checkReleasePolicy(t *testing.T) {
+	calls := 0
+	allowed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"allowed": %t, "reason": "change freeze"}`, allowed)
+	}))
+	defer server.Close()
+
+	policy, err := controllercommon.NewReleasePolicy(server.URL, time.Second, controllercommon.ReleasePolicyFailureDeny)
+	testrequire.Nil(t, err)
+	recorder := record.NewFakeRecorder(100)
+	r := &KeptnWorkloadInstanceReconciler{
+		Log:           logr.Discard(),
+		Recorder:      recorder,
+		ReleasePolicy: policy,
+	}
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{{TaskDefinitionName: "check", Status: common.StateSucceeded}},
+		},
+	}
+
+	// a denied release is blocked and requested again
+	testrequire.False(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	condition := meta.FindStatusCondition(workloadInstance.Status.Conditions, v1alpha1.ReleasePolicyConditionType)
+	testrequire.NotNil(t, condition)
+	testrequire.Equal(t, metav1.ConditionFalse, condition.Status)
+	testrequire.Equal(t, "change freeze", condition.Message)
+	event := <-recorder.Events
+	testrequire.True(t, strings.Contains(event, "ReleasePolicyDenied"))
+	testrequire.True(t, strings.Contains(event, "change freeze"))
+
+	// the same denial is not recorded again
+	testrequire.False(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	testrequire.Equal(t, 2, calls)
+	testrequire.Empty(t, recorder.Events)
+
+	// an allowed release is cached in the condition
+	allowed = true
+	testrequire.True(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	testrequire.True(t, workloadInstance.IsReleasePolicyAllowed())
+	testrequire.True(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	testrequire.Equal(t, 3, calls)
+}
+
+func TestKeptnWorkloadInstanceReconciler_checkReleasePolicyFailOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	policy, err := controllercommon.NewReleasePolicy(server.URL, time.Second, controllercommon.ReleasePolicyFailureAllow)
+	testrequire.Nil(t, err)
+	r := &KeptnWorkloadInstanceReconciler{
+		Log:           logr.Discard(),
+		Recorder:      record.NewFakeRecorder(100),
+		ReleasePolicy: policy,
+	}
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{}
+
+	// the release is allowed by the failure policy, but not recorded as a decision of the release policy
+	testrequire.True(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	testrequire.False(t, workloadInstance.IsReleasePolicyAllowed())
+	testrequire.Nil(t, meta.FindStatusCondition(workloadInstance.Status.Conditions, v1alpha1.ReleasePolicyConditionType))
+}
+
+func TestKeptnWorkloadInstanceReconciler_checkReleasePolicyNotConfigured(t *testing.T) {
+	r := &KeptnWorkloadInstanceReconciler{Log: logr.Discard()}
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{}
+
+	testrequire.True(t, r.checkReleasePolicy(context.TODO(), workloadInstance))
+	testrequire.Empty(t, workloadInstance.Status.Conditions)
+}
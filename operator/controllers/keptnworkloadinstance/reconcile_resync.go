@@ -0,0 +1,54 @@
+package keptnworkloadinstance
+
+import (
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkResyncInterval is the interval a phase of checks that has not finished yet is reconciled again in.
+// The instance is reconciled whenever one of its KeptnTasks or KeptnEvaluations changes, so this is only a safety net.
+// The interval is shortened to the time left until the lifecycle deadline, see capAtLifecycleDeadline.
+const checkResyncInterval = 2 * time.Minute
+
+// resyncCheckPhase replaces the short requeue of a phase of checks that has not finished yet by checkResyncInterval,
+// unless one of its checks has not been created yet, e.g. since it is cooling down, so there is nothing to watch.
+func resyncCheckPhase(result *controllercommon.PhaseResult, err error, uncreatedChecks bool) ctrl.Result {
+	if err != nil || uncreatedChecks || result.RequeueAfter != controllercommon.DefaultPhaseRequeueInterval {
+		return result.Result
+	}
+	res := result.Result
+	res.RequeueAfter = checkResyncInterval
+	return res
+}
+
+// backoffPhase replaces the short requeue of a phase that has not finished yet by the interval of the requeue backoff,
+// which grows as long as the instance stays in the same phase
+func (r *KeptnWorkloadInstanceReconciler) backoffPhase(workloadInstance *klcv1alpha1.KeptnWorkloadInstance, res ctrl.Result) ctrl.Result {
+	if res.RequeueAfter != controllercommon.DefaultPhaseRequeueInterval {
+		return res
+	}
+	res.RequeueAfter = r.RequeueBackoff.Next(client.ObjectKeyFromObject(workloadInstance).String(), workloadInstance.Status.CurrentPhase)
+	return res
+}
+
+func hasUncreatedTasks(statuses []klcv1alpha1.TaskStatus) bool {
+	for _, status := range statuses {
+		if status.TaskName == "" && !status.Status.IsCompleted() {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUncreatedEvaluations(statuses []klcv1alpha1.EvaluationStatus) bool {
+	for _, status := range statuses {
+		if status.EvaluationName == "" && !status.Status.IsCompleted() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+func TestKeptnWorkloadInstanceReconciler_watchedTaskCompletesPhase(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				AppName:            "my-app",
+				Version:            "1.0.0",
+				PreDeploymentTasks: []string{"my-task"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			PreDeploymentTaskStatus: []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", TaskName: "my-task-12345", Status: common.StateProgressing}},
+		},
+	}
+	task := &v1alpha1.KeptnTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-task-12345"},
+		Status:     v1alpha1.KeptnTaskStatus{Status: common.StateProgressing},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, task)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	phaseHandler := controllercommon.PhaseHandler{Client: r.Client, Recorder: r.Recorder, Log: r.Log}
+	_, span := r.Tracer.Start(context.TODO(), "test")
+	handlePhase := func() (*controllercommon.PhaseResult, error) {
+		return phaseHandler.HandlePhase(context.TODO(), context.TODO(), r.Tracer, workloadInstance, common.PhaseWorkloadPreDeployment, span, func() (common.KeptnState, error) {
+			return r.reconcilePrePostDeployment(context.TODO(), workloadInstance, common.PreDeploymentCheckType)
+		})
+	}
+
+	// the running task is watched, so the phase is only polled as a safety net
+	result, err := handlePhase()
+	testrequire.Nil(t, err)
+	testrequire.False(t, result.Continue)
+	testrequire.Equal(t, checkResyncInterval, resyncCheckPhase(result, err, hasUncreatedTasks(workloadInstance.Status.PreDeploymentTaskStatus)).RequeueAfter)
+
+	// the status change of the task triggers the next reconcile, which completes the phase right away
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-task-12345"}, task))
+	task.Status.Status = common.StateSucceeded
+	testrequire.Nil(t, r.Client.Status().Update(context.TODO(), task))
+
+	result, err = handlePhase()
+	testrequire.Nil(t, err)
+	testrequire.True(t, result.Continue)
+	testrequire.Equal(t, common.StateSucceeded, workloadInstance.Status.PreDeploymentStatus)
+}
+
+func TestOwnedCheckHandler(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0", UID: "instance-uid"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	r.Tracer = trace.NewNoopTracerProvider().Tracer("test")
+	name, err := r.createKeptnTask(context.TODO(), "default", workloadInstance, "my-task", common.PreDeploymentCheckType)
+	testrequire.Nil(t, err)
+	oldTask := &v1alpha1.KeptnTask{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, oldTask))
+	newTask := oldTask.DeepCopy()
+	newTask.Status.Status = common.StateSucceeded
+
+	eventHandler := ownedCheckHandler()
+	testrequire.Nil(t, eventHandler.(inject.Scheme).InjectScheme(r.Scheme))
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(v1alpha1.GroupVersion.WithKind("KeptnWorkloadInstance"), meta.RESTScopeNamespace)
+	testrequire.Nil(t, eventHandler.(inject.Mapper).InjectMapper(mapper))
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	// the status change of the task created by the instance enqueues the instance
+	eventHandler.Update(event.UpdateEvent{ObjectOld: oldTask, ObjectNew: newTask}, queue)
+	testrequire.Equal(t, 1, queue.Len())
+	item, _ := queue.Get()
+	testrequire.Equal(t, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}}, item)
+
+	// checks that have not been created by an instance are not mapped
+	eventHandler.Update(event.UpdateEvent{ObjectOld: &v1alpha1.KeptnTask{}, ObjectNew: &v1alpha1.KeptnTask{}}, queue)
+	testrequire.Equal(t, 0, queue.Len())
+}
+
+func TestKeptnWorkloadInstanceReconciler_capAtLifecycleDeadline(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "my-app-my-workload-1.0.0",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-59 * time.Minute)),
+		},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "1.0.0"},
+		},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	resync := ctrl.Result{Requeue: true, RequeueAfter: checkResyncInterval}
+
+	// without a deadline, the resync interval is kept
+	testrequire.Equal(t, resync, r.capAtLifecycleDeadline(context.TODO(), workloadInstance, resync))
+
+	// the instance is reconciled again right after its deadline
+	r.LifecycleDeadline = time.Hour
+	res := r.capAtLifecycleDeadline(context.TODO(), workloadInstance, resync)
+	testrequire.True(t, res.Requeue)
+	testrequire.LessOrEqual(t, res.RequeueAfter, 61*time.Second)
+	testrequire.Greater(t, res.RequeueAfter, 55*time.Second)
+
+	// shorter requeues and results without requeue are left alone
+	testrequire.Equal(t, 5*time.Second, r.capAtLifecycleDeadline(context.TODO(), workloadInstance, ctrl.Result{RequeueAfter: 5 * time.Second}).RequeueAfter)
+	testrequire.Equal(t, ctrl.Result{}, r.capAtLifecycleDeadline(context.TODO(), workloadInstance, ctrl.Result{}))
+}
+
+func TestResyncCheckPhase(t *testing.T) {
+	notFinished := &controllercommon.PhaseResult{Result: ctrl.Result{Requeue: true, RequeueAfter: controllercommon.DefaultPhaseRequeueInterval}}
+
+	testrequire.Equal(t, checkResyncInterval, resyncCheckPhase(notFinished, nil, false).RequeueAfter)
+	// a check cooling down is not watched, its start time has to be polled for
+	coolingDown := []v1alpha1.TaskStatus{{TaskDefinitionName: "my-task", Status: common.StatePending, Reason: CoolingDownReason}}
+	testrequire.Equal(t, controllercommon.DefaultPhaseRequeueInterval, resyncCheckPhase(notFinished, nil, hasUncreatedTasks(coolingDown)).RequeueAfter)
+	// failed phases are not requeued at all
+	testrequire.Equal(t, ctrl.Result{}, resyncCheckPhase(&controllercommon.PhaseResult{}, nil, false))
+}
+
+func TestKeptnWorkloadInstanceReconciler_backoffPhase(t *testing.T) {
+	r := &KeptnWorkloadInstanceReconciler{RequeueBackoff: controllercommon.NewRequeueBackoff(5*time.Second, 20*time.Second)}
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Status:     v1alpha1.KeptnWorkloadInstanceStatus{CurrentPhase: common.PhaseWorkloadDeployment.ShortName},
+	}
+	notFinished := ctrl.Result{Requeue: true, RequeueAfter: controllercommon.DefaultPhaseRequeueInterval}
+
+	var progression []time.Duration
+	for i := 0; i < 4; i++ {
+		progression = append(progression, r.backoffPhase(workloadInstance, notFinished).RequeueAfter)
+	}
+	testrequire.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 20 * time.Second}, progression)
+
+	// the backoff starts over once the instance is observed in another phase
+	workloadInstance.Status.CurrentPhase = common.PhaseWorkloadPostDeployment.ShortName
+	testrequire.Equal(t, 5*time.Second, r.backoffPhase(workloadInstance, notFinished).RequeueAfter)
+
+	// watched checks keep their resync interval
+	testrequire.Equal(t, checkResyncInterval, r.backoffPhase(workloadInstance, ctrl.Result{Requeue: true, RequeueAfter: checkResyncInterval}).RequeueAfter)
+
+	// without a backoff, phases are requeued after the default interval
+	r.RequeueBackoff = nil
+	testrequire.Equal(t, notFinished, r.backoffPhase(workloadInstance, notFinished))
+}
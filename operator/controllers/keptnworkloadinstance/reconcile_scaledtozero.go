@@ -0,0 +1,31 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+)
+
+// completeIfScaledToZero completes an in-flight KeptnWorkloadInstance whose workload has been scaled to zero replicas,
+// since no pods of this version are going to be deployed anymore. The instance did not pass its post-deployment checks,
+// so it is not reported as succeeded.
+func (r *KeptnWorkloadInstanceReconciler) completeIfScaledToZero(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	scaledToZero, err := controllercommon.IsScaledToZero(ctx, r.Client, workloadInstance.Namespace, workloadInstance.Spec.ResourceReference)
+	if err != nil || !scaledToZero {
+		return false, err
+	}
+
+	if err := r.SpanHandler.UnbindSpan(workloadInstance, workloadInstance.Status.CurrentPhase); err != nil {
+		r.Log.Error(err, "cannot unbind span")
+	}
+	workloadInstance.Status.CurrentPhase = common.PhaseCompleted.ShortName
+	workloadInstance.Status.Status = common.StateFailed
+	workloadInstance.CompleteWithReason("ScaledToZero", "workload has been scaled to zero replicas")
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return false, err
+	}
+	controllercommon.RecordEvent(r.Recorder, common.PhaseCompleted, "Normal", workloadInstance, "ScaledToZero", "has been cancelled since the workload has been scaled to zero", workloadInstance.GetVersion())
+	return true, nil
+}
@@ -0,0 +1,53 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeptnWorkloadInstanceReconciler_completeIfScaledToZero(t *testing.T) {
+	phases := []common.KeptnPhaseType{
+		common.PhaseWorkloadPreDeployment,
+		common.PhaseWorkloadPreEvaluation,
+		common.PhaseWorkloadDeployment,
+		common.PhaseWorkloadPostDeployment,
+		common.PhaseWorkloadPostEvaluation,
+	}
+	for _, phase := range phases {
+		for _, replicas := range []int32{1, 0} {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						ResourceReference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+					},
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{
+					CurrentPhase: phase.ShortName,
+					Status:       common.StateProgressing,
+				},
+			}
+			deployment, rs := makeDeployment(&replicas)
+			r := newWorkloadInstanceTestReconciler(t, workloadInstance, deployment, rs)
+
+			completed, err := r.completeIfScaledToZero(context.TODO(), workloadInstance)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, replicas == 0, completed, phase.ShortName)
+			testrequire.Equal(t, replicas == 0, workloadInstance.IsCompleted(), phase.ShortName)
+			if replicas == 0 {
+				condition := meta.FindStatusCondition(workloadInstance.Status.Conditions, v1alpha1.CompletedConditionType)
+				testrequire.Equal(t, "ScaledToZero", condition.Reason)
+				testrequire.Equal(t, common.PhaseCompleted.ShortName, workloadInstance.Status.CurrentPhase)
+				// the post-deployment checks have not passed, so the instance must not be reported as succeeded
+				testrequire.Equal(t, common.StateFailed, workloadInstance.Status.Status)
+				testrequire.True(t, isScaledToZero(workloadInstance))
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// propagateScaleUpGuard marks the Deployment of a failed KeptnWorkloadInstance with the failed version, so that
+// scaling up the Deployment can be blocked, and removes the mark again once a newer version has succeeded.
+// Only the latest completed instance of the workload changes the mark, so that propagating the result of an older
+// instance again, e.g. after a retry or a restart of the operator, cannot revert the result of a newer one.
+// Only Deployments opting in via the keptn.sh/scale-up-guard annotation are changed. While the APIs the scale-up guard
+// depends on are not served by the cluster, the propagation is skipped and the ScaleUpGuardUnavailable condition is set.
+func (r *KeptnWorkloadInstanceReconciler) propagateScaleUpGuard(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if !workloadInstance.Status.Status.IsSucceeded() && (!workloadInstance.Status.Status.IsFailed() || isScaledToZero(workloadInstance)) {
+		return nil
+	}
+	deployment, err := controllercommon.GetDeployment(ctx, r.Client, workloadInstance.Namespace, workloadInstance.Spec.ResourceReference)
+	if err != nil || deployment == nil {
+		return err
+	}
+	if deployment.Annotations[common.ScaleUpGuardAnnotation] != "enabled" {
+		return nil
+	}
+	if !r.Capabilities.Has(controllercommon.CapabilityHorizontalPodAutoscalerV2) {
+		message := fmt.Sprintf("%s is not served by the cluster", controllercommon.CapabilityHorizontalPodAutoscalerV2)
+		if !workloadInstance.SetScaleUpGuardUnavailable(true, "HorizontalPodAutoscalerV2NotInstalled", message) {
+			return nil
+		}
+		r.Recorder.Event(workloadInstance, "Warning", "ScaleUpGuardUnavailable", fmt.Sprintf("Scale-up guard is skipped since %s / Namespace: %s, Name: %s ", message, deployment.Namespace, deployment.Name))
+		return controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+	}
+	if workloadInstance.SetScaleUpGuardUnavailable(false, "", "") {
+		if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+			return err
+		}
+	}
+
+	latest, err := r.getLatestCompletedInstance(ctx, workloadInstance)
+	if err != nil {
+		return err
+	}
+	if latest.Name != workloadInstance.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(deployment.DeepCopy())
+	failedVersion, blocked := deployment.Annotations[common.FailedVersionAnnotation]
+	switch {
+	case workloadInstance.Status.Status.IsFailed() && failedVersion != workloadInstance.Spec.Version:
+		deployment.Annotations[common.FailedVersionAnnotation] = workloadInstance.Spec.Version
+	case workloadInstance.Status.Status.IsSucceeded() && blocked && failedVersion != workloadInstance.Spec.Version:
+		// as the latest completed instance, this one has been created after the instance of the failed version
+		delete(deployment.Annotations, common.FailedVersionAnnotation)
+	default:
+		return nil
+	}
+	return r.Client.Patch(ctx, deployment, patch)
+}
+
+// getLatestCompletedInstance returns the most recently created instance of the workload that has succeeded or failed,
+// ignoring instances that have been cancelled since the workload has been scaled to zero
+func (r *KeptnWorkloadInstanceReconciler) getLatestCompletedInstance(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (*klcv1alpha1.KeptnWorkloadInstance, error) {
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := r.Client.List(ctx, workloadInstances, client.InNamespace(workloadInstance.Namespace)); err != nil {
+		return nil, err
+	}
+	latest := workloadInstance
+	for i := range workloadInstances.Items {
+		other := &workloadInstances.Items[i]
+		if other.Spec.WorkloadName != workloadInstance.Spec.WorkloadName || !other.Status.Status.IsCompleted() || isScaledToZero(other) {
+			continue
+		}
+		if latest.CreationTimestamp.Before(&other.CreationTimestamp) ||
+			(latest.CreationTimestamp.Equal(&other.CreationTimestamp) && latest.Name < other.Name) {
+			latest = other
+		}
+	}
+	return latest, nil
+}
+
+// isScaledToZero returns true if the instance has been cancelled since its workload has been scaled to zero. Its
+// version did not fail any check, so scaling the workload up again must not be blocked.
+func isScaledToZero(workloadInstance *klcv1alpha1.KeptnWorkloadInstance) bool {
+	condition := workloadInstance.GetCondition(klcv1alpha1.CompletedConditionType)
+	return condition != nil && condition.Reason == "ScaledToZero"
+}
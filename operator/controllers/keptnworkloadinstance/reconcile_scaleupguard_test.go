@@ -0,0 +1,109 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	testrequire "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestKeptnWorkloadInstanceReconciler_propagateScaleUpGuard(t *testing.T) {
+	now := time.Now()
+	older := makeScaleUpGuardInstance("0.9.0", common.StateSucceeded, now.Add(-2*time.Hour))
+	failed := makeScaleUpGuardInstance("1.0.0", common.StateFailed, now.Add(-time.Hour))
+	newer := makeScaleUpGuardInstance("2.0.0", common.StateSucceeded, now)
+	deployment, rs := makeDeployment(nil)
+	deployment.Annotations = map[string]string{common.ScaleUpGuardAnnotation: "enabled"}
+	r := newWorkloadInstanceTestReconciler(t, older, failed, deployment, rs)
+
+	failedVersion := func() (string, bool) {
+		result := &appsv1.Deployment{}
+		testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-deployment"}, result))
+		version, ok := result.Annotations[common.FailedVersionAnnotation]
+		return version, ok
+	}
+
+	testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), failed))
+	version, blocked := failedVersion()
+	testrequire.True(t, blocked)
+	testrequire.Equal(t, "1.0.0", version)
+
+	// a version created before the failed one does not lift the block, e.g. when it is propagated again
+	testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), older))
+	_, blocked = failedVersion()
+	testrequire.True(t, blocked)
+
+	testrequire.Nil(t, r.Client.Create(context.TODO(), newer))
+	testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), newer))
+	_, blocked = failedVersion()
+	testrequire.False(t, blocked)
+
+	// propagating the failed version again does not revert the result of the newer version
+	testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), failed))
+	_, blocked = failedVersion()
+	testrequire.False(t, blocked)
+}
+
+func TestKeptnWorkloadInstanceReconciler_propagateScaleUpGuard_Unavailable(t *testing.T) {
+	failed := makeScaleUpGuardInstance("1.0.0", common.StateFailed, time.Now())
+	deployment, rs := makeDeployment(nil)
+	deployment.Annotations = map[string]string{common.ScaleUpGuardAnnotation: "enabled"}
+	r := newWorkloadInstanceTestReconciler(t, failed, deployment, rs)
+	// autoscaling/v2 has not been discovered
+	r.Capabilities = controllercommon.NewCapabilities(nil, logr.Discard())
+	recorder := r.Recorder.(*record.FakeRecorder)
+
+	for i := 0; i < 2; i++ {
+		result := &v1alpha1.KeptnWorkloadInstance{}
+		testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: failed.Name}, result))
+		testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), result))
+	}
+
+	result := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: failed.Name}, result))
+	condition := result.GetCondition(v1alpha1.ScaleUpGuardUnavailableConditionType)
+	testrequire.NotNil(t, condition)
+	testrequire.Equal(t, metav1.ConditionTrue, condition.Status)
+	testrequire.Equal(t, "HorizontalPodAutoscalerV2NotInstalled", condition.Reason)
+	// the event is only recorded when the condition changes
+	testrequire.Len(t, recorder.Events, 1)
+
+	deploymentResult := &appsv1.Deployment{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-deployment"}, deploymentResult))
+	testrequire.NotContains(t, deploymentResult.Annotations, common.FailedVersionAnnotation)
+
+	// once the API is served, the condition is cleared and the result is propagated
+	r.Capabilities = nil
+	testrequire.Nil(t, r.propagateScaleUpGuard(context.TODO(), result))
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: failed.Name}, result))
+	testrequire.Equal(t, metav1.ConditionFalse, result.GetCondition(v1alpha1.ScaleUpGuardUnavailableConditionType).Status)
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-deployment"}, deploymentResult))
+	testrequire.Equal(t, "1.0.0", deploymentResult.Annotations[common.FailedVersionAnnotation])
+}
+
+func makeScaleUpGuardInstance(version string, state common.KeptnState, created time.Time) *v1alpha1.KeptnWorkloadInstance {
+	return &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "my-app-my-workload-" + version,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				Version:           version,
+				ResourceReference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{Status: state},
+	}
+}
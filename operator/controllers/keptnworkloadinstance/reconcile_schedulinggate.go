@@ -0,0 +1,76 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=patch
+
+// jsonPatchOperation is an operation of a JSON patch (RFC 6902)
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// removeSchedulingGates removes the pre-deployment scheduling gate from the pods of the instance, once its pods have
+// been released. The gate of pods whose checks have failed is never removed. The pods are read as unstructured
+// objects, since the Pod type the operator is built with does not know spec.schedulingGates yet.
+func (r *KeptnWorkloadInstanceReconciler) removeSchedulingGates(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	if workloadInstance.Status.GateReleaseTime.IsZero() {
+		return nil
+	}
+	pods := &unstructured.UnstructuredList{}
+	pods.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PodList"))
+	if err := r.Client.List(ctx, pods, client.InNamespace(workloadInstance.Namespace), client.MatchingLabels{
+		common.SchedulingGatedLabel:  "true",
+		common.WorkloadInstanceLabel: common.GetWorkloadInstanceLabelValue(workloadInstance.Name),
+	}); err != nil {
+		return fmt.Errorf("could not list the gated pods: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		patch, err := json.Marshal(getSchedulingGatePatch(pod))
+		if err != nil {
+			return err
+		}
+		if err := r.Client.Patch(ctx, pod, client.RawPatch(types.JSONPatchType, patch)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not remove the scheduling gate of pod %s: %w", pod.GetName(), err)
+		}
+		r.Log.Info("Removed the scheduling gate of pod", "pod", pod.GetName(), "workloadInstance", workloadInstance.Name)
+	}
+	return nil
+}
+
+// getSchedulingGatePatch removes the pre-deployment scheduling gate and the labels the webhook has set with it. The
+// test operation makes the patch fail instead of removing another gate if the gates of the pod have changed in the
+// meantime.
+func getSchedulingGatePatch(pod *unstructured.Unstructured) []jsonPatchOperation {
+	var patch []jsonPatchOperation
+	gates, _, _ := unstructured.NestedSlice(pod.Object, "spec", "schedulingGates")
+	for i, gate := range gates {
+		if gate, ok := gate.(map[string]interface{}); ok && gate["name"] == common.PreDeploymentSchedulingGate {
+			path := fmt.Sprintf("/spec/schedulingGates/%d", i)
+			patch = append(patch,
+				jsonPatchOperation{Op: "test", Path: path + "/name", Value: common.PreDeploymentSchedulingGate},
+				jsonPatchOperation{Op: "remove", Path: path},
+			)
+			break
+		}
+	}
+	for _, label := range []string{common.SchedulingGatedLabel, common.WorkloadInstanceLabel} {
+		// "/" is escaped as "~1" in JSON pointers
+		patch = append(patch, jsonPatchOperation{Op: "remove", Path: "/metadata/labels/" + strings.ReplaceAll(label, "/", "~1")})
+	}
+	return patch
+}
@@ -0,0 +1,169 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGatedPod(name string, version string, gates ...string) *unstructured.Unstructured {
+	schedulingGates := make([]interface{}, 0, len(gates))
+	for _, gate := range gates {
+		schedulingGates = append(schedulingGates, map[string]interface{}{"name": gate})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      name,
+			"labels": map[string]interface{}{
+				common.SchedulingGatedLabel:  "true",
+				common.WorkloadInstanceLabel: "my-app-my-workload-" + version,
+				"app":                        "my-workload",
+			},
+			"annotations": map[string]interface{}{
+				common.AppAnnotation:      "my-app",
+				common.WorkloadAnnotation: "my-workload",
+				common.VersionAnnotation:  version,
+			},
+		},
+		"spec": map[string]interface{}{
+			"schedulingGates": schedulingGates,
+			"containers":      []interface{}{map[string]interface{}{"name": "app", "image": "nginx:" + version}},
+		},
+	}}
+}
+
+// newLabelsOnlyGatedPod names its workload with the recommended Kubernetes labels only, without any Keptn annotations
+func newLabelsOnlyGatedPod(name string, version string) *unstructured.Unstructured {
+	pod := newGatedPod(name, version, common.PreDeploymentSchedulingGate)
+	pod.SetAnnotations(nil)
+	pod.SetLabels(map[string]string{
+		common.SchedulingGatedLabel:              "true",
+		common.WorkloadInstanceLabel:             "my-app-my-workload-" + version,
+		common.K8sRecommendedAppAnnotations:      "my-app",
+		common.K8sRecommendedWorkloadAnnotations: "my-workload",
+		common.K8sRecommendedVersionAnnotations:  version,
+	})
+	return pod
+}
+
+// newSchedulingGateTestReconciler keeps pods unstructured, since the Pod type does not know spec.schedulingGates yet
+func newSchedulingGateTestReconciler(t *testing.T, objects ...client.Object) *KeptnWorkloadInstanceReconciler {
+	scheme := runtime.NewScheme()
+	testrequire.Nil(t, v1alpha1.AddToScheme(scheme))
+	return &KeptnWorkloadInstanceReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme: scheme,
+		Log:    logr.Discard(),
+	}
+}
+
+func getGatedPod(t *testing.T, r *KeptnWorkloadInstanceReconciler, name string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, pod))
+	return pod
+}
+
+func getSchedulingGates(pod *unstructured.Unstructured) []string {
+	gates, _, _ := unstructured.NestedSlice(pod.Object, "spec", "schedulingGates")
+	names := make([]string, 0, len(gates))
+	for _, gate := range gates {
+		names = append(names, gate.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestKeptnWorkloadInstanceReconciler_removeSchedulingGates(t *testing.T) {
+	released := metav1.NewTime(time.Now())
+	tests := []struct {
+		name      string
+		status    v1alpha1.KeptnWorkloadInstanceStatus
+		wantGated bool
+	}{
+		{
+			name:      "checks in progress",
+			status:    v1alpha1.KeptnWorkloadInstanceStatus{PreDeploymentStatus: common.StateProgressing},
+			wantGated: true,
+		},
+		{
+			name:      "checks failed",
+			status:    v1alpha1.KeptnWorkloadInstanceStatus{PreDeploymentStatus: common.StateFailed},
+			wantGated: true,
+		},
+		{
+			name:      "checks succeeded",
+			status:    v1alpha1.KeptnWorkloadInstanceStatus{PreDeploymentStatus: common.StateSucceeded, GateReleaseTime: released},
+			wantGated: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-2.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{AppName: "my-app", Version: "2.0.0"},
+					WorkloadName:      "my-app-my-workload",
+				},
+				Status: tt.status,
+			}
+			r := newSchedulingGateTestReconciler(t,
+				// the pod also carries the gate of another controller, which must be kept
+				newGatedPod("created-before-checks", "2.0.0", "example.com/quota", common.PreDeploymentSchedulingGate),
+				newGatedPod("other-version", "1.0.0", common.PreDeploymentSchedulingGate),
+				newLabelsOnlyGatedPod("labels-only", "2.0.0"),
+			)
+
+			testrequire.Nil(t, r.removeSchedulingGates(context.TODO(), workloadInstance))
+
+			pod := getGatedPod(t, r, "created-before-checks")
+			if tt.wantGated {
+				testrequire.Equal(t, []string{"example.com/quota", common.PreDeploymentSchedulingGate}, getSchedulingGates(pod))
+				testrequire.Equal(t, "true", pod.GetLabels()[common.SchedulingGatedLabel])
+			} else {
+				testrequire.Equal(t, []string{"example.com/quota"}, getSchedulingGates(pod))
+				testrequire.Equal(t, map[string]string{"app": "my-workload"}, pod.GetLabels())
+			}
+			labelsOnly := getGatedPod(t, r, "labels-only")
+			if tt.wantGated {
+				testrequire.Equal(t, []string{common.PreDeploymentSchedulingGate}, getSchedulingGates(labelsOnly))
+			} else {
+				testrequire.Empty(t, getSchedulingGates(labelsOnly))
+				testrequire.NotContains(t, labelsOnly.GetLabels(), common.WorkloadInstanceLabel)
+			}
+			// pods of other versions are left alone
+			testrequire.Equal(t, []string{common.PreDeploymentSchedulingGate}, getSchedulingGates(getGatedPod(t, r, "other-version")))
+		})
+	}
+}
+
+func TestGetSchedulingGatePatch(t *testing.T) {
+	pod := newGatedPod("my-pod", "1.0.0", "example.com/quota", common.PreDeploymentSchedulingGate)
+	testrequire.Equal(t, []jsonPatchOperation{
+		{Op: "test", Path: "/spec/schedulingGates/1/name", Value: common.PreDeploymentSchedulingGate},
+		{Op: "remove", Path: "/spec/schedulingGates/1"},
+		{Op: "remove", Path: "/metadata/labels/keptn.sh~1scheduling-gated"},
+		{Op: "remove", Path: "/metadata/labels/keptn.sh~1workload-instance"},
+	}, getSchedulingGatePatch(pod))
+
+	// the gate has already been removed by someone else
+	pod = newGatedPod("my-pod", "1.0.0")
+	testrequire.Equal(t, []jsonPatchOperation{
+		{Op: "remove", Path: "/metadata/labels/keptn.sh~1scheduling-gated"},
+		{Op: "remove", Path: "/metadata/labels/keptn.sh~1workload-instance"},
+	}, getSchedulingGatePatch(pod))
+}
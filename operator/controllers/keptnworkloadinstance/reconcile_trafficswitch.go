@@ -0,0 +1,91 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// trafficSwitchFieldManager is the field manager used for server-side applying the Service selector,
+// so that changes of other controllers to the same Service are not overwritten
+const trafficSwitchFieldManager = "keptn-traffic-switch"
+
+// trafficSwitchServiceTimeout is the time the traffic switch waits for its Service to be created before the
+// KeptnWorkloadInstance fails
+const trafficSwitchServiceTimeout = 5 * time.Minute
+
+var trafficSwitchPhase = common.KeptnPhaseType{
+	ShortName: "TrafficSwitch",
+	LongName:  "Traffic Switch",
+}
+
+func (r *KeptnWorkloadInstanceReconciler) switchTraffic(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	// the selector is applied with the resource version it has been read at, so that keys another controller adds in
+	// the meantime are not dropped; on a conflict, the selector is read and merged again
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return r.applyTrafficSwitchSelector(ctx, workloadInstance)
+	}); err != nil {
+		return err
+	}
+
+	workloadInstance.Status.TrafficSwitchTime = metav1.NewTime(time.Now().UTC())
+	return controllercommon.UpdateStatus(ctx, r.Client, workloadInstance)
+}
+
+func (r *KeptnWorkloadInstanceReconciler) applyTrafficSwitchSelector(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	trafficSwitch := workloadInstance.Spec.TrafficSwitch
+
+	existing := &corev1.Service{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: trafficSwitch.ServiceName, Namespace: workloadInstance.Namespace}, existing); err != nil {
+		return fmt.Errorf("could not retrieve Service %s: %w", trafficSwitch.ServiceName, err)
+	}
+
+	// the selector of a Service is atomic, so applying it replaces the whole selector instead of merging the keys
+	selector := make(map[string]string, len(existing.Spec.Selector)+len(trafficSwitch.Selector))
+	for key, value := range existing.Spec.Selector {
+		selector[key] = value
+	}
+	for key, value := range trafficSwitch.Selector {
+		selector[key] = value
+	}
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            trafficSwitch.ServiceName,
+			Namespace:       workloadInstance.Namespace,
+			ResourceVersion: existing.ResourceVersion,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	if err := r.Client.Patch(ctx, service, client.Apply, client.FieldOwner(trafficSwitchFieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("could not patch selector of Service %s: %w", trafficSwitch.ServiceName, err)
+	}
+	return nil
+}
+
+// failTrafficSwitch fails a KeptnWorkloadInstance whose Service has not been created within trafficSwitchServiceTimeout
+func (r *KeptnWorkloadInstanceReconciler) failTrafficSwitch(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	message := fmt.Sprintf("Service %s has not been found within %s", workloadInstance.Spec.TrafficSwitch.ServiceName, trafficSwitchServiceTimeout)
+	workloadInstance.Status.Status = common.StateFailed
+	workloadInstance.CompleteWithReason("TrafficSwitchFailed", message)
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return err
+	}
+	controllercommon.RecordEvent(r.Recorder, trafficSwitchPhase, "Warning", workloadInstance, "Failed", "has failed since the "+message, workloadInstance.GetVersion())
+	return nil
+}
@@ -0,0 +1,147 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// atomicSelectorApplyClient emulates server-side apply of Service selectors, which the fake client does not support.
+// Like the API server, it replaces the whole selector, since the selector of a Service is an atomic map.
+type atomicSelectorApplyClient struct {
+	client.Client
+}
+
+func (c atomicSelectorApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	applied, ok := obj.(*corev1.Service)
+	if !ok || patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	service := &corev1.Service{}
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(applied), service); err != nil {
+		return err
+	}
+	if applied.ResourceVersion != "" && applied.ResourceVersion != service.ResourceVersion {
+		return apierrors.NewConflict(corev1.Resource("services"), service.Name, fmt.Errorf("the object has been modified"))
+	}
+	service.Spec.Selector = applied.Spec.Selector
+	return c.Client.Update(ctx, service)
+}
+
+// concurrentSelectorChangeClient adds a key to the selector of the Service right before the first apply,
+// as if another controller had changed it after the traffic switch has read it
+type concurrentSelectorChangeClient struct {
+	atomicSelectorApplyClient
+	changed bool
+}
+
+func (c *concurrentSelectorChangeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if !c.changed {
+		c.changed = true
+		service := &corev1.Service{}
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), service); err != nil {
+			return err
+		}
+		service.Spec.Selector["tier"] = "web"
+		if err := c.Client.Update(ctx, service); err != nil {
+			return err
+		}
+	}
+	return c.atomicSelectorApplyClient.Patch(ctx, obj, patch, opts...)
+}
+
+func newTrafficSwitchTestInstance() *v1alpha1.KeptnWorkloadInstance {
+	return &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-2.0.0"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+				Version: "2.0.0",
+				TrafficSwitch: v1alpha1.TrafficSwitch{
+					ServiceName: "my-service",
+					Selector:    map[string]string{"version": "2.0.0"},
+				},
+			},
+			WorkloadName: "my-app-my-workload",
+		},
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_SwitchTraffic(t *testing.T) {
+	workloadInstance := newTrafficSwitchTestInstance()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-service"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "my-app", "version": "1.0.0"}},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, service)
+	r.Client = atomicSelectorApplyClient{Client: r.Client}
+
+	testrequire.Nil(t, r.switchTraffic(context.TODO(), workloadInstance))
+
+	// the keys of the switch are merged into the selector of the Service
+	result := &corev1.Service{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-service"}, result))
+	testrequire.Equal(t, map[string]string{"app": "my-app", "version": "2.0.0"}, result.Spec.Selector)
+
+	// the time of the switch is written to the status, so that the switch is not repeated
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, stored))
+	testrequire.False(t, stored.Status.TrafficSwitchTime.IsZero())
+	testrequire.False(t, stored.IsTrafficSwitchPending())
+}
+
+func TestKeptnWorkloadInstanceReconciler_SwitchTrafficRetriesOnConflict(t *testing.T) {
+	workloadInstance := newTrafficSwitchTestInstance()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-service"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "my-app", "version": "1.0.0"}},
+	}
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance, service)
+	r.Client = &concurrentSelectorChangeClient{atomicSelectorApplyClient: atomicSelectorApplyClient{Client: r.Client}}
+
+	testrequire.Nil(t, r.switchTraffic(context.TODO(), workloadInstance))
+
+	// the key added concurrently is kept, since the selector is read and merged again after the conflict
+	result := &corev1.Service{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-service"}, result))
+	testrequire.Equal(t, map[string]string{"app": "my-app", "tier": "web", "version": "2.0.0"}, result.Spec.Selector)
+}
+
+func TestKeptnWorkloadInstanceReconciler_TrafficSwitchWaitsForService(t *testing.T) {
+	workloadInstance := newTrafficSwitchTestInstance()
+	r := newWorkloadInstanceTestReconciler(t, workloadInstance)
+	l := &lifecycleRun{workloadInstance: workloadInstance, span: trace.SpanFromContext(context.TODO())}
+
+	// the Service may still be created
+	result, proceed, err := r.runTrafficSwitch(context.TODO(), l)
+	testrequire.NotNil(t, err)
+	testrequire.False(t, proceed)
+	testrequire.Equal(t, 10*time.Second, result.RequeueAfter)
+	stored := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, stored))
+	testrequire.Equal(t, trafficSwitchPhase.ShortName, stored.Status.CurrentPhase)
+	testrequire.False(t, stored.Status.PhaseStartTime.IsZero())
+	testrequire.False(t, stored.IsCompleted())
+
+	// the instance fails once the Service has not been created within the timeout
+	stored.Status.PhaseStartTime = metav1.NewTime(time.Now().Add(-trafficSwitchServiceTimeout - time.Minute))
+	result, proceed, err = r.runTrafficSwitch(context.TODO(), &lifecycleRun{workloadInstance: stored, span: l.span})
+	testrequire.Nil(t, err)
+	testrequire.False(t, proceed)
+	testrequire.False(t, result.Requeue)
+
+	failed := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: workloadInstance.Name}, failed))
+	testrequire.Equal(t, common.StateFailed, failed.Status.Status)
+	testrequire.True(t, failed.IsCompleted())
+}
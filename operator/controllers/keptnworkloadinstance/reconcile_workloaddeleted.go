@@ -0,0 +1,60 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// cancelIfWorkloadDeleted cancels an in-flight KeptnWorkloadInstance whose workload has been deleted,
+// so that its checks do not keep running and the instance does not succeed for a workload that does not exist anymore
+func (r *KeptnWorkloadInstanceReconciler) cancelIfWorkloadDeleted(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) (bool, error) {
+	deleted, err := controllercommon.IsWorkloadDeleted(ctx, r.Client, workloadInstance.Namespace, workloadInstance.Spec.ResourceReference)
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	if err := r.cancelChecks(ctx, workloadInstance); err != nil {
+		return false, err
+	}
+	if err := r.SpanHandler.UnbindSpan(workloadInstance, workloadInstance.Status.CurrentPhase); err != nil {
+		r.Log.Error(err, "cannot unbind span")
+	}
+	workloadInstance.Status.CurrentPhase = common.PhaseCancelled.ShortName
+	workloadInstance.Status.Status = common.StateFailed
+	workloadInstance.CompleteWithReason("WorkloadDeleted", "workload has been deleted")
+	if err := controllercommon.UpdateStatus(ctx, r.Client, workloadInstance); err != nil {
+		return false, err
+	}
+	controllercommon.RecordEvent(r.Recorder, common.PhaseCancelled, "Warning", workloadInstance, "WorkloadDeleted", "has been cancelled since the workload has been deleted", workloadInstance.GetVersion())
+	return true, nil
+}
+
+// cancelChecks deletes the KeptnTasks and KeptnEvaluations of the instance that have not finished yet and marks them
+// as failed
+func (r *KeptnWorkloadInstanceReconciler) cancelChecks(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance) error {
+	return controllercommon.CancelChecks(ctx, r.Client, workloadInstance.Namespace,
+		[][]klcv1alpha1.TaskStatus{workloadInstance.Status.PreDeploymentTaskStatus, workloadInstance.Status.PostDeploymentTaskStatus},
+		[][]klcv1alpha1.EvaluationStatus{workloadInstance.Status.PreDeploymentEvaluationTaskStatus, workloadInstance.Status.PostDeploymentEvaluationTaskStatus},
+	)
+}
+
+// workloadDeletedPredicate passes ReplicaSets that have been deleted or are being deleted
+var workloadDeletedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return false
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetDeletionTimestamp() == nil && e.ObjectNew.GetDeletionTimestamp() != nil
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return true
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
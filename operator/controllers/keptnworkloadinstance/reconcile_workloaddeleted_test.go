@@ -0,0 +1,101 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	testrequire "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestKeptnWorkloadInstanceReconciler_cancelIfWorkloadDeleted(t *testing.T) {
+	phases := []common.KeptnPhaseType{
+		common.PhaseWorkloadPreDeployment,
+		common.PhaseWorkloadPreEvaluation,
+		// the readiness wait of the workload
+		common.PhaseWorkloadDeployment,
+		common.PhaseWorkloadPostDeployment,
+		common.PhaseWorkloadPostEvaluation,
+	}
+	for _, phase := range phases {
+		for _, deleted := range []bool{false, true} {
+			workloadInstance := &v1alpha1.KeptnWorkloadInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+				Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+					KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{
+						ResourceReference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"},
+					},
+				},
+				Status: v1alpha1.KeptnWorkloadInstanceStatus{
+					CurrentPhase: phase.ShortName,
+					Status:       common.StateProgressing,
+					PreDeploymentTaskStatus: []v1alpha1.TaskStatus{
+						{TaskDefinitionName: "succeeded", TaskName: "succeeded-task", Status: common.StateSucceeded},
+						{TaskDefinitionName: "running", TaskName: "running-task", Status: common.StateProgressing},
+					},
+					PostDeploymentEvaluationTaskStatus: []v1alpha1.EvaluationStatus{
+						{EvaluationDefinitionName: "running", EvaluationName: "running-evaluation", Status: common.StateProgressing},
+					},
+				},
+			}
+			objects := []client.Object{
+				workloadInstance,
+				&v1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "succeeded-task"}},
+				&v1alpha1.KeptnTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running-task"}},
+				&v1alpha1.KeptnEvaluation{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running-evaluation"}},
+			}
+			if !deleted {
+				objects = append(objects, &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-deployment-rs", UID: "rs-uid"}})
+			}
+			r := newWorkloadInstanceTestReconciler(t, objects...)
+
+			cancelled, err := r.cancelIfWorkloadDeleted(context.TODO(), workloadInstance)
+			testrequire.Nil(t, err)
+			testrequire.Equal(t, deleted, cancelled, phase.ShortName)
+			testrequire.Equal(t, deleted, workloadInstance.IsCompleted(), phase.ShortName)
+
+			err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "running-task"}, &v1alpha1.KeptnTask{})
+			testrequire.Equal(t, deleted, errors.IsNotFound(err), phase.ShortName)
+			err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "running-evaluation"}, &v1alpha1.KeptnEvaluation{})
+			testrequire.Equal(t, deleted, errors.IsNotFound(err), phase.ShortName)
+			testrequire.Nil(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "succeeded-task"}, &v1alpha1.KeptnTask{}))
+
+			if deleted {
+				condition := meta.FindStatusCondition(workloadInstance.Status.Conditions, v1alpha1.CompletedConditionType)
+				testrequire.Equal(t, "WorkloadDeleted", condition.Reason)
+				testrequire.Equal(t, common.PhaseCancelled.ShortName, workloadInstance.Status.CurrentPhase)
+				testrequire.Equal(t, common.StateFailed, workloadInstance.Status.Status)
+				testrequire.Equal(t, common.StateSucceeded, workloadInstance.Status.PreDeploymentTaskStatus[0].Status)
+				testrequire.Equal(t, common.StateFailed, workloadInstance.Status.PreDeploymentTaskStatus[1].Status)
+				testrequire.Equal(t, common.StateFailed, workloadInstance.Status.PostDeploymentEvaluationTaskStatus[0].Status)
+			}
+		}
+	}
+}
+
+func TestKeptnWorkloadInstanceReconciler_workloadInstancesForWorkload(t *testing.T) {
+	running := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{ResourceReference: v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"}},
+		},
+	}
+	completed := running.DeepCopy()
+	completed.Name = "completed"
+	completed.Complete()
+	other := running.DeepCopy()
+	other.Name = "other"
+	other.Spec.ResourceReference.UID = "other-uid"
+
+	r := newWorkloadInstanceTestReconciler(t, running, completed, other)
+	requests := r.workloadInstancesForWorkload(&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", UID: "rs-uid"}})
+	testrequire.Len(t, requests, 1)
+	testrequire.Equal(t, "running", requests[0].Name)
+}
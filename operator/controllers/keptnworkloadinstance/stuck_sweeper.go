@@ -0,0 +1,145 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultStuckThreshold     = 30 * time.Minute
+	DefaultStuckSweepInterval = time.Minute
+	DefaultStuckSweepChunk    = 10
+	DefaultStuckSweepPause    = 100 * time.Millisecond
+)
+
+// PhaseAttribute labels the stuck instances gauge with the phase the instances are stuck in
+const PhaseAttribute attribute.Key = attribute.Key("keptn.deployment.phase")
+
+// StuckSweeper periodically looks for KeptnWorkloadInstances that remain in their current phase for longer than
+// Threshold, sets their Stuck condition and keeps track of the number of stuck instances per phase.
+// It reads from the cache of the manager, so that no additional requests are sent to the API server for the lookup.
+type StuckSweeper struct {
+	client.Client
+	Log       logr.Logger
+	Threshold time.Duration
+	Interval  time.Duration
+	// ChunkSize is the number of namespaces processed before the sweeper pauses for ChunkPause, so that large clusters
+	// do not flood the API server with status updates
+	ChunkSize  int
+	ChunkPause time.Duration
+
+	mtx   sync.RWMutex
+	stuck map[string]int64
+}
+
+// Start runs the sweeper until the given context is cancelled. It implements manager.Runnable.
+func (s *StuckSweeper) Start(ctx context.Context) error {
+	if s.Threshold <= 0 {
+		s.Threshold = DefaultStuckThreshold
+	}
+	if s.Interval <= 0 {
+		s.Interval = DefaultStuckSweepInterval
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Sweep(ctx, time.Now()); err != nil {
+			s.Log.Error(err, "could not sweep for stuck workload instances")
+		}
+	}, s.Interval)
+	return nil
+}
+
+// Sweep updates the Stuck condition of all workload instances and recalculates the number of stuck instances per phase
+func (s *StuckSweeper) Sweep(ctx context.Context, now time.Time) error {
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := s.List(ctx, workloadInstances); err != nil {
+		return fmt.Errorf("could not retrieve workload instances: %w", err)
+	}
+
+	byNamespace := map[string][]klcv1alpha1.KeptnWorkloadInstance{}
+	for _, workloadInstance := range workloadInstances.Items {
+		byNamespace[workloadInstance.Namespace] = append(byNamespace[workloadInstance.Namespace], workloadInstance)
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	chunkSize := s.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultStuckSweepChunk
+	}
+	chunkPause := s.ChunkPause
+	if chunkPause <= 0 {
+		chunkPause = DefaultStuckSweepPause
+	}
+
+	stuck := map[string]int64{}
+	for start := 0; start < len(namespaces); start += chunkSize {
+		if start > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(chunkPause):
+			}
+		}
+		end := start + chunkSize
+		if end > len(namespaces) {
+			end = len(namespaces)
+		}
+		for _, namespace := range namespaces[start:end] {
+			for i := range byNamespace[namespace] {
+				s.sweepWorkloadInstance(ctx, &byNamespace[namespace][i], now, stuck)
+			}
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	// phases that are no longer stuck are reported as zero instead of disappearing from the gauge
+	for phase := range s.stuck {
+		if _, ok := stuck[phase]; !ok {
+			stuck[phase] = 0
+		}
+	}
+	s.stuck = stuck
+	return nil
+}
+
+func (s *StuckSweeper) sweepWorkloadInstance(ctx context.Context, workloadInstance *klcv1alpha1.KeptnWorkloadInstance, now time.Time, stuck map[string]int64) {
+	isStuck := workloadInstance.IsStuck(s.Threshold, now)
+	if isStuck {
+		stuck[workloadInstance.Status.CurrentPhase]++
+	}
+	if !workloadInstance.SetStuck(isStuck, s.Threshold) {
+		return
+	}
+	if err := s.Status().Update(ctx, workloadInstance); err != nil {
+		s.Log.Error(err, "could not update stuck condition", "namespace", workloadInstance.Namespace, "name", workloadInstance.Name)
+	}
+}
+
+// GetStuckInstances returns the number of stuck workload instances per phase as of the last sweep
+func (s *StuckSweeper) GetStuckInstances(ctx context.Context) ([]common.GaugeValue, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := []common.GaugeValue{}
+	for phase, count := range s.stuck {
+		res = append(res, common.GaugeValue{
+			Value:      count,
+			Attributes: []attribute.KeyValue{PhaseAttribute.String(phase)},
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	testrequire "github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStuckSweeper_Sweep(t *testing.T) {
+	now := time.Now()
+	stuck := makeWorkloadInstance("ns1", "stuck", "PreDeployTasks", now.Add(-time.Hour))
+	progressing := makeWorkloadInstance("ns2", "progressing", "PreDeployTasks", now.Add(-time.Minute))
+	completed := makeWorkloadInstance("ns3", "completed", "PostDeployTasks", now.Add(-time.Hour))
+	completed.Status.EndTime = metav1.NewTime(now)
+
+	scheme := runtime.NewScheme()
+	testrequire.Nil(t, v1alpha1.AddToScheme(scheme))
+	s := &StuckSweeper{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(&stuck, &progressing, &completed).Build(),
+		Log:        logr.Discard(),
+		Threshold:  30 * time.Minute,
+		ChunkSize:  1,
+		ChunkPause: time.Millisecond,
+	}
+
+	testrequire.Nil(t, s.Sweep(context.TODO(), now))
+
+	gaugeValues, err := s.GetStuckInstances(context.TODO())
+	testrequire.Nil(t, err)
+	testrequire.Len(t, gaugeValues, 1)
+	testrequire.Equal(t, int64(1), gaugeValues[0].Value)
+	testrequire.Equal(t, "PreDeployTasks", gaugeValues[0].Attributes[0].Value.AsString())
+
+	result := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, s.Get(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "stuck"}, result))
+	testrequire.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.StuckConditionType))
+
+	testrequire.Nil(t, s.Get(context.TODO(), types.NamespacedName{Namespace: "ns2", Name: "progressing"}, result))
+	testrequire.Nil(t, meta.FindStatusCondition(result.Status.Conditions, v1alpha1.StuckConditionType))
+
+	// once the instance moved on, the condition is cleared and the gauge drops to zero
+	testrequire.Nil(t, s.Get(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "stuck"}, result))
+	result.SetCurrentPhase("PostDeployTasks")
+	testrequire.Nil(t, s.Status().Update(context.TODO(), result))
+
+	testrequire.Nil(t, s.Sweep(context.TODO(), now))
+
+	gaugeValues, err = s.GetStuckInstances(context.TODO())
+	testrequire.Nil(t, err)
+	testrequire.Len(t, gaugeValues, 1)
+	testrequire.Equal(t, int64(0), gaugeValues[0].Value)
+
+	testrequire.Nil(t, s.Get(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "stuck"}, result))
+	testrequire.True(t, meta.IsStatusConditionFalse(result.Status.Conditions, v1alpha1.StuckConditionType))
+}
+
+func makeWorkloadInstance(namespace string, name string, phase string, phaseStart time.Time) v1alpha1.KeptnWorkloadInstance {
+	return v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Status: v1alpha1.KeptnWorkloadInstanceStatus{
+			CurrentPhase:   phase,
+			PhaseStartTime: metav1.NewTime(phaseStart),
+		},
+	}
+}
+
+func TestStuckSweeper_SweepChunks(t *testing.T) {
+	now := time.Now()
+	first := makeWorkloadInstance("ns1", "first", "PreDeployTasks", now.Add(-time.Hour))
+	second := makeWorkloadInstance("ns2", "second", "PreDeployTasks", now.Add(-time.Hour))
+
+	scheme := runtime.NewScheme()
+	testrequire.Nil(t, v1alpha1.AddToScheme(scheme))
+	s := &StuckSweeper{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(&first, &second).Build(),
+		Log:       logr.Discard(),
+		Threshold: 30 * time.Minute,
+	}
+
+	// without a chunk size, the default is used instead of looping forever
+	testrequire.Nil(t, s.Sweep(context.TODO(), now))
+	gaugeValues, err := s.GetStuckInstances(context.TODO())
+	testrequire.Nil(t, err)
+	testrequire.Equal(t, int64(2), gaugeValues[0].Value)
+
+	// the sweeper stops in the pause between two chunks once the context is cancelled
+	s.ChunkSize = 1
+	s.ChunkPause = time.Hour
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	testrequire.ErrorIs(t, s.Sweep(ctx, now), context.DeadlineExceeded)
+}
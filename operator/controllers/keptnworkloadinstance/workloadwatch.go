@@ -0,0 +1,91 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resourceReferenceUIDField indexes KeptnWorkloadInstances by the UID of the ReplicaSet, StatefulSet or DaemonSet
+// they reference, so that the instances of a workload can be found without listing all instances of its namespace
+const resourceReferenceUIDField = "spec.resourceReference.uid"
+
+func indexResourceReferenceUID(obj client.Object) []string {
+	workloadInstance, ok := obj.(*klcv1alpha1.KeptnWorkloadInstance)
+	if !ok || workloadInstance.Spec.ResourceReference.UID == "" {
+		return nil
+	}
+	return []string{string(workloadInstance.Spec.ResourceReference.UID)}
+}
+
+// ownedCheckHandler maps the KeptnTasks and KeptnEvaluations of an instance to the instance, just like Owns does, so
+// that a phase of checks continues as soon as one of its checks changes
+func ownedCheckHandler() handler.EventHandler {
+	return &handler.EnqueueRequestForOwner{OwnerType: &klcv1alpha1.KeptnWorkloadInstance{}, IsController: true}
+}
+
+// workloadReadinessPredicate passes updates of ReplicaSets, StatefulSets and DaemonSets that change the number of
+// their ready pods. All other events are dropped before they are mapped to workload instances.
+var workloadReadinessPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return false
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldReady, ok := getReadyReplicas(e.ObjectOld)
+		if !ok {
+			return false
+		}
+		newReady, _ := getReadyReplicas(e.ObjectNew)
+		return oldReady != newReady
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+func getReadyReplicas(obj client.Object) (int32, bool) {
+	switch workload := obj.(type) {
+	case *appsv1.ReplicaSet:
+		return workload.Status.ReadyReplicas, true
+	case *appsv1.StatefulSet:
+		return workload.Status.ReadyReplicas, true
+	case *appsv1.DaemonSet:
+		return workload.Status.NumberReady, true
+	}
+	return 0, false
+}
+
+// workloadInstancesForWorkload returns the requests for all unfinished KeptnWorkloadInstances referencing the
+// ReplicaSet, StatefulSet or DaemonSet
+func (r *KeptnWorkloadInstanceReconciler) workloadInstancesForWorkload(obj client.Object) []reconcile.Request {
+	// the index is only available in the informer cache of the manager, the client may read from the API server
+	var reader client.Reader = r.Client
+	opts := []client.ListOption{client.InNamespace(obj.GetNamespace())}
+	if r.indexedReader != nil {
+		reader = r.indexedReader
+		opts = append(opts, client.MatchingFields{resourceReferenceUIDField: string(obj.GetUID())})
+	}
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := reader.List(context.TODO(), workloadInstances, opts...); err != nil {
+		r.Log.Error(err, "could not list workload instances", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, workloadInstance := range workloadInstances.Items {
+		if workloadInstance.Spec.ResourceReference.UID != obj.GetUID() || workloadInstance.IsCompleted() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: workloadInstance.Namespace, Name: workloadInstance.Name}})
+	}
+	return requests
+}
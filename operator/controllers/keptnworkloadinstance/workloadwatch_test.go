@@ -0,0 +1,105 @@
+package keptnworkloadinstance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	testrequire "github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// recordingReader records the options of the List calls it passes on
+type recordingReader struct {
+	client.Reader
+	listOptions client.ListOptions
+}
+
+func (r *recordingReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.listOptions.ApplyOptions(opts)
+	return r.Reader.List(ctx, list, opts...)
+}
+
+func TestIndexResourceReferenceUID(t *testing.T) {
+	workloadInstance := &v1alpha1.KeptnWorkloadInstance{}
+	testrequire.Nil(t, indexResourceReferenceUID(workloadInstance))
+
+	workloadInstance.Spec.ResourceReference = v1alpha1.ResourceReference{UID: "rs-uid", Kind: "ReplicaSet"}
+	testrequire.Equal(t, []string{"rs-uid"}, indexResourceReferenceUID(workloadInstance))
+
+	testrequire.Nil(t, indexResourceReferenceUID(&v1alpha1.KeptnWorkload{}))
+}
+
+func TestWorkloadReadinessPredicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		old    client.Object
+		new    client.Object
+		passes bool
+	}{
+		{
+			name:   "replicaset becomes ready",
+			old:    &appsv1.ReplicaSet{Status: appsv1.ReplicaSetStatus{ReadyReplicas: 1}},
+			new:    &appsv1.ReplicaSet{Status: appsv1.ReplicaSetStatus{ReadyReplicas: 2}},
+			passes: true,
+		},
+		{
+			name: "replicaset readiness unchanged",
+			old:  &appsv1.ReplicaSet{Status: appsv1.ReplicaSetStatus{ReadyReplicas: 2, ObservedGeneration: 1}},
+			new:  &appsv1.ReplicaSet{Status: appsv1.ReplicaSetStatus{ReadyReplicas: 2, ObservedGeneration: 2}},
+		},
+		{
+			name:   "statefulset becomes ready",
+			old:    &appsv1.StatefulSet{},
+			new:    &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{ReadyReplicas: 1}},
+			passes: true,
+		},
+		{
+			name:   "daemonset loses a ready pod",
+			old:    &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{NumberReady: 3}},
+			new:    &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{NumberReady: 2}},
+			passes: true,
+		},
+		{
+			name: "other kind",
+			old:  &corev1.Pod{},
+			new:  &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testrequire.Equal(t, tt.passes, workloadReadinessPredicate.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}))
+		})
+	}
+	testrequire.False(t, workloadReadinessPredicate.Create(event.CreateEvent{Object: &appsv1.ReplicaSet{}}))
+	testrequire.False(t, workloadReadinessPredicate.Delete(event.DeleteEvent{Object: &appsv1.ReplicaSet{}}))
+}
+
+func TestKeptnWorkloadInstanceReconciler_workloadInstancesForWorkloadUsesIndex(t *testing.T) {
+	running := &v1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running"},
+		Spec: v1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: v1alpha1.KeptnWorkloadSpec{ResourceReference: v1alpha1.ResourceReference{UID: "sts-uid", Kind: "StatefulSet"}},
+		},
+	}
+	other := running.DeepCopy()
+	other.Name = "other"
+	other.Spec.ResourceReference.UID = "other-uid"
+
+	r := newWorkloadInstanceTestReconciler(t, running, other)
+	reader := &recordingReader{Reader: r.Client}
+	r.indexedReader = reader
+
+	requests := r.workloadInstancesForWorkload(&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", UID: "sts-uid"}})
+	testrequire.Len(t, requests, 1)
+	testrequire.Equal(t, "running", requests[0].Name)
+	testrequire.Equal(t, "default", reader.listOptions.Namespace)
+	testrequire.Equal(t, resourceReferenceUIDField+"=sts-uid", reader.listOptions.FieldSelector.String())
+
+	// objects not referenced by any instance are dropped
+	testrequire.Empty(t, r.workloadInstancesForWorkload(&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", UID: "unknown-uid"}}))
+}
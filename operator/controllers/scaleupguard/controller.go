@@ -0,0 +1,177 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleupguard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ScaleUpGuardReconciler disables scaling up of Deployments whose latest KeptnWorkloadInstance has failed.
+// Deployments opt in with the keptn.sh/scale-up-guard: enabled annotation. While a Deployment carries the
+// keptn.sh/failed-version annotation, the scale-up select policy of its HorizontalPodAutoscalers is set to Disabled.
+// The previous policy is stored in an annotation of the HorizontalPodAutoscaler in the same update,
+// so that it can be restored after a restart of the operator.
+type ScaleUpGuardReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Log      logr.Logger
+}
+
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;update
+
+func (r *ScaleUpGuardReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, deployment); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("could not fetch Deployment: %w", err)
+	}
+
+	_, failed := deployment.Annotations[common.FailedVersionAnnotation]
+	blocked := failed && deployment.Annotations[common.ScaleUpGuardAnnotation] == "enabled"
+
+	hpas := &autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := r.List(ctx, hpas, client.InNamespace(deployment.Namespace)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not retrieve horizontal pod autoscalers: %w", err)
+	}
+	for i := range hpas.Items {
+		hpa := &hpas.Items[i]
+		if hpa.Spec.ScaleTargetRef.Kind != "Deployment" || hpa.Spec.ScaleTargetRef.Name != deployment.Name {
+			continue
+		}
+		changed := false
+		if blocked {
+			changed = blockScaleUp(hpa)
+		} else {
+			changed = restoreScaleUp(hpa)
+		}
+		if !changed {
+			continue
+		}
+		if err := r.Update(ctx, hpa); err != nil {
+			return reconcile.Result{}, fmt.Errorf("could not update HorizontalPodAutoscaler: %w", err)
+		}
+		if blocked {
+			r.Recorder.Event(deployment, "Warning", "ScaleUpBlocked", fmt.Sprintf("Blocked scale-up since version %s has failed / Namespace: %s, Name: %s ", deployment.Annotations[common.FailedVersionAnnotation], hpa.Namespace, hpa.Name))
+		} else {
+			r.Recorder.Event(deployment, "Normal", "ScaleUpRestored", fmt.Sprintf("Restored scale-up policy / Namespace: %s, Name: %s ", hpa.Namespace, hpa.Name))
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// blockScaleUp captures the current scale-up select policy and disables scaling up. It returns false if the
+// HorizontalPodAutoscaler has already been blocked, so that the captured policy is never overwritten.
+func blockScaleUp(hpa *autoscalingv2.HorizontalPodAutoscaler) bool {
+	if _, captured := hpa.Annotations[common.PreviousScaleUpPolicyAnnotation]; captured {
+		return false
+	}
+	previous := ""
+	if hpa.Spec.Behavior != nil && hpa.Spec.Behavior.ScaleUp != nil && hpa.Spec.Behavior.ScaleUp.SelectPolicy != nil {
+		previous = string(*hpa.Spec.Behavior.ScaleUp.SelectPolicy)
+	}
+	if hpa.Annotations == nil {
+		hpa.Annotations = map[string]string{}
+	}
+	hpa.Annotations[common.PreviousScaleUpPolicyAnnotation] = previous
+
+	if hpa.Spec.Behavior == nil {
+		hpa.Spec.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{}
+	}
+	if hpa.Spec.Behavior.ScaleUp == nil {
+		hpa.Spec.Behavior.ScaleUp = &autoscalingv2.HPAScalingRules{}
+	}
+	disabled := autoscalingv2.DisabledPolicySelect
+	hpa.Spec.Behavior.ScaleUp.SelectPolicy = &disabled
+	return true
+}
+
+// restoreScaleUp restores the captured scale-up select policy. An empty captured policy restores the default.
+func restoreScaleUp(hpa *autoscalingv2.HorizontalPodAutoscaler) bool {
+	previous, captured := hpa.Annotations[common.PreviousScaleUpPolicyAnnotation]
+	if !captured {
+		return false
+	}
+	delete(hpa.Annotations, common.PreviousScaleUpPolicyAnnotation)
+	if hpa.Spec.Behavior == nil || hpa.Spec.Behavior.ScaleUp == nil {
+		return true
+	}
+	if previous == "" {
+		hpa.Spec.Behavior.ScaleUp.SelectPolicy = nil
+	} else {
+		policy := autoscalingv2.ScalingPolicySelect(previous)
+		hpa.Spec.Behavior.ScaleUp.SelectPolicy = &policy
+	}
+	return true
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScaleUpGuardReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("scaleupguard").
+		// only Deployments that opt in, or just opted out, are reconciled
+		For(&appsv1.Deployment{}, builder.WithPredicates(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return hasScaleUpGuard(e.Object)
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return hasScaleUpGuard(e.ObjectOld) || hasScaleUpGuard(e.ObjectNew)
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return hasScaleUpGuard(e.Object)
+			},
+		})).
+		Watches(&source.Kind{Type: &autoscalingv2.HorizontalPodAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(scaleTargetRequest)).
+		Complete(r)
+}
+
+func hasScaleUpGuard(obj client.Object) bool {
+	_, ok := obj.GetAnnotations()[common.ScaleUpGuardAnnotation]
+	return ok
+}
+
+func scaleTargetRequest(obj client.Object) []reconcile.Request {
+	hpa, ok := obj.(*autoscalingv2.HorizontalPodAutoscaler)
+	if !ok || hpa.Spec.ScaleTargetRef.Kind != "Deployment" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: hpa.Namespace, Name: hpa.Spec.ScaleTargetRef.Name}}}
+}
@@ -0,0 +1,103 @@
+package scaleupguard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaleUpGuardReconciler_BlockAndRestore(t *testing.T) {
+	maxPolicy := autoscalingv2.MaxChangePolicySelect
+	for _, previous := range []*autoscalingv2.ScalingPolicySelect{nil, &maxPolicy} {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "my-deployment",
+				Annotations: map[string]string{
+					common.ScaleUpGuardAnnotation:  "enabled",
+					common.FailedVersionAnnotation: "2.0.0",
+				},
+			},
+		}
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-hpa"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "my-deployment"},
+			},
+		}
+		if previous != nil {
+			hpa.Spec.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+				ScaleUp: &autoscalingv2.HPAScalingRules{SelectPolicy: previous},
+			}
+		}
+		r := &ScaleUpGuardReconciler{
+			Client:   fake.NewClientBuilder().WithObjects(deployment, hpa).Build(),
+			Log:      logr.Discard(),
+			Recorder: record.NewFakeRecorder(100),
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-deployment"}}
+
+		_, err := r.Reconcile(context.TODO(), req)
+		require.Nil(t, err)
+		result := getHPA(t, r)
+		require.Equal(t, autoscalingv2.DisabledPolicySelect, *result.Spec.Behavior.ScaleUp.SelectPolicy)
+		require.Contains(t, result.Annotations, common.PreviousScaleUpPolicyAnnotation)
+
+		// reconciling a blocked HorizontalPodAutoscaler again must not overwrite the captured policy
+		_, err = r.Reconcile(context.TODO(), req)
+		require.Nil(t, err)
+		require.Equal(t, result.Annotations, getHPA(t, r).Annotations)
+
+		delete(deployment.Annotations, common.FailedVersionAnnotation)
+		require.Nil(t, r.Update(context.TODO(), deployment))
+
+		_, err = r.Reconcile(context.TODO(), req)
+		require.Nil(t, err)
+		result = getHPA(t, r)
+		require.NotContains(t, result.Annotations, common.PreviousScaleUpPolicyAnnotation)
+		require.Equal(t, previous, result.Spec.Behavior.ScaleUp.SelectPolicy)
+	}
+}
+
+func TestScaleUpGuardReconciler_IgnoresOtherTargets(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "my-deployment",
+			Annotations: map[string]string{
+				common.ScaleUpGuardAnnotation:  "enabled",
+				common.FailedVersionAnnotation: "2.0.0",
+			},
+		},
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-hpa"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "other-deployment"},
+		},
+	}
+	r := &ScaleUpGuardReconciler{
+		Client:   fake.NewClientBuilder().WithObjects(deployment, hpa).Build(),
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(100),
+	}
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-deployment"}})
+	require.Nil(t, err)
+	require.Nil(t, getHPA(t, r).Spec.Behavior)
+}
+
+func getHPA(t *testing.T, r *ScaleUpGuardReconciler) *autoscalingv2.HorizontalPodAutoscaler {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	require.Nil(t, r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-hpa"}, hpa))
+	return hpa
+}
@@ -0,0 +1,197 @@
+// Package featuregate keeps track of the optional behaviors of the operator that are enabled with the
+// --feature-gates flag, e.g. --feature-gates=PreventTaskEviction=true,StrictReferences=false.
+package featuregate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Feature is the name of a feature gate
+type Feature string
+
+// Stage is the maturity of a feature. Alpha features are disabled by default, Beta features are enabled by default
+// and GA features are always enabled.
+type Stage string
+
+const (
+	Alpha Stage = "Alpha"
+	Beta  Stage = "Beta"
+	GA    Stage = "GA"
+)
+
+const (
+	// PreventTaskEviction marks the pods of the Jobs of KeptnTasks as not safe to evict, so that the cluster
+	// autoscaler does not scale down their nodes while they are running
+	PreventTaskEviction Feature = "PreventTaskEviction"
+	// StrictReferences lets the webhook deny pods that reference KeptnTaskDefinitions or KeptnEvaluationDefinitions
+	// that do not exist, instead of failing their checks at runtime
+	StrictReferences Feature = "StrictReferences"
+	// SchedulingGates lets the webhook add the keptn.sh/pre-deployment scheduling gate to the pods of workloads, which
+	// is removed once their pre-deployment checks have succeeded. It needs Kubernetes 1.26 or later.
+	SchedulingGates Feature = "SchedulingGates"
+	// CrossNamespaceReferences lets KeptnTasks run KeptnTaskDefinitions of other namespaces, if these namespaces are
+	// listed as providers of KeptnTaskDefinitions
+	CrossNamespaceReferences Feature = "CrossNamespaceReferences"
+)
+
+// FeatureSpec describes a known feature gate
+type FeatureSpec struct {
+	Stage   Stage
+	Default bool
+}
+
+var knownFeatures = map[Feature]FeatureSpec{
+	PreventTaskEviction:      {Stage: Alpha, Default: false},
+	StrictReferences:         {Stage: Alpha, Default: false},
+	SchedulingGates:          {Stage: Alpha, Default: false},
+	CrossNamespaceReferences: {Stage: Alpha, Default: false},
+}
+
+// Gates holds the state of the known feature gates. It is set up once at startup and queried by the controllers
+// and webhooks at runtime. Every query that finds a feature enabled is counted as a use of the feature.
+// A nil *Gates reports the default of every feature.
+type Gates struct {
+	known   map[Feature]FeatureSpec
+	enabled map[Feature]bool
+	usage   map[Feature]*int64
+}
+
+// New returns the known feature gates with their defaults
+func New() *Gates {
+	return newGates(knownFeatures)
+}
+
+func newGates(known map[Feature]FeatureSpec) *Gates {
+	g := &Gates{
+		known:   known,
+		enabled: make(map[Feature]bool, len(known)),
+		usage:   make(map[Feature]*int64, len(known)),
+	}
+	for feature, spec := range known {
+		g.enabled[feature] = spec.Default || spec.Stage == GA
+		g.usage[feature] = new(int64)
+	}
+	return g
+}
+
+// Enabled returns true if the feature is enabled
+func (g *Gates) Enabled(feature Feature) bool {
+	if g == nil {
+		spec := knownFeatures[feature]
+		return spec.Default || spec.Stage == GA
+	}
+	enabled := g.enabled[feature]
+	if enabled {
+		atomic.AddInt64(g.usage[feature], 1)
+	}
+	return enabled
+}
+
+// SetEnabled enables or disables the feature. It fails for unknown features and for disabling GA features.
+func (g *Gates) SetEnabled(feature Feature, enabled bool) error {
+	spec, ok := g.known[feature]
+	if !ok {
+		return fmt.Errorf("unknown feature gate %s, known feature gates are %s", feature, strings.Join(g.KnownFeatures(), ", "))
+	}
+	if spec.Stage == GA && !enabled {
+		return fmt.Errorf("feature gate %s is GA and cannot be disabled", feature)
+	}
+	g.enabled[feature] = enabled
+	return nil
+}
+
+// Set parses a comma separated list of feature=bool pairs. It implements flag.Value.
+func (g *Gates) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, rawEnabled, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("missing value for feature gate %s, expected %s=true or %s=false", name, name, name)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(rawEnabled))
+		if err != nil {
+			return fmt.Errorf("invalid value %s for feature gate %s: %w", rawEnabled, name, err)
+		}
+		if err := g.SetEnabled(Feature(strings.TrimSpace(name)), enabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the enabled state of all known features. It implements flag.Value.
+func (g *Gates) String() string {
+	if g == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(g.known))
+	for _, name := range g.KnownFeatures() {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, g.enabled[Feature(name)]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// KnownFeatures returns the sorted names of the known features
+func (g *Gates) KnownFeatures() []string {
+	names := make([]string, 0, len(g.known))
+	for feature := range g.known {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Usage returns the number of times the feature has been found enabled
+func (g *Gates) Usage(feature Feature) int64 {
+	if g == nil || g.usage[feature] == nil {
+		return 0
+	}
+	return atomic.LoadInt64(g.usage[feature])
+}
+
+// GetStates returns 1 for every enabled and 0 for every disabled feature
+func (g *Gates) GetStates() []common.GaugeValue {
+	if g == nil {
+		return nil
+	}
+	res := make([]common.GaugeValue, 0, len(g.known))
+	for _, name := range g.KnownFeatures() {
+		feature := Feature(name)
+		value := int64(0)
+		if g.enabled[feature] {
+			value = 1
+		}
+		res = append(res, common.GaugeValue{Value: value, Attributes: g.attributes(feature)})
+	}
+	return res
+}
+
+// GetUsage returns the number of times every feature has been found enabled
+func (g *Gates) GetUsage() []common.GaugeValue {
+	if g == nil {
+		return nil
+	}
+	res := make([]common.GaugeValue, 0, len(g.known))
+	for _, name := range g.KnownFeatures() {
+		feature := Feature(name)
+		res = append(res, common.GaugeValue{Value: g.Usage(feature), Attributes: g.attributes(feature)})
+	}
+	return res
+}
+
+func (g *Gates) attributes(feature Feature) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		common.FeatureGateName.String(string(feature)),
+		common.FeatureGateStage.String(string(g.known[feature].Stage)),
+	}
+}
@@ -0,0 +1,120 @@
+package featuregate
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGates() *Gates {
+	return newGates(map[Feature]FeatureSpec{
+		"AlphaFeature": {Stage: Alpha},
+		"BetaFeature":  {Stage: Beta, Default: true},
+		"GAFeature":    {Stage: GA, Default: true},
+	})
+}
+
+func TestGates_Defaults(t *testing.T) {
+	g := newTestGates()
+	require.False(t, g.Enabled("AlphaFeature"))
+	require.True(t, g.Enabled("BetaFeature"))
+	require.True(t, g.Enabled("GAFeature"))
+	require.False(t, g.Enabled("UnknownFeature"))
+
+	var disabled *Gates
+	require.False(t, disabled.Enabled(PreventTaskEviction))
+	require.False(t, disabled.Enabled(StrictReferences))
+}
+
+func TestGates_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "enable and disable",
+			value: "AlphaFeature=true, BetaFeature=false",
+			want:  "AlphaFeature=true,BetaFeature=false,GAFeature=true",
+		},
+		{
+			name:  "empty",
+			value: "",
+			want:  "AlphaFeature=false,BetaFeature=true,GAFeature=true",
+		},
+		{
+			name:    "unknown feature",
+			value:   "AlphaFeature=true,RollbackHook=true",
+			wantErr: "unknown feature gate RollbackHook, known feature gates are AlphaFeature, BetaFeature, GAFeature",
+		},
+		{
+			name:    "GA feature cannot be disabled",
+			value:   "GAFeature=false",
+			wantErr: "feature gate GAFeature is GA and cannot be disabled",
+		},
+		{
+			name:    "missing value",
+			value:   "AlphaFeature",
+			wantErr: "missing value for feature gate AlphaFeature, expected AlphaFeature=true or AlphaFeature=false",
+		},
+		{
+			name:    "invalid value",
+			value:   "AlphaFeature=yes",
+			wantErr: "invalid value yes for feature gate AlphaFeature",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGates()
+			err := g.Set(tt.value)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, g.String())
+		})
+	}
+}
+
+func TestGates_Flag(t *testing.T) {
+	g := New()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(g, "feature-gates", "")
+
+	require.Nil(t, flags.Parse([]string{"--feature-gates=PreventTaskEviction=true"}))
+	require.True(t, g.Enabled(PreventTaskEviction))
+	require.False(t, g.Enabled(StrictReferences))
+
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Var(New(), "feature-gates", "")
+	require.NotNil(t, flags.Parse([]string{"--feature-gates=DriftDetection=false"}))
+}
+
+func TestGates_Metrics(t *testing.T) {
+	g := newTestGates()
+	require.Nil(t, g.Set("AlphaFeature=true"))
+	g.Enabled("AlphaFeature")
+	g.Enabled("AlphaFeature")
+	g.Enabled("BetaFeature")
+	require.Nil(t, g.SetEnabled("BetaFeature", false))
+	g.Enabled("BetaFeature")
+
+	states := g.GetStates()
+	require.Len(t, states, 3)
+	require.Equal(t, int64(1), states[0].Value)
+	require.Contains(t, states[0].Attributes, common.FeatureGateName.String("AlphaFeature"))
+	require.Contains(t, states[0].Attributes, common.FeatureGateStage.String("Alpha"))
+	require.Equal(t, int64(0), states[1].Value)
+
+	usage := g.GetUsage()
+	require.Equal(t, int64(2), usage[0].Value)
+	// a query that finds the feature disabled does not count as a use
+	require.Equal(t, int64(1), usage[1].Value)
+	require.Equal(t, int64(0), usage[2].Value)
+}
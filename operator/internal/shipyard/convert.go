@@ -0,0 +1,298 @@
+package shipyard
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultAppVersion is the version of the converted KeptnApp if none is given
+	DefaultAppVersion = "0.0.1"
+	// DefaultEvaluationSource is the provider of the converted KeptnEvaluationDefinitions
+	DefaultEvaluationSource = "prometheus"
+
+	deliverySequence    = "delivery"
+	remediationSequence = "remediation"
+)
+
+// Options configure the conversion of a shipyard
+type Options struct {
+	// Stage is the stage whose delivery sequence is converted, the first stage if empty
+	Stage string
+	// AppName is the name of the KeptnApp, the name of the shipyard if empty
+	AppName string
+	// AppVersion is the version of the KeptnApp, DefaultAppVersion if empty
+	AppVersion string
+	// Namespace of the converted objects, omitted if empty
+	Namespace string
+}
+
+// Result holds the converted objects and explains the constructs of the shipyard that have not been converted faithfully
+type Result struct {
+	Objects  []client.Object
+	Warnings []string
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// ParseShipyard reads a shipyard.yaml
+func ParseShipyard(data []byte) (*Shipyard, error) {
+	shipyard := &Shipyard{}
+	if err := yaml.Unmarshal(data, shipyard); err != nil {
+		return nil, fmt.Errorf("could not parse shipyard: %w", err)
+	}
+	if shipyard.Kind != "Shipyard" {
+		return nil, fmt.Errorf("could not parse shipyard: unexpected kind %q", shipyard.Kind)
+	}
+	return shipyard, nil
+}
+
+// ParseJobConfig reads the job/config.yaml of the job executor service
+func ParseJobConfig(data []byte) (*JobConfig, error) {
+	jobConfig := &JobConfig{}
+	if err := yaml.Unmarshal(data, jobConfig); err != nil {
+		return nil, fmt.Errorf("could not parse job config: %w", err)
+	}
+	return jobConfig, nil
+}
+
+// Convert maps the delivery sequence of a stage of the shipyard to KeptnTaskDefinitions, KeptnEvaluationDefinitions
+// and a KeptnApp running them. The tasks before the deployment task become pre-deployment checks, the tasks after it
+// post-deployment checks. The jobs of the job config, if given, are referenced in the converted task definitions.
+func Convert(shipyard *Shipyard, jobConfig *JobConfig, options Options) (*Result, error) {
+	stage, err := selectStage(shipyard, options.Stage)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var delivery *Sequence
+	for i, sequence := range stage.Sequences {
+		switch sequence.Name {
+		case deliverySequence:
+			delivery = &stage.Sequences[i]
+		case remediationSequence:
+			result.warn("sequence %q of stage %q is not converted: remediations are not supported", sequence.Name, stage.Name)
+		default:
+			result.warn("sequence %q of stage %q is not converted: only the %q sequence maps to the lifecycle of a deployment", sequence.Name, stage.Name, deliverySequence)
+		}
+	}
+	if delivery == nil {
+		return nil, fmt.Errorf("stage %q has no %q sequence", stage.Name, deliverySequence)
+	}
+	for _, trigger := range delivery.TriggeredOn {
+		result.warn("the %q trigger of the %q sequence of stage %q is not converted: checks run whenever the workloads of the app are deployed", trigger.Event, delivery.Name, stage.Name)
+	}
+
+	appName := options.AppName
+	if appName == "" {
+		appName = toName(shipyard.Metadata.Name)
+	}
+	appVersion := options.AppVersion
+	if appVersion == "" {
+		appVersion = DefaultAppVersion
+	}
+	app := &klcv1alpha1.KeptnApp{
+		TypeMeta:   metav1.TypeMeta{APIVersion: klcv1alpha1.GroupVersion.String(), Kind: "KeptnApp"},
+		ObjectMeta: metav1.ObjectMeta{Name: appName, Namespace: options.Namespace},
+		Spec:       klcv1alpha1.KeptnAppSpec{Version: appVersion},
+	}
+
+	checkType := common.PreDeploymentCheckType
+	deployed := false
+	converted := map[string]bool{}
+	for _, task := range delivery.Tasks {
+		if task.TriggeredAfter != "" {
+			result.warn("the delay of %s before task %q is not converted", task.TriggeredAfter, task.Name)
+		}
+		switch task.Name {
+		case "deployment":
+			deployed = true
+			checkType = common.PostDeploymentCheckType
+		case "release":
+			result.warn("task %q is not converted: the pods of a workload are released by the Keptn scheduler once the pre-deployment checks have succeeded", task.Name)
+		case "rollback":
+			result.warn("task %q is not converted: rollbacks are not supported", task.Name)
+		case "approval":
+			result.warn("task %q is not converted: the lifecycle toolkit has no manual approval; use a pre-deployment task that waits for an external approval instead", task.Name)
+		case "evaluation":
+			name := toName(fmt.Sprintf("%s-%s-evaluation", stage.Name, checkType))
+			if !converted[name] {
+				result.Objects = append(result.Objects, newEvaluationDefinition(name, options.Namespace))
+				converted[name] = true
+			}
+			result.warn("evaluation definition %q has no objectives: copy them from the slo.yaml and sli.yaml of stage %q", name, stage.Name)
+			if checkType == common.PreDeploymentCheckType {
+				app.Spec.PreDeploymentEvaluations = append(app.Spec.PreDeploymentEvaluations, name)
+			} else {
+				app.Spec.PostDeploymentEvaluations = append(app.Spec.PostDeploymentEvaluations, name)
+			}
+		default:
+			name := toName(task.Name)
+			if !converted[name] {
+				result.Objects = append(result.Objects, newTaskDefinition(name, options.Namespace, stage, task, jobConfig, result))
+				converted[name] = true
+			}
+			if checkType == common.PreDeploymentCheckType {
+				app.Spec.PreDeploymentTasks = append(app.Spec.PreDeploymentTasks, name)
+			} else {
+				app.Spec.PostDeploymentTasks = append(app.Spec.PostDeploymentTasks, name)
+			}
+		}
+	}
+	if !deployed {
+		result.warn("the %q sequence of stage %q has no deployment task: all of its tasks are converted to pre-deployment checks", delivery.Name, stage.Name)
+	}
+	result.warn("KeptnApp %q has no workloads: add the workloads of the project", appName)
+	result.Objects = append(result.Objects, app)
+	return result, nil
+}
+
+// WriteYAML writes the objects as a multi-document YAML stream, leaving out empty fields and the status
+func WriteYAML(w io.Writer, objects []client.Object) error {
+	for i, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		delete(content, "status")
+		pruneEmpty(content)
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func selectStage(shipyard *Shipyard, name string) (*Stage, error) {
+	if len(shipyard.Spec.Stages) == 0 {
+		return nil, fmt.Errorf("shipyard %q has no stages", shipyard.Metadata.Name)
+	}
+	if name == "" {
+		return &shipyard.Spec.Stages[0], nil
+	}
+	for i := range shipyard.Spec.Stages {
+		if shipyard.Spec.Stages[i].Name == name {
+			return &shipyard.Spec.Stages[i], nil
+		}
+	}
+	return nil, fmt.Errorf("shipyard %q has no stage %q", shipyard.Metadata.Name, name)
+}
+
+func newEvaluationDefinition(name string, namespace string) *klcv1alpha1.KeptnEvaluationDefinition {
+	return &klcv1alpha1.KeptnEvaluationDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: klcv1alpha1.GroupVersion.String(), Kind: "KeptnEvaluationDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: klcv1alpha1.KeptnEvaluationDefinitionSpec{
+			Source:     DefaultEvaluationSource,
+			Objectives: []klcv1alpha1.Objective{},
+		},
+	}
+}
+
+// newTaskDefinition converts a task of the shipyard to a KeptnTaskDefinition. Its properties are passed to the
+// function as parameters. The jobs the job executor service ran for the task are containers, which cannot be run by
+// a function, so they are listed in a placeholder function that fails until they have been ported.
+func newTaskDefinition(name string, namespace string, stage *Stage, task Task, jobConfig *JobConfig, result *Result) *klcv1alpha1.KeptnTaskDefinition {
+	code := &strings.Builder{}
+	fmt.Fprintf(code, "// converted from task %q of the %q sequence of stage %q\n", task.Name, deliverySequence, stage.Name)
+	jobs := findJobs(jobConfig, task, result)
+	if len(jobs) == 0 {
+		result.warn("task definition %q is a placeholder that fails: no action of the job config runs task %q", name, task.Name)
+	} else {
+		code.WriteString("// the job executor service ran the following jobs, which have to be ported to this function:\n")
+		for _, job := range jobs {
+			fmt.Fprintf(code, "//   %s: %s\n", job.Name, strings.Join(append(append([]string{job.Image}, job.Cmd...), job.Args...), " "))
+		}
+		result.warn("task definition %q is a placeholder that fails: the container jobs of task %q have to be ported to a function", name, task.Name)
+	}
+	fmt.Fprintf(code, "throw new Error(%q);\n", fmt.Sprintf("task %s has not been ported to a function yet", task.Name))
+
+	definition := &klcv1alpha1.KeptnTaskDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: klcv1alpha1.GroupVersion.String(), Kind: "KeptnTaskDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: klcv1alpha1.KeptnTaskDefinitionSpec{
+			Function: klcv1alpha1.FunctionSpec{
+				Inline: klcv1alpha1.Inline{Code: code.String()},
+			},
+		},
+	}
+	if len(task.Properties) > 0 {
+		parameters := map[string]string{}
+		for key, value := range task.Properties {
+			parameters[key] = fmt.Sprint(value)
+		}
+		definition.Spec.Function.Parameters = klcv1alpha1.TaskParameters{Inline: parameters}
+	}
+	return definition
+}
+
+// findJobs returns the jobs of the actions that the job executor service ran for the triggered event of the task.
+// Actions whose JSONPath filter matches a property of the task are only returned if the property has the expected value.
+func findJobs(jobConfig *JobConfig, task Task, result *Result) []JobTask {
+	if jobConfig == nil {
+		return nil
+	}
+	event := fmt.Sprintf("sh.keptn.event.%s.triggered", task.Name)
+	propertyPrefix := fmt.Sprintf("$.data.%s.", task.Name)
+	var jobs []JobTask
+	for _, action := range jobConfig.Actions {
+		for _, actionEvent := range action.Events {
+			if actionEvent.Name != event {
+				continue
+			}
+			if property := actionEvent.JSONPath.Property; property != "" {
+				if !strings.HasPrefix(property, propertyPrefix) {
+					result.warn("the %s filter of action %q is not converted: the action is assumed to run for task %q", property, action.Name, task.Name)
+				} else if fmt.Sprint(task.Properties[strings.TrimPrefix(property, propertyPrefix)]) != actionEvent.JSONPath.Match {
+					continue
+				}
+			}
+			jobs = append(jobs, action.Tasks...)
+			break
+		}
+	}
+	return jobs
+}
+
+var invalidNameCharacters = regexp.MustCompile("[^a-z0-9-]+")
+
+// toName turns a name of the shipyard into a valid name of a Kubernetes object
+func toName(name string) string {
+	return strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// pruneEmpty removes the empty maps and nil values left by struct fields without omitempty
+func pruneEmpty(content map[string]interface{}) {
+	for key, value := range content {
+		switch value := value.(type) {
+		case nil:
+			delete(content, key)
+		case map[string]interface{}:
+			pruneEmpty(value)
+			if len(value) == 0 {
+				delete(content, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package shipyard
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		dir     string
+		options Options
+	}{
+		{dir: "sockshop", options: Options{Namespace: "sockshop"}},
+		{dir: "sockshop", options: Options{Stage: "production", AppName: "carts", AppVersion: "1.2.0"}},
+		{dir: "podtatohead"},
+	}
+	for _, tt := range tests {
+		name := tt.dir
+		if tt.options.Stage != "" {
+			name += "-" + tt.options.Stage
+		}
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.dir, "shipyard.yaml"))
+			require.Nil(t, err)
+			shipyard, err := ParseShipyard(data)
+			require.Nil(t, err)
+
+			var jobConfig *JobConfig
+			if data, err := os.ReadFile(filepath.Join("testdata", tt.dir, "job-config.yaml")); err == nil {
+				jobConfig, err = ParseJobConfig(data)
+				require.Nil(t, err)
+			}
+
+			result, err := Convert(shipyard, jobConfig, tt.options)
+			require.Nil(t, err)
+			output := &bytes.Buffer{}
+			require.Nil(t, WriteYAML(output, result.Objects))
+			warnings := strings.Join(result.Warnings, "\n") + "\n"
+
+			assertGolden(t, filepath.Join("testdata", name+".golden.yaml"), output.String())
+			assertGolden(t, filepath.Join("testdata", name+".warnings.golden"), warnings)
+		})
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	_, err := ParseShipyard([]byte("kind: Deployment"))
+	require.ErrorContains(t, err, "unexpected kind")
+
+	shipyard := &Shipyard{Metadata: Metadata{Name: "project"}, Spec: ShipyardSpec{Stages: []Stage{{Name: "dev"}}}}
+	_, err = Convert(shipyard, nil, Options{Stage: "production"})
+	require.ErrorContains(t, err, `no stage "production"`)
+	_, err = Convert(shipyard, nil, Options{})
+	require.ErrorContains(t, err, `no "delivery" sequence`)
+}
+
+func assertGolden(t *testing.T, path string, actual string) {
+	if *update {
+		require.Nil(t, os.WriteFile(path, []byte(actual), 0o600))
+	}
+	expected, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, string(expected), actual)
+}
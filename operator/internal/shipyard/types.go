@@ -0,0 +1,68 @@
+package shipyard
+
+// Shipyard is the shipyard.yaml of a Keptn v1 project
+type Shipyard struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Metadata   Metadata     `json:"metadata"`
+	Spec       ShipyardSpec `json:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name"`
+}
+
+type ShipyardSpec struct {
+	Stages []Stage `json:"stages"`
+}
+
+type Stage struct {
+	Name      string     `json:"name"`
+	Sequences []Sequence `json:"sequences"`
+}
+
+type Sequence struct {
+	Name        string    `json:"name"`
+	TriggeredOn []Trigger `json:"triggeredOn,omitempty"`
+	Tasks       []Task    `json:"tasks"`
+}
+
+type Trigger struct {
+	Event string `json:"event"`
+}
+
+type Task struct {
+	Name           string                 `json:"name"`
+	TriggeredAfter string                 `json:"triggeredAfter,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty"`
+}
+
+// JobConfig is the job/config.yaml of the Keptn v1 job executor service
+type JobConfig struct {
+	APIVersion string   `json:"apiVersion"`
+	Actions    []Action `json:"actions"`
+}
+
+type Action struct {
+	Name   string        `json:"name"`
+	Events []ActionEvent `json:"events"`
+	Tasks  []JobTask     `json:"tasks"`
+}
+
+type ActionEvent struct {
+	Name     string   `json:"name"`
+	JSONPath JSONPath `json:"jsonpath,omitempty"`
+}
+
+type JSONPath struct {
+	Property string `json:"property,omitempty"`
+	Match    string `json:"match,omitempty"`
+}
+
+type JobTask struct {
+	Name  string   `json:"name"`
+	Image string   `json:"image"`
+	Cmd   []string `json:"cmd,omitempty"`
+	Args  []string `json:"args,omitempty"`
+	Files []string `json:"files,omitempty"`
+}
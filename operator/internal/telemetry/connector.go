@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// DefaultStartupDeadline is the time the operator waits for the collector at startup before it starts without it
+	DefaultStartupDeadline = 3 * time.Second
+	// DefaultAttemptTimeout is the time a single attempt to connect to the collector may take
+	DefaultAttemptTimeout = 3 * time.Second
+	// DefaultRetryInterval is the time between the first attempts to connect to the collector
+	DefaultRetryInterval = time.Second
+	// DefaultMaxRetryInterval is the time the interval between attempts doubles up to
+	DefaultMaxRetryInterval = time.Minute
+)
+
+// DegradedReason is reported by the readiness check of a Connector that is not connected to the collector
+const DegradedReason = "TelemetryDegraded"
+
+// ConnectFunc returns a tracer provider exporting to the collector, or an error if it cannot be reached
+type ConnectFunc func(ctx context.Context) (TracerProvider, error)
+
+// Connector connects a Provider to the collector without blocking the start of the operator.
+// Until the collector has been reached, the Provider keeps the tracer provider it has been created with, which
+// does not export to the collector. The Connector keeps retrying in the background with an increasing interval and
+// reloads the Provider once it succeeds. It implements manager.Runnable and can be used as readiness check.
+type Connector struct {
+	Provider         *Provider
+	Connect          ConnectFunc
+	Log              logr.Logger
+	AttemptTimeout   time.Duration
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	mu        sync.Mutex
+	connected bool
+	lastErr   error
+}
+
+// NewConnector returns a Connector reloading the given provider with the tracer provider returned by connect
+func NewConnector(provider *Provider, connect ConnectFunc, log logr.Logger) *Connector {
+	return &Connector{
+		Provider:         provider,
+		Connect:          connect,
+		Log:              log,
+		AttemptTimeout:   DefaultAttemptTimeout,
+		RetryInterval:    DefaultRetryInterval,
+		MaxRetryInterval: DefaultMaxRetryInterval,
+		lastErr:          errors.New("not connected yet"),
+	}
+}
+
+// WaitConnected tries to connect until it succeeds or the given context is done, and reports whether it has succeeded
+func (c *Connector) WaitConnected(ctx context.Context) bool {
+	c.run(ctx)
+	return c.IsConnected()
+}
+
+// Start keeps trying to connect until it succeeds or the given context is cancelled
+func (c *Connector) Start(ctx context.Context) error {
+	c.run(ctx)
+	return nil
+}
+
+// NeedLeaderElection returns false, since every replica exports its telemetry
+func (c *Connector) NeedLeaderElection() bool {
+	return false
+}
+
+// IsConnected reports whether the Provider exports to the collector
+func (c *Connector) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Check returns an error as long as the Provider does not export to the collector. It implements healthz.Checker.
+func (c *Connector) Check(_ *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connected {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", DegradedReason, c.lastErr)
+}
+
+func (c *Connector) run(ctx context.Context) {
+	interval := c.RetryInterval
+	for attempt := 1; !c.IsConnected(); attempt++ {
+		err := c.attempt(ctx)
+		if err == nil {
+			c.Log.Info("connected to the collector", "attempts", attempt)
+			return
+		}
+		if attempt == 1 {
+			c.Log.Info("could not connect to the collector, continuing without exporting traces until it can be reached", "error", err.Error())
+		} else {
+			c.Log.V(1).Info("could not connect to the collector", "attempts", attempt, "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > c.MaxRetryInterval {
+			interval = c.MaxRetryInterval
+		}
+	}
+}
+
+func (c *Connector) attempt(ctx context.Context) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, c.AttemptTimeout)
+	defer cancel()
+	tracerProvider, err := c.Connect(attemptCtx)
+
+	c.mu.Lock()
+	if c.connected {
+		// connected concurrently by WaitConnected or Start
+		c.mu.Unlock()
+		if err == nil {
+			_ = tracerProvider.Shutdown(ctx)
+		}
+		return nil
+	}
+	if err != nil {
+		c.lastErr = err
+		c.mu.Unlock()
+		return err
+	}
+	c.connected = true
+	c.lastErr = nil
+	c.mu.Unlock()
+
+	// spans that are in flight are exported by the previous tracer provider, which does not export to the collector
+	reloadCtx, cancelReload := context.WithTimeout(ctx, c.Provider.ShutdownTimeout)
+	defer cancelReload()
+	if err := c.Provider.Reload(reloadCtx, tracerProvider); err != nil {
+		c.Log.Error(err, "could not shut down the previous tracer provider")
+	}
+	return nil
+}
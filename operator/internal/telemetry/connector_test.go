@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// reserveAddress returns a local address nothing is listening on
+func reserveAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	address := listener.Addr().String()
+	require.Nil(t, listener.Close())
+	return address
+}
+
+func TestConnector_RecoversWhenCollectorAppears(t *testing.T) {
+	address := reserveAddress(t)
+	startupTracerProvider, startupExporter := newBatchingTracerProvider()
+	provider := NewProvider(startupTracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+
+	collectorTracerProvider, collectorExporter := newBatchingTracerProvider()
+	connect := func(ctx context.Context) (TracerProvider, error) {
+		conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		if err != nil {
+			return nil, err
+		}
+		_ = conn.Close()
+		return collectorTracerProvider, nil
+	}
+	connector := NewConnector(provider, connect, logr.Discard())
+	connector.RetryInterval = 10 * time.Millisecond
+	connector.MaxRetryInterval = 50 * time.Millisecond
+
+	// the operator starts without the collector once the startup deadline has passed
+	startTime := time.Now()
+	startupCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.False(t, connector.WaitConnected(startupCtx))
+	require.Less(t, time.Since(startTime), time.Second)
+	require.ErrorContains(t, connector.Check(nil), DegradedReason)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "degraded")
+	span.End()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	done := make(chan error)
+	go func() {
+		done <- connector.Start(ctx)
+	}()
+
+	// a collector appears
+	listener, err := net.Listen("tcp", address)
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	require.Nil(t, <-done)
+	require.True(t, connector.IsConnected())
+	require.Nil(t, connector.Check(nil))
+
+	_, span = provider.Tracer("test").Start(context.Background(), "recovered")
+	span.End()
+	require.Nil(t, provider.Shutdown(context.Background()))
+
+	require.Len(t, startupExporter.GetSpans(), 1)
+	require.Equal(t, "degraded", startupExporter.GetSpans()[0].Name)
+	require.Len(t, collectorExporter.GetSpans(), 1)
+	require.Equal(t, "recovered", collectorExporter.GetSpans()[0].Name)
+}
+
+func TestConnector_ConnectsAtStartup(t *testing.T) {
+	startupTracerProvider, _ := newBatchingTracerProvider()
+	provider := NewProvider(startupTracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+	collectorTracerProvider, collectorExporter := newBatchingTracerProvider()
+	attempts := 0
+	connector := NewConnector(provider, func(ctx context.Context) (TracerProvider, error) {
+		attempts++
+		return collectorTracerProvider, nil
+	}, logr.Discard())
+
+	require.True(t, connector.WaitConnected(context.Background()))
+	require.Nil(t, connector.Check(nil))
+
+	// starting the connector once it is connected does not connect again
+	require.Nil(t, connector.Start(context.Background()))
+	require.Equal(t, 1, attempts)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "connected")
+	span.End()
+	require.Nil(t, provider.Shutdown(context.Background()))
+	require.Len(t, collectorExporter.GetSpans(), 1)
+}
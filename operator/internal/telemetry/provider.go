@@ -0,0 +1,231 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultShutdownTimeout is the time the provider waits for buffered spans and metrics to be flushed
+// when the manager stops. It has to be lower than the graceful shutdown timeout of the manager.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// TracerProvider is a trace.TracerProvider that buffers spans and has to be flushed and shut down,
+// like the TracerProvider of the OTel SDK
+type TracerProvider interface {
+	trace.TracerProvider
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// MeterProvider is a metric.MeterProvider that has to be flushed and shut down, like the MeterProvider of the OTel SDK
+type MeterProvider interface {
+	metric.MeterProvider
+	ForceFlush(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// Provider is the single source of tracers and meters of the operator.
+// The tracer provider can be replaced at runtime with Reload: tracers obtained before a reload start their
+// spans with the new provider, while the spans that are still in flight are ended and exported by the old one.
+// Start flushes and shuts down all providers when the manager stops. It implements manager.Runnable.
+type Provider struct {
+	Log             logr.Logger
+	ShutdownTimeout time.Duration
+
+	meterProvider metric.MeterProvider
+
+	mu      sync.RWMutex
+	current *generation
+}
+
+// NewProvider returns a Provider using the given tracer and meter provider
+func NewProvider(tracerProvider TracerProvider, meterProvider MeterProvider, log logr.Logger) *Provider {
+	return &Provider{
+		Log:             log,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		meterProvider:   meterProvider,
+		current:         newGeneration(tracerProvider),
+	}
+}
+
+// NewNoopProvider returns a Provider whose tracers and meters do not record anything
+func NewNoopProvider(log logr.Logger) *Provider {
+	return &Provider{
+		Log:             log,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		meterProvider:   metric.NewNoopMeterProvider(),
+		current:         newGeneration(noopTracerProvider{TracerProvider: trace.NewNoopTracerProvider()}),
+	}
+}
+
+// Tracer returns a tracer that always starts its spans with the current tracer provider
+func (p *Provider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &tracer{provider: p, name: name, opts: opts}
+}
+
+// Meter returns a meter of the shared meter provider
+func (p *Provider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.meterProvider.Meter(name, opts...)
+}
+
+// Reload replaces the tracer provider. The previous provider is shut down as soon as all spans started with it
+// have ended, or when the given context is done, whichever happens first. Shutting it down flushes its spans.
+func (p *Provider) Reload(ctx context.Context, tracerProvider TracerProvider) error {
+	p.mu.Lock()
+	old := p.current
+	p.current = newGeneration(tracerProvider)
+	p.mu.Unlock()
+
+	old.retire()
+	select {
+	case <-old.idle:
+	case <-ctx.Done():
+		p.Log.Info("shutting down tracer provider with spans in flight", "spans", old.inFlight())
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), p.ShutdownTimeout)
+	defer cancel()
+	return old.provider.Shutdown(shutdownCtx)
+}
+
+// Start waits until the given context is cancelled and flushes and shuts down the tracer and meter providers
+func (p *Provider) Start(ctx context.Context) error {
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), p.ShutdownTimeout)
+	defer cancel()
+	if err := p.Shutdown(shutdownCtx); err != nil {
+		p.Log.Error(err, "could not flush telemetry")
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false, since every replica records telemetry
+func (p *Provider) NeedLeaderElection() bool {
+	return false
+}
+
+// Shutdown flushes and shuts down the tracer and meter providers
+func (p *Provider) Shutdown(ctx context.Context) error {
+	p.mu.RLock()
+	current := p.current
+	p.mu.RUnlock()
+
+	// all providers are shut down even if flushing one of them fails, the first error is returned
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	record(current.provider.ForceFlush(ctx))
+	record(current.provider.Shutdown(ctx))
+	if meterProvider, ok := p.meterProvider.(MeterProvider); ok {
+		record(meterProvider.ForceFlush(ctx))
+		record(meterProvider.Shutdown(ctx))
+	}
+	return firstErr
+}
+
+// acquire returns the current generation with one more span in flight
+func (p *Provider) acquire() *generation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	p.current.acquire()
+	return p.current
+}
+
+// generation is a tracer provider together with the number of its spans that have not ended yet
+type generation struct {
+	provider TracerProvider
+
+	mu      sync.Mutex
+	active  int
+	retired bool
+	idle    chan struct{}
+}
+
+func newGeneration(provider TracerProvider) *generation {
+	return &generation{provider: provider, idle: make(chan struct{})}
+}
+
+func (g *generation) acquire() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active++
+}
+
+func (g *generation) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	g.closeIfIdle()
+}
+
+func (g *generation) retire() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.retired = true
+	g.closeIfIdle()
+}
+
+func (g *generation) inFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.active
+}
+
+func (g *generation) closeIfIdle() {
+	if g.retired && g.active == 0 {
+		select {
+		case <-g.idle:
+		default:
+			close(g.idle)
+		}
+	}
+}
+
+type tracer struct {
+	provider *Provider
+	name     string
+	opts     []trace.TracerOption
+}
+
+func (t *tracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	g := t.provider.acquire()
+	ctx, s := g.provider.Tracer(t.name, t.opts...).Start(ctx, spanName, opts...)
+	if !s.IsRecording() {
+		g.release()
+		return ctx, s
+	}
+	wrapped := &span{Span: s, generation: g}
+	return trace.ContextWithSpan(ctx, wrapped), wrapped
+}
+
+// span releases its generation once it has ended
+type span struct {
+	trace.Span
+	generation *generation
+	once       sync.Once
+}
+
+func (s *span) End(options ...trace.SpanEndOption) {
+	s.Span.End(options...)
+	s.once.Do(s.generation.release)
+}
+
+type noopTracerProvider struct {
+	trace.TracerProvider
+}
+
+func (noopTracerProvider) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (noopTracerProvider) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeTracerProvider struct {
+	trace.TracerProvider
+	flushed  int
+	shutdown int
+}
+
+func (f *fakeTracerProvider) ForceFlush(context.Context) error {
+	f.flushed++
+	return nil
+}
+
+func (f *fakeTracerProvider) Shutdown(context.Context) error {
+	f.shutdown++
+	return nil
+}
+
+// keepingExporter keeps the exported spans after it has been shut down
+type keepingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keepingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func newBatchingTracerProvider() (*sdktrace.TracerProvider, keepingExporter) {
+	exporter := keepingExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Hour))), exporter
+}
+
+func TestProvider_FlushesOnStop(t *testing.T) {
+	tracerProvider := &fakeTracerProvider{TracerProvider: trace.NewNoopTracerProvider()}
+	provider := NewProvider(tracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- provider.Start(ctx)
+	}()
+	cancel()
+
+	require.Nil(t, <-done)
+	require.Equal(t, 1, tracerProvider.flushed)
+	require.Equal(t, 1, tracerProvider.shutdown)
+}
+
+func TestProvider_ExportsBufferedSpansOnStop(t *testing.T) {
+	tracerProvider, exporter := newBatchingTracerProvider()
+	provider := NewProvider(tracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "buffered")
+	span.End()
+	require.Empty(t, exporter.GetSpans())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Nil(t, provider.Start(ctx))
+
+	require.Len(t, exporter.GetSpans(), 1)
+	require.Equal(t, "buffered", exporter.GetSpans()[0].Name)
+}
+
+func TestProvider_ReloadKeepsSpansInFlight(t *testing.T) {
+	oldTracerProvider, oldExporter := newBatchingTracerProvider()
+	newTracerProvider, newExporter := newBatchingTracerProvider()
+	provider := NewProvider(oldTracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+	tracer := provider.Tracer("test")
+
+	_, inFlight := tracer.Start(context.Background(), "in-flight")
+
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- provider.Reload(context.Background(), newTracerProvider)
+	}()
+
+	// the old provider must not be shut down while the span is still in flight
+	select {
+	case <-reloaded:
+		t.Fatal("reload returned before the span in flight has ended")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// tracers obtained before the reload use the new provider
+	_, next := tracer.Start(context.Background(), "next")
+	next.End()
+
+	inFlight.End()
+	require.Nil(t, <-reloaded)
+
+	require.Len(t, oldExporter.GetSpans(), 1)
+	require.Equal(t, "in-flight", oldExporter.GetSpans()[0].Name)
+
+	require.Nil(t, provider.Shutdown(context.Background()))
+	require.Len(t, newExporter.GetSpans(), 1)
+	require.Equal(t, "next", newExporter.GetSpans()[0].Name)
+}
+
+func TestProvider_ReloadGivesUpAfterContextIsDone(t *testing.T) {
+	oldTracerProvider, oldExporter := newBatchingTracerProvider()
+	newTracerProvider, _ := newBatchingTracerProvider()
+	provider := NewProvider(oldTracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+
+	_, _ = provider.Tracer("test").Start(context.Background(), "never-ending")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Nil(t, provider.Reload(ctx, newTracerProvider))
+	require.Empty(t, oldExporter.GetSpans())
+}
+
+func TestProvider_SpanEndedTwiceIsReleasedOnce(t *testing.T) {
+	tracerProvider, _ := newBatchingTracerProvider()
+	provider := NewProvider(tracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+	tracer := provider.Tracer("test")
+
+	_, first := tracer.Start(context.Background(), "first")
+	_, second := tracer.Start(context.Background(), "second")
+	first.End()
+	first.End()
+
+	require.Equal(t, 1, provider.current.inFlight())
+	second.End()
+	require.Equal(t, 0, provider.current.inFlight())
+}
+
+func TestProvider_ContextCarriesWrappedSpan(t *testing.T) {
+	tracerProvider, _ := newBatchingTracerProvider()
+	provider := NewProvider(tracerProvider, sdkmetric.NewMeterProvider(), logr.Discard())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "parent")
+	require.Equal(t, span, trace.SpanFromContext(ctx))
+
+	trace.SpanFromContext(ctx).End()
+	require.Equal(t, 0, provider.current.inFlight())
+}
+
+func TestNoopProvider(t *testing.T) {
+	provider := NewNoopProvider(logr.Discard())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "noop")
+	require.False(t, span.IsRecording())
+	span.End()
+
+	counter, err := provider.Meter("test").SyncInt64().Counter("test.count")
+	require.Nil(t, err)
+	counter.Add(context.Background(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Nil(t, provider.Start(ctx))
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -39,8 +40,12 @@ import (
 
 	"github.com/keptn/lifecycle-toolkit/operator/controllers/keptnapp"
 	"github.com/keptn/lifecycle-toolkit/operator/controllers/keptnevaluation"
+	"github.com/keptn/lifecycle-toolkit/operator/controllers/keptnlifecycleoverview"
 	"github.com/keptn/lifecycle-toolkit/operator/controllers/keptntask"
 	"github.com/keptn/lifecycle-toolkit/operator/controllers/keptntaskdefinition"
+	"github.com/keptn/lifecycle-toolkit/operator/controllers/scaleupguard"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/telemetry"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
@@ -67,10 +72,12 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	lifecyclev1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 
@@ -94,6 +101,17 @@ func init() {
 
 type envConfig struct {
 	OTelCollectorURL string `envconfig:"OTEL_COLLECTOR_URL" default:""`
+	// LifecycleExportURL is an HTTP endpoint receiving a newline delimited JSON record for each completed workload instance and app version
+	LifecycleExportURL string `envconfig:"LIFECYCLE_EXPORT_URL" default:""`
+	// LifecycleExportSQLDSN is a PostgreSQL database receiving a row for each completed workload instance and app version
+	LifecycleExportSQLDSN string `envconfig:"LIFECYCLE_EXPORT_SQL_DSN" default:""`
+	// LifecycleExportSQLDriver is the name of the database/sql driver used for LifecycleExportSQLDSN. The driver is not
+	// part of the operator and has to be linked into the binary.
+	LifecycleExportSQLDriver string `envconfig:"LIFECYCLE_EXPORT_SQL_DRIVER" default:"pgx"`
+	// CloudEventsSinkURL is an HTTP endpoint receiving a CloudEvent for each completed workload instance and app version
+	CloudEventsSinkURL string `envconfig:"CLOUDEVENTS_SINK_URL" default:""`
+	// OTelDisabled replaces all tracers and meters with no-op implementations
+	OTelDisabled bool `envconfig:"OTEL_SDK_DISABLED" default:"false"`
 }
 
 func main() {
@@ -104,21 +122,44 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var disableWebhook bool
+	var webhookPort int
+	var webhookCertDir string
 	var probeAddr string
+	var stuckThreshold time.Duration
+	var stuckSweepInterval time.Duration
+	var creationQPS float64
+	var creationBurst int
+	var overviewInterval time.Duration
+	var releasePolicyURL string
+	var releasePolicyTimeout time.Duration
+	var releasePolicyFailurePolicy string
+	var liveReads bool
+	var lifecycleDeadline time.Duration
+	var lifecycleDeadlineGatePolicy string
+	var loadSheddingQueueDepth int
+	var maxActiveVersions int
+	var enforcementPercentage int
+	var providerFailureThreshold int
+	var providerOpenDuration time.Duration
+	var strictReferences bool
+	var taskDefinitionProviderNamespaces string
+	var asyncWorkloadCreation bool
+	var taskInfrastructureRetries int
+	var taskQueue keptntask.QueueConfig
+	var preventTaskEviction bool
+	var workloadInstanceRequeueInterval time.Duration
+	var workloadInstanceRequeueMaxInterval time.Duration
+	var telemetryStartupDeadline time.Duration
+	var cloudEventsQueueSize int
+	var cloudEventsMaxAttempts int
+	featureGates := featuregate.New()
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 
 	// OTEL SETUP
-	// The exporter embeds a default OpenTelemetry Reader and
-	// implements prometheus.Collector, allowing it to be used as
-	// both a Reader and Collector.
-
-	exporter, err := otelprom.New()
-	if err != nil {
-		setupLog.Error(err, "unable to start OTel")
-	}
-	provider := metric.NewMeterProvider(metric.WithReader(exporter))
-	meter := provider.Meter("keptn/task")
+	// All tracers and meters are obtained from the telemetry provider, which flushes them when the manager stops.
+	telemetryProvider, telemetryConnector := newTelemetryProvider(env)
+	meter := telemetryProvider.Meter("keptn/task")
 	deploymentCount, err := meter.SyncInt64().Counter("keptn.deployment.count", instrument.WithDescription("a simple counter for Keptn Deployments"))
 	if err != nil {
 		setupLog.Error(err, "unable to start OTel")
@@ -139,6 +180,22 @@ func main() {
 	if err != nil {
 		setupLog.Error(err, "unable to start OTel")
 	}
+	taskSchedulingDuration, err := meter.SyncFloat64().Histogram("keptn.task.scheduling.duration", instrument.WithDescription("a histogram of the time the Jobs of Keptn Tasks waited for their container to start, including pod scheduling and image pulls"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	taskExecutionDuration, err := meter.SyncFloat64().Histogram("keptn.task.execution.duration", instrument.WithDescription("a histogram of the time the containers of Keptn Tasks have been running"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	taskRestarts, err := meter.SyncInt64().Counter("keptn.task.restarts", instrument.WithDescription("a simple counter of restarted attempts of the Jobs of Keptn Tasks"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	taskInterruptions, err := meter.SyncInt64().Counter("keptn.task.interruptions", instrument.WithDescription("a simple counter of Jobs of Keptn Tasks that have failed since their pod has been removed by the infrastructure"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
 	taskActiveGauge, err := meter.AsyncInt64().Gauge("keptn.task.active", instrument.WithDescription("a simple counter of active Keptn Tasks"))
 	if err != nil {
 		setupLog.Error(err, "unable to start OTel")
@@ -187,15 +244,120 @@ func main() {
 		setupLog.Error(err, "unable to start OTel")
 	}
 
+	gateWaitDuration, err := meter.SyncFloat64().Histogram("keptn.gate.wait", instrument.WithDescription("a histogram of the time pods of Keptn Deployments have been waiting for their pre-deployment checks"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	preDeploymentDuration, err := meter.SyncFloat64().Histogram("keptn.deployment.predeployment.duration", instrument.WithDescription("a histogram of the time the pre-deployment checks of Keptn Deployments have taken"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	preDeploymentChecks, err := meter.SyncInt64().Counter("keptn.predeployment.checks", instrument.WithDescription("a counter of the pre-deployment tasks and evaluations of Keptn Deployments by their result"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	preDeploymentCheckDuration, err := meter.SyncFloat64().Histogram("keptn.predeployment.check.duration", instrument.WithDescription("a histogram of the duration of the pre-deployment tasks and evaluations of Keptn Deployments"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	blockedDeploymentsGauge, err := meter.AsyncInt64().Gauge("keptn.deployment.blocked", instrument.WithDescription("a gauge of the Keptn Deployments whose pods are held back until their pre-deployment checks have finished"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	enforcementGauge, err := meter.AsyncInt64().Gauge("keptn.deployment.enforcement", instrument.WithDescription("a gauge of the Keptn Deployments in flight by whether their pods are held back by their pre-deployment checks or their checks run in audit mode"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+	stuckInstancesGauge, err := meter.AsyncInt64().Gauge("keptn.instances.stuck", instrument.WithDescription("a gauge of the workload instances that remain in their current phase for longer than the stuck threshold"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	providerBreakerGauge, err := meter.AsyncInt64().Gauge("keptn.evaluation.provider.breaker", instrument.WithDescription("a gauge of the circuit breaker state of each evaluation provider, 0 if closed, 1 if half-open and 2 if open"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	capabilityGauge, err := meter.AsyncInt64().Gauge("keptn.capability.available", instrument.WithDescription("a gauge of the optional APIs the integrations of the operator depend on, 1 if the API is served by the cluster"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	lifecycleExportDropped, err := meter.SyncInt64().Counter("keptn.export.dropped", instrument.WithDescription("a simple counter of lifecycle records that could not be exported"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	cloudEventsDropped, err := meter.SyncInt64().Counter("keptn.cloudevents.dropped", instrument.WithDescription("a simple counter of CloudEvents that have been dropped since the queue was full, they could not be delivered or the operator has shut down"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	cloudEventsQueueGauge, err := meter.AsyncInt64().Gauge("keptn.cloudevents.queue.depth", instrument.WithDescription("a gauge of the CloudEvents waiting to be sent"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	featureGateGauge, err := meter.AsyncInt64().Gauge("keptn.featuregate.enabled", instrument.WithDescription("a gauge of the feature gates of the operator, 1 if the feature is enabled"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	featureGateUsage, err := meter.AsyncInt64().Counter("keptn.featuregate.usage", instrument.WithDescription("a counter of the times an enabled feature has been applied"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	deferredStarts, err := meter.SyncInt64().Counter("keptn.deployment.deferred", instrument.WithDescription("a simple counter of workload instances whose start has been deferred since the work queue of the operator is backed up"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	gateBypasses, err := meter.SyncInt64().Counter("keptn.gate.bypass", instrument.WithDescription("a simple counter of workload instances whose pods have been released before their pre-deployment checks have succeeded, by reason"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	parkedVersions, err := meter.SyncInt64().Counter("keptn.deployment.parked", instrument.WithDescription("a simple counter of workload instances that are held back since too many versions of their workload are active"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	objectCreationCount, err := meter.SyncInt64().Counter("keptn.object.creation.count", instrument.WithDescription("a simple counter of the objects created by the operator, by kind and result"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	objectCreationDuration, err := meter.SyncFloat64().Histogram("keptn.object.creation.duration", instrument.WithDescription("a histogram of the latency of the creation of objects by the operator"), instrument.WithUnit(unit.Unit("s")))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
+	creationThrottled, err := meter.SyncInt64().Counter("keptn.creation.throttled", instrument.WithDescription("a simple counter of KeptnTask, KeptnEvaluation and Job creations that have been throttled"))
+	if err != nil {
+		setupLog.Error(err, "unable to start OTel")
+	}
+
 	meters := common.KeptnMeters{
-		TaskCount:          taskCount,
-		TaskDuration:       taskDuration,
-		DeploymentCount:    deploymentCount,
-		DeploymentDuration: deploymentDuration,
-		AppCount:           appCount,
-		AppDuration:        appDuration,
-		EvaluationCount:    evaluationCount,
-		EvaluationDuration: evaluationDuration,
+		TaskCount:                  taskCount,
+		TaskDuration:               taskDuration,
+		TaskSchedulingDuration:     taskSchedulingDuration,
+		TaskExecutionDuration:      taskExecutionDuration,
+		TaskRestarts:               taskRestarts,
+		TaskInterruptions:          taskInterruptions,
+		DeploymentCount:            deploymentCount,
+		DeploymentDuration:         deploymentDuration,
+		AppCount:                   appCount,
+		AppDuration:                appDuration,
+		EvaluationCount:            evaluationCount,
+		EvaluationDuration:         evaluationDuration,
+		GateWaitDuration:           gateWaitDuration,
+		GateBypasses:               gateBypasses,
+		PreDeploymentDuration:      preDeploymentDuration,
+		PreDeploymentChecks:        preDeploymentChecks,
+		PreDeploymentCheckDuration: preDeploymentCheckDuration,
+		DeferredStarts:             deferredStarts,
+		ParkedVersions:             parkedVersions,
 	}
 
 	// Start the prometheus HTTP server and pass the exporter Collector to it
@@ -203,9 +365,41 @@ func main() {
 
 	// As recommended by the kubebuilder docs, webhook registration should be disabled if running locally. See https://book.kubebuilder.io/cronjob-tutorial/running.html#running-webhooks-locally for reference
 	flag.BoolVar(&disableWebhook, "disable-webhook", false, "Disable the registration of webhooks.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server listens on.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "The directory holding the serving certificate (tls.crt) and key (tls.key) of the webhook server, usually mounted from the Secret issued by cert-manager.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&stuckThreshold, "stuck-threshold", keptnworkloadinstance.DefaultStuckThreshold, "The time a workload instance may remain in a phase before it is reported as stuck.")
+	flag.DurationVar(&stuckSweepInterval, "stuck-sweep-interval", keptnworkloadinstance.DefaultStuckSweepInterval, "The interval in which workload instances are checked for being stuck.")
+	flag.Float64Var(&creationQPS, "creation-qps", controllercommon.DefaultCreationQPS, "The number of KeptnTasks, KeptnEvaluations, Jobs and Events that may be created per second. A value of 0 disables the limit.")
+	flag.IntVar(&creationBurst, "creation-burst", controllercommon.DefaultCreationBurst, "The number of KeptnTasks, KeptnEvaluations, Jobs and Events that may be created at once before creation-qps applies.")
+	flag.DurationVar(&overviewInterval, "overview-interval", keptnlifecycleoverview.DefaultSummaryInterval, "The interval in which the KeptnLifecycleOverview is recomputed.")
+	flag.StringVar(&releasePolicyURL, "release-policy-url", "", "The URL of an HTTP endpoint, e.g. an OPA server, that has to allow releasing the pods of a workload after its pre-deployment checks have succeeded. If empty, no release policy is evaluated.")
+	flag.DurationVar(&releasePolicyTimeout, "release-policy-timeout", controllercommon.DefaultReleasePolicyTimeout, "The timeout for requests to the release policy endpoint.")
+	flag.StringVar(&releasePolicyFailurePolicy, "release-policy-failure-policy", controllercommon.ReleasePolicyFailureDeny, "Whether pods are released (allow) or remain gated (deny) if the release policy endpoint cannot be reached.")
+	flag.BoolVar(&liveReads, "live-reads", false, "Read objects from the API server instead of the informer cache of the operator. This increases the load on the API server, but reconciliations never act on a cache that lags behind.")
+	flag.DurationVar(&lifecycleDeadline, "lifecycle-deadline", 0, "The maximum time the lifecycle of a workload instance may take if neither its workload nor its app define a lifecycle deadline. A value of 0 disables the limit.")
+	flag.StringVar(&lifecycleDeadlineGatePolicy, "lifecycle-deadline-gate-policy", keptnworkloadinstance.LifecycleDeadlineGatePolicyKeep, "Whether the pods of a workload instance whose lifecycle deadline is exceeded before its pre-deployment checks have succeeded are rejected (keep) or released (release).")
+	flag.IntVar(&providerFailureThreshold, "provider-failure-threshold", controllercommon.DefaultBreakerFailureThreshold, "The number of consecutive failed queries after which an evaluation provider is not queried for provider-open-duration. A value of 0 disables the circuit breaker.")
+	flag.DurationVar(&providerOpenDuration, "provider-open-duration", controllercommon.DefaultBreakerOpenDuration, "The time an evaluation provider is not queried after consecutive failures, before a single probe query is sent.")
+	flag.BoolVar(&strictReferences, "strict-references", false, "Deprecated: use --feature-gates=StrictReferences=true instead.")
+	flag.StringVar(&taskDefinitionProviderNamespaces, "task-definition-provider-namespaces", "", "A comma separated list of namespaces whose KeptnTaskDefinitions may be referenced from other namespaces as namespace/name. Only applies if the CrossNamespaceReferences feature gate is enabled.")
+	flag.BoolVar(&asyncWorkloadCreation, "async-workload-creation", false, "Create the KeptnApps and KeptnWorkloads of admitted pods after the admission request has been answered, so that admitting a pod does not wait for these API requests.")
+	flag.IntVar(&loadSheddingQueueDepth, "load-shedding-queue-depth", 0, "The number of queued workload instance reconciliations above which workload instances that have not started yet are deferred, so that instances in flight finish first. A value of 0 disables load shedding.")
+	flag.IntVar(&enforcementPercentage, "enforcement-percentage", 100, "The percentage of workloads whose pods are held back until their pre-deployment checks have succeeded. The checks of all other workloads run in audit mode, without holding back their pods. Workloads are chosen by a hash of their namespace and name, so raising the percentage only adds workloads. Instances that have already started keep their decision.")
+	flag.IntVar(&maxActiveVersions, "max-active-versions", keptnworkloadinstance.DefaultMaxActiveVersions, "The number of versions of a workload whose lifecycle may be in flight at the same time. Newer workload instances wait until older ones have completed. A value of 0 disables the limit.")
+	flag.StringVar(&taskQueue.Name, "task-queue-name", "", "The queue, e.g. a LocalQueue of Kueue, the Jobs of KeptnTasks are submitted to if their KeptnTaskDefinition does not set one. Queued Jobs are created suspended and wait to be admitted by the queue. If empty, Jobs run right away.")
+	flag.StringVar(&taskQueue.Label, "task-queue-label", keptntask.DefaultQueueLabel, "The label of queued Jobs that holds the name of their queue.")
+	flag.DurationVar(&taskQueue.Timeout, "task-queue-timeout", keptntask.DefaultQueueTimeout, "The time a queued Job may wait to be admitted if its KeptnTaskDefinition does not set a timeout. If it is exceeded, the KeptnTask fails.")
+	flag.IntVar(&taskInfrastructureRetries, "task-infrastructure-retries", keptntask.DefaultInfrastructureRetryLimit, "The number of times a KeptnTask is retried with a new Job after its pod has been removed by the infrastructure, e.g. by the cluster autoscaler scaling down its node.")
+	flag.BoolVar(&preventTaskEviction, "prevent-task-eviction", false, "Deprecated: use --feature-gates=PreventTaskEviction=true instead.")
+	flag.Var(featureGates, "feature-gates", fmt.Sprintf("A comma separated list of feature=true|false pairs that enable or disable optional features of the operator. Known features are %s.", strings.Join(featureGates.KnownFeatures(), ", ")))
+	flag.DurationVar(&workloadInstanceRequeueInterval, "workloadinstance-requeue-interval", controllercommon.DefaultPhaseRequeueInterval, "The interval a phase of a workload instance that has not finished yet is reconciled again in.")
+	flag.DurationVar(&workloadInstanceRequeueMaxInterval, "workloadinstance-requeue-max-interval", 0, "The maximum interval a phase of a workload instance is reconciled again in. The interval doubles with every reconciliation that finds the instance in the same phase, up to this maximum. A value below workloadinstance-requeue-interval disables the backoff.")
+	flag.DurationVar(&telemetryStartupDeadline, "telemetry-startup-deadline", telemetry.DefaultStartupDeadline, "The time the operator waits for the OTel collector at startup. If it cannot be reached in time, the operator starts without exporting traces and connects to the collector in the background.")
+	flag.IntVar(&cloudEventsQueueSize, "cloudevents-queue-size", controllercommon.DefaultCloudEventQueueSize, "The number of CloudEvents that are queued for the sink. Once the queue is full, the oldest event is dropped for a new one.")
+	flag.IntVar(&cloudEventsMaxAttempts, "cloudevents-max-attempts", controllercommon.DefaultCloudEventMaxAttempts, "The number of times the delivery of a CloudEvent is attempted before it is dropped.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -214,27 +408,31 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	// Enabling OTel
-	tpOptions, err := getOTelTracerProviderOptions(env)
-	if err != nil {
-		setupLog.Error(err, "unable to initialize OTel tracer options")
+	// the deprecated flags enable their feature gates
+	if strictReferences {
+		setupLog.Info("--strict-references is deprecated, use --feature-gates=StrictReferences=true instead")
+		_ = featureGates.SetEnabled(featuregate.StrictReferences, true)
 	}
+	if preventTaskEviction {
+		setupLog.Info("--prevent-task-eviction is deprecated, use --feature-gates=PreventTaskEviction=true instead")
+		_ = featureGates.SetEnabled(featuregate.PreventTaskEviction, true)
+	}
+	setupLog.Info("feature gates", "featureGates", featureGates.String())
 
-	tp := trace.NewTracerProvider(tpOptions...)
-
-	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			setupLog.Error(err, "unable to shutdown  OTel exporter")
-			os.Exit(1)
-		}
-	}()
-	otel.SetTracerProvider(tp)
+	// Enabling OTel
+	if telemetryConnector != nil {
+		startupCtx, cancel := context.WithTimeout(context.Background(), telemetryStartupDeadline)
+		telemetryConnector.WaitConnected(startupCtx)
+		cancel()
+	}
+	otel.SetTracerProvider(telemetryProvider)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
+		Port:                   webhookPort,
+		CertDir:                webhookCertDir,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "6b866dd9.keptn.sh",
@@ -255,24 +453,135 @@ func main() {
 		os.Exit(1)
 	}
 
+	// reconcilers read from the informer cache unless live reads are requested, writes always go to the API server
+	k8sClient := mgr.GetClient()
+	if liveReads {
+		k8sClient = controllercommon.NewLiveReadClient(mgr.GetClient(), mgr.GetAPIReader())
+	}
+	k8sClient = controllercommon.NewInstrumentedClient(k8sClient, objectCreationCount, objectCreationDuration)
+
+	if err = mgr.Add(telemetryProvider); err != nil {
+		setupLog.Error(err, "unable to add telemetry provider")
+		os.Exit(1)
+	}
+	if telemetryConnector != nil && !telemetryConnector.IsConnected() {
+		if err = mgr.Add(telemetryConnector); err != nil {
+			setupLog.Error(err, "unable to add telemetry connector")
+			os.Exit(1)
+		}
+	}
+
 	spanHandler := controllercommon.SpanHandler{}
+	creationLimiter := controllercommon.NewCreationLimiter(creationQPS, creationBurst, creationThrottled)
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	capabilities := controllercommon.NewCapabilities(discoveryClient, ctrl.Log.WithName("Capabilities"))
+	if err := capabilities.Refresh(); err != nil {
+		setupLog.Error(err, "unable to discover capabilities")
+	}
+	if err = mgr.Add(capabilities); err != nil {
+		setupLog.Error(err, "unable to add capabilities")
+		os.Exit(1)
+	}
+
+	releasePolicy, err := controllercommon.NewReleasePolicy(releasePolicyURL, releasePolicyTimeout, releasePolicyFailurePolicy)
+	if err != nil {
+		setupLog.Error(err, "unable to set up release policy")
+		os.Exit(1)
+	}
+
+	enforcementRollout, err := controllercommon.NewEnforcementRollout(enforcementPercentage)
+	if err != nil {
+		setupLog.Error(err, "unable to set up enforcement rollout")
+		os.Exit(1)
+	}
+
+	crossNamespaceReferences := controllercommon.NewCrossNamespaceReferences(featureGates, taskDefinitionProviderNamespaces)
+
+	if lifecycleDeadlineGatePolicy != keptnworkloadinstance.LifecycleDeadlineGatePolicyKeep && lifecycleDeadlineGatePolicy != keptnworkloadinstance.LifecycleDeadlineGatePolicyRelease {
+		setupLog.Error(fmt.Errorf("unknown lifecycle deadline gate policy %s", lifecycleDeadlineGatePolicy), "unable to set up lifecycle deadline")
+		os.Exit(1)
+	}
+
+	var lifecycleSink controllercommon.LifecycleSink
+	switch {
+	case env.LifecycleExportURL != "" && env.LifecycleExportSQLDSN != "":
+		setupLog.Error(fmt.Errorf("LIFECYCLE_EXPORT_URL and LIFECYCLE_EXPORT_SQL_DSN cannot be set both"), "unable to set up lifecycle exporter")
+		os.Exit(1)
+	case env.LifecycleExportURL != "":
+		lifecycleSink = controllercommon.NewHTTPLifecycleSink(env.LifecycleExportURL)
+	case env.LifecycleExportSQLDSN != "":
+		lifecycleSink, err = controllercommon.NewSQLLifecycleSink(env.LifecycleExportSQLDriver, env.LifecycleExportSQLDSN)
+		if err != nil {
+			setupLog.Error(err, "unable to set up lifecycle exporter")
+			os.Exit(1)
+		}
+	}
+
+	var lifecycleExporter *controllercommon.LifecycleExporter
+	if lifecycleSink != nil {
+		lifecycleExporter = controllercommon.NewLifecycleExporter(lifecycleSink, controllercommon.DefaultLifecycleExportQueueSize, lifecycleExportDropped, ctrl.Log.WithName("Lifecycle Exporter"))
+		if err = mgr.Add(lifecycleExporter); err != nil {
+			setupLog.Error(err, "unable to add lifecycle exporter")
+			os.Exit(1)
+		}
+	}
+
+	var cloudEventSender *controllercommon.CloudEventSender
+	if env.CloudEventsSinkURL != "" {
+		cloudEventSender = controllercommon.NewCloudEventSender(env.CloudEventsSinkURL, cloudEventsQueueSize, cloudEventsMaxAttempts, cloudEventsDropped, ctrl.Log.WithName("CloudEvent Sender"))
+		if err = mgr.Add(cloudEventSender); err != nil {
+			setupLog.Error(err, "unable to add CloudEvent sender")
+			os.Exit(1)
+		}
+	}
 
 	if !disableWebhook {
-		mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{
-			Handler: &webhooks.PodMutatingWebhook{
-				Client:   mgr.GetClient(),
-				Tracer:   otel.Tracer("keptn/webhook"),
-				Recorder: mgr.GetEventRecorderFor("keptn/webhook"),
-				Log:      ctrl.Log.WithName("Mutating Webhook"),
-			}})
+		podWebhook := &webhooks.PodMutatingWebhook{
+			Client:                   k8sClient,
+			Tracer:                   telemetryProvider.Tracer("keptn/webhook"),
+			Recorder:                 mgr.GetEventRecorderFor("keptn/webhook"),
+			Log:                      ctrl.Log.WithName("Mutating Webhook"),
+			FeatureGates:             featureGates,
+			CrossNamespaceReferences: crossNamespaceReferences,
+		}
+		if asyncWorkloadCreation {
+			podWebhook.WorkloadCreator = webhooks.NewWorkloadCreator(podWebhook, mgr.GetAPIReader(), webhooks.DefaultWorkloadCreatorQueueSize, ctrl.Log.WithName("Workload Creator"))
+			if err = mgr.Add(podWebhook.WorkloadCreator); err != nil {
+				setupLog.Error(err, "unable to add workload creator")
+				os.Exit(1)
+			}
+		}
+		mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{Handler: podWebhook})
+
+		if err = mgr.GetFieldIndexer().IndexField(context.Background(), &lifecyclev1alpha1.KeptnWorkloadInstance{}, webhooks.ActiveWorkloadInstanceField, webhooks.IndexActiveWorkloadInstances); err != nil {
+			setupLog.Error(err, "unable to index the active workload instances")
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().Register("/validate-apps-v1-deployment", &webhook.Admission{Handler: &webhooks.DeploymentWarningWebhook{
+			Reader: mgr.GetCache(),
+			Log:    ctrl.Log.WithName("Deployment Warning Webhook"),
+		}})
+		mgr.GetWebhookServer().Register("/validate-lifecycle-keptn-sh-v1alpha1-keptntaskdefinition", &webhook.Admission{Handler: &webhooks.TaskDefinitionValidatingWebhook{
+			Log: ctrl.Log.WithName("Task Definition Validating Webhook"),
+		}})
 	}
 	taskReconciler := &keptntask.KeptnTaskReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Log:      ctrl.Log.WithName("KeptnTask Controller"),
-		Recorder: mgr.GetEventRecorderFor("keptntask-controller"),
-		Meters:   meters,
-		Tracer:   otel.Tracer("keptn/operator/task"),
+		Client:                   k8sClient,
+		Scheme:                   mgr.GetScheme(),
+		Log:                      ctrl.Log.WithName("KeptnTask Controller"),
+		Recorder:                 creationLimiter.EventRecorder(mgr.GetEventRecorderFor("keptntask-controller")),
+		Meters:                   meters,
+		Tracer:                   telemetryProvider.Tracer("keptn/operator/task"),
+		CreationLimiter:          creationLimiter,
+		InfrastructureRetryLimit: taskInfrastructureRetries,
+		FeatureGates:             featureGates,
+		Queue:                    taskQueue,
+		CrossNamespaceReferences: crossNamespaceReferences,
 	}
 	if err = (taskReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnTask")
@@ -280,7 +589,7 @@ func main() {
 	}
 
 	taskDefinitionReconciler := &keptntaskdefinition.KeptnTaskDefinitionReconciler{
-		Client:   mgr.GetClient(),
+		Client:   k8sClient,
 		Scheme:   mgr.GetScheme(),
 		Log:      ctrl.Log.WithName("KeptnTaskDefinition Controller"),
 		Recorder: mgr.GetEventRecorderFor("keptntaskdefinition-controller"),
@@ -291,11 +600,11 @@ func main() {
 	}
 
 	appReconciler := &keptnapp.KeptnAppReconciler{
-		Client:   mgr.GetClient(),
+		Client:   k8sClient,
 		Scheme:   mgr.GetScheme(),
 		Log:      ctrl.Log.WithName("KeptnApp Controller"),
 		Recorder: mgr.GetEventRecorderFor("keptnapp-controller"),
-		Tracer:   otel.Tracer("keptn/operator/app"),
+		Tracer:   telemetryProvider.Tracer("keptn/operator/app"),
 	}
 	if err = (appReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnApp")
@@ -303,39 +612,101 @@ func main() {
 	}
 
 	workloadReconciler := &keptnworkload.KeptnWorkloadReconciler{
-		Client:   mgr.GetClient(),
+		Client:   k8sClient,
 		Scheme:   mgr.GetScheme(),
 		Log:      ctrl.Log.WithName("KeptnWorkload Controller"),
 		Recorder: mgr.GetEventRecorderFor("keptnworkload-controller"),
-		Tracer:   otel.Tracer("keptn/operator/workload"),
+		Tracer:   telemetryProvider.Tracer("keptn/operator/workload"),
 	}
 	if err = (workloadReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnWorkload")
 		os.Exit(1)
 	}
 
+	// the name of the controller is the lower-case kind of the workload instance
+	workloadInstanceQueueDepth := controllercommon.NewQueueDepth(metrics.Registry, "keptnworkloadinstance", ctrl.Log.WithName("Queue Depth"))
+	if loadSheddingQueueDepth > 0 {
+		if err = mgr.Add(workloadInstanceQueueDepth); err != nil {
+			setupLog.Error(err, "unable to add queue depth sampler")
+			os.Exit(1)
+		}
+	}
+
 	workloadInstanceReconciler := &keptnworkloadinstance.KeptnWorkloadInstanceReconciler{
-		Client:      mgr.GetClient(),
-		Scheme:      mgr.GetScheme(),
-		Log:         ctrl.Log.WithName("KeptnWorkloadInstance Controller"),
-		Recorder:    mgr.GetEventRecorderFor("keptnworkloadinstance-controller"),
-		Meters:      meters,
-		Tracer:      otel.Tracer("keptn/operator/workloadinstance"),
-		SpanHandler: spanHandler,
+		Client:                      k8sClient,
+		Scheme:                      mgr.GetScheme(),
+		Log:                         ctrl.Log.WithName("KeptnWorkloadInstance Controller"),
+		Recorder:                    creationLimiter.EventRecorder(mgr.GetEventRecorderFor("keptnworkloadinstance-controller")),
+		Meters:                      meters,
+		Tracer:                      telemetryProvider.Tracer("keptn/operator/workloadinstance"),
+		SpanHandler:                 spanHandler,
+		Exporter:                    lifecycleExporter,
+		CloudEvents:                 cloudEventSender,
+		CreationLimiter:             creationLimiter,
+		ReleasePolicy:               releasePolicy,
+		EnforcementRollout:          enforcementRollout,
+		Capabilities:                capabilities,
+		LifecycleDeadline:           lifecycleDeadline,
+		LifecycleDeadlineGatePolicy: lifecycleDeadlineGatePolicy,
+		QueueDepth:                  workloadInstanceQueueDepth,
+		LoadSheddingQueueDepth:      loadSheddingQueueDepth,
+		MaxActiveVersions:           maxActiveVersions,
+		RequeueBackoff:              controllercommon.NewRequeueBackoff(workloadInstanceRequeueInterval, workloadInstanceRequeueMaxInterval),
+		FeatureGates:                featureGates,
 	}
 	if err = (workloadInstanceReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnWorkloadInstance")
 		os.Exit(1)
 	}
 
+	scaleUpGuardReconciler := &scaleupguard.ScaleUpGuardReconciler{
+		Client:   k8sClient,
+		Scheme:   mgr.GetScheme(),
+		Log:      ctrl.Log.WithName("ScaleUpGuard Controller"),
+		Recorder: mgr.GetEventRecorderFor("scaleupguard-controller"),
+	}
+	// the scale-up guard watches HorizontalPodAutoscalers, which would never sync if their API is not served
+	if capabilities.Has(controllercommon.CapabilityHorizontalPodAutoscalerV2) {
+		if err = (scaleUpGuardReconciler).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ScaleUpGuard")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("scale-up guard is disabled since its API is not served by the cluster", "capability", controllercommon.CapabilityHorizontalPodAutoscalerV2.String())
+	}
+
+	stuckSweeper := &keptnworkloadinstance.StuckSweeper{
+		Client:    k8sClient,
+		Log:       ctrl.Log.WithName("Stuck Sweeper"),
+		Threshold: stuckThreshold,
+		Interval:  stuckSweepInterval,
+	}
+	if err = mgr.Add(stuckSweeper); err != nil {
+		setupLog.Error(err, "unable to add stuck sweeper")
+		os.Exit(1)
+	}
+
+	overviewSummarizer := &keptnlifecycleoverview.Summarizer{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("Lifecycle Overview Summarizer"),
+		Interval: overviewInterval,
+	}
+	if err = mgr.Add(overviewSummarizer); err != nil {
+		setupLog.Error(err, "unable to add lifecycle overview summarizer")
+		os.Exit(1)
+	}
+
 	appVersionReconciler := &keptnappversion.KeptnAppVersionReconciler{
-		Client:      mgr.GetClient(),
-		Scheme:      mgr.GetScheme(),
-		Log:         ctrl.Log.WithName("KeptnAppVersion Controller"),
-		Recorder:    mgr.GetEventRecorderFor("keptnappversion-controller"),
-		Tracer:      otel.Tracer("keptn/operator/appversion"),
-		Meters:      meters,
-		SpanHandler: spanHandler,
+		Client:          k8sClient,
+		Scheme:          mgr.GetScheme(),
+		Log:             ctrl.Log.WithName("KeptnAppVersion Controller"),
+		Recorder:        creationLimiter.EventRecorder(mgr.GetEventRecorderFor("keptnappversion-controller")),
+		Tracer:          telemetryProvider.Tracer("keptn/operator/appversion"),
+		Meters:          meters,
+		SpanHandler:     spanHandler,
+		Exporter:        lifecycleExporter,
+		CloudEvents:     cloudEventSender,
+		CreationLimiter: creationLimiter,
 	}
 	if err = (appVersionReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnAppVersion")
@@ -343,12 +714,13 @@ func main() {
 	}
 
 	evaluationReconciler := &keptnevaluation.KeptnEvaluationReconciler{
-		Client:   mgr.GetClient(),
+		Client:   k8sClient,
 		Scheme:   mgr.GetScheme(),
 		Log:      ctrl.Log.WithName("KeptnEvaluation Controller"),
 		Recorder: mgr.GetEventRecorderFor("keptnevaluation-controller"),
-		Tracer:   otel.Tracer("keptn/operator/evaluation"),
+		Tracer:   telemetryProvider.Tracer("keptn/operator/evaluation"),
 		Meters:   meters,
+		Breakers: controllercommon.NewCircuitBreakers(providerFailureThreshold, providerOpenDuration),
 	}
 	if err = (evaluationReconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeptnEvaluation")
@@ -366,6 +738,14 @@ func main() {
 			appDeploymentDurationGauge,
 			workloadDeploymentIntervalGauge,
 			workloadDeploymentDurationGauge,
+			stuckInstancesGauge,
+			blockedDeploymentsGauge,
+			enforcementGauge,
+			capabilityGauge,
+			providerBreakerGauge,
+			cloudEventsQueueGauge,
+			featureGateGauge,
+			featureGateUsage,
 		},
 		func(ctx context.Context) {
 			activeDeployments, err := workloadInstanceReconciler.GetActiveDeployments(ctx)
@@ -432,6 +812,50 @@ func main() {
 				workloadDeploymentDurationGauge.Observe(ctx, val.Value, val.Attributes...)
 			}
 
+			blockedDeployments, err := workloadInstanceReconciler.GetBlockedDeployments(ctx)
+			if err != nil {
+				setupLog.Error(err, "unable to gather blocked deployments")
+			}
+			for _, val := range blockedDeployments {
+				blockedDeploymentsGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			enforcementSplit, err := workloadInstanceReconciler.GetEnforcementSplit(ctx)
+			if err != nil {
+				setupLog.Error(err, "unable to gather the enforcement split")
+			}
+			for _, val := range enforcementSplit {
+				enforcementGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			stuckInstances, err := stuckSweeper.GetStuckInstances(ctx)
+			if err != nil {
+				setupLog.Error(err, "unable to gather stuck instances")
+			}
+			for _, val := range stuckInstances {
+				stuckInstancesGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			for _, val := range capabilities.GetCapabilities() {
+				capabilityGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			for _, val := range evaluationReconciler.Breakers.GetStates() {
+				providerBreakerGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			if cloudEventSender != nil {
+				cloudEventsQueueGauge.Observe(ctx, int64(cloudEventSender.QueueLength()))
+			}
+
+			for _, val := range featureGates.GetStates() {
+				featureGateGauge.Observe(ctx, val.Value, val.Attributes...)
+			}
+
+			for _, val := range featureGates.GetUsage() {
+				featureGateUsage.Observe(ctx, val.Value, val.Attributes...)
+			}
+
 		})
 	if err != nil {
 		fmt.Println("Failed to register callback")
@@ -446,6 +870,13 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if telemetryConnector != nil {
+		// the readiness probe excludes this check, it only reports why traces are not exported
+		if err := mgr.AddReadyzCheck("telemetry", telemetryConnector.Check); err != nil {
+			setupLog.Error(err, "unable to set up telemetry check")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
 	setupLog.Info("Keptn lifecycle operator is alive")
@@ -455,25 +886,54 @@ func main() {
 	}
 }
 
-func getOTelTracerProviderOptions(env envConfig) ([]trace.TracerProviderOption, error) {
-	tracerProviderOptions := []trace.TracerProviderOption{}
+// newTelemetryProvider returns the telemetry provider of the operator. If a collector is configured, its tracer
+// provider does not export to the collector until the returned connector has reached it.
+func newTelemetryProvider(env envConfig) (*telemetry.Provider, *telemetry.Connector) {
+	if env.OTelDisabled {
+		return telemetry.NewNoopProvider(ctrl.Log.WithName("Telemetry")), nil
+	}
 
-	stdOutExp, err := newStdOutExporter()
+	// The exporter embeds a default OpenTelemetry Reader and
+	// implements prometheus.Collector, allowing it to be used as
+	// both a Reader and Collector.
+	exporter, err := otelprom.New()
 	if err != nil {
-		return nil, fmt.Errorf("could not create stdout OTel exporter: %w", err)
+		setupLog.Error(err, "unable to start OTel")
 	}
-	tracerProviderOptions = append(tracerProviderOptions, trace.WithBatcher(stdOutExp))
+	meterProvider := metric.NewMeterProvider(metric.WithReader(exporter))
 
-	if env.OTelCollectorURL != "" {
-		// try to set OTel exporter for Jaeger
-		otelExporter, err := newOTelExporter(env)
+	tpOptions, err := getOTelTracerProviderOptions()
+	if err != nil {
+		setupLog.Error(err, "unable to initialize OTel tracer options")
+	}
+	tracerProvider := trace.NewTracerProvider(tpOptions...)
+
+	provider := telemetry.NewProvider(tracerProvider, meterProvider, ctrl.Log.WithName("Telemetry"))
+	if env.OTelCollectorURL == "" {
+		return provider, nil
+	}
+	connect := func(ctx context.Context) (telemetry.TracerProvider, error) {
+		otelExporter, err := newOTelExporter(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		tpOptions, err := getOTelTracerProviderOptions()
 		if err != nil {
-			// log the error, but do not break if Jaeger exporter cannot be created
-			setupLog.Error(err, "Could not set up OTel exporter")
-		} else if otelExporter != nil {
-			tracerProviderOptions = append(tracerProviderOptions, trace.WithBatcher(otelExporter))
+			return nil, err
 		}
+		return trace.NewTracerProvider(append(tpOptions, trace.WithBatcher(otelExporter))...), nil
 	}
+	return provider, telemetry.NewConnector(provider, connect, ctrl.Log.WithName("Telemetry"))
+}
+
+func getOTelTracerProviderOptions() ([]trace.TracerProviderOption, error) {
+	tracerProviderOptions := []trace.TracerProviderOption{}
+
+	stdOutExp, err := newStdOutExporter()
+	if err != nil {
+		return nil, fmt.Errorf("could not create stdout OTel exporter: %w", err)
+	}
+	tracerProviderOptions = append(tracerProviderOptions, trace.WithBatcher(stdOutExp))
 	tracerProviderOptions = append(tracerProviderOptions, trace.WithResource(newResource()))
 
 	return tracerProviderOptions, nil
@@ -488,9 +948,7 @@ func newStdOutExporter() (trace.SpanExporter, error) {
 	)
 }
 
-func newOTelExporter(env envConfig) (trace.SpanExporter, error) {
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+func newOTelExporter(ctx context.Context, env envConfig) (trace.SpanExporter, error) {
 	conn, err := grpc.DialContext(ctx, env.OTelCollectorURL, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gRPC connection to collector at %s: %w", env.OTelCollectorURL, err)
@@ -0,0 +1,132 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-apps-v1-deployment,mutating=false,failurePolicy=ignore,groups=apps,resources=deployments,verbs=update;delete,versions=v1,name=vdeployment.keptn.sh,admissionReviewVersions=v1,sideEffects=None,timeoutSeconds=2
+
+// ActiveWorkloadInstanceField indexes the KeptnWorkloadInstances that have not completed yet by the name of their workload
+const ActiveWorkloadInstanceField = "spec.workloadName.active"
+
+// IndexActiveWorkloadInstances returns the workload name of a KeptnWorkloadInstance that has not completed yet
+func IndexActiveWorkloadInstances(obj client.Object) []string {
+	workloadInstance, ok := obj.(*klcv1alpha1.KeptnWorkloadInstance)
+	if !ok || workloadInstance.IsCompleted() {
+		return nil
+	}
+	return []string{workloadInstance.Spec.WorkloadName}
+}
+
+// DeploymentWarningWebhook warns about the lifecycle activity of a workload that is still in flight when its
+// Deployment is deleted or its images are changed. It never denies a request and only reads from the cache, so that
+// kubectl operations are not slowed down.
+type DeploymentWarningWebhook struct {
+	// Reader must be backed by a cache holding the ActiveWorkloadInstanceField index
+	Reader  client.Reader
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// Handle returns the in-flight KeptnWorkloadInstances of the workload of a Deployment as admission warnings
+func (a *DeploymentWarningWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Delete && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := a.decoder.DecodeRaw(req.OldObject, deployment); err != nil {
+		a.Log.Error(err, "could not decode the deployment")
+		return admission.Allowed("")
+	}
+	consequence := "deleting the Deployment cancels its running checks"
+	if req.Operation == admissionv1.Update {
+		updated := &appsv1.Deployment{}
+		if err := a.decoder.DecodeRaw(req.Object, updated); err != nil {
+			a.Log.Error(err, "could not decode the deployment")
+			return admission.Allowed("")
+		}
+		if reflect.DeepEqual(getImages(deployment.Spec.Template.Spec), getImages(updated.Spec.Template.Spec)) {
+			return admission.Allowed("")
+		}
+		consequence = "changing the images starts a new version while its checks are still running"
+	}
+
+	workloadName, ok := getDeploymentWorkloadName(deployment)
+	if !ok {
+		return admission.Allowed("")
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := a.Reader.Get(ctx, types.NamespacedName{Name: req.Namespace}, namespace); err != nil {
+		a.Log.Error(err, "could not get namespace", "namespace", req.Namespace)
+		return admission.Allowed("")
+	}
+	if namespace.GetAnnotations()[common.NamespaceEnabledAnnotation] != "enabled" {
+		return admission.Allowed("")
+	}
+
+	workloadInstances := &klcv1alpha1.KeptnWorkloadInstanceList{}
+	if err := a.Reader.List(ctx, workloadInstances, client.InNamespace(req.Namespace), client.MatchingFields{ActiveWorkloadInstanceField: workloadName}); err != nil {
+		a.Log.Error(err, "could not list the workload instances", "workload", workloadName)
+		return admission.Allowed("")
+	}
+
+	var warnings []string
+	for _, workloadInstance := range workloadInstances.Items {
+		if workloadInstance.Spec.WorkloadName != workloadName || workloadInstance.IsCompleted() {
+			continue
+		}
+		phase := workloadInstance.Status.CurrentPhase
+		if phase == "" {
+			phase = "Pending"
+		}
+		warnings = append(warnings, fmt.Sprintf("KeptnWorkloadInstance %s of version %s is in phase %s: %s", workloadInstance.Name, workloadInstance.Spec.Version, phase, consequence))
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// DeploymentWarningWebhook implements admission.DecoderInjector.
+// A decoder will be automatically injected.
+
+// InjectDecoder injects the decoder.
+func (a *DeploymentWarningWebhook) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
+
+// getDeploymentWorkloadName returns the name of the KeptnWorkload the pods of a Deployment belong to, if they are
+// annotated with a workload
+func getDeploymentWorkloadName(deployment *appsv1.Deployment) (string, bool) {
+	pod := &corev1.Pod{ObjectMeta: deployment.Spec.Template.ObjectMeta}
+	workloadName, ok := getLabelOrAnnotation(pod, common.WorkloadAnnotation, common.K8sRecommendedWorkloadAnnotations)
+	if !ok {
+		return "", false
+	}
+	applicationName, _ := getLabelOrAnnotation(pod, common.AppAnnotation, common.K8sRecommendedAppAnnotations)
+	return strings.ToLower(applicationName + "-" + workloadName), true
+}
+
+func getImages(podSpec corev1.PodSpec) []string {
+	var images []string
+	for _, container := range podSpec.InitContainers {
+		images = append(images, container.Image)
+	}
+	for _, container := range podSpec.Containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestIndexActiveWorkloadInstances(t *testing.T) {
+	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{Spec: klcv1alpha1.KeptnWorkloadInstanceSpec{WorkloadName: "my-app-my-workload"}}
+	require.Equal(t, []string{"my-app-my-workload"}, IndexActiveWorkloadInstances(workloadInstance))
+
+	workloadInstance.Complete()
+	require.Nil(t, IndexActiveWorkloadInstances(workloadInstance))
+	require.Nil(t, IndexActiveWorkloadInstances(&klcv1alpha1.KeptnWorkload{}))
+}
+
+func TestDeploymentWarningWebhook_Handle(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-workload"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					common.AppAnnotation:      "my-app",
+					common.WorkloadAnnotation: "my-workload",
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.0.0"}}},
+			},
+		},
+	}
+	newImage := deployment.DeepCopy()
+	newImage.Spec.Template.Spec.Containers[0].Image = "nginx:2.0.0"
+	scaled := deployment.DeepCopy()
+	scaled.Spec.Replicas = new(int32)
+	unannotated := deployment.DeepCopy()
+	unannotated.Spec.Template.Annotations = nil
+
+	running := &klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Spec: klcv1alpha1.KeptnWorkloadInstanceSpec{
+			KeptnWorkloadSpec: klcv1alpha1.KeptnWorkloadSpec{Version: "1.0.0"},
+			WorkloadName:      "my-app-my-workload",
+		},
+		Status: klcv1alpha1.KeptnWorkloadInstanceStatus{CurrentPhase: common.PhaseWorkloadPreDeployment.ShortName},
+	}
+	completed := running.DeepCopy()
+	completed.Name = "my-app-my-workload-0.9.0"
+	completed.Complete()
+	otherWorkload := running.DeepCopy()
+	otherWorkload.Name = "my-app-other-workload-1.0.0"
+	otherWorkload.Spec.WorkloadName = "my-app-other-workload"
+
+	tests := []struct {
+		name       string
+		operation  admissionv1.Operation
+		old        *appsv1.Deployment
+		new        *appsv1.Deployment
+		disabled   bool
+		wantWarned bool
+	}{
+		{
+			name:       "delete",
+			operation:  admissionv1.Delete,
+			old:        deployment,
+			wantWarned: true,
+		},
+		{
+			name:       "image change",
+			operation:  admissionv1.Update,
+			old:        deployment,
+			new:        newImage,
+			wantWarned: true,
+		},
+		{
+			name:      "update without image change",
+			operation: admissionv1.Update,
+			old:       deployment,
+			new:       scaled,
+		},
+		{
+			name:      "unannotated deployment",
+			operation: admissionv1.Delete,
+			old:       unannotated,
+		},
+		{
+			name:      "namespace not enabled",
+			operation: admissionv1.Delete,
+			old:       deployment,
+			disabled:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			if !tt.disabled {
+				namespace.Annotations = map[string]string{common.NamespaceEnabledAnnotation: "enabled"}
+			}
+			scheme := runtime.NewScheme()
+			require.Nil(t, clientgoscheme.AddToScheme(scheme))
+			require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+			decoder, err := admission.NewDecoder(scheme)
+			require.Nil(t, err)
+			a := &DeploymentWarningWebhook{
+				Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace, running, completed, otherWorkload).Build(),
+				Log:    logr.Discard(),
+			}
+			require.Nil(t, a.InjectDecoder(decoder))
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Namespace: "default", Operation: tt.operation}}
+			req.OldObject.Raw, err = json.Marshal(tt.old)
+			require.Nil(t, err)
+			if tt.new != nil {
+				req.Object.Raw, err = json.Marshal(tt.new)
+				require.Nil(t, err)
+			}
+
+			resp := a.Handle(context.TODO(), req)
+			require.True(t, resp.Allowed)
+			if !tt.wantWarned {
+				require.Empty(t, resp.Warnings)
+				return
+			}
+			require.Len(t, resp.Warnings, 1)
+			require.Contains(t, resp.Warnings[0], running.Name)
+			require.Contains(t, resp.Warnings[0], common.PhaseWorkloadPreDeployment.ShortName)
+		})
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
 	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/semconv"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
@@ -20,10 +23,15 @@ import (
 
 	"hash/fnv"
 
+	admissionv1 "k8s.io/api/admission/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -32,6 +40,9 @@ import (
 // +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,failurePolicy=fail,groups="",resources=pods,verbs=create;update,versions=v1,name=mpod.keptn.sh,admissionReviewVersions=v1,sideEffects=None
 //+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
+// maxOwnerDepth limits the number of Jobs that are looked up when following the controllers of a pod
+const maxOwnerDepth = 5
+
 // PodMutatingWebhook annotates Pods
 type PodMutatingWebhook struct {
 	Client   client.Client
@@ -39,6 +50,13 @@ type PodMutatingWebhook struct {
 	decoder  *admission.Decoder
 	Recorder record.EventRecorder
 	Log      logr.Logger
+	// FeatureGates enable optional behaviors, e.g. denying pods referencing KeptnTaskDefinitions or
+	// KeptnEvaluationDefinitions that do not exist, instead of discovering them when the checks are run
+	FeatureGates *featuregate.Gates
+	// WorkloadCreator creates the KeptnApp and KeptnWorkload of admitted pods asynchronously if set
+	WorkloadCreator *WorkloadCreator
+	// CrossNamespaceReferences decides whether pods may reference KeptnTaskDefinitions of other namespaces
+	CrossNamespaceReferences *controllercommon.CrossNamespaceReferences
 }
 
 // Handle inspects incoming Pods and injects the Keptn scheduler if they contain the Keptn lifecycle annotations.
@@ -73,42 +91,109 @@ func (a *PodMutatingWebhook) Handle(ctx context.Context, req admission.Request)
 		return admission.Allowed("namespace is not enabled for lifecycle controller")
 	}
 
+	// the lookup of the owners must not block pods of Jobs that cannot be looked up, they are treated as not managed
+	managed, err := a.isManagedByToolkit(ctx, pod, req.Namespace)
+	if err != nil {
+		logger.Error(err, "could not check the owners of the pod")
+	}
+	if managed {
+		logger.Info("pod belongs to a Job of the lifecycle toolkit")
+		return admission.Allowed("pod belongs to a Job of the lifecycle toolkit")
+	}
+
+	// the lookup of the workload must not block pods of namespaces that are enabled, but not annotated
+	workload, err := a.getOwningWorkload(ctx, pod, req.Namespace)
+	if err != nil {
+		logger.Error(err, "could not get the workload of the pod")
+	}
+	if inheritWorkloadAnnotations(pod, workload) {
+		logger.Info("Pod inherits the Keptn annotations of its workload", "workload", workload.GetName())
+	}
+
 	logger.Info(fmt.Sprintf("Pod annotations: %v", pod.Annotations))
 
+	// the version annotation is set on the pod if it has to be derived from its containers
+	versionSource := getVersionSource(pod)
 	isAnnotated, err := a.isKeptnAnnotated(pod)
 	if err != nil {
 		span.SetStatus(codes.Error, "Invalid annotations")
 		return admission.Errored(http.StatusBadRequest, err)
 	}
+	gate := false
 	if isAnnotated {
 		logger.Info("Resource is annotated with Keptn annotations, using Keptn scheduler")
 		pod.Spec.SchedulerName = "keptn-scheduler"
 		logger.Info("Annotations", "annotations", pod.Annotations)
 
+		if err := a.checkCrossNamespaceReferences(pod, req.Namespace); err != nil {
+			span.SetStatus(codes.Error, "Cross-namespace reference")
+			return admission.Denied(err.Error())
+		}
+
+		if a.FeatureGates.Enabled(featuregate.StrictReferences) {
+			missing, err := a.getMissingReferences(ctx, pod, req.Namespace)
+			if err != nil {
+				logger.Error(err, "could not resolve the referenced definitions")
+				return admission.Errored(http.StatusInternalServerError, err)
+			}
+			if len(missing) > 0 {
+				span.SetStatus(codes.Error, "Missing definitions")
+				// pods are created again by their controllers, so definitions applied in the same batch are picked up by the retry
+				return admission.Denied(fmt.Sprintf("referenced definitions do not exist: %s; the pod is admitted once they have been applied", strings.Join(missing, ", ")))
+			}
+		}
+
 		isAppAnnotationPresent, err := a.isAppAnnotationPresent(pod)
 		if err != nil {
 			span.SetStatus(codes.Error, "Invalid annotations")
 			return admission.Errored(http.StatusBadRequest, err)
 		}
-		if !isAppAnnotationPresent {
-			if err := a.handleApp(ctx, logger, pod, req.Namespace); err != nil {
-				logger.Error(err, "Could not handle App")
-				span.SetStatus(codes.Error, err.Error())
-				return admission.Errored(http.StatusBadRequest, err)
-			}
-		}
 		semconv.AddAttributeFromAnnotations(span, pod.Annotations)
 
 		logger.Info("Attributes from annotations set")
 
-		if err := a.handleWorkload(ctx, logger, pod, req.Namespace); err != nil {
-			logger.Error(err, "Could not handle Workload")
-			span.SetStatus(codes.Error, err.Error())
-			return admission.Errored(http.StatusBadRequest, err)
+		request := creationRequest{
+			pod:           pod.DeepCopy(),
+			namespace:     req.Namespace,
+			versionSource: versionSource,
+			generateApp:   !isAppAnnotationPresent,
+			spanContext:   span.SpanContext(),
+		}
+		if a.WorkloadCreator.Enqueue(request) {
+			logger.Info("Workload is created asynchronously")
+		} else {
+			if !isAppAnnotationPresent {
+				if err := a.handleApp(ctx, logger, pod, req.Namespace); err != nil {
+					logger.Error(err, "Could not handle App")
+					span.SetStatus(codes.Error, err.Error())
+					return a.admitUnchecked(pod, workload, "KeptnApp", err)
+				}
+			}
+
+			if err := a.handleWorkload(ctx, logger, pod, req.Namespace, versionSource); err != nil {
+				logger.Error(err, "Could not handle Workload")
+				span.SetStatus(codes.Error, err.Error())
+				return a.admitUnchecked(pod, workload, "KeptnWorkload", err)
+			}
+		}
+
+		// scheduling gates can only be set when a pod is created
+		if a.FeatureGates.Enabled(featuregate.SchedulingGates) && req.Operation == admissionv1.Create {
+			released, err := a.isReleased(ctx, pod, req.Namespace)
+			if err != nil {
+				// the pod is still held back by the Keptn scheduler
+				logger.Error(err, "could not get the workload instance of the pod, the pod is not gated")
+			} else if !released {
+				markSchedulingGated(pod, a.getWorkloadInstanceName(pod))
+				gate = true
+			}
 		}
 	}
 
 	marshaledPod, err := json.Marshal(pod)
+	if err == nil {
+		marshaledPod, err = setSchedulingGates(req.Object.Raw, marshaledPod, gate)
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, "Failed to marshal")
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -117,6 +202,18 @@ func (a *PodMutatingWebhook) Handle(ctx context.Context, req admission.Request)
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
 
+// admitUnchecked admits the pod unchanged if its KeptnApp or KeptnWorkload could not be created, so that a failing
+// API request never blocks a rollout. The pod runs without pre-deployment checks, which is recorded as an event of
+// its workload, or of the pod if it does not belong to one.
+func (a *PodMutatingWebhook) admitUnchecked(pod *corev1.Pod, workload client.Object, kind string, err error) admission.Response {
+	var involved runtime.Object = pod
+	if workload != nil {
+		involved = workload
+	}
+	a.Recorder.Eventf(involved, "Warning", "LifecycleSkipped", "Could not create or update the %s of the pod, the pod is admitted without checks: %s", kind, err.Error())
+	return admission.Allowed(fmt.Sprintf("could not create or update the %s of the pod", kind))
+}
+
 // PodMutatingWebhook implements admission.DecoderInjector.
 // A decoder will be automatically injected.
 
@@ -126,6 +223,40 @@ func (a *PodMutatingWebhook) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
+// isManagedByToolkit returns true if the pod belongs to a Job of a KeptnTask, either directly or further up
+// its chain of controllers. Such pods must never start a lifecycle of their own, even if they carry Keptn annotations.
+// The owners are only looked up for pods carrying a workload annotation, since pods of Jobs do not inherit the
+// annotations of their workload and could not start a lifecycle otherwise.
+func (a *PodMutatingWebhook) isManagedByToolkit(ctx context.Context, pod *corev1.Pod, namespace string) (bool, error) {
+	if pod.Labels[common.ManagedByLabel] == common.ManagedByLifecycleToolkit {
+		return true, nil
+	}
+	if _, annotated := getLabelOrAnnotation(pod, common.WorkloadAnnotation, common.K8sRecommendedWorkloadAnnotations); !annotated {
+		return false, nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	for depth := 0; owner != nil && depth < maxOwnerDepth; depth++ {
+		if owner.APIVersion == klcv1alpha1.GroupVersion.String() && owner.Kind == "KeptnTask" {
+			return true, nil
+		}
+		if owner.APIVersion != batchv1.SchemeGroupVersion.String() || owner.Kind != "Job" {
+			return false, nil
+		}
+		job := &batchv1.Job{}
+		if err := a.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, job); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if job.Labels[common.ManagedByLabel] == common.ManagedByLifecycleToolkit {
+			return true, nil
+		}
+		owner = metav1.GetControllerOf(job)
+	}
+	return false, nil
+}
+
 func (a *PodMutatingWebhook) isKeptnAnnotated(pod *corev1.Pod) (bool, error) {
 	workload, gotWorkloadAnnotation := getLabelOrAnnotation(pod, common.WorkloadAnnotation, common.K8sRecommendedWorkloadAnnotations)
 	version, gotVersionAnnotation := getLabelOrAnnotation(pod, common.VersionAnnotation, common.K8sRecommendedVersionAnnotations)
@@ -133,8 +264,15 @@ func (a *PodMutatingWebhook) isKeptnAnnotated(pod *corev1.Pod) (bool, error) {
 	if len(workload) > common.MaxWorkloadNameLength || len(version) > common.MaxVersionLength {
 		return false, common.ErrTooLongAnnotations
 	}
+	// the version ends up in the names of the workload instance and in the labels of the Jobs of its tasks
+	if errs := validation.IsValidLabelValue(version); gotVersionAnnotation && len(errs) > 0 {
+		return false, fmt.Errorf("invalid version %q: %s", version, strings.Join(errs, ", "))
+	}
 
 	if gotWorkloadAnnotation {
+		if err := common.ValidateMetadata(getMetadata(pod)); err != nil {
+			return false, err
+		}
 		if !gotVersionAnnotation {
 			if len(pod.Annotations) == 0 {
 				pod.Annotations = make(map[string]string)
@@ -167,9 +305,9 @@ func (a *PodMutatingWebhook) calculateVersion(pod *corev1.Pod) string {
 	name := ""
 
 	if len(pod.Spec.Containers) == 1 {
-		image := strings.Split(pod.Spec.Containers[0].Image, ":")
-		if len(image) > 1 && image[1] != "" && image[1] != "latest" {
-			return image[1]
+		tag := getImageTag(pod.Spec.Containers[0].Image)
+		if tag != "" && tag != "latest" && len(validation.IsValidLabelValue(tag)) == 0 {
+			return tag
 		}
 	}
 
@@ -185,12 +323,80 @@ func (a *PodMutatingWebhook) calculateVersion(pod *corev1.Pod) string {
 	return fmt.Sprint(h.Sum32())
 }
 
-func (a *PodMutatingWebhook) handleWorkload(ctx context.Context, logger logr.Logger, pod *corev1.Pod, namespace string) error {
+// checkCrossNamespaceReferences returns an error if the pod references a KeptnTaskDefinition of another namespace,
+// which the namespace of the pod must not use
+func (a *PodMutatingWebhook) checkCrossNamespaceReferences(pod *corev1.Pod, namespace string) error {
+	for _, taskAnnotation := range []string{common.PreDeploymentTaskAnnotation, common.PostDeploymentTaskAnnotation} {
+		annotation, found := getLabelOrAnnotation(pod, taskAnnotation, "")
+		if !found {
+			continue
+		}
+		for _, reference := range strings.Split(annotation, ",") {
+			definitionNamespace, _ := common.ParseTaskDefinitionReference(reference)
+			if err := a.CrossNamespaceReferences.Check(namespace, definitionNamespace); err != nil {
+				return fmt.Errorf("%w: KeptnTaskDefinition %s is referenced by %s", err, reference, taskAnnotation)
+			}
+		}
+	}
+	return nil
+}
+
+// getMissingReferences returns the KeptnTaskDefinitions and KeptnEvaluationDefinitions that are referenced
+// by the annotations of the pod, but do not exist in the namespace they have been searched in. KeptnTaskDefinitions
+// are searched in the namespace they are referenced with, all other definitions in the namespace of the pod.
+func (a *PodMutatingWebhook) getMissingReferences(ctx context.Context, pod *corev1.Pod, namespace string) ([]string, error) {
+	references := []struct {
+		annotation string
+		kind       string
+		object     client.Object
+	}{
+		{annotation: common.PreDeploymentTaskAnnotation, kind: "KeptnTaskDefinition", object: &klcv1alpha1.KeptnTaskDefinition{}},
+		{annotation: common.PostDeploymentTaskAnnotation, kind: "KeptnTaskDefinition", object: &klcv1alpha1.KeptnTaskDefinition{}},
+		{annotation: common.PreDeploymentEvaluationAnnotation, kind: "KeptnEvaluationDefinition", object: &klcv1alpha1.KeptnEvaluationDefinition{}},
+		{annotation: common.PostDeploymentEvaluationAnnotation, kind: "KeptnEvaluationDefinition", object: &klcv1alpha1.KeptnEvaluationDefinition{}},
+	}
+	var missing []string
+	for _, reference := range references {
+		annotation, found := getLabelOrAnnotation(pod, reference.annotation, "")
+		if !found {
+			continue
+		}
+		for _, name := range strings.Split(annotation, ",") {
+			if name == "" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: namespace, Name: name}
+			if reference.kind == "KeptnTaskDefinition" {
+				key = controllercommon.GetTaskDefinitionKey(namespace, name)
+			}
+			err := a.Client.Get(ctx, key, reference.object)
+			if errors.IsNotFound(err) {
+				missing = append(missing, fmt.Sprintf("%s/%s in namespace %s", reference.kind, key.Name, key.Namespace))
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return missing, nil
+}
+
+// getVersionSource returns whether the version of the pod is given explicitly or has to be derived from its containers
+func getVersionSource(pod *corev1.Pod) klcv1alpha1.VersionSource {
+	if _, found := getLabelOrAnnotation(pod, common.VersionAnnotation, common.K8sRecommendedVersionAnnotations); found {
+		return klcv1alpha1.VersionSourceAnnotation
+	}
+	return klcv1alpha1.VersionSourceImage
+}
+
+func (a *PodMutatingWebhook) handleWorkload(ctx context.Context, logger logr.Logger, pod *corev1.Pod, namespace string, versionSource klcv1alpha1.VersionSource) error {
 
 	ctx, span := a.Tracer.Start(ctx, "create_workload", trace.WithSpanKind(trace.SpanKindProducer))
 	defer span.End()
 
 	newWorkload := a.generateWorkload(ctx, pod, namespace)
+	newWorkload.Spec.VersionSource = versionSource
 
 	semconv.AddAttributeFromWorkload(span, *newWorkload)
 
@@ -272,6 +478,12 @@ func (a *PodMutatingWebhook) handleApp(ctx context.Context, logger logr.Logger,
 		return fmt.Errorf("could not fetch App"+": %+v", err)
 	}
 
+	// the concurrency settings, lifecycle deadline and propagation policy are not derived from the pod and must not be reset
+	newApp.Spec.ConcurrencyPolicy = app.Spec.ConcurrencyPolicy
+	newApp.Spec.ConcurrencyWaitTimeout = app.Spec.ConcurrencyWaitTimeout
+	newApp.Spec.LifecycleDeadline = app.Spec.LifecycleDeadline
+	newApp.Spec.PropagationPolicy = app.Spec.PropagationPolicy
+
 	if reflect.DeepEqual(app.Spec, newApp.Spec) {
 		logger.Info("Pod not changed, not updating anything")
 		return nil
@@ -318,6 +530,19 @@ func (a *PodMutatingWebhook) generateWorkload(ctx context.Context, pod *corev1.P
 		postDeploymentEvaluation = strings.Split(annotations, ",")
 	}
 
+	// invalid durations are ignored, so that the instances fall back to the lifecycle deadline of the app
+	var lifecycleDeadline *metav1.Duration
+	if annotation, found := getLabelOrAnnotation(pod, common.LifecycleDeadlineAnnotation, ""); found {
+		if deadline, err := time.ParseDuration(annotation); err == nil && deadline > 0 {
+			lifecycleDeadline = &metav1.Duration{Duration: deadline}
+		}
+	}
+
+	preDeploymentChecks := klcv1alpha1.PreDeploymentChecksIfNotDeployed
+	if annotation, found := getLabelOrAnnotation(pod, common.PreDeploymentChecksAnnotation, ""); found && strings.EqualFold(annotation, string(klcv1alpha1.PreDeploymentChecksAlways)) {
+		preDeploymentChecks = klcv1alpha1.PreDeploymentChecksAlways
+	}
+
 	// create TraceContext
 	// follow up with a Keptn propagator that JSON-encoded the OTel map into our own key
 	traceContextCarrier := propagation.MapCarrier{}
@@ -337,6 +562,10 @@ func (a *PodMutatingWebhook) generateWorkload(ctx context.Context, pod *corev1.P
 			PostDeploymentTasks:       postDeploymentTasks,
 			PreDeploymentEvaluations:  preDeploymentEvaluation,
 			PostDeploymentEvaluations: postDeploymentEvaluation,
+			LifecycleDeadline:         lifecycleDeadline,
+			PreDeploymentChecks:       preDeploymentChecks,
+			Metadata:                  getMetadata(pod),
+			TrafficSwitch:             getTrafficSwitch(pod),
 		},
 	}
 }
@@ -399,6 +628,49 @@ func (a *PodMutatingWebhook) getResourceReference(pod *corev1.Pod) klcv1alpha1.R
 	return reference
 }
 
+// getImageTag returns the tag of a container image reference. Registry ports and digests are not considered to be a tag.
+func getImageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i >= 0 {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// getTrafficSwitch returns the Service whose selector is switched to the new version of the workload. Selectors that
+// cannot be parsed are ignored, so that the pod is still admitted.
+func getTrafficSwitch(pod *corev1.Pod) klcv1alpha1.TrafficSwitch {
+	serviceName, found := getLabelOrAnnotation(pod, common.TrafficSwitchServiceAnnotation, "")
+	if !found {
+		return klcv1alpha1.TrafficSwitch{}
+	}
+	annotation, _ := getLabelOrAnnotation(pod, common.TrafficSwitchSelectorAnnotation, "")
+	selector, err := labels.ConvertSelectorToLabelsMap(annotation)
+	if err != nil || len(selector) == 0 {
+		return klcv1alpha1.TrafficSwitch{}
+	}
+	return klcv1alpha1.TrafficSwitch{ServiceName: serviceName, Selector: selector}
+}
+
+// getMetadata returns the metadata given by the keptn.sh/metadata.<key> annotations of the pod
+func getMetadata(pod *corev1.Pod) map[string]string {
+	var metadata map[string]string
+	for key, value := range pod.Annotations {
+		if name := strings.TrimPrefix(key, common.MetadataAnnotationPrefix); name != key {
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			metadata[name] = value
+		}
+	}
+	return metadata
+}
+
 func getLabelOrAnnotation(pod *corev1.Pod, primaryAnnotation string, secondaryAnnotation string) (string, bool) {
 	if pod.Annotations[primaryAnnotation] != "" {
 		return pod.Annotations[primaryAnnotation], true
@@ -0,0 +1,531 @@
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetImageTag(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "nginx", want: ""},
+		{image: "nginx:1.23.1", want: "1.23.1"},
+		{image: "nginx:latest", want: "latest"},
+		{image: "ghcr.io/podtato-head/podtato-server:v0.1.1", want: "v0.1.1"},
+		{image: "localhost:5000/podtato-server", want: ""},
+		{image: "localhost:5000/podtato-server:v0.2.0", want: "v0.2.0"},
+		{image: "nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31", want: ""},
+		{image: "nginx:1.23.1@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31", want: "1.23.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			require.Equal(t, tt.want, getImageTag(tt.image))
+		})
+	}
+}
+
+func FuzzCalculateVersion(f *testing.F) {
+	for _, image := range []string{
+		"nginx",
+		"nginx:1.23.1",
+		"ghcr.io/podtato-head/podtato-server:v0.1.1",
+		"localhost:5000/podtato-server",
+		"nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31",
+		":",
+		"@",
+		"/:",
+	} {
+		f.Add(image)
+	}
+
+	a := &PodMutatingWebhook{}
+	f.Fuzz(func(t *testing.T, image string) {
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: image}},
+			},
+		}
+		version := a.calculateVersion(pod)
+		if version == "" {
+			t.Errorf("empty version calculated for image %q", image)
+		}
+		if strings.ContainsAny(version, "/@") {
+			t.Errorf("version %q of image %q contains parts of the repository or digest", version, image)
+		}
+	})
+}
+
+func FuzzGetWorkloadName(f *testing.F) {
+	f.Add("podtato-head", "podtato-head-entry")
+	f.Add("", "")
+	f.Add("Podtato", "Entry")
+	f.Add("app", strings.Repeat("a", common.MaxWorkloadNameLength+1))
+
+	a := &PodMutatingWebhook{}
+	f.Fuzz(func(t *testing.T, app string, workload string) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					common.AppAnnotation:      app,
+					common.WorkloadAnnotation: workload,
+				},
+			},
+		}
+		name := a.getWorkloadName(pod)
+		if name != strings.ToLower(name) {
+			t.Errorf("workload name %q is not lower case", name)
+		}
+		if _, err := a.isKeptnAnnotated(pod); err == nil && len(workload) > common.MaxWorkloadNameLength {
+			t.Errorf("too long workload annotation %q has been accepted", workload)
+		}
+	})
+}
+
+func controlledBy(apiVersion string, kind string, name string) []metav1.OwnerReference {
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, UID: types.UID("uid-" + name), Controller: pointer.Bool(true)}}
+}
+
+func TestPodMutatingWebhook_isManagedByToolkit(t *testing.T) {
+	managedLabels := map[string]string{common.ManagedByLabel: common.ManagedByLifecycleToolkit}
+	annotations := map[string]string{common.WorkloadAnnotation: "my-workload"}
+	jobs := []client.Object{
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "check", Labels: managedLabels}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user-job"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nested", OwnerReferences: controlledBy("batch/v1", "Job", "check")}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "task-job", OwnerReferences: controlledBy(klcv1alpha1.GroupVersion.String(), "KeptnTask", "my-task")}},
+	}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{
+			name: "labeled pod",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: managedLabels}},
+			want: true,
+		},
+		{
+			name: "pod of a labeled Job",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("batch/v1", "Job", "check")}},
+			want: true,
+		},
+		{
+			name: "pod of a Job controlled by a labeled Job",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("batch/v1", "Job", "nested")}},
+			want: true,
+		},
+		{
+			name: "pod of a Job controlled by a KeptnTask",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("batch/v1", "Job", "task-job")}},
+			want: true,
+		},
+		{
+			name: "pod of a Job of a user",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("batch/v1", "Job", "user-job")}},
+			want: false,
+		},
+		{
+			name: "pod of a Job that does not exist",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("batch/v1", "Job", "gone")}},
+			want: false,
+		},
+		{
+			name: "pod of a labeled Job without a workload annotation",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: controlledBy("batch/v1", "Job", "check")}},
+			want: false,
+		},
+		{
+			name: "pod of a ReplicaSet",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations, OwnerReferences: controlledBy("apps/v1", "ReplicaSet", "my-rs")}},
+			want: false,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	a := &PodMutatingWebhook{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(jobs...).Build()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managed, err := a.isManagedByToolkit(context.TODO(), tt.pod, "default")
+			require.Nil(t, err)
+			require.Equal(t, tt.want, managed)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_isManagedByToolkitLookupError(t *testing.T) {
+	// Jobs cannot be looked up without batch/v1 in the scheme
+	a := &PodMutatingWebhook{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+	owners := controlledBy("batch/v1", "Job", "check")
+
+	// pods without a workload annotation are not looked up
+	managed, err := a.isManagedByToolkit(context.TODO(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: owners}}, "default")
+	require.Nil(t, err)
+	require.False(t, managed)
+
+	managed, err = a.isManagedByToolkit(context.TODO(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Annotations:     map[string]string{common.WorkloadAnnotation: "my-workload"},
+		OwnerReferences: owners,
+	}}, "default")
+	require.NotNil(t, err)
+	require.False(t, managed)
+}
+
+func TestPodMutatingWebhook_generateWorkloadLifecycleDeadline(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       *metav1.Duration
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:       "valid duration",
+			annotation: "1h",
+			want:       &metav1.Duration{Duration: time.Hour},
+		},
+		{
+			name:       "invalid duration",
+			annotation: "one hour",
+		},
+	}
+	a := &PodMutatingWebhook{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{common.WorkloadAnnotation: "my-workload", common.AppAnnotation: "my-app"},
+			}}
+			if tt.annotation != "" {
+				pod.Annotations[common.LifecycleDeadlineAnnotation] = tt.annotation
+			}
+			workload := a.generateWorkload(context.TODO(), pod, "default")
+			require.Equal(t, tt.want, workload.Spec.LifecycleDeadline)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_handleAppKeepsSettings(t *testing.T) {
+	app := &klcv1alpha1.KeptnApp{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app"},
+		Spec: klcv1alpha1.KeptnAppSpec{
+			Version:                "1.0.0",
+			ConcurrencyPolicy:      klcv1alpha1.ConcurrencyPolicyLatestWins,
+			ConcurrencyWaitTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+			LifecycleDeadline:      &metav1.Duration{Duration: time.Hour},
+			PropagationPolicy:      klcv1alpha1.PropagationPolicyCascade,
+		},
+	}
+	a := newWorkloadCreatorTestWebhook(t, app)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Annotations: map[string]string{common.AppAnnotation: "my-app", common.VersionAnnotation: "2.0.0"},
+	}}
+
+	require.Nil(t, a.handleApp(context.TODO(), a.Log, pod, "default"))
+
+	result := &klcv1alpha1.KeptnApp{}
+	require.Nil(t, a.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-app"}, result))
+	require.Equal(t, "2.0.0", result.Spec.Version)
+	require.Equal(t, app.Spec.ConcurrencyPolicy, result.Spec.ConcurrencyPolicy)
+	require.Equal(t, app.Spec.ConcurrencyWaitTimeout, result.Spec.ConcurrencyWaitTimeout)
+	require.Equal(t, app.Spec.LifecycleDeadline, result.Spec.LifecycleDeadline)
+	require.Equal(t, app.Spec.PropagationPolicy, result.Spec.PropagationPolicy)
+}
+
+func TestPodMutatingWebhook_generateWorkloadPreDeploymentChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       klcv1alpha1.PreDeploymentChecksMode
+	}{
+		{
+			name: "no annotation",
+			want: klcv1alpha1.PreDeploymentChecksIfNotDeployed,
+		},
+		{
+			name:       "always",
+			annotation: "always",
+			want:       klcv1alpha1.PreDeploymentChecksAlways,
+		},
+		{
+			name:       "unknown value",
+			annotation: "sometimes",
+			want:       klcv1alpha1.PreDeploymentChecksIfNotDeployed,
+		},
+	}
+	a := &PodMutatingWebhook{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{common.WorkloadAnnotation: "my-workload", common.AppAnnotation: "my-app"},
+			}}
+			if tt.annotation != "" {
+				pod.Annotations[common.PreDeploymentChecksAnnotation] = tt.annotation
+			}
+			workload := a.generateWorkload(context.TODO(), pod, "default")
+			require.Equal(t, tt.want, workload.Spec.PreDeploymentChecks)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_generateWorkloadTrafficSwitch(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        klcv1alpha1.TrafficSwitch
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name: "service and selector",
+			annotations: map[string]string{
+				common.TrafficSwitchServiceAnnotation:  "my-service",
+				common.TrafficSwitchSelectorAnnotation: "version=2.0.0, track=stable",
+			},
+			want: klcv1alpha1.TrafficSwitch{
+				ServiceName: "my-service",
+				Selector:    map[string]string{"version": "2.0.0", "track": "stable"},
+			},
+		},
+		{
+			name:        "service without selector",
+			annotations: map[string]string{common.TrafficSwitchServiceAnnotation: "my-service"},
+		},
+		{
+			name: "invalid selector",
+			annotations: map[string]string{
+				common.TrafficSwitchServiceAnnotation:  "my-service",
+				common.TrafficSwitchSelectorAnnotation: "version",
+			},
+		},
+	}
+	a := &PodMutatingWebhook{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{common.WorkloadAnnotation: "my-workload", common.AppAnnotation: "my-app"},
+			}}
+			for key, value := range tt.annotations {
+				pod.Annotations[key] = value
+			}
+			workload := a.generateWorkload(context.TODO(), pod, "default")
+			require.Equal(t, tt.want, workload.Spec.TrafficSwitch)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_isKeptnAnnotatedVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		image             string
+		wantErr           bool
+		wantVersion       string
+		wantVersionSource klcv1alpha1.VersionSource
+	}{
+		{
+			name:              "version annotation",
+			annotations:       map[string]string{common.WorkloadAnnotation: "my-workload", common.VersionAnnotation: "config-2"},
+			image:             "nginx:1.23.1",
+			wantVersion:       "config-2",
+			wantVersionSource: klcv1alpha1.VersionSourceAnnotation,
+		},
+		{
+			name:              "version derived from the image",
+			annotations:       map[string]string{common.WorkloadAnnotation: "my-workload"},
+			image:             "nginx:1.23.1",
+			wantVersion:       "1.23.1",
+			wantVersionSource: klcv1alpha1.VersionSourceImage,
+		},
+		{
+			name:        "version annotation that is not a valid label value",
+			annotations: map[string]string{common.WorkloadAnnotation: "my-workload", common.VersionAnnotation: "v1/beta"},
+			image:       "nginx:1.23.1",
+			wantErr:     true,
+		},
+	}
+	a := &PodMutatingWebhook{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: tt.image}}},
+			}
+			versionSource := getVersionSource(pod)
+			_, err := a.isKeptnAnnotated(pod)
+			require.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+			require.Equal(t, tt.wantVersionSource, versionSource)
+			require.Equal(t, tt.wantVersion, pod.Annotations[common.VersionAnnotation])
+		})
+	}
+}
+
+func TestPodMutatingWebhook_generateWorkloadMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     string
+		want        map[string]string
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name: "metadata annotations",
+			annotations: map[string]string{
+				"keptn.sh/metadata.ticket":     "ABC-123",
+				"keptn.sh/metadata.commit-sha": "9f2c1e4",
+				"example.com/team":             "checkout",
+			},
+			want: map[string]string{"ticket": "ABC-123", "commit-sha": "9f2c1e4"},
+		},
+		{
+			name:        "key colliding with a span attribute of the toolkit",
+			annotations: map[string]string{"keptn.sh/metadata.keptn.deployment.app.name": "other-app"},
+			wantErr:     "reserved",
+		},
+		{
+			name:        "key colliding with a CloudEvent attribute",
+			annotations: map[string]string{"keptn.sh/metadata.Subject": "other"},
+			wantErr:     "reserved",
+		},
+		{
+			name:        "value too long",
+			annotations: map[string]string{"keptn.sh/metadata.build-url": strings.Repeat("a", common.MaxMetadataValueLength+1)},
+			wantErr:     "longer than",
+		},
+	}
+	a := &PodMutatingWebhook{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{common.WorkloadAnnotation: "my-workload", common.AppAnnotation: "my-app", common.VersionAnnotation: "1.0.0"}
+			for key, value := range tt.annotations {
+				annotations[key] = value
+			}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+
+			_, err := a.isKeptnAnnotated(pod)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			workload := a.generateWorkload(context.TODO(), pod, "default")
+			require.Equal(t, tt.want, workload.Spec.Metadata)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_calculateVersionInvalidTag(t *testing.T) {
+	a := &PodMutatingWebhook{}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:_1.23"}}}}
+	version := a.calculateVersion(pod)
+	require.NotEqual(t, "_1.23", version)
+	require.Empty(t, validation.IsValidLabelValue(version))
+}
+
+func TestPodMutatingWebhook_getMissingReferences(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	a := &PodMutatingWebhook{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&klcv1alpha1.KeptnTaskDefinition{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "notify"}},
+		&klcv1alpha1.KeptnEvaluationDefinition{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cpu"}},
+	).Build()}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		common.PreDeploymentTaskAnnotation:        "notify,load-test",
+		common.PostDeploymentTaskAnnotation:       "notify",
+		common.PreDeploymentEvaluationAnnotation:  "cpu",
+		common.PostDeploymentEvaluationAnnotation: "latency",
+	}}}
+	missing, err := a.getMissingReferences(context.TODO(), pod, "default")
+	require.Nil(t, err)
+	require.Equal(t, []string{"KeptnTaskDefinition/load-test in namespace default", "KeptnEvaluationDefinition/latency in namespace default"}, missing)
+
+	missing, err = a.getMissingReferences(context.TODO(), &corev1.Pod{}, "default")
+	require.Nil(t, err)
+	require.Empty(t, missing)
+
+	// definitions of other namespaces are searched in the namespace they are referenced with
+	pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		common.PreDeploymentTaskAnnotation: "default/notify,platform/migrate",
+	}}}
+	missing, err = a.getMissingReferences(context.TODO(), pod, "team-a")
+	require.Nil(t, err)
+	require.Equal(t, []string{"KeptnTaskDefinition/migrate in namespace platform"}, missing)
+}
+
+func TestPodMutatingWebhook_HandleCrossNamespaceReferences(t *testing.T) {
+	enabled := featuregate.New()
+	require.Nil(t, enabled.SetEnabled(featuregate.CrossNamespaceReferences, true))
+	tests := []struct {
+		name       string
+		references *controllercommon.CrossNamespaceReferences
+		tasks      string
+		wantReason string
+	}{
+		{
+			name:       "disabled by default",
+			tasks:      "notify,platform/migrate",
+			wantReason: "cross-namespace task references are disabled: KeptnTaskDefinition platform/migrate is referenced by keptn.sh/pre-deployment-tasks",
+		},
+		{
+			name:       "namespace not listed as provider",
+			references: controllercommon.NewCrossNamespaceReferences(enabled, "platform"),
+			tasks:      "team-b/migrate",
+			wantReason: "namespace team-b does not provide KeptnTaskDefinitions to other namespaces: KeptnTaskDefinition team-b/migrate is referenced by keptn.sh/pre-deployment-tasks",
+		},
+		{
+			name:       "enabled for the namespace",
+			references: controllercommon.NewCrossNamespaceReferences(enabled, "platform"),
+			tasks:      "notify,platform/migrate",
+		},
+		{
+			name:  "own namespace",
+			tasks: "default/notify",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newWorkloadCreatorTestWebhook(t, defaultNamespace())
+			a.CrossNamespaceReferences = tt.references
+			pod := newGatedTestPod()
+			pod.Annotations[common.PreDeploymentTaskAnnotation] = tt.tasks
+
+			resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, pod))
+			if tt.wantReason != "" {
+				require.False(t, resp.Allowed)
+				require.Equal(t, tt.wantReason, string(resp.Result.Reason))
+				return
+			}
+			require.True(t, resp.Allowed)
+			require.True(t, hasPatch(resp, "/spec/schedulerName"))
+		})
+	}
+}
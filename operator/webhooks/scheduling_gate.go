@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=lifecycle.keptn.sh,resources=keptnworkloadinstances,verbs=get
+
+// isReleased returns true if the pre-deployment checks of the workload instance of the pod have already succeeded,
+// so that pods created afterwards, e.g. when the workload is scaled up, are not held back
+func (a *PodMutatingWebhook) isReleased(ctx context.Context, pod *corev1.Pod, namespace string) (bool, error) {
+	workloadInstance := &klcv1alpha1.KeptnWorkloadInstance{}
+	if err := a.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: a.getWorkloadInstanceName(pod)}, workloadInstance); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return !workloadInstance.Status.GateReleaseTime.IsZero(), nil
+}
+
+func (a *PodMutatingWebhook) getWorkloadInstanceName(pod *corev1.Pod) string {
+	version, _ := getLabelOrAnnotation(pod, common.VersionAnnotation, common.K8sRecommendedVersionAnnotations)
+	return strings.ToLower(a.getWorkloadName(pod) + "-" + version)
+}
+
+// markSchedulingGated labels the pod as carrying the pre-deployment scheduling gate, and with the workload instance
+// that removes the gate. The pod may name its workload in labels or annotations, so the instance selects its pods by
+// the label instead of reading their metadata.
+func markSchedulingGated(pod *corev1.Pod, workloadInstanceName string) {
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[common.SchedulingGatedLabel] = "true"
+	pod.Labels[common.WorkloadInstanceLabel] = common.GetWorkloadInstanceLabelValue(workloadInstanceName)
+}
+
+// setSchedulingGates writes the scheduling gates of the admitted pod into the mutated pod, and adds the pre-deployment
+// scheduling gate if gate is true. The Pod type the operator is built with does not know spec.schedulingGates yet, so
+// that the gates of the request are lost when the pod is decoded.
+func setSchedulingGates(raw []byte, mutated []byte, gate bool) ([]byte, error) {
+	var original struct {
+		Spec struct {
+			SchedulingGates []map[string]interface{} `json:"schedulingGates,omitempty"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &original); err != nil {
+		return nil, err
+	}
+	gates := original.Spec.SchedulingGates
+	if gate && !hasSchedulingGate(gates, common.PreDeploymentSchedulingGate) {
+		gates = append(gates, map[string]interface{}{"name": common.PreDeploymentSchedulingGate})
+	}
+	if len(gates) == 0 {
+		return mutated, nil
+	}
+
+	pod := map[string]interface{}{}
+	if err := json.Unmarshal(mutated, &pod); err != nil {
+		return nil, err
+	}
+	spec, ok := pod["spec"].(map[string]interface{})
+	if !ok {
+		spec = map[string]interface{}{}
+		pod["spec"] = spec
+	}
+	spec["schedulingGates"] = gates
+	return json.Marshal(pod)
+}
+
+func hasSchedulingGate(gates []map[string]interface{}, name string) bool {
+	for _, gate := range gates {
+		if gate["name"] == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/keptn/lifecycle-toolkit/operator/internal/featuregate"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newSchedulingGatesTestWebhook(t *testing.T, objects ...client.Object) *PodMutatingWebhook {
+	a := newWorkloadCreatorTestWebhook(t, objects...)
+	a.FeatureGates = featuregate.New()
+	require.Nil(t, a.FeatureGates.SetEnabled(featuregate.SchedulingGates, true))
+	return a
+}
+
+func newGatedTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-pod", Annotations: map[string]string{
+			common.AppAnnotation:      "my-app",
+			common.WorkloadAnnotation: "my-workload",
+			common.VersionAnnotation:  "1.0.0",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.0.0"}}},
+	}
+}
+
+func TestSetSchedulingGates(t *testing.T) {
+	mutated := []byte(`{"metadata":{"name":"my-pod"},"spec":{"schedulerName":"keptn-scheduler"}}`)
+
+	// the gates of the request are kept, and the pre-deployment gate is added once
+	raw := []byte(`{"metadata":{"name":"my-pod"},"spec":{"schedulingGates":[{"name":"example.com/quota"}]}}`)
+	res, err := setSchedulingGates(raw, mutated, true)
+	require.Nil(t, err)
+	require.JSONEq(t, `{"metadata":{"name":"my-pod"},"spec":{"schedulerName":"keptn-scheduler","schedulingGates":[{"name":"example.com/quota"},{"name":"keptn.sh/pre-deployment"}]}}`, string(res))
+
+	raw = []byte(`{"metadata":{"name":"my-pod"},"spec":{"schedulingGates":[{"name":"keptn.sh/pre-deployment"}]}}`)
+	res, err = setSchedulingGates(raw, mutated, true)
+	require.Nil(t, err)
+	require.JSONEq(t, `{"metadata":{"name":"my-pod"},"spec":{"schedulerName":"keptn-scheduler","schedulingGates":[{"name":"keptn.sh/pre-deployment"}]}}`, string(res))
+
+	// without any gates the mutated pod is left as it is
+	res, err = setSchedulingGates([]byte(`{"spec":{}}`), mutated, false)
+	require.Nil(t, err)
+	require.Equal(t, mutated, res)
+
+	_, err = setSchedulingGates([]byte(`not json`), mutated, true)
+	require.NotNil(t, err)
+}
+
+func TestPodMutatingWebhook_HandleAddsSchedulingGate(t *testing.T) {
+	a := newSchedulingGatesTestWebhook(t, defaultNamespace())
+
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newGatedTestPod()))
+	require.True(t, resp.Allowed)
+	require.True(t, hasPatch(resp, "/spec/schedulingGates"))
+	require.True(t, hasPatch(resp, "/metadata/labels"))
+	for _, patch := range resp.Patches {
+		switch patch.Path {
+		case "/spec/schedulingGates":
+			require.Equal(t, []interface{}{map[string]interface{}{"name": common.PreDeploymentSchedulingGate}}, patch.Value)
+		case "/metadata/labels":
+			require.Equal(t, map[string]interface{}{
+				common.SchedulingGatedLabel:  "true",
+				common.WorkloadInstanceLabel: "my-app-my-workload-1.0.0",
+			}, patch.Value)
+		}
+	}
+}
+
+func TestPodMutatingWebhook_HandleGatesLabelsOnlyPods(t *testing.T) {
+	a := newSchedulingGatesTestWebhook(t, defaultNamespace())
+	// the pod names its workload with the recommended Kubernetes labels only, and has no annotations at all
+	pod := newGatedTestPod()
+	pod.Annotations = nil
+	pod.Labels = map[string]string{
+		common.K8sRecommendedAppAnnotations:      "my-app",
+		common.K8sRecommendedWorkloadAnnotations: "my-workload",
+		common.K8sRecommendedVersionAnnotations:  "1.0.0",
+	}
+
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, pod))
+	require.True(t, resp.Allowed)
+	require.True(t, hasPatch(resp, "/spec/schedulingGates"))
+	labels := map[string]interface{}{}
+	for _, patch := range resp.Patches {
+		if patch.Path == "/metadata/labels/keptn.sh~1scheduling-gated" || patch.Path == "/metadata/labels/keptn.sh~1workload-instance" {
+			labels[patch.Path] = patch.Value
+		}
+	}
+	require.Equal(t, map[string]interface{}{
+		"/metadata/labels/keptn.sh~1scheduling-gated":  "true",
+		"/metadata/labels/keptn.sh~1workload-instance": "my-app-my-workload-1.0.0",
+	}, labels)
+}
+
+func TestPodMutatingWebhook_HandleKeepsSchedulingGatesOfRequest(t *testing.T) {
+	a := newSchedulingGatesTestWebhook(t, defaultNamespace())
+	req := newPodAdmissionRequest(t, newGatedTestPod())
+	pod := map[string]interface{}{}
+	require.Nil(t, json.Unmarshal(req.Object.Raw, &pod))
+	pod["spec"].(map[string]interface{})["schedulingGates"] = []interface{}{map[string]interface{}{"name": "example.com/quota"}}
+	raw, err := json.Marshal(pod)
+	require.Nil(t, err)
+	req.Object.Raw = raw
+
+	resp := a.Handle(context.TODO(), req)
+	require.True(t, resp.Allowed)
+	// only the pre-deployment gate is appended, the gate of the request is not removed
+	for _, patch := range resp.Patches {
+		require.NotEqual(t, "remove", patch.Operation)
+	}
+	require.True(t, hasPatch(resp, "/spec/schedulingGates/1"))
+}
+
+func TestPodMutatingWebhook_HandleDoesNotGateReleasedPods(t *testing.T) {
+	released := &klcv1alpha1.KeptnWorkloadInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-app-my-workload-1.0.0"},
+		Status:     klcv1alpha1.KeptnWorkloadInstanceStatus{GateReleaseTime: metav1.NewTime(time.Now())},
+	}
+	a := newSchedulingGatesTestWebhook(t, defaultNamespace(), released)
+
+	// the workload is scaled up after its pre-deployment checks have succeeded
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newGatedTestPod()))
+	require.True(t, resp.Allowed)
+	require.False(t, hasPatch(resp, "/spec/schedulingGates"))
+	require.True(t, hasPatch(resp, "/spec/schedulerName"))
+}
+
+func TestPodMutatingWebhook_HandleGatesOnlyCreatedPods(t *testing.T) {
+	a := newSchedulingGatesTestWebhook(t, defaultNamespace())
+	req := newPodAdmissionRequest(t, newGatedTestPod())
+	req.Operation = admissionv1.Update
+
+	resp := a.Handle(context.TODO(), req)
+	require.True(t, resp.Allowed)
+	require.False(t, hasPatch(resp, "/spec/schedulingGates"))
+}
+
+func TestPodMutatingWebhook_HandleWithoutSchedulingGates(t *testing.T) {
+	a := newWorkloadCreatorTestWebhook(t, defaultNamespace())
+
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newGatedTestPod()))
+	require.True(t, resp.Allowed)
+	require.False(t, hasPatch(resp, "/spec/schedulingGates"))
+}
+
+func defaultNamespace() *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{common.NamespaceEnabledAnnotation: "enabled"}}}
+}
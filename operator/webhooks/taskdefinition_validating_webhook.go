@@ -0,0 +1,48 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-lifecycle-keptn-sh-v1alpha1-keptntaskdefinition,mutating=false,failurePolicy=fail,groups=lifecycle.keptn.sh,resources=keptntaskdefinitions,verbs=create;update,versions=v1alpha1,name=vkeptntaskdefinition.keptn.sh,admissionReviewVersions=v1,sideEffects=None
+
+// TaskDefinitionValidatingWebhook denies KeptnTaskDefinitions that request API access with a ClusterRole that is not
+// allowed, so that the mistake is reported when the definition is applied and not only when a task fails
+type TaskDefinitionValidatingWebhook struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// Handle validates the apiAccess of created and updated KeptnTaskDefinitions
+func (a *TaskDefinitionValidatingWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	definition := &klcv1alpha1.KeptnTaskDefinition{}
+	if err := a.decoder.Decode(req, definition); err != nil {
+		a.Log.Error(err, "could not decode the task definition")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := controllercommon.ValidateApiAccess(definition.Spec.ApiAccess.ClusterRole); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+// TaskDefinitionValidatingWebhook implements admission.DecoderInjector.
+// A decoder will be automatically injected.
+
+// InjectDecoder injects the decoder.
+func (a *TaskDefinitionValidatingWebhook) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
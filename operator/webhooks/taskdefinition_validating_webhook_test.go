@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	controllercommon "github.com/keptn/lifecycle-toolkit/operator/controllers/common"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestTaskDefinitionValidatingWebhook_Handle(t *testing.T) {
+	t.Setenv(controllercommon.AllowedClusterRolesEnv, "view")
+
+	tests := []struct {
+		name        string
+		clusterRole string
+		wantAllowed bool
+	}{
+		{
+			name:        "no api access",
+			wantAllowed: true,
+		},
+		{
+			name:        "allowed ClusterRole",
+			clusterRole: "view",
+			wantAllowed: true,
+		},
+		{
+			name:        "ClusterRole not allowed",
+			clusterRole: "cluster-admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+			decoder, err := admission.NewDecoder(scheme)
+			require.Nil(t, err)
+			a := &TaskDefinitionValidatingWebhook{Log: logr.Discard()}
+			require.Nil(t, a.InjectDecoder(decoder))
+
+			definition := &klcv1alpha1.KeptnTaskDefinition{
+				TypeMeta:   metav1.TypeMeta{APIVersion: klcv1alpha1.GroupVersion.String(), Kind: "KeptnTaskDefinition"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "migrate"},
+				Spec:       klcv1alpha1.KeptnTaskDefinitionSpec{ApiAccess: klcv1alpha1.ApiAccess{ClusterRole: tt.clusterRole}},
+			}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Namespace: "default", Operation: admissionv1.Create}}
+			req.Object.Raw, err = json.Marshal(definition)
+			require.Nil(t, err)
+
+			resp := a.Handle(context.TODO(), req)
+			require.Equal(t, tt.wantAllowed, resp.Allowed)
+			if !tt.wantAllowed {
+				require.Contains(t, string(resp.Result.Reason), "ClusterRole cluster-admin is not allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets;daemonsets,verbs=get
+
+// inheritedAnnotations are the annotations a pod inherits from the Deployment, StatefulSet or DaemonSet it belongs to
+var inheritedAnnotations = []string{
+	common.AppAnnotation,
+	common.WorkloadAnnotation,
+	common.VersionAnnotation,
+	common.PreDeploymentTaskAnnotation,
+	common.PostDeploymentTaskAnnotation,
+	common.PreDeploymentEvaluationAnnotation,
+	common.PostDeploymentEvaluationAnnotation,
+	common.LifecycleDeadlineAnnotation,
+	common.PreDeploymentChecksAnnotation,
+}
+
+// getOwningWorkload returns the Deployment, StatefulSet or DaemonSet the pod belongs to, or nil if it belongs to
+// none of them or its owners do not exist (anymore)
+func (a *PodMutatingWebhook) getOwningWorkload(ctx context.Context, pod *corev1.Pod, namespace string) (client.Object, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.APIVersion != appsv1.SchemeGroupVersion.String() {
+		return nil, nil
+	}
+	if owner.Kind == "ReplicaSet" {
+		replicaSet := &appsv1.ReplicaSet{}
+		if err := a.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, replicaSet); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		owner = metav1.GetControllerOf(replicaSet)
+		if owner == nil || owner.APIVersion != appsv1.SchemeGroupVersion.String() {
+			return nil, nil
+		}
+	}
+
+	var workload client.Object
+	switch owner.Kind {
+	case "Deployment":
+		workload = &appsv1.Deployment{}
+	case "StatefulSet":
+		workload = &appsv1.StatefulSet{}
+	case "DaemonSet":
+		workload = &appsv1.DaemonSet{}
+	default:
+		return nil, nil
+	}
+	if err := a.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, workload); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return workload, nil
+}
+
+// inheritWorkloadAnnotations copies the Keptn annotations of the workload the pod belongs to onto the pod, if the
+// workload is annotated with keptn.sh/app or keptn.sh/workload and the pod is not annotated itself. Annotations of the
+// pod take precedence. Without keptn.sh/workload, the name of the workload is used. Without keptn.sh/version, the
+// version is derived from the image of the pod later on. It returns true if the pod has inherited the annotations.
+func inheritWorkloadAnnotations(pod *corev1.Pod, workload client.Object) bool {
+	if workload == nil {
+		return false
+	}
+	if _, found := getLabelOrAnnotation(pod, common.WorkloadAnnotation, common.K8sRecommendedWorkloadAnnotations); found {
+		return false
+	}
+	annotations := workload.GetAnnotations()
+	if annotations[common.AppAnnotation] == "" && annotations[common.WorkloadAnnotation] == "" {
+		return false
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	for _, key := range inheritedAnnotations {
+		if value := annotations[key]; value != "" && pod.Annotations[key] == "" {
+			pod.Annotations[key] = value
+		}
+	}
+	for key, value := range annotations {
+		if strings.HasPrefix(key, common.MetadataAnnotationPrefix) && pod.Annotations[key] == "" {
+			pod.Annotations[key] = value
+		}
+	}
+	if pod.Annotations[common.WorkloadAnnotation] == "" {
+		pod.Annotations[common.WorkloadAnnotation] = workload.GetName()
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// failingCreateClient fails to create any object
+type failingCreateClient struct {
+	client.Client
+}
+
+func (c failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return errors.New("the server is currently unable to handle the request")
+}
+
+func newAnnotatedDeployment(annotations map[string]string) []client.Object {
+	return []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{common.NamespaceEnabledAnnotation: "enabled"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-deployment", Annotations: annotations}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-deployment-5d9c", OwnerReferences: controlledBy("apps/v1", "Deployment", "my-deployment")}},
+	}
+}
+
+func newPodAdmissionRequest(t *testing.T, pod *corev1.Pod) admission.Request {
+	raw, err := json.Marshal(pod)
+	require.Nil(t, err)
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Namespace: "default",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func newReplicaSetPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "my-deployment-5d9c-x7k2p",
+			Annotations:     annotations,
+			OwnerReferences: controlledBy("apps/v1", "ReplicaSet", "my-deployment-5d9c"),
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.25"}}},
+	}
+}
+
+func hasPatch(resp admission.Response, path string) bool {
+	for _, patch := range resp.Patches {
+		if patch.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPodMutatingWebhook_HandleInheritsWorkloadAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		podAnnots   map[string]string
+		wantName    string
+		wantVersion string
+	}{
+		{
+			name:        "app and version",
+			annotations: map[string]string{common.AppAnnotation: "my-app", common.VersionAnnotation: "2.0.0"},
+			wantName:    "my-app-my-deployment",
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "version from the image tag",
+			annotations: map[string]string{common.AppAnnotation: "my-app"},
+			wantName:    "my-app-my-deployment",
+			wantVersion: "1.25",
+		},
+		{
+			name:        "workload name of the Deployment",
+			annotations: map[string]string{common.AppAnnotation: "my-app", common.WorkloadAnnotation: "frontend", common.VersionAnnotation: "2.0.0"},
+			wantName:    "my-app-frontend",
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "annotations of the pod take precedence",
+			annotations: map[string]string{common.AppAnnotation: "my-app", common.VersionAnnotation: "2.0.0"},
+			podAnnots:   map[string]string{common.WorkloadAnnotation: "backend", common.AppAnnotation: "other-app", common.VersionAnnotation: "3.0.0"},
+			wantName:    "other-app-backend",
+			wantVersion: "3.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newWorkloadCreatorTestWebhook(t, newAnnotatedDeployment(tt.annotations)...)
+
+			resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newReplicaSetPod(tt.podAnnots)))
+			require.True(t, resp.Allowed)
+			require.True(t, hasPatch(resp, "/spec/schedulerName"))
+
+			workload := &klcv1alpha1.KeptnWorkload{}
+			require.Nil(t, a.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: tt.wantName}, workload))
+			require.Equal(t, tt.wantVersion, workload.Spec.Version)
+		})
+	}
+}
+
+func TestPodMutatingWebhook_HandleIgnoresUnannotatedWorkloads(t *testing.T) {
+	a := newWorkloadCreatorTestWebhook(t, newAnnotatedDeployment(map[string]string{"team": "checkout"})...)
+
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newReplicaSetPod(nil)))
+	require.True(t, resp.Allowed)
+	require.Empty(t, resp.Patches)
+
+	workloads := &klcv1alpha1.KeptnWorkloadList{}
+	require.Nil(t, a.Client.List(context.TODO(), workloads))
+	require.Empty(t, workloads.Items)
+}
+
+func TestPodMutatingWebhook_HandleFailsOpen(t *testing.T) {
+	a := newWorkloadCreatorTestWebhook(t, newAnnotatedDeployment(map[string]string{common.AppAnnotation: "my-app"})...)
+	a.Client = failingCreateClient{Client: a.Client}
+	recorder := record.NewFakeRecorder(10)
+	a.Recorder = recorder
+
+	resp := a.Handle(context.TODO(), newPodAdmissionRequest(t, newReplicaSetPod(nil)))
+	require.True(t, resp.Allowed)
+	// the pod is admitted unchanged, so that it is not held back by the Keptn scheduler
+	require.Empty(t, resp.Patches)
+
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	require.Contains(t, events, "Warning LifecycleSkipped Could not create or update the KeptnWorkload of the pod, the pod is admitted without checks: the server is currently unable to handle the request")
+}
+
+func TestInheritWorkloadAnnotations(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "my-deployment", Annotations: map[string]string{
+		common.AppAnnotation:                       "my-app",
+		common.PreDeploymentTaskAnnotation:         "migrate",
+		common.MetadataAnnotationPrefix + "ticket": "ABC-123",
+		// annotations the operator sets on the Deployment are not copied
+		common.ReleasedVersionAnnotation: "1.0.0",
+	}}}
+
+	pod := &corev1.Pod{}
+	require.True(t, inheritWorkloadAnnotations(pod, deployment))
+	require.Equal(t, map[string]string{
+		common.AppAnnotation:                       "my-app",
+		common.WorkloadAnnotation:                  "my-deployment",
+		common.PreDeploymentTaskAnnotation:         "migrate",
+		common.MetadataAnnotationPrefix + "ticket": "ABC-123",
+	}, pod.Annotations)
+
+	require.False(t, inheritWorkloadAnnotations(&corev1.Pod{}, nil))
+	require.False(t, inheritWorkloadAnnotations(&corev1.Pod{}, &appsv1.StatefulSet{}))
+}
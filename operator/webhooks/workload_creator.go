@@ -0,0 +1,202 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultWorkloadCreatorQueueSize = 1000
+
+	// workloadCreatorRetryDelay is the time to wait before creating the objects of a pod again after a failure
+	workloadCreatorRetryDelay = 5 * time.Second
+	// workloadCreatorMaxRetries is the number of times the objects of a pod are created again after a failure
+	workloadCreatorMaxRetries = 5
+	// workloadCreatorResyncPageSize is the number of pods listed at once on start
+	workloadCreatorResyncPageSize = 500
+	keptnSchedulerName            = "keptn-scheduler"
+)
+
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+// creationRequest holds an admitted pod whose KeptnApp and KeptnWorkload have not been created yet
+type creationRequest struct {
+	pod           *corev1.Pod
+	namespace     string
+	versionSource klcv1alpha1.VersionSource
+	generateApp   bool
+	// spanContext links the created objects to the trace of the admission request
+	spanContext trace.SpanContext
+	// retries is the number of times the creation has already failed
+	retries int
+}
+
+// WorkloadCreator creates the KeptnApps and KeptnWorkloads of admitted pods off the admission path, so that admitting
+// a pod does not wait for these API requests. The pods are held back by the Keptn scheduler until their workload
+// instance exists. Requests that are lost on a restart of the operator are recovered on start, by listing the pods
+// that are still waiting for the Keptn scheduler.
+type WorkloadCreator struct {
+	Webhook *PodMutatingWebhook
+	// Reader lists the waiting pods on start, usually the API reader of the manager, so that no informer is started for all pods
+	Reader client.Reader
+	Log    logr.Logger
+
+	queue chan creationRequest
+}
+
+func NewWorkloadCreator(webhook *PodMutatingWebhook, reader client.Reader, queueSize int, log logr.Logger) *WorkloadCreator {
+	return &WorkloadCreator{
+		Webhook: webhook,
+		Reader:  reader,
+		Log:     log,
+		queue:   make(chan creationRequest, queueSize),
+	}
+}
+
+// Enqueue hands the request over to the creator. It returns false if the queue is full, in which case the caller
+// has to create the objects itself.
+func (c *WorkloadCreator) Enqueue(req creationRequest) bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case c.queue <- req:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start creates the objects of the enqueued pods until the given context is cancelled. It implements manager.Runnable.
+// The waiting pods are listed while the queue is already consumed, so that none of them is dropped if there are more
+// than fit into the queue.
+func (c *WorkloadCreator) Start(ctx context.Context) error {
+	go func() {
+		if err := c.resync(ctx); err != nil {
+			c.Log.Error(err, "could not list the pods waiting for their workload")
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case req := <-c.queue:
+			if err := c.create(ctx, req); err != nil {
+				if req.retries >= workloadCreatorMaxRetries {
+					c.Log.Error(err, "could not create the workload of pod, giving up", "namespace", req.namespace, "pod", req.pod.Name, "retries", req.retries)
+					continue
+				}
+				c.Log.Error(err, "could not create the workload of pod, retrying", "namespace", req.namespace, "pod", req.pod.Name)
+				req.retries++
+				go c.retry(ctx, req)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false, since pods are admitted by all replicas of the operator
+func (c *WorkloadCreator) NeedLeaderElection() bool {
+	return false
+}
+
+func (c *WorkloadCreator) retry(ctx context.Context, req creationRequest) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(workloadCreatorRetryDelay):
+	}
+	select {
+	case <-ctx.Done():
+	case c.queue <- req:
+	}
+}
+
+func (c *WorkloadCreator) create(ctx context.Context, req creationRequest) error {
+	ctx = trace.ContextWithSpanContext(ctx, req.spanContext)
+	logger := c.Log.WithValues("namespace", req.namespace, "pod", req.pod.Name)
+	if req.generateApp {
+		if err := c.Webhook.handleApp(ctx, logger, req.pod, req.namespace); err != nil {
+			return err
+		}
+	}
+	return c.Webhook.handleWorkload(ctx, logger, req.pod, req.namespace, req.versionSource)
+}
+
+// resync enqueues the annotated pods of enabled namespaces that are waiting for the Keptn scheduler, waiting for room
+// in the queue. The webhook has already mutated them, so whether their app has been generated and where their version
+// comes from is derived from the stored pods.
+func (c *WorkloadCreator) resync(ctx context.Context) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := c.Reader.List(ctx, namespaces); err != nil {
+		return err
+	}
+	for _, namespace := range namespaces.Items {
+		if namespace.Annotations[common.NamespaceEnabledAnnotation] != "enabled" {
+			continue
+		}
+		if err := c.resyncNamespace(ctx, namespace.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resyncNamespace lists the pods of the namespace page by page, so that the pods of large namespaces are not held in
+// memory at once
+func (c *WorkloadCreator) resyncNamespace(ctx context.Context, namespace string) error {
+	continueToken := ""
+	for {
+		pods := &corev1.PodList{}
+		if err := c.Reader.List(ctx, pods, client.InNamespace(namespace), client.Limit(workloadCreatorResyncPageSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		if err := c.resyncPods(ctx, pods.Items); err != nil {
+			return err
+		}
+		if continueToken = pods.Continue; continueToken == "" {
+			return nil
+		}
+	}
+}
+
+func (c *WorkloadCreator) resyncPods(ctx context.Context, pods []corev1.Pod) error {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.SchedulerName != keptnSchedulerName || pod.Spec.NodeName != "" {
+			continue
+		}
+		workload, found := getLabelOrAnnotation(pod, common.WorkloadAnnotation, common.K8sRecommendedWorkloadAnnotations)
+		if !found {
+			continue
+		}
+		// generated apps are named after the workload, existing apps are left to their owner
+		generateApp := false
+		if app, _ := getLabelOrAnnotation(pod, common.AppAnnotation, common.K8sRecommendedAppAnnotations); app == workload {
+			err := c.Reader.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: app}, &klcv1alpha1.KeptnApp{})
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			generateApp = errors.IsNotFound(err)
+		}
+		// a computed version is always derived from the containers in the same way
+		versionSource := klcv1alpha1.VersionSourceAnnotation
+		if version, _ := getLabelOrAnnotation(pod, common.VersionAnnotation, common.K8sRecommendedVersionAnnotations); version == c.Webhook.calculateVersion(pod) {
+			versionSource = klcv1alpha1.VersionSourceImage
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case c.queue <- creationRequest{pod: pod, namespace: pod.Namespace, versionSource: versionSource, generateApp: generateApp}:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	klcv1alpha1 "github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1"
+	"github.com/keptn/lifecycle-toolkit/operator/api/v1alpha1/common"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newWorkloadCreatorTestWebhook(t testing.TB, objects ...client.Object) *PodMutatingWebhook {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, klcv1alpha1.AddToScheme(scheme))
+	decoder, err := admission.NewDecoder(scheme)
+	require.Nil(t, err)
+	a := &PodMutatingWebhook{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Tracer:   trace.NewNoopTracerProvider().Tracer("test"),
+		Recorder: &record.FakeRecorder{},
+		Log:      logr.Discard(),
+	}
+	require.Nil(t, a.InjectDecoder(decoder))
+	return a
+}
+
+func TestWorkloadCreator_resync(t *testing.T) {
+	waiting := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "waiting", Annotations: map[string]string{
+			common.WorkloadAnnotation: "my-workload",
+			common.AppAnnotation:      "my-workload",
+			common.VersionAnnotation:  "1.0.0",
+		}},
+		Spec: corev1.PodSpec{SchedulerName: keptnSchedulerName, Containers: []corev1.Container{{Name: "app", Image: "nginx:1.0.0"}}},
+	}
+	scheduled := waiting.DeepCopy()
+	scheduled.Name = "scheduled"
+	scheduled.Annotations[common.WorkloadAnnotation] = "other-workload"
+	scheduled.Spec.NodeName = "node-1"
+	// pods of namespaces that are not enabled are never handled by the webhook
+	disabled := waiting.DeepCopy()
+	disabled.Namespace = "disabled"
+	a := newWorkloadCreatorTestWebhook(t, defaultNamespace(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "disabled"}}, waiting, scheduled, disabled)
+	creator := NewWorkloadCreator(a, a.Client, 10, logr.Discard())
+
+	require.Nil(t, creator.resync(context.TODO()))
+	require.Len(t, creator.queue, 1)
+	req := <-creator.queue
+	require.Equal(t, "waiting", req.pod.Name)
+	require.True(t, req.generateApp)
+	require.Equal(t, klcv1alpha1.VersionSourceImage, req.versionSource)
+
+	require.Nil(t, creator.create(context.TODO(), req))
+	require.Nil(t, a.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-workload"}, &klcv1alpha1.KeptnApp{}))
+	workload := &klcv1alpha1.KeptnWorkload{}
+	require.Nil(t, a.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "my-workload-my-workload"}, workload))
+	require.Equal(t, "1.0.0", workload.Spec.Version)
+}
+
+func TestWorkloadCreator_StartResyncsMorePodsThanFitIntoTheQueue(t *testing.T) {
+	objects := []client.Object{defaultNamespace()}
+	for i := 0; i < 5; i++ {
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("waiting-%d", i), Annotations: map[string]string{
+				common.WorkloadAnnotation: fmt.Sprintf("my-workload-%d", i),
+				common.AppAnnotation:      "my-app",
+				common.VersionAnnotation:  "1.0.0",
+			}},
+			Spec: corev1.PodSpec{SchedulerName: keptnSchedulerName, Containers: []corev1.Container{{Name: "app", Image: "nginx:1.0.0"}}},
+		})
+	}
+	a := newWorkloadCreatorTestWebhook(t, objects...)
+	creator := NewWorkloadCreator(a, a.Client, 1, logr.Discard())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go func() {
+		_ = creator.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		workloads := &klcv1alpha1.KeptnWorkloadList{}
+		require.Nil(t, a.Client.List(context.TODO(), workloads))
+		return len(workloads.Items) == 5
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func BenchmarkPodMutatingWebhook_Handle(b *testing.B) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: map[string]string{common.NamespaceEnabledAnnotation: "enabled"}}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-pod", Annotations: map[string]string{
+			common.WorkloadAnnotation: "my-workload",
+			common.VersionAnnotation:  "1.0.0",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.0.0"}}},
+	}
+	raw, err := json.Marshal(pod)
+	require.Nil(b, err)
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Namespace: "default",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+
+	for _, async := range []bool{false, true} {
+		name := "sync"
+		if async {
+			name = "async"
+		}
+		b.Run(name, func(b *testing.B) {
+			a := newWorkloadCreatorTestWebhook(b, namespace)
+			if async {
+				// the queue is not consumed, so that only the admission itself is measured
+				a.WorkloadCreator = NewWorkloadCreator(a, a.Client, b.N, logr.Discard())
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if resp := a.Handle(context.TODO(), req); !resp.Allowed {
+					b.Fatalf("pod has not been admitted: %v", resp.Result)
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
@@ -17,10 +18,21 @@ const (
 	PluginName = "KLCPermit"
 )
 
+const (
+	// blockingReasonInterval is the minimum time between two blocking reason events for the same pod,
+	// unless the reason changes in between
+	blockingReasonInterval = 3 * time.Minute
+	// blockingReasonQPS and blockingReasonBurst limit the number of blocking reason events emitted across all pods,
+	// so that rollouts of many pods at once do not cause an event storm
+	blockingReasonQPS   = 1
+	blockingReasonBurst = 10
+)
+
 // Permit is a plugin that waits for pre-deployment checks to be successfully finished
 type Permit struct {
 	handler         framework.Handle
 	workloadManager *WorkloadManager
+	eventLimiter    flowcontrol.RateLimiter
 }
 
 var _ framework.PermitPlugin = &Permit{}
@@ -35,7 +47,7 @@ func (pl *Permit) Permit(ctx context.Context, state *framework.CycleState, p *v1
 	klog.Infof("[Keptn Permit Plugin] waiting for pre-deployment checks on %s", p.GetObjectMeta().GetName())
 
 	// check the permit immediately, to fail early in case the pod cannot be queued
-	switch pl.workloadManager.Permit(ctx, p) {
+	switch status, _ := pl.workloadManager.Permit(ctx, p); status {
 
 	case Failure:
 		klog.Infof("[Keptn Permit Plugin] failed pre-deployment checks on %s", p.GetObjectMeta().GetName())
@@ -59,8 +71,11 @@ func (pl *Permit) Permit(ctx context.Context, state *framework.CycleState, p *v1
 func (pl *Permit) monitorPod(ctx context.Context, p *v1.Pod) {
 	waitingPodHandler := pl.handler.GetWaitingPod(p.UID)
 
+	lastReason := ""
+	lastEmitted := time.Time{}
 	for {
-		switch pl.workloadManager.Permit(ctx, p) {
+		status, reason := pl.workloadManager.Permit(ctx, p)
+		switch status {
 		case Failure:
 			waitingPodHandler.Reject(PluginName, "Pre Deployment Check failed")
 			return
@@ -68,12 +83,28 @@ func (pl *Permit) monitorPod(ctx context.Context, p *v1.Pod) {
 			waitingPodHandler.Allow(PluginName)
 			return
 		default:
+			if reason != lastReason || time.Since(lastEmitted) >= blockingReasonInterval {
+				if pl.emitBlockingReason(p, reason) {
+					lastReason = reason
+					lastEmitted = time.Now()
+				}
+			}
 			time.Sleep(10 * time.Second)
 		}
 	}
 
 }
 
+// emitBlockingReason records an event on the pod that explains why it is still pending.
+// It returns false if the event has been dropped by the rate limiter
+func (pl *Permit) emitBlockingReason(p *v1.Pod, reason string) bool {
+	if !pl.eventLimiter.TryAccept() {
+		return false
+	}
+	pl.handler.EventRecorder().Eventf(p, nil, v1.EventTypeNormal, "KeptnBlocked", "Scheduling", "Keptn Lifecycle Toolkit is %s", reason)
+	return true
+}
+
 // New initializes a new plugin and returns it.
 func New(_ runtime.Object, h framework.Handle) (framework.Plugin, error) {
 	client, err := newClient()
@@ -84,6 +115,7 @@ func New(_ runtime.Object, h framework.Handle) (framework.Plugin, error) {
 	return &Permit{
 		workloadManager: NewWorkloadManager(client),
 		handler:         h,
+		eventLimiter:    flowcontrol.NewTokenBucketRateLimiter(blockingReasonQPS, blockingReasonBurst),
 	}, nil
 }
 
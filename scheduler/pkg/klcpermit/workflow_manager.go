@@ -48,7 +48,7 @@ const K8sRecommendedVersionAnnotations = "app.kubernetes.io/version"
 const K8sRecommendedAppAnnotations = "app.kubernetes.io/part-of"
 
 type Manager interface {
-	Permit(context.Context, *corev1.Pod) Status
+	Permit(context.Context, *corev1.Pod) (Status, string)
 }
 
 type WorkloadManager struct {
@@ -66,18 +66,28 @@ func NewWorkloadManager(d dynamic.Interface) *WorkloadManager {
 
 var bindCRDSpan = make(map[string]trace.Span, 100)
 
-func (sMgr *WorkloadManager) Permit(ctx context.Context, pod *corev1.Pod) Status {
+// Permit returns whether the pod may be scheduled. Unless the pod is permitted or rejected, it also returns a
+// human-readable description of what is currently holding back the scheduling of the pod, taken from the same read of
+// the KeptnWorkloadInstance
+func (sMgr *WorkloadManager) Permit(ctx context.Context, pod *corev1.Pod) (Status, string) {
 	//List workloadInstance run CRDs
 	name := getCRDName(pod)
 	crd, err := sMgr.GetCRD(ctx, pod.Namespace, name)
 
 	if err != nil {
 		klog.Infof("[Keptn Permit Plugin] could not find workloadInstance crd %s, err:%s", name, err.Error())
-		return WorkloadInstanceNotFound
+		return WorkloadInstanceNotFound, fmt.Sprintf("waiting for KeptnWorkloadInstance %s to be created", name)
 	}
 
 	ctx, span := sMgr.getSpan(ctx, crd, pod)
 
+	// the operator releases the pods without successful checks if the lifecycle deadline of the workload instance has been exceeded
+	if released, found, err := unstructured.NestedString(crd.UnstructuredContent(), "status", "gateReleaseTime"); err == nil && found && released != "" {
+		span.End()
+		unbindSpan(pod)
+		return Success, ""
+	}
+
 	//check CRD status
 	phase, found, err := unstructured.NestedString(crd.UnstructuredContent(), "status", "preDeploymentEvaluationStatus")
 	klog.Infof("[Keptn Permit Plugin] workloadInstance crd %s, found %s with phase %s ", crd, found, phase)
@@ -88,20 +98,55 @@ func (sMgr *WorkloadManager) Permit(ctx context.Context, pod *corev1.Pod) Status
 			span.SetStatus(codes.Error, "Failed")
 			span.End()
 			unbindSpan(pod)
-			return Failure
+			return Failure, ""
 		case StateSucceeded:
 			span.End()
 			unbindSpan(pod)
-			return Success
+			return Success, ""
 		case StatePending:
-			return Wait
+			return Wait, getBlockingReason(crd)
 		case StateRunning:
-			return Wait
+			return Wait, getBlockingReason(crd)
 		case StateUnknown:
-			return Wait
+			return Wait, getBlockingReason(crd)
+		}
+	}
+	return WorkloadInstanceStatusNotSpecified, getBlockingReason(crd)
+}
+
+// getBlockingReason names the first pre-deployment check of the KeptnWorkloadInstance that has not succeeded yet,
+// or its current phase
+func getBlockingReason(crd *unstructured.Unstructured) string {
+	workload, _, _ := unstructured.NestedString(crd.UnstructuredContent(), "spec", "workloadName")
+	version, _, _ := unstructured.NestedString(crd.UnstructuredContent(), "spec", "version")
+
+	checks := []struct {
+		field   string
+		nameKey string
+		kind    string
+	}{
+		{field: "preDeploymentTaskStatus", nameKey: "taskDefinitionName", kind: "pre-deployment task"},
+		{field: "preDeploymentEvaluationTaskStatus", nameKey: "evaluationDefinitionName", kind: "pre-deployment evaluation"},
+	}
+	for _, check := range checks {
+		items, _, _ := unstructured.NestedSlice(crd.UnstructuredContent(), "status", check.field)
+		for _, item := range items {
+			status, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if KeptnState(fmt.Sprint(status["status"])) == StateSucceeded {
+				continue
+			}
+			return fmt.Sprintf("waiting for %s %v of workload %s %s", check.kind, status[check.nameKey], workload, version)
 		}
 	}
-	return WorkloadInstanceStatusNotSpecified
+
+	phase, _, _ := unstructured.NestedString(crd.UnstructuredContent(), "status", "currentPhase")
+	if phase == "" {
+		return fmt.Sprintf("waiting for pre-deployment checks of workload %s %s to start", workload, version)
+	}
+	return fmt.Sprintf("waiting for phase %s of workload %s %s", phase, workload, version)
 }
 
 // GetCRD returns unstructured to avoid tight coupling with the CRD resource
@@ -153,9 +198,9 @@ func calculateVersion(pod *corev1.Pod) string {
 	name := ""
 
 	if len(pod.Spec.Containers) == 1 {
-		image := strings.Split(pod.Spec.Containers[0].Image, ":")
-		if len(image) > 0 && image[1] != "" && image[1] != "latest" {
-			return image[1]
+		tag := getImageTag(pod.Spec.Containers[0].Image)
+		if tag != "" && tag != "latest" {
+			return tag
 		}
 	}
 
@@ -170,3 +215,18 @@ func calculateVersion(pod *corev1.Pod) string {
 	h.Write([]byte(name))
 	return fmt.Sprint(h.Sum32())
 }
+
+// getImageTag returns the tag of a container image reference. Registry ports and digests are not considered to be a tag.
+// It has to be kept in sync with the version calculation of the pod mutating webhook of the operator.
+func getImageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i >= 0 {
+		return image[i+1:]
+	}
+	return ""
+}
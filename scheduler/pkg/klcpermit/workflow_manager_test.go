@@ -0,0 +1,146 @@
+package klcpermit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func FuzzCalculateVersion(f *testing.F) {
+	for _, image := range []string{
+		"nginx",
+		"nginx:1.23.1",
+		"ghcr.io/podtato-head/podtato-server:v0.1.1",
+		"localhost:5000/podtato-server",
+		"nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31",
+		":",
+		"",
+	} {
+		f.Add(image)
+	}
+
+	f.Fuzz(func(t *testing.T, image string) {
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: image}},
+			},
+		}
+		version := calculateVersion(pod)
+		if version == "" {
+			t.Errorf("empty version calculated for image %q", image)
+		}
+		if strings.ContainsAny(version, "/@") {
+			t.Errorf("version %q of image %q contains parts of the repository or digest", version, image)
+		}
+	})
+}
+
+func newWorkloadInstance(status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "lifecycle.keptn.sh/v1alpha1",
+		"kind":       "KeptnWorkloadInstance",
+		"metadata":   map[string]interface{}{"name": "myapp-myworkload-1.0.0", "namespace": "default"},
+		"spec":       map[string]interface{}{"workloadName": "myapp-myworkload", "version": "1.0.0"},
+		"status":     status,
+	}}
+}
+
+func TestGetBlockingReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "checks not started",
+			status: map[string]interface{}{},
+			want:   "waiting for pre-deployment checks of workload myapp-myworkload 1.0.0 to start",
+		},
+		{
+			name: "task running",
+			status: map[string]interface{}{
+				"currentPhase": "WorkloadPreDeployTasks",
+				"preDeploymentTaskStatus": []interface{}{
+					map[string]interface{}{"taskDefinitionName": "migrate", "status": "Succeeded"},
+					map[string]interface{}{"taskDefinitionName": "smoke-test", "status": "Running"},
+				},
+			},
+			want: "waiting for pre-deployment task smoke-test of workload myapp-myworkload 1.0.0",
+		},
+		{
+			name: "evaluation pending",
+			status: map[string]interface{}{
+				"currentPhase": "WorkloadPreEvaluations",
+				"preDeploymentTaskStatus": []interface{}{
+					map[string]interface{}{"taskDefinitionName": "migrate", "status": "Succeeded"},
+				},
+				"preDeploymentEvaluationTaskStatus": []interface{}{
+					map[string]interface{}{"evaluationDefinitionName": "error-rate", "status": "Pending"},
+				},
+			},
+			want: "waiting for pre-deployment evaluation error-rate of workload myapp-myworkload 1.0.0",
+		},
+		{
+			name: "all checks succeeded",
+			status: map[string]interface{}{
+				"currentPhase": "WorkloadPreEvaluations",
+				"preDeploymentTaskStatus": []interface{}{
+					map[string]interface{}{"taskDefinitionName": "migrate", "status": "Succeeded"},
+				},
+			},
+			want: "waiting for phase WorkloadPreEvaluations of workload myapp-myworkload 1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBlockingReason(newWorkloadInstance(tt.status)); got != tt.want {
+				t.Errorf("getBlockingReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadManager_PermitReturnsBlockingReason(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mypod",
+			Namespace: "default",
+			Annotations: map[string]string{
+				AppAnnotation:      "myapp",
+				WorkloadAnnotation: "myworkload",
+				VersionAnnotation:  "1.0.0",
+			},
+		},
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	sMgr := NewWorkloadManager(client)
+	status, reason := sMgr.Permit(context.TODO(), pod)
+	if status != WorkloadInstanceNotFound || reason != "waiting for KeptnWorkloadInstance myapp-myworkload-1.0.0 to be created" {
+		t.Errorf("Permit() = %q, %q", status, reason)
+	}
+
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme(), newWorkloadInstance(map[string]interface{}{
+		"currentPhase":                  "WorkloadPreDeployTasks",
+		"preDeploymentEvaluationStatus": "Running",
+		"preDeploymentTaskStatus": []interface{}{
+			map[string]interface{}{"taskDefinitionName": "migrate", "status": "Running"},
+		},
+	}))
+	sMgr = NewWorkloadManager(client)
+	status, reason = sMgr.Permit(context.TODO(), pod)
+	if status != Wait || reason != "waiting for pre-deployment task migrate of workload myapp-myworkload 1.0.0" {
+		t.Errorf("Permit() = %q, %q", status, reason)
+	}
+	// the reason is taken from the same read as the status
+	if actions := client.Actions(); len(actions) != 1 {
+		t.Errorf("expected the KeptnWorkloadInstance to be read once, got %d reads", len(actions))
+	}
+	unbindSpan(pod)
+}